@@ -0,0 +1,121 @@
+package tpuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Aggregation is an aggregate function computed over the documents matching a query.
+// Use AggregateCount or AggregateSum to construct one.
+type Aggregation []interface{}
+
+// AggregateCount counts matching documents.
+func AggregateCount() Aggregation {
+	return Aggregation{"Count"}
+}
+
+// AggregateSum sums the given numeric attribute over matching documents.
+func AggregateSum(attribute string) Aggregation {
+	return Aggregation{"Sum", attribute}
+}
+
+type AggregateRequest struct {
+	// AggregateBy maps result labels to the aggregation to compute.  Required.
+	AggregateBy map[string]Aggregation `json:"aggregate_by"`
+	// GroupBy is an optional list of attributes to group by.  When set, one set of aggregations is
+	// returned per distinct combination of values.
+	GroupBy []string `json:"group_by,omitempty"`
+	// Filters restricts the documents which are aggregated.
+	Filters Filter `json:"filters,omitempty"`
+	// TopK is the maximum number of groups to return when GroupBy is set.
+	TopK int `json:"top_k,omitempty"`
+}
+
+// AggregationGroup holds the aggregations for a single group of a grouped aggregate query.
+type AggregationGroup struct {
+	// Key holds the values of the GroupBy attributes for this group.
+	Key map[string]json.RawMessage
+	// Aggregations holds the aggregate values for this group, keyed by label.
+	Aggregations map[string]float64
+}
+
+type AggregateResult struct {
+	// Aggregations holds the aggregate values keyed by label.  Set when GroupBy is empty.
+	Aggregations map[string]float64
+	// Groups holds the aggregations per group.  Set when GroupBy is not empty.
+	Groups []*AggregationGroup
+}
+
+type aggregateResponse struct {
+	Aggregations      map[string]float64           `json:"aggregations"`
+	AggregationGroups []map[string]json.RawMessage `json:"aggregation_groups"`
+}
+
+// Aggregate computes aggregations, such as counts or sums, over the documents in a namespace,
+// optionally grouped by attribute values.  For example, to count documents per category:
+//
+//	client.Aggregate(ctx, namespace, &AggregateRequest{
+//		AggregateBy: map[string]Aggregation{"count": AggregateCount()},
+//		GroupBy:     []string{"category"},
+//	})
+//
+// See https://turbopuffer.com/docs/query#aggregations
+func (c *Client) Aggregate(ctx context.Context, namespace string, request *AggregateRequest) (*AggregateResult, error) {
+	if len(request.AggregateBy) == 0 {
+		return nil, fmt.Errorf("at least one aggregation is required")
+	}
+	path := c.namespacePath(namespace, "/query")
+	reqJson, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	respData, err := c.post(ctx, OperationQuery, path, reqJson)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate documents: %w", err)
+	}
+
+	var response aggregateResponse
+	if err := json.Unmarshal(respData, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	result := &AggregateResult{Aggregations: response.Aggregations}
+	for _, rawGroup := range response.AggregationGroups {
+		group := &AggregationGroup{
+			Key:          map[string]json.RawMessage{},
+			Aggregations: map[string]float64{},
+		}
+		for name, value := range rawGroup {
+			if _, ok := request.AggregateBy[name]; !ok {
+				group.Key[name] = value
+				continue
+			}
+			var aggregate float64
+			if err := json.Unmarshal(value, &aggregate); err != nil {
+				return nil, fmt.Errorf("failed to decode aggregation %s: %w", name, err)
+			}
+			group.Aggregations[name] = aggregate
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	return result, nil
+}
+
+// Count returns the number of documents in a namespace matching the given filter, or all documents if
+// filter is nil.  The count may be approximate for very large namespaces.
+func (c *Client) Count(ctx context.Context, namespace string, filter Filter) (int64, error) {
+	result, err := c.Aggregate(ctx, namespace, &AggregateRequest{
+		AggregateBy: map[string]Aggregation{"count": AggregateCount()},
+		Filters:     filter,
+	})
+	if err != nil {
+		return 0, err
+	}
+	count, ok := result.Aggregations["count"]
+	if !ok {
+		return 0, fmt.Errorf("count missing from response")
+	}
+	return int64(count), nil
+}
@@ -0,0 +1,176 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *tpuf.AggregateRequest
+		httpResponse   *http.Response
+		expectedError  string
+		expectedBody   string
+		expectedResult *tpuf.AggregateResult
+	}{
+		{
+			name: "count and sum",
+			request: &tpuf.AggregateRequest{
+				AggregateBy: map[string]tpuf.Aggregation{
+					"count": tpuf.AggregateCount(),
+					"total": tpuf.AggregateSum("price"),
+				},
+				Filters: &tpuf.BaseFilter{Attribute: "category", Operator: tpuf.OpEq, Value: "electronics"},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"aggregations":{"count":3,"total":1250.5}}`)),
+			},
+			expectedBody: `{"aggregate_by":{"count":["Count"],"total":["Sum","price"]},"filters":["category","Eq","electronics"]}`,
+			expectedResult: &tpuf.AggregateResult{
+				Aggregations: map[string]float64{"count": 3, "total": 1250.5},
+			},
+		},
+		{
+			name: "count grouped by category",
+			request: &tpuf.AggregateRequest{
+				AggregateBy: map[string]tpuf.Aggregation{"count": tpuf.AggregateCount()},
+				GroupBy:     []string{"category"},
+				TopK:        10,
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(bytes.NewBufferString(`{"aggregation_groups":[
+					{"category":"electronics","count":3},
+					{"category":"books","count":7}
+				]}`)),
+			},
+			expectedBody: `{"aggregate_by":{"count":["Count"]},"group_by":["category"],"top_k":10}`,
+			expectedResult: &tpuf.AggregateResult{
+				Groups: []*tpuf.AggregationGroup{
+					{
+						Key:          map[string]json.RawMessage{"category": json.RawMessage(`"electronics"`)},
+						Aggregations: map[string]float64{"count": 3},
+					},
+					{
+						Key:          map[string]json.RawMessage{"category": json.RawMessage(`"books"`)},
+						Aggregations: map[string]float64{"count": 7},
+					},
+				},
+			},
+		},
+		{
+			name: "aggregate error",
+			request: &tpuf.AggregateRequest{
+				AggregateBy: map[string]tpuf.Aggregation{"count": tpuf.AggregateCount()},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid query","status":"error"}`)),
+			},
+			expectedError: "failed to aggregate documents: error: Invalid query (HTTP 400)",
+			expectedBody:  `{"aggregate_by":{"count":["Count"]}}`,
+		},
+		{
+			name:          "missing aggregations",
+			request:       &tpuf.AggregateRequest{},
+			expectedError: "at least one aggregation is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, http.MethodPost, req.Method, "unexpected request method")
+						assert.Equal(t, "https://api.turbopuffer.com/v1/vectors/test-namespace/query", req.URL.String(), "unexpected request URL")
+
+						body, _ := io.ReadAll(req.Body)
+						assert.JSONEq(t, tt.expectedBody, string(body), "unexpected request body")
+
+						return tt.httpResponse, nil
+					},
+				},
+			}
+
+			result, err := client.Aggregate(context.Background(), "test-namespace", tt.request)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result, "unexpected aggregate result")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, result)
+			}
+		})
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        tpuf.Filter
+		responseBody  string
+		expectedBody  string
+		expectedError string
+		expectedCount int64
+	}{
+		{
+			name:          "count with filter",
+			filter:        &tpuf.BaseFilter{Attribute: "category", Operator: tpuf.OpEq, Value: "electronics"},
+			responseBody:  `{"aggregations":{"count":42}}`,
+			expectedBody:  `{"aggregate_by":{"count":["Count"]},"filters":["category","Eq","electronics"]}`,
+			expectedCount: 42,
+		},
+		{
+			name:          "count all documents",
+			responseBody:  `{"aggregations":{"count":1000}}`,
+			expectedBody:  `{"aggregate_by":{"count":["Count"]}}`,
+			expectedCount: 1000,
+		},
+		{
+			name:          "count missing from response",
+			responseBody:  `{"aggregations":{}}`,
+			expectedBody:  `{"aggregate_by":{"count":["Count"]}}`,
+			expectedError: "count missing from response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, _ := io.ReadAll(req.Body)
+						assert.JSONEq(t, tt.expectedBody, string(body), "unexpected request body")
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(tt.responseBody)),
+						}, nil
+					},
+				},
+			}
+
+			count, err := client.Count(context.Background(), "test-namespace", tt.filter)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCount, count, "unexpected count")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package tpuf
+
+import "context"
+
+// API is the core of the turbopuffer API, as implemented by Client.  Depend on it rather than on *Client
+// to substitute a fake, or a wrapper such as tpuftest.RecordingClient, in tests.
+type API interface {
+	Upsert(ctx context.Context, namespace string, request *UpsertRequest) (*UpsertResponse, error)
+	Query(ctx context.Context, namespace string, request *QueryRequest) ([]*QueryResult, error)
+	Delete(ctx context.Context, namespace string, ids []string) (*DeleteReport, error)
+	DeleteByFilter(ctx context.Context, namespace string, filter Filter) (*DeleteResponse, error)
+	Export(ctx context.Context, namespace string, cursor string) (*ExportResponse, error)
+	Schema(ctx context.Context, namespace string) (Schema, error)
+	UpdateSchema(ctx context.Context, namespace string, schema Schema) (Schema, error)
+	Namespaces(ctx context.Context, request *NamespacesRequest) (*NamespacesResponse, error)
+	DeleteNamespace(ctx context.Context, namespace string) error
+}
+
+var _ API = (*Client)(nil)
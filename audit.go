@@ -0,0 +1,54 @@
+package tpuf
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent describes a completed write: an upsert, a deletion by ID or filter, or a namespace deletion.
+type AuditEvent struct {
+	// Operation is OperationUpsert, OperationDelete or OperationDeleteNamespace.
+	Operation Operation
+	// Namespace is the namespace written to.
+	Namespace string
+	// Time is when the write was started.
+	Time time.Time
+	// Documents is the number of documents sent in the request, after any deduplication.  It is 0 for
+	// deletions by filter and namespace deletions, which don't name documents.
+	Documents int
+	// RowsAffected is the number of documents the server reported writing or deleting, or nil if it didn't.
+	RowsAffected *int
+	// Filter is the JSON of the filter for deletions by filter, or of the condition for conditional upserts.
+	Filter json.RawMessage
+	// CopyFromNamespace is the source namespace, for upserts which copy one.
+	CopyFromNamespace string
+	// Err is the error which failed the write, if any.  A failed write may still have been applied.
+	Err error
+}
+
+// AuditHook is notified of every write made by a Client once it completes, successfully or not, so that
+// logging of destructive operations can be centralized.  Implementations must be safe for concurrent use.
+type AuditHook interface {
+	Audit(ctx context.Context, event *AuditEvent)
+}
+
+// AuditHookFunc adapts an ordinary function to the AuditHook interface.
+type AuditHookFunc func(ctx context.Context, event *AuditEvent)
+
+func (f AuditHookFunc) Audit(ctx context.Context, event *AuditEvent) {
+	f(ctx, event)
+}
+
+// audit notifies the AuditHook, if set, of a completed write.  filter may be nil.
+func (c *Client) audit(ctx context.Context, event *AuditEvent, filter Filter, err error) {
+	if c.AuditHook == nil {
+		return
+	}
+	if filter != nil {
+		// The filter has already been marshaled successfully as part of the request.
+		event.Filter, _ = json.Marshal(filter)
+	}
+	event.Err = err
+	c.AuditHook.Audit(ctx, event)
+}
@@ -0,0 +1,148 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditHook(t *testing.T) {
+	tests := []struct {
+		name          string
+		call          func(client *tpuf.Client) error
+		status        int
+		responseBody  string
+		expectedEvent *tpuf.AuditEvent
+	}{
+		{
+			name: "upsert",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "docs", &tpuf.UpsertRequest{
+					Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{1}}, {ID: "2", Vector: []float32{2}}},
+				})
+				return err
+			},
+			status:        http.StatusOK,
+			responseBody:  `{"status":"OK","rows_affected":2}`,
+			expectedEvent: &tpuf.AuditEvent{Operation: tpuf.OperationUpsert, Namespace: "docs", Documents: 2, RowsAffected: intPtr(2)},
+		},
+		{
+			name: "conditional upsert",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "docs", &tpuf.UpsertRequest{
+					Upserts:         []*tpuf.Upsert{{ID: "1", Vector: []float32{1}}},
+					UpsertCondition: tpuf.Lt("version", tpuf.RefNew("version")),
+				})
+				return err
+			},
+			status:       http.StatusOK,
+			responseBody: `{"status":"OK"}`,
+			expectedEvent: &tpuf.AuditEvent{
+				Operation: tpuf.OperationUpsert,
+				Namespace: "docs",
+				Documents: 1,
+				Filter:    json.RawMessage(`["version","Lt",{"$ref_new":"version"}]`),
+			},
+		},
+		{
+			name: "copy namespace",
+			call: func(client *tpuf.Client) error {
+				return client.CopyNamespace(context.Background(), "docs", "old-docs", &tpuf.CopyNamespaceOptions{AllowNonEmpty: true})
+			},
+			status:        http.StatusOK,
+			responseBody:  `{"status":"OK"}`,
+			expectedEvent: &tpuf.AuditEvent{Operation: tpuf.OperationUpsert, Namespace: "docs", CopyFromNamespace: "old-docs"},
+		},
+		{
+			name: "delete by id",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Delete(context.Background(), "docs", []string{"1", "2", "3"})
+				return err
+			},
+			status:        http.StatusOK,
+			responseBody:  `{"status":"OK"}`,
+			expectedEvent: &tpuf.AuditEvent{Operation: tpuf.OperationDelete, Namespace: "docs", Documents: 3},
+		},
+		{
+			name: "delete by filter",
+			call: func(client *tpuf.Client) error {
+				_, err := client.DeleteByFilter(context.Background(), "docs", tpuf.Eq("tenant", "a"))
+				return err
+			},
+			status:       http.StatusOK,
+			responseBody: `{"status":"OK","rows_affected":7}`,
+			expectedEvent: &tpuf.AuditEvent{
+				Operation:    tpuf.OperationDelete,
+				Namespace:    "docs",
+				RowsAffected: intPtr(7),
+				Filter:       json.RawMessage(`["tenant","Eq","a"]`),
+			},
+		},
+		{
+			name: "delete namespace",
+			call: func(client *tpuf.Client) error {
+				return client.DeleteNamespace(context.Background(), "docs")
+			},
+			status:        http.StatusOK,
+			responseBody:  `{"status":"OK"}`,
+			expectedEvent: &tpuf.AuditEvent{Operation: tpuf.OperationDeleteNamespace, Namespace: "docs"},
+		},
+		{
+			name: "failed delete namespace",
+			call: func(client *tpuf.Client) error {
+				return client.DeleteNamespace(context.Background(), "docs")
+			},
+			status:        http.StatusForbidden,
+			responseBody:  `{"status":"error","error":"forbidden"}`,
+			expectedEvent: &tpuf.AuditEvent{Operation: tpuf.OperationDeleteNamespace, Namespace: "docs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []*tpuf.AuditEvent
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: tt.status, Body: io.NopCloser(bytes.NewBufferString(tt.responseBody))}, nil
+					},
+				},
+				AuditHook: tpuf.AuditHookFunc(func(ctx context.Context, event *tpuf.AuditEvent) {
+					events = append(events, event)
+				}),
+			}
+
+			err := tt.call(client)
+
+			if assert.Len(t, events, 1) {
+				event := events[0]
+				assert.False(t, event.Time.IsZero(), "missing time")
+				assert.Equal(t, err, event.Err)
+				if tt.expectedEvent.Filter != nil {
+					assert.JSONEq(t, string(tt.expectedEvent.Filter), string(event.Filter))
+				}
+				event.Time, event.Err, event.Filter = tt.expectedEvent.Time, nil, tt.expectedEvent.Filter
+				assert.Equal(t, tt.expectedEvent, event)
+			}
+		})
+	}
+}
+
+func TestAuditHookSkipsRejectedWrites(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		AuditHook: tpuf.AuditHookFunc(func(ctx context.Context, event *tpuf.AuditEvent) {
+			t.Errorf("unexpected audit event for a write which wasn't sent: %+v", event)
+		}),
+	}
+	_, err := client.Upsert(context.Background(), "docs", &tpuf.UpsertRequest{Upserts: []*tpuf.Upsert{{ID: "1"}}})
+	assert.Error(t, err)
+}
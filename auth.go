@@ -0,0 +1,86 @@
+package tpuf
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// Authenticator adds credentials to each attempt of an API request, after all other headers have been set.
+// The request's body can be read via GetBody, e.g. to sign it, and its context via Context.
+// Implementations must be safe for concurrent use.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc adapts an ordinary function to the Authenticator interface.
+type AuthenticatorFunc func(req *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// BearerAuth authenticates requests with an "Authorization: Bearer <token>" header.  This is the default,
+// using Client.ApiToken.
+func BearerAuth(token string) Authenticator {
+	return HeaderAuth("Authorization", "Bearer "+token)
+}
+
+// HeaderAuth authenticates requests by setting the named header to value, e.g. for a gateway which expects
+// the token in a header of its own.
+func HeaderAuth(name string, value string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set(name, value)
+		return nil
+	})
+}
+
+// QueryParamAuth authenticates requests by adding the token to the URL as the named query parameter, for
+// proxies which can't be given headers.  The client redacts the token from the URLs in its errors, but note
+// that URLs are more likely than headers to be logged elsewhere, e.g. by proxies.
+func QueryParamAuth(name string, token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		query := req.URL.Query()
+		query.Set(name, token)
+		req.URL.RawQuery = query.Encode()
+		return nil
+	})
+}
+
+// authenticator returns the Authenticator to use for requests.
+func (c *Client) authenticator() Authenticator {
+	if c.Auth != nil {
+		return c.Auth
+	}
+	return BearerAuth(c.ApiToken)
+}
+
+// redactAuthParams replaces the values of query parameters which the Authenticator added to a request's URL,
+// such as QueryParamAuth's token, in the URL reported by a *url.Error, which would otherwise include them in
+// the error's message and so in logs.
+func redactAuthParams(err error, unauthenticated, authenticated *url.URL) {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || authenticated.RawQuery == unauthenticated.RawQuery {
+		return
+	}
+	before := unauthenticated.Query()
+	var added []string
+	for name, values := range authenticated.Query() {
+		if before.Get(name) != values[0] {
+			added = append(added, name)
+		}
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		urlErr.URL = unauthenticated.Redacted()
+		return
+	}
+	query := u.Query()
+	for _, name := range added {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+		}
+	}
+	u.RawQuery = query.Encode()
+	urlErr.URL = u.Redacted()
+}
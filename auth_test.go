@@ -0,0 +1,141 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticator(t *testing.T) {
+	tests := []struct {
+		name            string
+		auth            tpuf.Authenticator
+		expectedURL     string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:            "default bearer token",
+			expectedURL:     "https://api.turbopuffer.com/v1/vectors/docs/query",
+			expectedHeaders: map[string]string{"Authorization": "Bearer test-token"},
+		},
+		{
+			name:            "custom header",
+			auth:            tpuf.HeaderAuth("X-Gateway-Key", "gateway-token"),
+			expectedURL:     "https://api.turbopuffer.com/v1/vectors/docs/query",
+			expectedHeaders: map[string]string{"Authorization": "", "X-Gateway-Key": "gateway-token"},
+		},
+		{
+			name:            "query parameter",
+			auth:            tpuf.QueryParamAuth("api_key", "proxy token"),
+			expectedURL:     "https://api.turbopuffer.com/v1/vectors/docs/query?api_key=proxy+token",
+			expectedHeaders: map[string]string{"Authorization": ""},
+		},
+		{
+			name: "request signer",
+			auth: tpuf.AuthenticatorFunc(func(req *http.Request) error {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				data, err := io.ReadAll(body)
+				if err != nil {
+					return err
+				}
+				sum := sha256.Sum256(append([]byte(req.Method+" "+req.URL.Path+" "), data...))
+				req.Header.Set("X-Signature", hex.EncodeToString(sum[:]))
+				return nil
+			}),
+			expectedURL: "https://api.turbopuffer.com/v1/vectors/docs/query",
+			expectedHeaders: map[string]string{
+				"Authorization": "",
+				"X-Signature":   hex.EncodeToString(sha256Sum(`POST /v1/vectors/docs/query {"top_k":1}`)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				Auth:     tt.auth,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, tt.expectedURL, req.URL.String())
+						for name, value := range tt.expectedHeaders {
+							assert.Equal(t, value, req.Header.Get(name), "unexpected %s header", name)
+						}
+						body, _ := io.ReadAll(req.Body)
+						assert.JSONEq(t, `{"top_k":1}`, string(body), "the body should still be sent in full")
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`[]`))}, nil
+					},
+				},
+			}
+			_, err := client.Query(context.Background(), "docs", &tpuf.QueryRequest{TopK: 1})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func sha256Sum(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+func TestAuthenticatorError(t *testing.T) {
+	attempts := 0
+	client, err := tpuf.NewClient("", tpuf.WithTimer(&fakeTimer{}), tpuf.WithAuth(tpuf.AuthenticatorFunc(func(req *http.Request) error {
+		attempts++
+		return errors.New("token expired")
+	})), tpuf.WithHttpClient(&fakeHttpClient{
+		doFunc: func(req *http.Request) (*http.Response, error) {
+			t.Fatal("unexpected request")
+			return nil, nil
+		},
+	}))
+	assert.NoError(t, err, "an API token should not be required with WithAuth")
+
+	_, err = client.Query(context.Background(), "docs", &tpuf.QueryRequest{TopK: 1})
+	assert.EqualError(t, err, "failed to query documents: failed to authenticate request: token expired")
+	assert.Equal(t, 1, attempts, "authentication errors should not be retried")
+
+	_, err = tpuf.NewClient("")
+	assert.EqualError(t, err, "an API token is required")
+}
+
+func TestQueryParamAuthRedactsErrors(t *testing.T) {
+	var logs bytes.Buffer
+	client, err := tpuf.NewClient("",
+		tpuf.WithTimer(&fakeTimer{}),
+		tpuf.WithAuth(tpuf.QueryParamAuth("api_key", "secret-token")),
+		tpuf.WithLogger(tpuf.StdLogger(log.New(&logs, "", 0), tpuf.LogLevelDebug)),
+		tpuf.WithHttpClient(&fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: errors.New("connection refused")}
+			},
+		}),
+	)
+	assert.NoError(t, err)
+
+	_, queryErr := client.Query(context.Background(), "docs", &tpuf.QueryRequest{TopK: 1})
+	_, pingErr := client.Ping(context.Background())
+
+	for _, err := range []error{queryErr, pingErr} {
+		if assert.Error(t, err) {
+			assert.NotContains(t, err.Error(), "secret-token")
+			assert.Contains(t, err.Error(), "api_key=REDACTED")
+		}
+	}
+	assert.ErrorIs(t, pingErr, tpuf.ErrUnreachable)
+	assert.Contains(t, logs.String(), "request failed")
+	assert.NotContains(t, logs.String(), "secret-token", "the token should not be logged")
+}
@@ -0,0 +1,276 @@
+package tpuf
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// BackupFormat identifies a namespace backup archive.
+	BackupFormat = "tpuf-backup"
+	// BackupVersion is the archive version written by Backup.  Restore rejects archives with newer versions.
+	BackupVersion = 1
+)
+
+// BackupHeader is the first record of a backup archive.
+//
+// An archive is newline-delimited JSON: a BackupHeader, then one Document per line, then a trailer
+// recording the number of documents, which Restore uses to detect a truncated archive.
+type BackupHeader struct {
+	// Format is always BackupFormat.
+	Format string `json:"format"`
+	// Version is the archive version.
+	Version int `json:"version"`
+	// Namespace is the namespace the backup was taken from.
+	Namespace string `json:"namespace"`
+	// DistanceMetric is the namespace's distance metric, if known.
+	DistanceMetric DistanceMetric `json:"distance_metric,omitempty"`
+	// Schema is the namespace's schema at the start of the backup.
+	Schema Schema `json:"schema,omitempty"`
+}
+
+type backupTrailer struct {
+	Documents int64 `json:"documents"`
+}
+
+type backupRecord struct {
+	Document
+	End *backupTrailer `json:"end,omitempty"`
+}
+
+// UnmarshalJSON decodes the trailer as well as the Document, whose UnmarshalJSON would otherwise be promoted.
+func (r *backupRecord) UnmarshalJSON(data []byte) error {
+	var trailer struct {
+		End *backupTrailer `json:"end,omitempty"`
+	}
+	if err := json.Unmarshal(data, &trailer); err != nil {
+		return err
+	}
+	if r.End = trailer.End; r.End != nil {
+		return nil
+	}
+	return json.Unmarshal(data, &r.Document)
+}
+
+// BackupOptions configures Backup.
+type BackupOptions struct {
+	// DistanceMetric is recorded in the archive so that Restore can recreate the namespace.  The API doesn't
+	// report a namespace's distance metric, so it must be provided for namespaces with vectors.
+	DistanceMetric DistanceMetric
+	// Resume continues an interrupted backup from the last reported progress, appending to the same archive.
+	// The header is not written again.  If dst is an *os.File, or another writer with Truncate and Seek
+	// methods, it is first truncated to the size recorded in the progress, discarding a partially written
+	// page.  Other writers must already end after the last reported page.
+	Resume *BackupProgress
+	// Progress, if set, is called after each page has been written to the archive.  Persist the progress
+	// to be able to resume the backup.
+	Progress func(*BackupProgress)
+}
+
+// BackupProgress reports how much of a namespace has been backed up.
+type BackupProgress struct {
+	// Documents is the number of documents written.
+	Documents int64 `json:"documents"`
+	// Cursor is the export cursor of the next page to write.
+	Cursor string `json:"cursor"`
+	// Bytes is the size of the archive up to the end of the last page written.
+	Bytes int64 `json:"bytes"`
+}
+
+// truncater is implemented by *os.File.
+type truncater interface {
+	Truncate(size int64) error
+	Seek(offset int64, whence int) (int64, error)
+}
+
+// truncateArchive discards anything written to dst after size bytes, such as a page which was only partly
+// written when a backup was interrupted, so that resuming the backup doesn't leave a partial line or
+// duplicate documents in the archive.  Writers which can't be truncated are left as they are.
+func truncateArchive(dst io.Writer, size int64) error {
+	f, ok := dst.(truncater)
+	if !ok {
+		return nil
+	}
+	if size <= 0 {
+		return fmt.Errorf("the archive size is required to resume a backup")
+	}
+	if err := f.Truncate(size); err != nil {
+		return fmt.Errorf("failed to truncate backup archive: %w", err)
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to truncate backup archive: %w", err)
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Backup writes the schema, distance metric and every document of a namespace to dst as a versioned
+// archive, which Restore reads to recreate the namespace.  dst is flushed after each page before
+// progress is reported.  opts may be nil.
+func Backup(ctx context.Context, client *Client, namespace string, dst io.Writer, opts *BackupOptions) (*BackupProgress, error) {
+	if opts == nil {
+		opts = &BackupOptions{}
+	}
+	counter := &countingWriter{w: dst}
+	buffered := bufio.NewWriter(counter)
+	encoder := json.NewEncoder(buffered)
+
+	progress := &BackupProgress{}
+	it := client.ExportIter(ctx, namespace)
+	if opts.Resume != nil {
+		if opts.Resume.Cursor == "" {
+			return nil, fmt.Errorf("a cursor is required to resume a backup")
+		}
+		if err := truncateArchive(dst, opts.Resume.Bytes); err != nil {
+			return nil, err
+		}
+		*progress = *opts.Resume
+		counter.n = opts.Resume.Bytes
+		it.cursor = opts.Resume.Cursor
+	} else {
+		schema, err := client.Schema(ctx, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up schema: %w", err)
+		}
+		header := &BackupHeader{
+			Format:         BackupFormat,
+			Version:        BackupVersion,
+			Namespace:      namespace,
+			DistanceMetric: opts.DistanceMetric,
+			Schema:         schema,
+		}
+		if err := encoder.Encode(header); err != nil {
+			return nil, fmt.Errorf("failed to write backup header: %w", err)
+		}
+	}
+
+	for it.Next() {
+		for _, document := range it.Documents() {
+			if err := encoder.Encode(document); err != nil {
+				return progress, fmt.Errorf("failed to write document %s: %w", document.ID, err)
+			}
+		}
+		if err := buffered.Flush(); err != nil {
+			return progress, fmt.Errorf("failed to write documents: %w", err)
+		}
+		progress.Documents += int64(len(it.Page().IDs))
+		progress.Cursor = it.Cursor()
+		progress.Bytes = counter.n
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return progress, fmt.Errorf("failed to back up namespace %s: %w", namespace, err)
+	}
+
+	trailer := struct {
+		End *backupTrailer `json:"end"`
+	}{&backupTrailer{Documents: progress.Documents}}
+	if err := encoder.Encode(&trailer); err != nil {
+		return progress, fmt.Errorf("failed to write backup trailer: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return progress, fmt.Errorf("failed to write backup trailer: %w", err)
+	}
+	return progress, nil
+}
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	// DistanceMetric overrides the distance metric recorded in the archive.
+	DistanceMetric DistanceMetric
+	// BatchSize is the number of documents per upsert request.  Defaults to 1000.
+	BatchSize int
+	// Checkpoints, if set, records restore progress.  Restoring the same archive with the same store after a
+	// failure skips the documents which were already written.  See BulkUpserter.Checkpoints.
+	Checkpoints CheckpointStore
+}
+
+// ErrTruncatedBackup is returned by Restore when an archive ends before its trailer, e.g. because the
+// backup was interrupted, including when it ends partway through a line.  Documents before the truncation
+// have been restored.
+var ErrTruncatedBackup = errors.New("backup archive is truncated")
+
+// Restore upserts the documents of an archive written by Backup into a namespace, along with the schema and
+// distance metric recorded in the archive.  The namespace doesn't need to be the one the backup was taken
+// from.  It returns the number of documents read from the archive.  opts may be nil.
+func Restore(ctx context.Context, client *Client, namespace string, src io.Reader, opts *RestoreOptions) (int64, error) {
+	if opts == nil {
+		opts = &RestoreOptions{}
+	}
+	decoder := json.NewDecoder(bufio.NewReader(src))
+
+	var header BackupHeader
+	if err := decoder.Decode(&header); err != nil {
+		return 0, fmt.Errorf("failed to read backup header: %w", err)
+	}
+	if header.Format != BackupFormat {
+		return 0, fmt.Errorf("not a backup archive: unexpected format %q", header.Format)
+	}
+	if header.Version > BackupVersion {
+		return 0, fmt.Errorf("unsupported backup version %d, expected at most %d", header.Version, BackupVersion)
+	}
+	distanceMetric := opts.DistanceMetric
+	if distanceMetric == "" {
+		distanceMetric = header.DistanceMetric
+	}
+
+	upserter := &BulkUpserter{
+		Client:         client,
+		Namespace:      namespace,
+		BatchSize:      opts.BatchSize,
+		DistanceMetric: distanceMetric,
+		Schema:         header.Schema,
+		AllowNoVector:  true,
+		Checkpoints:    opts.Checkpoints,
+	}
+	var count int64
+	var trailer *backupTrailer
+	for {
+		var record backupRecord
+		if err := decoder.Decode(&record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = ErrTruncatedBackup
+				if flushErr := upserter.Flush(ctx); flushErr != nil {
+					return count, fmt.Errorf("failed to restore documents: %w", flushErr)
+				}
+			}
+			return count, fmt.Errorf("failed to read document %d: %w", count+1, err)
+		}
+		if record.End != nil {
+			trailer = record.End
+			break
+		}
+		if len(record.Vector) > 0 && distanceMetric == "" {
+			return count, fmt.Errorf("a distance metric is required to restore documents with vectors")
+		}
+		err := upserter.Add(ctx, record.Document.upsert())
+		if err != nil {
+			return count, fmt.Errorf("failed to restore documents: %w", err)
+		}
+		count++
+	}
+	if err := upserter.Flush(ctx); err != nil {
+		return count, fmt.Errorf("failed to restore documents: %w", err)
+	}
+	if trailer.Documents != count {
+		return count, fmt.Errorf("backup archive is corrupt: its trailer records %d documents, but %d were read", trailer.Documents, count)
+	}
+	return count, nil
+}
@@ -0,0 +1,247 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackup(t *testing.T) {
+	responses := map[string]string{
+		"/v1/vectors/test-ns/schema":       `{"title":{"type":"string"}}`,
+		"/v1/vectors/test-ns":              `{"ids":["1"],"vectors":[[0.1]],"attributes":{"title":["a"]},"next_cursor":"page2"}`,
+		"/v1/vectors/test-ns?cursor=page2": `{"ids":["2","3"],"vectors":[[0.2],[0.3]],"attributes":{"title":["b",null]}}`,
+	}
+
+	tests := []struct {
+		name             string
+		opts             *tpuf.BackupOptions
+		expectedArchive  string
+		expectedProgress []tpuf.BackupProgress
+	}{
+		{
+			name: "full backup",
+			opts: &tpuf.BackupOptions{DistanceMetric: tpuf.DistanceMetricCosine},
+			expectedArchive: `{"format":"tpuf-backup","version":1,"namespace":"test-ns","distance_metric":"cosine_distance","schema":{"title":{"type":"string"}}}
+{"id":"1","vector":[0.1],"attributes":{"title":"a"}}
+{"id":"2","vector":[0.2],"attributes":{"title":"b"}}
+{"id":"3","vector":[0.3]}
+{"end":{"documents":3}}
+`,
+			expectedProgress: []tpuf.BackupProgress{{Documents: 1, Cursor: "page2", Bytes: 185}, {Documents: 3, Bytes: 264}},
+		},
+		{
+			name: "resume",
+			opts: &tpuf.BackupOptions{Resume: &tpuf.BackupProgress{Documents: 1, Cursor: "page2", Bytes: 185}},
+			expectedArchive: `{"id":"2","vector":[0.2],"attributes":{"title":"b"}}
+{"id":"3","vector":[0.3]}
+{"end":{"documents":3}}
+`,
+			expectedProgress: []tpuf.BackupProgress{{Documents: 3, Bytes: 264}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, ok := responses[req.URL.RequestURI()]
+						assert.True(t, ok, "unexpected request %s", req.URL.RequestURI())
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(body)),
+						}, nil
+					},
+				},
+			}
+
+			var progress []tpuf.BackupProgress
+			tt.opts.Progress = func(p *tpuf.BackupProgress) {
+				progress = append(progress, *p)
+			}
+
+			var archive bytes.Buffer
+			result, err := tpuf.Backup(context.Background(), client, "test-ns", &archive, tt.opts)
+
+			assert.NoError(t, err)
+			assert.Equal(t, &tpuf.BackupProgress{Documents: 3, Bytes: 264}, result)
+			assert.Equal(t, tt.expectedArchive, archive.String())
+			assert.Equal(t, tt.expectedProgress, progress)
+		})
+	}
+}
+
+func TestBackupResumeTruncatesPartialPage(t *testing.T) {
+	const header = `{"format":"tpuf-backup","version":1,"namespace":"test-ns","schema":{"title":{"type":"string"}}}` + "\n"
+	const page1 = `{"id":"1","vector":[0.1]}` + "\n"
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "/v1/vectors/test-ns?cursor=page2", req.URL.RequestURI())
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"ids":["2","3"],"vectors":[[0.2],[0.3]]}`)),
+				}, nil
+			},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "backup.jsonl")
+	interrupted := header + page1 + `{"id":"2","vector":[0.2]}` + "\n" + `{"id":"3","vec`
+	assert.NoError(t, os.WriteFile(path, []byte(interrupted), 0o644))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	resume := &tpuf.BackupProgress{Documents: 1, Cursor: "page2", Bytes: int64(len(header + page1))}
+	_, err = tpuf.Backup(context.Background(), client, "test-ns", file, &tpuf.BackupOptions{Resume: resume})
+	assert.NoError(t, err)
+
+	archive, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, header+page1+`{"id":"2","vector":[0.2]}
+{"id":"3","vector":[0.3]}
+{"end":{"documents":3}}
+`, string(archive), "the partially written page should be discarded before appending")
+
+	_, err = tpuf.Backup(context.Background(), client, "test-ns", file, &tpuf.BackupOptions{Resume: &tpuf.BackupProgress{Cursor: "page2"}})
+	assert.EqualError(t, err, "the archive size is required to resume a backup")
+}
+
+func TestRestore(t *testing.T) {
+	const header = `{"format":"tpuf-backup","version":1,"namespace":"old-ns","distance_metric":"cosine_distance","schema":{"title":{"type":"string"}}}`
+
+	tests := []struct {
+		name            string
+		archive         string
+		opts            *tpuf.RestoreOptions
+		expectedCount   int64
+		expectedUpserts []string
+		expectedError   string
+	}{
+		{
+			name: "restores schema, metric and documents",
+			archive: header + `
+{"id":"1","vector":[0.1],"attributes":{"title":"a"}}
+{"id":"2","vector":[0.2]}
+{"end":{"documents":2}}
+`,
+			expectedCount: 2,
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","schema":{"title":{"type":"string"}},"upserts":[{"id":"1","vector":[0.1],"attributes":{"title":"a"}},{"id":"2","vector":[0.2]}]}`,
+			},
+		},
+		{
+			name: "overrides distance metric",
+			archive: header + `
+{"id":"1","vector":[0.1]}
+{"end":{"documents":1}}
+`,
+			opts:          &tpuf.RestoreOptions{DistanceMetric: tpuf.DistanceMetricEuclidean},
+			expectedCount: 1,
+			expectedUpserts: []string{
+				`{"distance_metric":"euclidean_squared","schema":{"title":{"type":"string"}},"upserts":[{"id":"1","vector":[0.1]}]}`,
+			},
+		},
+		{
+			name: "truncated archive",
+			archive: header + `
+{"id":"1","vector":[0.1]}
+`,
+			expectedCount: 1,
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","schema":{"title":{"type":"string"}},"upserts":[{"id":"1","vector":[0.1]}]}`,
+			},
+			expectedError: "failed to read document 2: backup archive is truncated",
+		},
+		{
+			name: "archive truncated mid-line",
+			archive: header + `
+{"id":"1","vector":[0.1]}
+{"id":"2","vec`,
+			expectedCount: 1,
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","schema":{"title":{"type":"string"}},"upserts":[{"id":"1","vector":[0.1]}]}`,
+			},
+			expectedError: "failed to read document 2: backup archive is truncated",
+		},
+		{
+			name: "trailer count mismatch",
+			archive: header + `
+{"id":"1","vector":[0.1]}
+{"end":{"documents":2}}
+`,
+			expectedCount: 1,
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","schema":{"title":{"type":"string"}},"upserts":[{"id":"1","vector":[0.1]}]}`,
+			},
+			expectedError: "backup archive is corrupt: its trailer records 2 documents, but 1 were read",
+		},
+		{
+			name:          "unsupported version",
+			archive:       `{"format":"tpuf-backup","version":2,"namespace":"old-ns"}`,
+			expectedError: "unsupported backup version 2, expected at most 1",
+		},
+		{
+			name:          "not an archive",
+			archive:       `{"id":"1"}`,
+			expectedError: `not a backup archive: unexpected format ""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upserts []string
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "/v1/vectors/new-ns", req.URL.Path, "unexpected request path")
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						upserts = append(upserts, string(body))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+						}, nil
+					},
+				},
+			}
+
+			count, err := tpuf.Restore(context.Background(), client, "new-ns", strings.NewReader(tt.archive), tt.opts)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedCount, count, "unexpected document count")
+			assert.Equal(t, len(tt.expectedUpserts), len(upserts), "unexpected number of upserts")
+			for i := range upserts {
+				if i < len(tt.expectedUpserts) {
+					assert.JSONEq(t, tt.expectedUpserts[i], upserts[i], "unexpected upsert body")
+				}
+			}
+		})
+	}
+
+	t.Run("truncated error is detectable", func(t *testing.T) {
+		client := &tpuf.Client{ApiToken: "test-token"}
+		_, err := tpuf.Restore(context.Background(), client, "new-ns", strings.NewReader(header), nil)
+		assert.True(t, errors.Is(err, tpuf.ErrTruncatedBackup))
+	})
+}
@@ -0,0 +1,184 @@
+package tpuf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers are dropped rather than returned to the pool,
+// so that an occasional huge upsert doesn't pin its buffer in memory.
+const maxPooledBufferSize = 16 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// marshalPooled encodes v as JSON into a pooled buffer.  The output is identical to json.Marshal.  Return the
+// buffer with putBuffer once the request using it has completed.
+func marshalPooled(v interface{}) (*bytes.Buffer, error) {
+	buf := getBuffer()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	// Encode terminates the value with a newline, which json.Marshal doesn't.
+	buf.Truncate(buf.Len() - 1)
+	return buf, nil
+}
+
+// requestPayload is the body of a request: either fixed bytes, or a function which writes the body anew for
+// each attempt.
+type requestPayload struct {
+	data   []byte
+	encode func(w io.Writer) error
+}
+
+// jsonPayload returns a payload which encodes v as JSON, and a function to call once the request has
+// completed.  If the client streams request bodies, v is encoded directly into each attempt's request body
+// and must not be modified until the request has completed.  Otherwise it is encoded into a pooled buffer.
+func (c *Client) jsonPayload(v interface{}) (requestPayload, func(), error) {
+	if c.StreamRequestBodies {
+		encode := func(w io.Writer) error {
+			return json.NewEncoder(w).Encode(v)
+		}
+		return requestPayload{encode: encode}, func() {}, nil
+	}
+	buf, err := marshalPooled(v)
+	if err != nil {
+		return requestPayload{}, nil, err
+	}
+	return requestPayload{data: buf.Bytes()}, func() { putBuffer(buf) }, nil
+}
+
+// requestBody guards a request body so that it can be reused by every attempt of a request and then safely
+// returned to a pool.  The HTTP transport may still be reading the body of an attempt after the response
+// has been returned, e.g. when the server responds before consuming the whole body, so once the request
+// has completed, close prevents any further reads from touching the underlying buffer.
+type requestBody struct {
+	mu     sync.Mutex
+	data   []byte
+	closed bool
+	// contentEncoding is the Content-Encoding of the body, if it is compressed.
+	contentEncoding string
+	// compressionLevel is the level at which streamed bodies are compressed.
+	compressionLevel int
+	// pooled, if set, holds data and is returned to the pool when the body is closed.
+	pooled *bytes.Buffer
+	// encode, if set, writes the body for each attempt in place of data.
+	encode func(w io.Writer) error
+	// pipes are the readers of attempts whose bodies are being encoded, and encoders tracks their encoding
+	// goroutines, so that close can stop them.
+	pipes    []*io.PipeReader
+	encoders sync.WaitGroup
+}
+
+func newRequestBody(data []byte) *requestBody {
+	return &requestBody{data: data}
+}
+
+func (b *requestBody) empty() bool {
+	return b.encode == nil && len(b.data) == 0
+}
+
+// contentLength returns the length of the body, or 0 if it's unknown because the body is encoded as it is sent.
+func (b *requestBody) contentLength() int64 {
+	if b.encode != nil {
+		return 0
+	}
+	return int64(len(b.data))
+}
+
+// reader returns a reader over the body for one attempt.
+func (b *requestBody) reader() io.ReadCloser {
+	if b.encode == nil {
+		return &requestBodyReader{body: b}
+	}
+
+	pr, pw := io.Pipe()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		pr.CloseWithError(errRequestBodyClosed)
+		return pr
+	}
+	b.pipes = append(b.pipes, pr)
+	b.encoders.Add(1)
+	go func() {
+		defer b.encoders.Done()
+		pw.CloseWithError(b.writeEncoded(pw))
+	}()
+	return pr
+}
+
+func (b *requestBody) writeEncoded(w io.Writer) error {
+	if b.contentEncoding != "gzip" {
+		return b.encode(w)
+	}
+	zw := getGzipWriter(w, b.compressionLevel)
+	err := b.encode(zw)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	putGzipWriter(zw, b.compressionLevel)
+	return err
+}
+
+// close stops any further reads of the body and releases its buffer.  It waits for attempts' encoders to
+// stop, so that the encoded value is no longer used once close returns.
+func (b *requestBody) close() {
+	b.mu.Lock()
+	b.closed = true
+	pipes := b.pipes
+	b.pipes = nil
+	if b.pooled != nil {
+		putBuffer(b.pooled)
+		b.pooled = nil
+	}
+	b.mu.Unlock()
+
+	for _, pr := range pipes {
+		pr.CloseWithError(errRequestBodyClosed)
+	}
+	b.encoders.Wait()
+}
+
+type requestBodyReader struct {
+	body   *requestBody
+	offset int
+}
+
+func (r *requestBodyReader) Read(p []byte) (int, error) {
+	r.body.mu.Lock()
+	defer r.body.mu.Unlock()
+	if r.body.closed {
+		return 0, errRequestBodyClosed
+	}
+	if r.offset >= len(r.body.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.body.data[r.offset:])
+	r.offset += n
+	return n, nil
+}
+
+func (r *requestBodyReader) Close() error {
+	return nil
+}
+
+var errRequestBodyClosed = errors.New("request body read after the request completed")
@@ -0,0 +1,233 @@
+package tpuf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalPooled(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "upsert request", value: benchmarkUpsertRequest(3, 4)},
+		{name: "html characters", value: map[string]string{"text": "<b>fish & chips</b>"}},
+		{name: "null", value: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := json.Marshal(tt.value)
+			assert.NoError(t, err)
+
+			buf, err := marshalPooled(tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), buf.String())
+			putBuffer(buf)
+		})
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	body := newRequestBody([]byte("hello"))
+
+	for i := 0; i < 2; i++ {
+		data, err := io.ReadAll(body.reader())
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(data), "each attempt should read the whole body")
+	}
+
+	reader := body.reader()
+	body.close()
+	_, err := reader.Read(make([]byte, 5))
+	assert.Equal(t, errRequestBodyClosed, err)
+}
+
+func TestStreamedRequestBody(t *testing.T) {
+	body := &requestBody{encode: func(w io.Writer) error {
+		_, err := io.WriteString(w, "hello")
+		return err
+	}}
+
+	for i := 0; i < 2; i++ {
+		data, err := io.ReadAll(body.reader())
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(data), "each attempt should encode the whole body")
+	}
+
+	// An attempt whose body is never read mustn't keep its encoder blocked once the request completes.
+	unread := body.reader()
+	body.close()
+	_, err := unread.Read(make([]byte, 5))
+	assert.Error(t, err)
+	_, err = body.reader().Read(make([]byte, 5))
+	assert.Error(t, err)
+}
+
+func TestClientStreamRequestBodies(t *testing.T) {
+	const expectedBody = `{"upserts":[{"id":"1","vector":[0.1]}]}`
+
+	for _, useGzip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%v", useGzip), func(t *testing.T) {
+			calls := 0
+			var observed *RequestStats
+			client := &Client{
+				ApiToken:            "test-token",
+				StreamRequestBodies: true,
+				UseGzipEncoding:     useGzip,
+				Timer:               &fakeTimer{},
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						calls++
+						assert.Equal(t, int64(0), req.ContentLength, "streamed bodies have no known length")
+						data, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						if useGzip {
+							assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+							data = []byte(gunzip(t, data))
+						}
+						assert.JSONEq(t, expectedBody, string(data), "each attempt should send the whole body")
+						status := http.StatusOK
+						if calls == 1 {
+							status = http.StatusServiceUnavailable
+						}
+						return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`))}, nil
+					},
+				},
+				Observer: RequestObserverFunc(func(ctx context.Context, stats *RequestStats) {
+					observed = stats
+				}),
+			}
+
+			_, err := client.Upsert(context.Background(), "test-ns", &UpsertRequest{
+				Upserts: []*Upsert{{ID: "1", Vector: []float32{0.1}}},
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 2, calls, "unexpected number of calls")
+			if assert.NotNil(t, observed) {
+				assert.Equal(t, -1, observed.RequestBytes)
+			}
+		})
+	}
+}
+
+func TestClientSendsContentLength(t *testing.T) {
+	client := &Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, int64(len(`{"top_k":1}`)), req.ContentLength)
+				retry, err := req.GetBody()
+				assert.NoError(t, err)
+				data, err := io.ReadAll(retry)
+				assert.NoError(t, err)
+				assert.Equal(t, `{"top_k":1}`, string(data))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`[]`))}, nil
+			},
+		},
+	}
+
+	_, err := client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+	assert.NoError(t, err)
+}
+
+func benchmarkUpsertRequest(documents int, dimensions int) *UpsertRequest {
+	request := &UpsertRequest{DistanceMetric: DistanceMetricCosine}
+	for i := 0; i < documents; i++ {
+		vector := make([]float32, dimensions)
+		for j := range vector {
+			vector[j] = float32(i*dimensions+j) / 1000
+		}
+		request.Upserts = append(request.Upserts, &Upsert{
+			ID:         fmt.Sprint(i),
+			Vector:     vector,
+			Attributes: map[string]interface{}{"title": fmt.Sprintf("document %d", i)},
+		})
+	}
+	return request
+}
+
+func benchmarkClient() *Client {
+	return &Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				if _, err := io.Copy(io.Discard, req.Body); err != nil {
+					return nil, err
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`))}, nil
+			},
+		},
+	}
+}
+
+// BenchmarkMarshalUpsertRequest compares encoding an upsert request with json.Marshal to encoding it into
+// a pooled buffer, as the client does.
+func BenchmarkMarshalUpsertRequest(b *testing.B) {
+	request := benchmarkUpsertRequest(100, 256)
+
+	b.Run("json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(request); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, err := marshalPooled(request)
+			if err != nil {
+				b.Fatal(err)
+			}
+			putBuffer(buf)
+		}
+	})
+}
+
+func BenchmarkUpsert(b *testing.B) {
+	request := benchmarkUpsertRequest(100, 256)
+	for _, stream := range []bool{false, true} {
+		b.Run(fmt.Sprintf("stream=%v", stream), func(b *testing.B) {
+			client := benchmarkClient()
+			client.StreamRequestBodies = stream
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.Upsert(context.Background(), "test-ns", request); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	client := benchmarkClient()
+	client.HttpClient = &fakeHttpClient{
+		doFunc: func(req *http.Request) (*http.Response, error) {
+			if _, err := io.Copy(io.Discard, req.Body); err != nil {
+				return nil, err
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0.1}]`))}, nil
+		},
+	}
+	request := &QueryRequest{Vector: benchmarkUpsertRequest(1, 1536).Upserts[0].Vector, TopK: 10}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Query(context.Background(), "test-ns", request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
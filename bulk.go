@@ -0,0 +1,275 @@
+package tpuf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultBulkBatchSize = 1000
+
+// defaultMaxBisectRejections is the number of invalid documents BisectOnError isolates in a batch unless
+// BulkUpserter.MaxBisectRejections is set.
+const defaultMaxBisectRejections = 10
+
+// ErrTooManyRejected is returned by BulkUpserter.Flush when BisectOnError has rejected more than
+// BulkUpserter.MaxBisectRejections documents of a batch.
+var ErrTooManyRejected = errors.New("too many documents rejected")
+
+// BulkUpserter batches documents into upsert requests against a single namespace.
+// Documents are buffered by Add and written once BatchSize documents are pending, or when Flush is called.
+// Retriable errors are retried per batch by the Client.  If a batch still fails, its documents remain
+// pending and calling Flush again retries only that batch.  A batch which the server rejects as too large
+// is split by Client.Upsert, and documents which are too large on their own are reported by a
+// *BulkRejectedError once the rest of the batch has been written.
+// A BulkUpserter is not safe for concurrent use.
+type BulkUpserter struct {
+	// Client is the client used to perform upserts.  Required.
+	Client *Client
+	// Namespace is the namespace to upsert into.  Required.
+	Namespace string
+	// BatchSize is the number of documents per upsert request.  Defaults to 1000.
+	BatchSize int
+	// DistanceMetric is sent with every batch.
+	DistanceMetric DistanceMetric
+	// Schema is sent with every batch.
+	Schema Schema
+	// AllowNoVector permits documents without vectors.  See UpsertRequest.AllowNoVector.
+	AllowNoVector bool
+	// DuplicateIDs is applied to every batch.  Note that duplicates in different batches are not detected.
+	// See UpsertRequest.DuplicateIDs.
+	DuplicateIDs DuplicateIDPolicy
+	// SkipUnchanged skips documents whose attributes haven't changed.  See UpsertRequest.SkipUnchanged.
+	SkipUnchanged bool
+	// Version is stamped on every document.  See UpsertRequest.Version.
+	Version uint64
+	// Checkpoints, if set, records progress after every successful flush.  When a BulkUpserter is
+	// created with the same store after a crash, documents up to the saved offset are skipped,
+	// so the caller can simply replay its input from the beginning.
+	Checkpoints CheckpointStore
+	// BisectOnError, if set, splits a batch which the server rejects as invalid into halves and retries
+	// each recursively, so that only the offending documents are dropped.  Rejected documents are
+	// reported by a *BulkRejectedError once the rest of the batch has been written.
+	//
+	// Each invalid document costs up to 2*log2(BatchSize) extra requests to isolate, so a batch of n
+	// documents which are all invalid would take about 2n requests.  Bisecting therefore stops once more
+	// than MaxBisectRejections documents of a batch have been rejected, and Flush returns an error wrapping
+	// ErrTooManyRejected, leaving the batch pending.
+	BisectOnError bool
+	// MaxBisectRejections is the number of invalid documents BisectOnError isolates in a batch before
+	// giving up.  Defaults to 10.
+	MaxBisectRejections int
+
+	initialized bool
+	skip        int64
+	offset      int64
+	pending     []*Upsert
+}
+
+// Checkpoint records the progress of a bulk load.
+type Checkpoint struct {
+	// Offset is the number of documents, counted from the start of the input, which have been written.
+	Offset int64 `json:"offset"`
+	// LastID is the ID of the last document written.  Numeric IDs are recorded in decimal.
+	LastID string `json:"last_id"`
+}
+
+// CheckpointStore persists bulk load progress so that an interrupted load can resume.
+type CheckpointStore interface {
+	// Load returns the last saved checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*Checkpoint, error)
+	// Save persists the given checkpoint, replacing any previous one.
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+}
+
+func (b *BulkUpserter) batchSize() int {
+	if b.BatchSize <= 0 {
+		return defaultBulkBatchSize
+	}
+	return b.BatchSize
+}
+
+func (b *BulkUpserter) maxBisectRejections() int {
+	if b.MaxBisectRejections <= 0 {
+		return defaultMaxBisectRejections
+	}
+	return b.MaxBisectRejections
+}
+
+func (b *BulkUpserter) init(ctx context.Context) error {
+	if b.initialized {
+		return nil
+	}
+	if b.Checkpoints != nil {
+		checkpoint, err := b.Checkpoints.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+		if checkpoint != nil {
+			b.skip = checkpoint.Offset
+		}
+	}
+	b.initialized = true
+	return nil
+}
+
+// Add buffers a document for upsert, flushing if the batch is full.
+// Documents already covered by a loaded checkpoint are skipped.
+func (b *BulkUpserter) Add(ctx context.Context, upsert *Upsert) error {
+	if err := b.init(ctx); err != nil {
+		return err
+	}
+	b.offset++
+	if b.offset <= b.skip {
+		return nil
+	}
+	b.pending = append(b.pending, upsert)
+	if len(b.pending) >= b.batchSize() {
+		return b.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush writes any pending documents and saves a checkpoint.
+// Flush must be called once all documents have been added.
+func (b *BulkUpserter) Flush(ctx context.Context) error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	var rejected []*RejectedDocument
+	if err := b.bisect(ctx, b.pending, &rejected); err != nil {
+		return fmt.Errorf("failed to flush batch ending at offset %d: %w", b.offset, err)
+	}
+	lastID := b.pending[len(b.pending)-1].documentID().str
+	b.pending = nil
+
+	if b.Checkpoints != nil {
+		if err := b.Checkpoints.Save(ctx, &Checkpoint{Offset: b.offset, LastID: lastID}); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+	}
+	if len(rejected) > 0 {
+		return &BulkRejectedError{Rejected: rejected}
+	}
+	return nil
+}
+
+func (b *BulkUpserter) write(ctx context.Context, upserts []*Upsert) error {
+	_, err := b.Client.Upsert(ctx, b.Namespace, &UpsertRequest{
+		DistanceMetric: b.DistanceMetric,
+		Schema:         b.Schema,
+		Upserts:        upserts,
+		AllowNoVector:  b.AllowNoVector,
+		DuplicateIDs:   b.DuplicateIDs,
+		SkipUnchanged:  b.SkipUnchanged,
+		Version:        b.Version,
+	})
+	return err
+}
+
+// bisect writes the given documents, recursively halving the batch on validation errors if BisectOnError
+// is set, until the offending documents are isolated or too many of them have been rejected.  Documents
+// which Client.Upsert rejected as too large are collected along with them.
+func (b *BulkUpserter) bisect(ctx context.Context, upserts []*Upsert, rejected *[]*RejectedDocument) error {
+	err := b.write(ctx, upserts)
+	var rejectedErr *BulkRejectedError
+	if errors.As(err, &rejectedErr) {
+		*rejected = append(*rejected, rejectedErr.Rejected...)
+		return nil
+	}
+	if err == nil || !b.BisectOnError || !isValidationError(err) {
+		return err
+	}
+	if len(upserts) == 1 {
+		*rejected = append(*rejected, &RejectedDocument{ID: upserts[0].documentID().str, Err: err})
+		if limit := b.maxBisectRejections(); len(*rejected) > limit {
+			return fmt.Errorf("%w: stopped bisecting after more than %d documents were rejected: %w", ErrTooManyRejected, limit, err)
+		}
+		return nil
+	}
+	mid := len(upserts) / 2
+	if err := b.bisect(ctx, upserts[:mid], rejected); err != nil {
+		return err
+	}
+	return b.bisect(ctx, upserts[mid:], rejected)
+}
+
+func isValidationError(err error) bool {
+	var apiErr ApiError
+	return errors.As(err, &apiErr) &&
+		(apiErr.HttpStatus == http.StatusBadRequest || apiErr.HttpStatus == http.StatusUnprocessableEntity)
+}
+
+// RejectedDocument is a document which the server refused to write.
+type RejectedDocument struct {
+	ID  string
+	Err error
+}
+
+// BulkRejectedError is returned by BulkUpserter.Flush and Client.Upsert when some documents were rejected,
+// either for being too large on their own or, with BulkUpserter.BisectOnError, for being invalid.  All other
+// documents were written.
+type BulkRejectedError struct {
+	Rejected []*RejectedDocument
+}
+
+func (e *BulkRejectedError) Error() string {
+	details := make([]string, len(e.Rejected))
+	for i, doc := range e.Rejected {
+		details[i] = fmt.Sprintf("%s (%v)", doc.ID, doc.Err)
+	}
+	return fmt.Sprintf("%d documents rejected: %s", len(e.Rejected), strings.Join(details, ", "))
+}
+
+// FileCheckpointStore is a CheckpointStore which keeps the checkpoint as JSON in a local file.
+type FileCheckpointStore struct {
+	// Path is the file in which the checkpoint is stored.  Required.
+	Path string
+}
+
+func (s *FileCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint to a temporary file and renames it into place, so that a crash
+// mid-write never leaves a corrupt checkpoint behind.
+func (s *FileCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	return writeFileAtomic(s.Path, data)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,199 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkUpserter(t *testing.T) {
+	tests := []struct {
+		name               string
+		numDocs            int
+		numericIDs         bool
+		batchSize          int
+		checkpoint         *tpuf.Checkpoint
+		failOnCall         int
+		bisectOnError      bool
+		maxRejections      int
+		invalidIDs         []string
+		maxBatch           int
+		oversizedID        string
+		expectedError      string
+		expectedBatches    [][]string
+		expectedCheckpoint *tpuf.Checkpoint
+	}{
+		{
+			name:               "batches documents",
+			numDocs:            5,
+			batchSize:          2,
+			expectedBatches:    [][]string{{"1", "2"}, {"3", "4"}, {"5"}},
+			expectedCheckpoint: &tpuf.Checkpoint{Offset: 5, LastID: "5"},
+		},
+		{
+			name:               "checkpoints numeric ids",
+			numDocs:            3,
+			numericIDs:         true,
+			batchSize:          2,
+			expectedBatches:    [][]string{{"1", "2"}, {"3"}},
+			expectedCheckpoint: &tpuf.Checkpoint{Offset: 3, LastID: "3"},
+		},
+		{
+			name:               "resumes from checkpoint",
+			numDocs:            5,
+			batchSize:          2,
+			checkpoint:         &tpuf.Checkpoint{Offset: 3, LastID: "3"},
+			expectedBatches:    [][]string{{"4", "5"}},
+			expectedCheckpoint: &tpuf.Checkpoint{Offset: 5, LastID: "5"},
+		},
+		{
+			name:               "failed batch is not checkpointed",
+			numDocs:            5,
+			batchSize:          2,
+			failOnCall:         2,
+			expectedError:      "failed to flush batch ending at offset 4: failed to upsert documents: error: Invalid request (HTTP 400)",
+			expectedBatches:    [][]string{{"1", "2"}, {"3", "4"}},
+			expectedCheckpoint: &tpuf.Checkpoint{Offset: 2, LastID: "2"},
+		},
+		{
+			name:               "bisects to isolate invalid document",
+			numDocs:            5,
+			batchSize:          4,
+			bisectOnError:      true,
+			invalidIDs:         []string{"3"},
+			expectedError:      "1 documents rejected: 3 (failed to upsert documents: error: Invalid request (HTTP 400))",
+			expectedBatches:    [][]string{{"1", "2", "3", "4"}, {"1", "2"}, {"3", "4"}, {"3"}, {"4"}},
+			expectedCheckpoint: &tpuf.Checkpoint{Offset: 4, LastID: "4"},
+		},
+		{
+			name:               "stops bisecting after too many invalid documents",
+			numDocs:            4,
+			batchSize:          4,
+			bisectOnError:      true,
+			maxRejections:      1,
+			invalidIDs:         []string{"1", "2", "3", "4"},
+			expectedError:      "failed to flush batch ending at offset 4: too many documents rejected: stopped bisecting after more than 1 documents were rejected: failed to upsert documents: error: Invalid request (HTTP 400)",
+			expectedBatches:    [][]string{{"1", "2", "3", "4"}, {"1", "2"}, {"1"}, {"2"}},
+			expectedCheckpoint: nil,
+		},
+		{
+			name:               "splits batches which are too large",
+			numDocs:            5,
+			batchSize:          4,
+			maxBatch:           2,
+			expectedBatches:    [][]string{{"1", "2", "3", "4"}, {"1", "2"}, {"3", "4"}, {"5"}},
+			expectedCheckpoint: &tpuf.Checkpoint{Offset: 5, LastID: "5"},
+		},
+		{
+			name:               "rejects documents which are too large on their own",
+			numDocs:            4,
+			batchSize:          4,
+			oversizedID:        "2",
+			expectedError:      "1 documents rejected: 2 (failed to upsert documents: error: Payload too large (HTTP 413))",
+			expectedBatches:    [][]string{{"1", "2", "3", "4"}, {"1", "2"}, {"1"}, {"2"}, {"3", "4"}},
+			expectedCheckpoint: &tpuf.Checkpoint{Offset: 4, LastID: "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &tpuf.FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+			if tt.checkpoint != nil {
+				assert.NoError(t, store.Save(context.Background(), tt.checkpoint))
+			}
+
+			var batches [][]string
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						var body struct {
+							Upserts []struct {
+								ID json.RawMessage `json:"id"`
+							} `json:"upserts"`
+						}
+						assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+						var ids []string
+						for _, upsert := range body.Upserts {
+							ids = append(ids, strings.Trim(string(upsert.ID), `"`))
+						}
+						batches = append(batches, ids)
+
+						invalid, oversized := false, tt.maxBatch > 0 && len(ids) > tt.maxBatch
+						for _, id := range ids {
+							for _, invalidID := range tt.invalidIDs {
+								invalid = invalid || id == invalidID
+							}
+							oversized = oversized || id == tt.oversizedID
+						}
+						if oversized {
+							return &http.Response{
+								StatusCode: http.StatusRequestEntityTooLarge,
+								Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Payload too large","status":"error"}`)),
+							}, nil
+						}
+						if len(batches) == tt.failOnCall || invalid {
+							return &http.Response{
+								StatusCode: http.StatusBadRequest,
+								Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid request","status":"error"}`)),
+							}, nil
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+						}, nil
+					},
+				},
+			}
+
+			upserter := &tpuf.BulkUpserter{
+				Client:              client,
+				Namespace:           "test-namespace",
+				BatchSize:           tt.batchSize,
+				Checkpoints:         store,
+				BisectOnError:       tt.bisectOnError,
+				MaxBisectRejections: tt.maxRejections,
+			}
+
+			var err error
+			for i := 1; i <= tt.numDocs && err == nil; i++ {
+				upsert := &tpuf.Upsert{ID: strconv.Itoa(i), Vector: []float32{0.1}}
+				if tt.numericIDs {
+					upsert = &tpuf.Upsert{IDUint64: tpuf.Uint64(uint64(i)), Vector: []float32{0.1}}
+				}
+				err = upserter.Add(context.Background(), upsert)
+			}
+			if err == nil {
+				err = upserter.Flush(context.Background())
+			}
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedBatches, batches, "unexpected batches")
+
+			checkpoint, err := store.Load(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCheckpoint, checkpoint, "unexpected checkpoint")
+		})
+	}
+}
+
+func TestFileCheckpointStoreMissingFile(t *testing.T) {
+	store := &tpuf.FileCheckpointStore{Path: filepath.Join(t.TempDir(), "missing.json")}
+	checkpoint, err := store.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, checkpoint)
+}
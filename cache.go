@@ -0,0 +1,76 @@
+package tpuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cache warm statuses returned by WarmCache.
+const (
+	// CacheWarmStatusAccepted indicates that the namespace has started warming.
+	CacheWarmStatusAccepted = "ACCEPTED"
+	// CacheWarmStatusOK indicates that the namespace is already warm.
+	CacheWarmStatusOK = "OK"
+)
+
+type WarmCacheResponse struct {
+	// Status is CacheWarmStatusAccepted if warming has started, or CacheWarmStatusOK if the cache is already warm.
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// WarmCache hints to turbopuffer that the namespace is about to be queried, so that it can begin
+// loading the namespace into cache.  This returns immediately, without waiting for the cache to warm.
+// See https://turbopuffer.com/docs/warm-cache for more details.
+func (c *Client) WarmCache(ctx context.Context, namespace string) (*WarmCacheResponse, error) {
+	path := fmt.Sprintf("/v1/namespaces/%s/hint_cache_warm", namespace)
+	respData, err := c.get(ctx, OperationWarmCache, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to warm cache: %w", err)
+	}
+
+	var response WarmCacheResponse
+	if err := json.Unmarshal(respData, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &response, nil
+}
+
+type QueryWarmOptions struct {
+	// WaitUntilWarm polls the cache warm hint until it reports that the namespace is warm before querying.
+	// Use the context to bound how long to wait.
+	WaitUntilWarm bool
+	// PollInterval is the time between polls while waiting.  Defaults to 1 second.
+	PollInterval time.Duration
+}
+
+// QueryWarm warms the namespace cache and then queries it, optionally waiting for the cache to warm
+// first.  This is useful to reduce the latency of the first query of an interactive session.  opts may be nil.
+func (c *Client) QueryWarm(ctx context.Context, namespace string, request *QueryRequest, opts *QueryWarmOptions) ([]*QueryResult, error) {
+	if opts == nil {
+		opts = &QueryWarmOptions{}
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	for {
+		response, err := c.WarmCache(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !opts.WaitUntilWarm || response.Status == CacheWarmStatusOK {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for cache to warm: %w", ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+
+	return c.Query(ctx, namespace, request)
+}
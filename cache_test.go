@@ -0,0 +1,160 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmCache(t *testing.T) {
+	tests := []struct {
+		name           string
+		httpResponse   *http.Response
+		expectedError  string
+		expectedResult *tpuf.WarmCacheResponse
+	}{
+		{
+			name: "warming started",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"ACCEPTED","message":"cache starting to warm"}`)),
+			},
+			expectedResult: &tpuf.WarmCacheResponse{Status: tpuf.CacheWarmStatusAccepted, Message: "cache starting to warm"},
+		},
+		{
+			name: "already warm",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK","message":"cache is already warm"}`)),
+			},
+			expectedResult: &tpuf.WarmCacheResponse{Status: tpuf.CacheWarmStatusOK, Message: "cache is already warm"},
+		},
+		{
+			name: "namespace not found",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"error","error":"namespace not found"}`)),
+			},
+			expectedError: "failed to warm cache: error: namespace not found (HTTP 404)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, http.MethodGet, req.Method, "unexpected request method")
+						assert.Equal(t, "https://api.turbopuffer.com/v1/namespaces/test-namespace/hint_cache_warm", req.URL.String(), "unexpected request URL")
+						return tt.httpResponse, nil
+					},
+				},
+			}
+
+			result, err := client.WarmCache(context.Background(), "test-namespace")
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result, "unexpected warm cache response")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, result)
+			}
+		})
+	}
+}
+
+func TestQueryWarm(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          *tpuf.QueryWarmOptions
+		warmStatuses  []string
+		expectedWarms int
+	}{
+		{
+			name:          "without waiting",
+			warmStatuses:  []string{"ACCEPTED"},
+			expectedWarms: 1,
+		},
+		{
+			name:          "wait until warm",
+			opts:          &tpuf.QueryWarmOptions{WaitUntilWarm: true, PollInterval: time.Millisecond},
+			warmStatuses:  []string{"ACCEPTED", "ACCEPTED", "OK"},
+			expectedWarms: 3,
+		},
+		{
+			name:          "wait when already warm",
+			opts:          &tpuf.QueryWarmOptions{WaitUntilWarm: true, PollInterval: time.Millisecond},
+			warmStatuses:  []string{"OK"},
+			expectedWarms: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warms := 0
+			queries := 0
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						if strings.HasSuffix(req.URL.Path, "/hint_cache_warm") {
+							assert.Zero(t, queries, "warmed cache after querying")
+							status := tt.warmStatuses[warms]
+							warms++
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body:       io.NopCloser(bytes.NewBufferString(`{"status":"` + status + `"}`)),
+							}, nil
+						}
+						assert.Equal(t, "/v1/vectors/test-namespace/query", req.URL.Path, "unexpected request path")
+						queries++
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0.5}]`)),
+						}, nil
+					},
+				},
+			}
+
+			results, err := client.QueryWarm(context.Background(), "test-namespace", &tpuf.QueryRequest{TopK: 1}, tt.opts)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedWarms, warms, "unexpected number of cache warm requests")
+			assert.Equal(t, 1, queries, "unexpected number of queries")
+			assert.Len(t, results, 1)
+		})
+	}
+}
+
+func TestQueryWarmCanceled(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				assert.True(t, strings.HasSuffix(req.URL.Path, "/hint_cache_warm"), "unexpected query before cache was warm")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"ACCEPTED"}`)),
+				}, nil
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.QueryWarm(ctx, "test-namespace", &tpuf.QueryRequest{TopK: 1}, &tpuf.QueryWarmOptions{
+		WaitUntilWarm: true,
+		PollInterval:  time.Millisecond,
+	})
+
+	assert.EqualError(t, err, "failed to wait for cache to warm: context deadline exceeded")
+}
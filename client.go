@@ -3,9 +3,9 @@
 package tpuf
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -21,10 +21,15 @@ type HttpClient interface {
 
 // Client represents the main client for interacting with the API.
 type Client struct {
-	// ApiToken is the turbopuffer API token used to authenticate all requests.  Required.
+	// ApiToken is the turbopuffer API token used to authenticate all requests.  Required unless Auth is set.
 	ApiToken string
 
-	// BaseURL is the base URL for all API endpoints.
+	// Auth, if set, authenticates requests in place of the default "Authorization: Bearer <ApiToken>" header,
+	// e.g. to route through a gateway which expects credentials elsewhere, or to sign each request.
+	Auth Authenticator
+
+	// BaseURL is the base URL for all API endpoints.  It may include a path prefix and query parameters,
+	// e.g. for a gateway in front of the API, which are kept on every request.
 	// Defaults to https://api.turbopuffer.com
 	BaseURL string
 
@@ -33,15 +38,104 @@ type Client struct {
 	// Retry interval is exponential backoff starting out at 2 seconds and maxing at 64.
 	MaxRetries int
 
-	// DisableRetry disables retries for all requests.
+	// DisableRetry disables retries for all requests, regardless of RetryPolicies.
 	DisableRetry bool
 
+	// RequestTimeout limits each attempt of a request, including reading its response, when the caller's
+	// context has no deadline.  Attempts which time out are retried like other network errors.  Defaults to
+	// no limit.
+	RequestTimeout time.Duration
+
+	// NotFoundGracePeriod, if set, retries reads of a namespace which fail with 404 Not Found for this long
+	// after this client last wrote to the namespace, to absorb the delay before a newly created namespace
+	// becomes visible.
+	NotFoundGracePeriod time.Duration
+
+	// RetryPolicies overrides the retry settings for individual operations, e.g. to retry queries
+	// persistently while retrying upserts at most once.  Operations without a policy use MaxRetries.
+	RetryPolicies map[Operation]*RetryPolicy
+
+	// UseGzipEncoding compresses request bodies with gzip, which reduces upload time for large upserts at
+	// the cost of CPU.  Responses are decompressed transparently by the default HTTP transport regardless.
+	UseGzipEncoding bool
+
+	// CompressAboveBytes, if positive, compresses only request bodies of at least this many bytes with gzip,
+	// so that small queries skip the overhead while large upserts are compressed.  Streamed request bodies,
+	// whose size isn't known upfront, are always compressed.  UseGzipEncoding takes precedence.
+	CompressAboveBytes int
+
+	// CompressionLevel is the gzip compression level of compressed request bodies, from gzip.HuffmanOnly
+	// to gzip.BestCompression.  Use gzip.BestSpeed to save CPU, or gzip.BestCompression to save bandwidth.
+	// Defaults to gzip.DefaultCompression if nil; gzip.NoCompression is a valid level.
+	CompressionLevel *int
+
+	// ResponseDecoders, if set, are advertised in the Accept-Encoding header, in order of preference, and
+	// decode responses compressed with their encodings, e.g. brotli using the tpufbrotli module.  gzip
+	// responses are decoded regardless.
+	ResponseDecoders []*ContentDecoder
+
+	// StreamRequestBodies encodes upsert and query requests directly into the connection rather than into
+	// a buffer first, which roughly halves peak memory for large upserts.  The request is encoded again for
+	// each retry, and is sent with chunked transfer encoding since its length isn't known upfront.
+	StreamRequestBodies bool
+
 	// HttpClient is the HTTP client used for making requests.
 	// Defaults to &http.Client{}.
 	HttpClient HttpClient
 
 	// Timer is the timer used for exponential backoff.
 	Timer backoff.Timer
+
+	// VectorEncoding controls how vectors are encoded in upsert and query payloads.
+	// Defaults to VectorEncodingFloat.
+	VectorEncoding VectorEncoding
+
+	// PathStyle selects which API paths namespace requests are sent to.  Set it to PathStyleNamespaces to opt
+	// in to the consolidated /v1/namespaces paths.  Defaults to PathStyleVectors.
+	PathStyle PathStyle
+
+	// MaxTopK is the largest TopK sent in a single query.  Query and QueryWithMetadata split queries ordered by
+	// ID, i.e. filter-only queries and queries ranked by Asc(IDAttribute) or Desc(IDAttribute), with a larger
+	// TopK into several requests, each continuing after the last ID returned, and concatenate their results.
+	// The requests may observe different writes.  Other queries are sent unchanged.  Defaults to 1200, the
+	// API's limit.
+	MaxTopK int
+
+	// DeleteBatchSize is the largest number of IDs sent in a single request by Delete and DeleteUint64, which
+	// split longer lists into several requests to stay under the API's payload limit.  Defaults to 10000.
+	DeleteBatchSize int
+
+	// DeleteConcurrency is the number of batch requests Delete and DeleteUint64 send at once.  Defaults to 4.
+	DeleteConcurrency int
+
+	// Consistency is the default consistency level for queries which don't specify one.
+	// Defaults to the server default, which is strong consistency.
+	Consistency ConsistencyLevel
+
+	// Logger, if set, receives debug logs of completed requests and warnings of retries and failures.
+	Logger Logger
+
+	// Observer, if set, is notified of every API request once it completes, e.g. to record metrics.
+	Observer RequestObserver
+
+	// DisableNameValidation skips checking namespace and schema attribute names before sending requests.
+	// See ValidateNamespaceName and ValidateAttributeName.
+	DisableNameValidation bool
+
+	// AuditHook, if set, is notified of every upsert and deletion once it completes, e.g. for compliance logging.
+	AuditHook AuditHook
+
+	// RetryWritesOnNetworkError enables retrying writes, such as upserts and deletes, which fail without
+	// a response from the server, e.g. due to a connection reset.  Such writes may already have been
+	// applied, so they are not retried by default.  Reads are always retried on network errors.
+	// RetryOnNetworkError takes precedence if set.
+	RetryWritesOnNetworkError bool
+
+	// RetryOnNetworkError, if set, selects which kinds of network errors are retried, for reads and
+	// writes alike.  It can be overridden per operation by RetryPolicies.
+	RetryOnNetworkError *NetworkErrorPolicy
+
+	writes *writeTracker
 }
 
 const defaultBaseURL = "https://api.turbopuffer.com"
@@ -62,83 +156,225 @@ func (c *Client) httpClient() HttpClient {
 	return c.HttpClient
 }
 
-const defaultMaxRetries = 6
+func (c *Client) get(ctx context.Context, op Operation, path string, values url.Values) ([]byte, error) {
+	return c.do(ctx, op, http.MethodGet, path, values, nil)
+}
 
-func (c *Client) maxRetries() int {
-	if c.DisableRetry {
-		return 0
-	}
-	if c.MaxRetries == 0 {
-		return defaultMaxRetries
-	}
-	return c.MaxRetries
+func (c *Client) post(ctx context.Context, op Operation, path string, body []byte) ([]byte, error) {
+	return c.do(ctx, op, http.MethodPost, path, nil, body)
 }
 
-func (c *Client) get(ctx context.Context, path string, values url.Values) ([]byte, error) {
-	return c.do(ctx, http.MethodGet, path, values, nil)
+func (c *Client) delete(ctx context.Context, op Operation, path string) ([]byte, error) {
+	return c.do(ctx, op, http.MethodDelete, path, nil, nil)
 }
 
-func (c *Client) post(ctx context.Context, path string, body []byte) ([]byte, error) {
-	return c.do(ctx, http.MethodPost, path, nil, body)
+func (c *Client) do(ctx context.Context, op Operation, method string, path string, values url.Values, body []byte) ([]byte, error) {
+	respData, _, err := c.doWithHeader(ctx, op, method, path, values, requestPayload{data: body})
+	return respData, err
 }
 
-func (c *Client) delete(ctx context.Context, path string) ([]byte, error) {
-	return c.do(ctx, http.MethodDelete, path, nil, nil)
+// doWithHeader is like do, but also returns the headers of the successful response.
+func (c *Client) doWithHeader(ctx context.Context, op Operation, method string, path string, values url.Values, body requestPayload) ([]byte, http.Header, error) {
+	if err := c.validatePath(path); err != nil {
+		return nil, nil, err
+	}
+	reqUrl, err := c.requestURL(path, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type result struct {
+		body   []byte
+		header http.Header
+	}
+	stats := c.startStats(op, path, body)
+	reqBody, err := c.newRequestBody(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reqBody.close()
+	res, err := withRetries(c, op, c.logRetry(ctx, stats), func() (result, error) {
+		stats.Attempts++
+		resp, err := c.send(ctx, op, stats, method, reqUrl, reqBody)
+		if err != nil {
+			return result{}, err
+		}
+		defer drainAndClose(resp.Body)
+		respData, err := io.ReadAll(resp.Body)
+		stats.ResponseBytes = len(respData)
+		return result{respData, resp.Header}, err
+	})
+	c.logRequest(ctx, stats, err)
+	c.observe(ctx, stats, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.recordWrite(op, stats.Namespace)
+	return res.body, res.header, nil
 }
 
-func (c *Client) do(ctx context.Context, method string, path string, values url.Values, body []byte) ([]byte, error) {
-	endpoint, err := url.JoinPath(c.baseURL(), path)
+// doStream is like do, but returns the response body unread so that it can be decoded incrementally.
+// Retries apply only until a successful response is received.  The caller must close the body.
+func (c *Client) doStream(ctx context.Context, op Operation, method string, path string, values url.Values, body requestPayload) (io.ReadCloser, error) {
+	if err := c.validatePath(path); err != nil {
+		return nil, err
+	}
+	reqUrl, err := c.requestURL(path, values)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := c.startStats(op, path, body)
+	reqBody, err := c.newRequestBody(body)
 	if err != nil {
 		return nil, err
 	}
-	reqUrl, err := url.Parse(endpoint)
+	defer reqBody.close()
+	resp, err := withRetries(c, op, c.logRetry(ctx, stats), func() (*http.Response, error) {
+		stats.Attempts++
+		return c.send(ctx, op, stats, method, reqUrl, reqBody)
+	})
+	stats.ResponseBytes = -1
+	c.logRequest(ctx, stats, err)
+	c.observe(ctx, stats, err)
 	if err != nil {
 		return nil, err
 	}
-	reqUrl.RawQuery = values.Encode()
+	return &drainingBody{resp.Body}, nil
+}
+
+// maxDrainBytes bounds how much of an unread response body is discarded so that its connection can be
+// reused.  Abandoning the connection is cheaper than reading a larger remainder.
+const maxDrainBytes = 256 << 10
+
+// drainAndClose discards the rest of a response body before closing it, so that the transport can return
+// the connection to its pool rather than closing it.
+func drainAndClose(body io.ReadCloser) error {
+	_, _ = io.CopyN(io.Discard, body, maxDrainBytes)
+	return body.Close()
+}
+
+// drainingBody is a response body which is drained when closed, e.g. when a streamed query is abandoned
+// before its last result, or the decoder stops before the trailing newline.
+type drainingBody struct {
+	io.ReadCloser
+}
+
+func (b *drainingBody) Close() error {
+	return drainAndClose(b.ReadCloser)
+}
+
+// requestURL joins an API path onto the base URL.  Any path prefix and query parameters of the base URL are
+// preserved, e.g. for a gateway at https://gateway.internal/turbopuffer/proxy?tenant=search.
+func (c *Client) requestURL(path string, values url.Values) (*url.URL, error) {
+	base, err := url.Parse(c.baseURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	reqUrl := base.JoinPath(path)
+	if len(values) > 0 {
+		if reqUrl.RawQuery == "" {
+			reqUrl.RawQuery = values.Encode()
+		} else {
+			reqUrl.RawQuery += "&" + values.Encode()
+		}
+	}
+	return reqUrl, nil
+}
 
+func withRetries[T any](c *Client, op Operation, notify backoff.Notify, operation backoff.OperationWithData[T]) (T, error) {
+	policy := c.retryPolicy(op)
 	return backoff.RetryNotifyWithTimerAndData(
-		func() ([]byte, error) {
-			var bodyToUse io.Reader
-			if len(body) > 0 {
-				bodyToUse = bytes.NewReader(body)
-			}
-			return c.doOnce(ctx, method, reqUrl, bodyToUse)
-		},
+		operation,
 		backoff.WithMaxRetries(backoff.NewExponentialBackOff(
-			backoff.WithInitialInterval(2*time.Second),
+			backoff.WithInitialInterval(policy.InitialInterval),
 			backoff.WithMultiplier(2.0),
-			backoff.WithMaxInterval(64*time.Second),
-		), uint64(c.maxRetries())),
-		nil,
+			backoff.WithMaxInterval(policy.MaxInterval),
+		), uint64(policy.MaxRetries)),
+		notify,
 		c.Timer,
 	)
 }
 
-func (c *Client) doOnce(ctx context.Context, method string, reqUrl *url.URL, body io.Reader) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, method, reqUrl.String(), body)
+// send performs a single request, returning the response with its body unread if it was successful.
+// Any rate limit reported in the response is recorded in stats.
+func (c *Client) send(ctx context.Context, op Operation, stats *RequestStats, method string, reqUrl *url.URL, body *requestBody) (*http.Response, error) {
+	attemptCtx, cancel := c.attemptContext(ctx)
+	req, err := http.NewRequestWithContext(attemptCtx, method, reqUrl.String(), nil)
 	if err != nil {
+		cancel()
 		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+c.ApiToken)
+	if !body.empty() {
+		req.Body = body.reader()
+		req.GetBody = func() (io.ReadCloser, error) { return body.reader(), nil }
+		req.ContentLength = body.contentLength()
+	}
 	req.Header.Set("Content-Type", "application/json")
+	if body.contentEncoding != "" {
+		req.Header.Set("Content-Encoding", body.contentEncoding)
+	}
 	req.Header.Set("Accept", "application/json")
+	if len(c.ResponseDecoders) > 0 {
+		req.Header.Set("Accept-Encoding", c.acceptEncoding())
+	}
+	if err := c.authenticator().Authenticate(req); err != nil {
+		cancel()
+		return nil, backoff.Permanent(fmt.Errorf("failed to authenticate request: %w", err))
+	}
 
 	resp, err := c.httpClient().Do(req)
 	if err != nil {
+		cancel()
+		redactAuthParams(err, reqUrl, req.URL)
+		if ctx.Err() != nil || !c.retryPolicy(op).RetryOnNetworkError.retries(err) {
+			return nil, backoff.Permanent(err)
+		}
 		return nil, err
 	}
-	defer resp.Body.Close()
 
+	resp.Body = &cancelingBody{ReadCloser: resp.Body, cancel: cancel}
+	if rateLimit := parseRateLimit(resp.Header, time.Now()); rateLimit != nil {
+		stats.RateLimit = rateLimit
+	}
+	if err := c.decodeResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decode %s response: %w", resp.Header.Get("Content-Encoding"), err)
+	}
 	if resp.StatusCode != http.StatusOK {
+		defer drainAndClose(resp.Body)
 		apiErr := c.toApiError(resp)
-		if !isRetriable(resp.StatusCode) {
+		if !isRetriable(resp.StatusCode) && !c.inNotFoundGracePeriod(op, stats.Namespace, resp.StatusCode) {
 			return nil, backoff.Permanent(apiErr)
 		}
 		return nil, apiErr
 	}
 
-	return io.ReadAll(resp.Body)
+	return resp, nil
+}
+
+// attemptContext returns the context for one attempt of a request, which is limited to RequestTimeout if the
+// caller's context has no deadline of its own.
+func (c *Client) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.RequestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.RequestTimeout)
+}
+
+// cancelingBody is a response body which cancels its attempt's context when closed.
+type cancelingBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func isRetriable(statusCode int) bool {
@@ -157,7 +393,7 @@ func (c *Client) toApiError(resp *http.Response) error {
 		HttpStatus: resp.StatusCode,
 	}
 	if decodeErr := json.Unmarshal(respBody, &apiErr); decodeErr != nil {
-		return fmt.Errorf("failed to decode api error: %w (raw response: %s, status code: %d)", decodeErr, string(respBody), resp.StatusCode)
+		return &undecodedApiError{err: decodeErr, body: string(respBody), statusCode: resp.StatusCode}
 	}
 	if resp.StatusCode == http.StatusOK && apiErr.Status == ApiStatusOK {
 		return nil
@@ -176,3 +412,33 @@ const ApiStatusOK = "OK"
 func (e ApiError) Error() string {
 	return fmt.Sprintf("%s: %s (HTTP %d)", e.Status, e.Err, e.HttpStatus)
 }
+
+// undecodedApiError is returned for an error response whose body isn't an API error, e.g. one from a proxy.
+type undecodedApiError struct {
+	err        error
+	body       string
+	statusCode int
+}
+
+func (e *undecodedApiError) Error() string {
+	return fmt.Sprintf("failed to decode api error: %v (raw response: %s, status code: %d)", e.err, e.body, e.statusCode)
+}
+
+func (e *undecodedApiError) Unwrap() error {
+	return e.err
+}
+
+// As exposes the response's status as an ApiError, so that checks of the HTTP status of a failed request,
+// such as for 413 Payload Too Large, don't depend on the response having an API error body.
+func (e *undecodedApiError) As(target interface{}) bool {
+	apiErr, ok := target.(*ApiError)
+	if ok {
+		*apiErr = ApiError{Status: "error", Err: e.body, HttpStatus: e.statusCode}
+	}
+	return ok
+}
+
+func isNotFound(err error) bool {
+	var apiErr ApiError
+	return errors.As(err, &apiErr) && apiErr.HttpStatus == http.StatusNotFound
+}
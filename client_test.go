@@ -3,8 +3,15 @@ package tpuf
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -22,6 +29,9 @@ func TestClientDo(t *testing.T) {
 		expectedCalls int
 		method        string
 		requestBody   string
+		operation     Operation
+		retryWrites   bool
+		retryPolicies map[Operation]*RetryPolicy
 	}{
 		{
 			name:       "success on first try",
@@ -134,6 +144,109 @@ func TestClientDo(t *testing.T) {
 			},
 			expectedCalls: 1,
 		},
+		{
+			name:       "retry read on network error",
+			maxRetries: 3,
+			operation:  OperationQuery,
+			httpErrors: []error{errors.New("connection reset by peer")},
+			httpResponses: []*http.Response{
+				nil,
+				{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:          "no retry of write on network error",
+			maxRetries:    3,
+			operation:     OperationUpsert,
+			httpErrors:    []error{errors.New("connection reset by peer")},
+			httpResponses: []*http.Response{nil},
+			expectedError: "connection reset by peer",
+			expectedCalls: 1,
+		},
+		{
+			name:        "retry write on network error when enabled",
+			maxRetries:  3,
+			operation:   OperationUpsert,
+			retryWrites: true,
+			httpErrors:  []error{errors.New("connection reset by peer")},
+			httpResponses: []*http.Response{
+				nil,
+				{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:       "retry write on 500 InternalServerError",
+			maxRetries: 3,
+			operation:  OperationUpsert,
+			httpResponses: []*http.Response{
+				{
+					StatusCode: http.StatusInternalServerError,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				},
+				{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:          "per-operation retry limit",
+			maxRetries:    3,
+			operation:     OperationUpsert,
+			retryPolicies: map[Operation]*RetryPolicy{OperationUpsert: {MaxRetries: 1}},
+			httpResponses: []*http.Response{
+				{
+					StatusCode: http.StatusInternalServerError,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"error","error":"Internal Server Error"}`)),
+				},
+				{
+					StatusCode: http.StatusInternalServerError,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"error","error":"Internal Server Error"}`)),
+				},
+			},
+			expectedError: "error: Internal Server Error (HTTP 500)",
+			expectedCalls: 2,
+		},
+		{
+			name:          "per-operation retry disabled",
+			maxRetries:    3,
+			operation:     OperationUpsert,
+			retryPolicies: map[Operation]*RetryPolicy{OperationUpsert: {Disable: true}},
+			httpResponses: []*http.Response{
+				{
+					StatusCode: http.StatusInternalServerError,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"error","error":"Internal Server Error"}`)),
+				},
+			},
+			expectedError: "error: Internal Server Error (HTTP 500)",
+			expectedCalls: 1,
+		},
+		{
+			name:          "policy for another operation doesn't apply",
+			maxRetries:    3,
+			operation:     OperationQuery,
+			retryPolicies: map[Operation]*RetryPolicy{OperationUpsert: {Disable: true}},
+			httpResponses: []*http.Response{
+				{
+					StatusCode: http.StatusInternalServerError,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				},
+				{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBuffer(nil)),
+				},
+			},
+			expectedCalls: 2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -141,9 +254,11 @@ func TestClientDo(t *testing.T) {
 			fakeTimer := &fakeTimer{}
 			callCount := 0
 			client := &Client{
-				ApiToken:     "test-token",
-				MaxRetries:   tt.maxRetries,
-				DisableRetry: tt.disableRetry,
+				ApiToken:                  "test-token",
+				MaxRetries:                tt.maxRetries,
+				DisableRetry:              tt.disableRetry,
+				RetryWritesOnNetworkError: tt.retryWrites,
+				RetryPolicies:             tt.retryPolicies,
 				HttpClient: &fakeHttpClient{
 					doFunc: func(req *http.Request) (*http.Response, error) {
 						assert.Equal(t, "Bearer test-token", req.Header.Get("Authorization"), "unexpected Authorization header")
@@ -173,7 +288,12 @@ func TestClientDo(t *testing.T) {
 				method = http.MethodGet
 			}
 
-			_, err := client.do(context.Background(), method, "/test", nil, []byte(tt.requestBody))
+			operation := tt.operation
+			if operation == "" {
+				operation = OperationQuery
+			}
+
+			_, err := client.do(context.Background(), operation, method, "/test", nil, []byte(tt.requestBody))
 
 			assert.Equal(t, tt.expectedCalls, callCount, "unexpected number of calls")
 
@@ -186,6 +306,361 @@ func TestClientDo(t *testing.T) {
 	}
 }
 
+func TestClientReusesConnections(t *testing.T) {
+	var manyResults strings.Builder
+	manyResults.WriteString("[")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			manyResults.WriteString(",")
+		}
+		fmt.Fprintf(&manyResults, `{"id":"%d","dist":0.5}`, i)
+	}
+	manyResults.WriteString("]\n")
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		call   func(client *Client) error
+	}{
+		{
+			name:   "successful query",
+			status: http.StatusOK,
+			body:   `[{"id":"1"}]`,
+			call: func(client *Client) error {
+				_, err := client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+				return err
+			},
+		},
+		{
+			name:   "error response",
+			status: http.StatusBadRequest,
+			body:   `{"status":"error","error":"bad request"}`,
+			call: func(client *Client) error {
+				_, err := client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+				assert.Error(t, err)
+				return nil
+			},
+		},
+		{
+			name:   "undecodable error response",
+			status: http.StatusBadRequest,
+			body:   `not json`,
+			call: func(client *Client) error {
+				_, err := client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+				assert.Error(t, err)
+				return nil
+			},
+		},
+		{
+			name:   "streamed query with trailing newline",
+			status: http.StatusOK,
+			body:   manyResults.String(),
+			call: func(client *Client) error {
+				return client.QueryStream(context.Background(), "test-ns", &QueryRequest{TopK: 1000}, func(*QueryResult) error {
+					return nil
+				})
+			},
+		},
+		{
+			name:   "abandoned streamed query",
+			status: http.StatusOK,
+			body:   manyResults.String(),
+			call: func(client *Client) error {
+				stop := errors.New("stop")
+				err := client.QueryStream(context.Background(), "test-ns", &QueryRequest{TopK: 1000}, func(*QueryResult) error {
+					return stop
+				})
+				assert.ErrorIs(t, err, stop)
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			connections := 0
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					mu.Lock()
+					defer mu.Unlock()
+					connections++
+				}
+			}
+			server.Start()
+			defer server.Close()
+
+			client := &Client{
+				ApiToken:     "test-token",
+				BaseURL:      server.URL,
+				DisableRetry: true,
+				HttpClient:   server.Client(),
+			}
+			for i := 0; i < 3; i++ {
+				assert.NoError(t, tt.call(client))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, 1, connections, "requests should reuse one connection")
+		})
+	}
+}
+
+func TestClientRequestURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		path     string
+		values   url.Values
+		expected string
+	}{
+		{
+			name:     "default",
+			path:     "/v1/vectors/test-ns",
+			expected: "https://api.turbopuffer.com/v1/vectors/test-ns",
+		},
+		{
+			name:     "path prefix",
+			baseURL:  "https://gateway.internal/turbopuffer/proxy",
+			path:     "/v1/vectors/test-ns/query",
+			expected: "https://gateway.internal/turbopuffer/proxy/v1/vectors/test-ns/query",
+		},
+		{
+			name:     "path prefix with trailing slash",
+			baseURL:  "https://gateway.internal/turbopuffer/proxy/",
+			path:     "/v1/vectors/test-ns",
+			expected: "https://gateway.internal/turbopuffer/proxy/v1/vectors/test-ns",
+		},
+		{
+			name:     "query parameters",
+			baseURL:  "https://api.turbopuffer.com",
+			path:     "/v1/vectors/test-ns",
+			values:   url.Values{"cursor": {"abc"}},
+			expected: "https://api.turbopuffer.com/v1/vectors/test-ns?cursor=abc",
+		},
+		{
+			name:     "base URL query parameters are preserved",
+			baseURL:  "https://gateway.internal/proxy?tenant=search&region=us",
+			path:     "/v1/vectors",
+			expected: "https://gateway.internal/proxy/v1/vectors?tenant=search&region=us",
+		},
+		{
+			name:     "base URL query parameters are combined with request parameters",
+			baseURL:  "https://gateway.internal/proxy?tenant=search",
+			path:     "/v1/vectors",
+			values:   url.Values{"prefix": {"docs-"}, "page_size": {"10"}},
+			expected: "https://gateway.internal/proxy/v1/vectors?tenant=search&page_size=10&prefix=docs-",
+		},
+		{
+			name:     "port",
+			baseURL:  "http://localhost:8080/tpuf",
+			path:     "/v1/vectors/test-ns",
+			expected: "http://localhost:8080/tpuf/v1/vectors/test-ns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{BaseURL: tt.baseURL}
+			reqUrl, err := client.requestURL(tt.path, tt.values)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, reqUrl.String())
+		})
+	}
+
+	t.Run("requests go to the gateway", func(t *testing.T) {
+		var requested string
+		client := &Client{
+			ApiToken: "test-token",
+			BaseURL:  "https://gateway.internal/turbopuffer/proxy?tenant=search",
+			HttpClient: &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					requested = req.URL.String()
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"ids":[]}`))}, nil
+				},
+			},
+		}
+
+		_, err := client.Export(context.Background(), "test-ns", "page2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://gateway.internal/turbopuffer/proxy/v1/vectors/test-ns?tenant=search&cursor=page2", requested)
+	})
+}
+
+func TestClientRequestTimeout(t *testing.T) {
+	t.Run("hung attempt is retried", func(t *testing.T) {
+		calls := 0
+		client := &Client{
+			ApiToken:       "test-token",
+			RequestTimeout: 10 * time.Millisecond,
+			Timer:          &fakeTimer{},
+			HttpClient: &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					calls++
+					_, ok := req.Context().Deadline()
+					assert.True(t, ok, "attempt should have a deadline")
+					if calls == 1 {
+						<-req.Context().Done()
+						return nil, req.Context().Err()
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`[]`))}, nil
+				},
+			},
+		}
+
+		_, err := client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls, "unexpected number of calls")
+	})
+
+	tests := []struct {
+		name             string
+		requestTimeout   time.Duration
+		callerDeadline   time.Time
+		expectedDeadline func(deadline time.Time) bool
+	}{
+		{
+			name:             "no timeout",
+			expectedDeadline: func(deadline time.Time) bool { return deadline.IsZero() },
+		},
+		{
+			name:           "timeout without caller deadline",
+			requestTimeout: time.Minute,
+			expectedDeadline: func(deadline time.Time) bool {
+				return !deadline.IsZero() && time.Until(deadline) <= time.Minute
+			},
+		},
+		{
+			name:             "caller deadline takes precedence",
+			requestTimeout:   time.Minute,
+			callerDeadline:   time.Now().Add(time.Hour),
+			expectedDeadline: func(deadline time.Time) bool { return time.Until(deadline) > time.Minute },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			client := &Client{
+				ApiToken:       "test-token",
+				RequestTimeout: tt.requestTimeout,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						deadline, _ = req.Context().Deadline()
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`[]`))}, nil
+					},
+				},
+			}
+			ctx := context.Background()
+			if !tt.callerDeadline.IsZero() {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, tt.callerDeadline)
+				defer cancel()
+			}
+
+			_, err := client.Query(ctx, "test-ns", &QueryRequest{TopK: 1})
+
+			assert.NoError(t, err)
+			assert.True(t, tt.expectedDeadline(deadline), "unexpected deadline %v", deadline)
+		})
+	}
+}
+
+// trackingBody records whether a response body was read to the end before it was closed.
+type trackingBody struct {
+	io.Reader
+	drained bool
+	closed  bool
+}
+
+func (b *trackingBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err == io.EOF {
+		b.drained = true
+	}
+	return n, err
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestClientDrainsResponseBodies(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		call   func(client *Client)
+	}{
+		{
+			name:   "successful query",
+			status: http.StatusOK,
+			body:   `[{"id":"1"}]`,
+			call: func(client *Client) {
+				_, _ = client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+			},
+		},
+		{
+			name:   "error response",
+			status: http.StatusBadRequest,
+			body:   `{"status":"error","error":"bad request"}`,
+			call: func(client *Client) {
+				_, _ = client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+			},
+		},
+		{
+			name:   "streamed query with trailing newline",
+			status: http.StatusOK,
+			body:   "[{\"id\":\"1\"},{\"id\":\"2\"}]\n",
+			call: func(client *Client) {
+				_ = client.QueryStream(context.Background(), "test-ns", &QueryRequest{TopK: 2}, func(*QueryResult) error {
+					return nil
+				})
+			},
+		},
+		{
+			name:   "abandoned streamed query",
+			status: http.StatusOK,
+			body:   `[{"id":"1"},{"id":"2"}]`,
+			call: func(client *Client) {
+				_ = client.QueryStream(context.Background(), "test-ns", &QueryRequest{TopK: 2}, func(*QueryResult) error {
+					return errors.New("stop")
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &trackingBody{Reader: strings.NewReader(tt.body)}
+			client := &Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						return &http.Response{StatusCode: tt.status, Body: body}, nil
+					},
+				},
+			}
+
+			tt.call(client)
+
+			assert.True(t, body.drained, "response body should be read to the end")
+			assert.True(t, body.closed, "response body should be closed")
+		})
+	}
+}
+
 type fakeHttpClient struct {
 	doFunc func(*http.Request) (*http.Response, error)
 }
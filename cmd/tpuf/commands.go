@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bamo/tpuf-go"
+)
+
+func namespacesList(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("namespaces list", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	prefix := fs.String("prefix", "", "only list namespaces with this prefix")
+	suffix := fs.String("suffix", "", "only list namespaces with this suffix")
+	match := fs.String("match", "", "only list namespaces matching this regular expression")
+	if err := c.parse(fs, args, nil); err != nil {
+		return err
+	}
+	client, err := c.client(&cf)
+	if err != nil {
+		return err
+	}
+
+	request := &tpuf.NamespacesRequest{Prefix: *prefix, Suffix: *suffix}
+	if *match != "" {
+		if request.Match, err = regexp.Compile(*match); err != nil {
+			return fmt.Errorf("invalid -match: %w", err)
+		}
+	}
+	for {
+		response, err := client.Namespaces(ctx, request)
+		if err != nil {
+			return err
+		}
+		for _, namespace := range response.Namespaces {
+			fmt.Fprintln(c.stdout, namespace.ID)
+		}
+		if response.NextCursor == "" {
+			return nil
+		}
+		request.Cursor = response.NextCursor
+	}
+}
+
+func namespacesDelete(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("namespaces delete", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	namespace := fs.String("namespace", "", "namespace to delete (required)")
+	yes := fs.Bool("yes", false, "confirm deletion of the namespace and all of its documents")
+	if err := c.parse(fs, args, namespace); err != nil {
+		return err
+	}
+	if !*yes {
+		return fmt.Errorf("refusing to delete namespace %s without -yes", *namespace)
+	}
+	client, err := c.client(&cf)
+	if err != nil {
+		return err
+	}
+
+	if err := client.DeleteNamespace(ctx, *namespace); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "deleted namespace %s\n", *namespace)
+	return nil
+}
+
+func query(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	namespace := fs.String("namespace", "", "namespace to query (required)")
+	vectorFile := fs.String("vector-file", "", "file containing the query vector as a JSON array, or - for stdin")
+	distanceMetric := fs.String("distance-metric", string(tpuf.DistanceMetricCosine), "distance metric for vector queries")
+	bm25 := fs.String("bm25", "", "text to rank by with BM25")
+	bm25Attribute := fs.String("bm25-attribute", "text", "attribute to rank by with BM25")
+	filter := fs.String("filter", "", `filter expression, e.g. 'category = "docs" AND year >= 2020'`)
+	topK := fs.Int("top-k", 10, "number of results to return")
+	includeAttributes := fs.String("include-attributes", "", "comma-separated attributes to return, or * for all")
+	includeVectors := fs.Bool("include-vectors", false, "return vectors")
+	if err := c.parse(fs, args, namespace); err != nil {
+		return err
+	}
+	if *vectorFile != "" && *bm25 != "" {
+		return fmt.Errorf("-vector-file and -bm25 are mutually exclusive")
+	}
+	client, err := c.client(&cf)
+	if err != nil {
+		return err
+	}
+
+	request := &tpuf.QueryRequest{TopK: *topK, IncludeVectors: *includeVectors}
+	if *vectorFile != "" {
+		data, err := c.readFile(*vectorFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &request.Vector); err != nil {
+			return fmt.Errorf("failed to decode vector from %s: %w", *vectorFile, err)
+		}
+		request.DistanceMetric = tpuf.DistanceMetric(*distanceMetric)
+	}
+	if *bm25 != "" {
+		request.RankBy = tpuf.BM25(*bm25Attribute, *bm25)
+	}
+	if *filter != "" {
+		request.Filters, err = tpuf.ParseFilter(*filter)
+		if err != nil {
+			return err
+		}
+	}
+	switch *includeAttributes {
+	case "":
+	case "*":
+		request.IncludeAttributes = tpuf.IncludeAllAttributes()
+	default:
+		request.IncludeAttributes = tpuf.IncludeAttributeNames(strings.Split(*includeAttributes, ",")...)
+	}
+
+	results, err := client.Query(ctx, *namespace, request)
+	if err != nil {
+		return err
+	}
+	return c.printJSON(results)
+}
+
+func upsert(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("upsert", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	namespace := fs.String("namespace", "", "namespace to upsert into (required)")
+	file := fs.String("file", "-", `JSON lines file of documents like {"id": ..., "vector": [...], "attributes": {...}}, or - for stdin`)
+	distanceMetric := fs.String("distance-metric", "", "distance metric, required for documents with vectors")
+	batchSize := fs.Int("batch-size", 0, "documents per upsert request (default 1000)")
+	if err := c.parse(fs, args, namespace); err != nil {
+		return err
+	}
+	client, err := c.client(&cf)
+	if err != nil {
+		return err
+	}
+	r, err := c.open(*file)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	upserter := &tpuf.BulkUpserter{
+		Client:         client,
+		Namespace:      *namespace,
+		BatchSize:      *batchSize,
+		DistanceMetric: tpuf.DistanceMetric(*distanceMetric),
+		AllowNoVector:  true,
+	}
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	var count int64
+	for decoder.More() {
+		var document tpuf.Document
+		if err := decoder.Decode(&document); err != nil {
+			return fmt.Errorf("failed to read document %d: %w", count+1, err)
+		}
+		if document.ID == "" {
+			return fmt.Errorf("document %d has no id", count+1)
+		}
+		upsert := &tpuf.Upsert{ID: document.ID, IDUint64: document.IDUint64, Vector: document.Vector}
+		if len(document.Attributes) > 0 {
+			upsert.Attributes = document.Attributes
+		}
+		if err := upserter.Add(ctx, upsert); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := upserter.Flush(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stderr, "upserted %d documents\n", count)
+	return nil
+}
+
+func export(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	namespace := fs.String("namespace", "", "namespace to export (required)")
+	output := fs.String("o", "-", "file to write JSON lines to, or - for stdout")
+	excludeVectors := fs.Bool("exclude-vectors", false, "omit vectors")
+	if err := c.parse(fs, args, namespace); err != nil {
+		return err
+	}
+	client, err := c.client(&cf)
+	if err != nil {
+		return err
+	}
+
+	w := c.stdout
+	var f *os.File
+	if *output != "-" {
+		f, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	count, err := client.ExportAll(ctx, *namespace, w, &tpuf.ExportAllOptions{
+		ExcludeVectors: *excludeVectors,
+		Progress: func(documents int64) {
+			fmt.Fprintf(c.stderr, "exported %d documents\r", documents)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(c.stderr, "exported %d documents\n", count)
+	return nil
+}
+
+func schemaGet(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("schema get", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	namespace := fs.String("namespace", "", "namespace whose schema to print (required)")
+	if err := c.parse(fs, args, namespace); err != nil {
+		return err
+	}
+	client, err := c.client(&cf)
+	if err != nil {
+		return err
+	}
+
+	schema, err := client.Schema(ctx, *namespace)
+	if err != nil {
+		return err
+	}
+	return c.printJSON(schema)
+}
+
+func recall(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("recall", flag.ContinueOnError)
+	var cf clientFlags
+	cf.register(fs)
+	namespace := fs.String("namespace", "", "namespace to measure (required)")
+	num := fs.Int("num", 0, "number of sampled queries (default 25)")
+	topK := fs.Int("top-k", 0, "number of neighbors per query (default 10)")
+	filter := fs.String("filter", "", "filter expression applied to every query")
+	if err := c.parse(fs, args, namespace); err != nil {
+		return err
+	}
+	client, err := c.client(&cf)
+	if err != nil {
+		return err
+	}
+
+	request := &tpuf.RecallRequest{Num: *num, TopK: *topK}
+	if *filter != "" {
+		request.Filters, err = tpuf.ParseFilter(*filter)
+		if err != nil {
+			return err
+		}
+	}
+	response, err := client.Recall(ctx, *namespace, request)
+	if err != nil {
+		return err
+	}
+	return c.printJSON(response)
+}
+
+func (c *cli) open(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(c.stdin), nil
+	}
+	return os.Open(path)
+}
+
+func (c *cli) readFile(path string) ([]byte, error) {
+	r, err := c.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,130 @@
+// Command tpuf is a command-line interface to the Turbopuffer API, built on the tpuf package.
+//
+// Usage:
+//
+//	tpuf <command> [flags]
+//
+// The API token is read from the TURBOPUFFER_API_KEY environment variable, or the -api-key flag.
+// Run a command with -h to see its flags.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/bamo/tpuf-go"
+)
+
+const usage = `Usage: tpuf <command> [flags]
+
+Commands:
+  namespaces list     List namespaces
+  namespaces delete   Delete a namespace
+  query               Query a namespace by vector, BM25 text, or filter
+  upsert              Upsert documents from a JSON lines file
+  export              Export every document to a JSON lines file
+  schema get          Print a namespace's schema
+  recall              Measure the recall of a namespace's ANN index
+
+The API token is read from TURBOPUFFER_API_KEY, or the -api-key flag.
+`
+
+// cli holds the environment of a CLI invocation, so that commands can be tested.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// httpClient, if set, is used by every client, e.g. in tests.
+	httpClient tpuf.HttpClient
+}
+
+type command func(ctx context.Context, c *cli, args []string) error
+
+var commands = map[string]command{
+	"namespaces list":   namespacesList,
+	"namespaces delete": namespacesDelete,
+	"query":             query,
+	"upsert":            upsert,
+	"export":            export,
+	"schema get":        schemaGet,
+	"recall":            recall,
+}
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	if err := c.run(ctx, os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "tpuf: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) >= 2 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd(ctx, c, args[2:])
+		}
+	}
+	if len(args) >= 1 {
+		if cmd, ok := commands[args[0]]; ok {
+			return cmd(ctx, c, args[1:])
+		}
+	}
+	fmt.Fprint(c.stderr, usage)
+	if len(args) > 0 && args[0] != "help" && args[0] != "-h" && args[0] != "-help" {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q, expected one of %q", args[0], names)
+	}
+	return errUsage
+}
+
+// clientFlags are the flags common to every command.
+type clientFlags struct {
+	apiKey  string
+	baseURL string
+}
+
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.apiKey, "api-key", os.Getenv("TURBOPUFFER_API_KEY"), "API token (default $TURBOPUFFER_API_KEY)")
+	fs.StringVar(&f.baseURL, "base-url", os.Getenv("TURBOPUFFER_BASE_URL"), "API base URL (default $TURBOPUFFER_BASE_URL, or https://api.turbopuffer.com)")
+}
+
+func (c *cli) client(f *clientFlags) (*tpuf.Client, error) {
+	if f.apiKey == "" {
+		return nil, fmt.Errorf("an API token is required; set TURBOPUFFER_API_KEY or -api-key")
+	}
+	return &tpuf.Client{ApiToken: f.apiKey, BaseURL: f.baseURL, HttpClient: c.httpClient}, nil
+}
+
+// parse parses args, requiring a -namespace flag if namespace is set and no positional arguments.
+func (c *cli) parse(fs *flag.FlagSet, args []string, namespace *string) error {
+	fs.SetOutput(c.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	if namespace != nil && *namespace == "" {
+		fs.Usage()
+		return fmt.Errorf("-namespace is required")
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHttpClient struct {
+	doFunc func(*http.Request) (*http.Response, error)
+}
+
+func (f *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	return f.doFunc(req)
+}
+
+func TestRun(t *testing.T) {
+	vectorFile := filepath.Join(t.TempDir(), "vector.json")
+	assert.NoError(t, os.WriteFile(vectorFile, []byte(`[0.1, 0.2]`), 0o644))
+
+	tests := []struct {
+		name             string
+		args             []string
+		stdin            string
+		responses        map[string]string
+		expectedRequests []string
+		expectedStdout   string
+		expectedError    string
+	}{
+		{
+			name: "namespaces list follows cursors",
+			args: []string{"namespaces", "list", "-prefix", "docs"},
+			responses: map[string]string{
+				"GET /v1/vectors?prefix=docs":             `{"namespaces":[{"id":"docs-a"}],"next_cursor":"next"}`,
+				"GET /v1/vectors?cursor=next&prefix=docs": `{"namespaces":[{"id":"docs-b"}]}`,
+			},
+			expectedRequests: []string{"GET /v1/vectors?prefix=docs", "GET /v1/vectors?cursor=next&prefix=docs"},
+			expectedStdout:   "docs-a\ndocs-b\n",
+		},
+		{
+			name: "namespaces list filters client-side",
+			args: []string{"namespaces", "list", "-suffix", "-prod", "-match", "^docs-[a-z]+-"},
+			responses: map[string]string{
+				"GET /v1/vectors": `{"namespaces":[{"id":"docs-a-prod"},{"id":"docs-b-staging"},{"id":"docs-1-prod"}]}`,
+			},
+			expectedRequests: []string{"GET /v1/vectors"},
+			expectedStdout:   "docs-a-prod\n",
+		},
+		{
+			name:          "namespaces list rejects invalid patterns",
+			args:          []string{"namespaces", "list", "-match", "("},
+			expectedError: "invalid -match: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:          "namespaces delete requires confirmation",
+			args:          []string{"namespaces", "delete", "-namespace", "docs"},
+			expectedError: "refusing to delete namespace docs without -yes",
+		},
+		{
+			name: "namespaces delete",
+			args: []string{"namespaces", "delete", "-namespace", "docs", "-yes"},
+			responses: map[string]string{
+				"DELETE /v1/vectors/docs": `{"status":"OK"}`,
+			},
+			expectedRequests: []string{"DELETE /v1/vectors/docs"},
+		},
+		{
+			name: "vector query with filter",
+			args: []string{"query", "-namespace", "docs", "-vector-file", vectorFile, "-top-k", "1", "-filter", `year >= 2020`, "-include-attributes", "title"},
+			responses: map[string]string{
+				"POST /v1/vectors/docs/query": `[{"id":"1","dist":0.5,"attributes":{"title":"a"}}]`,
+			},
+			expectedRequests: []string{
+				`POST /v1/vectors/docs/query {"vector":[0.1,0.2],"distance_metric":"cosine_distance","top_k":1,"filters":["year","Gte",2020],"include_attributes":["title"]}`,
+			},
+			expectedStdout: `[
+  {
+    "dist": 0.5,
+    "id": "1",
+    "attributes": {
+      "title": "a"
+    }
+  }
+]
+`,
+		},
+		{
+			name: "bm25 query",
+			args: []string{"query", "-namespace", "docs", "-bm25", "moon base", "-bm25-attribute", "body", "-include-attributes", "*"},
+			responses: map[string]string{
+				"POST /v1/vectors/docs/query": `[]`,
+			},
+			expectedRequests: []string{
+				`POST /v1/vectors/docs/query {"rank_by":["body","BM25","moon base"],"top_k":10,"include_attributes":true}`,
+			},
+			expectedStdout: "[]\n",
+		},
+		{
+			name:          "invalid filter",
+			args:          []string{"query", "-namespace", "docs", "-filter", "year >="},
+			expectedError: "filter syntax error at line 1, column 8: expected value, found end of expression",
+		},
+		{
+			name:  "upsert from stdin",
+			args:  []string{"upsert", "-namespace", "docs", "-distance-metric", "cosine_distance"},
+			stdin: "{\"id\":\"1\",\"vector\":[0.1],\"attributes\":{\"title\":\"a\"}}\n{\"id\":\"2\",\"vector\":[0.2]}\n",
+			responses: map[string]string{
+				"POST /v1/vectors/docs": `{"status":"OK"}`,
+			},
+			expectedRequests: []string{
+				`POST /v1/vectors/docs {"distance_metric":"cosine_distance","upserts":[{"id":"1","vector":[0.1],"attributes":{"title":"a"}},{"id":"2","vector":[0.2]}]}`,
+			},
+		},
+		{
+			name:          "upsert rejects documents without ids",
+			args:          []string{"upsert", "-namespace", "docs"},
+			stdin:         `{"vector":[0.1]}`,
+			expectedError: "document 1 has no id",
+		},
+		{
+			name: "export to stdout",
+			args: []string{"export", "-namespace", "docs"},
+			responses: map[string]string{
+				"GET /v1/vectors/docs": `{"ids":["1"],"vectors":[[0.1]],"attributes":{"title":["a"]}}`,
+			},
+			expectedRequests: []string{"GET /v1/vectors/docs"},
+			expectedStdout:   `{"id":"1","vector":[0.1],"attributes":{"title":"a"}}` + "\n",
+		},
+		{
+			name: "schema get",
+			args: []string{"schema", "get", "-namespace", "docs"},
+			responses: map[string]string{
+				"GET /v1/vectors/docs/schema": `{"title":{"type":"string"}}`,
+			},
+			expectedRequests: []string{"GET /v1/vectors/docs/schema"},
+			expectedStdout:   "{\n  \"title\": {\n    \"type\": \"string\"\n  }\n}\n",
+		},
+		{
+			name: "recall",
+			args: []string{"recall", "-namespace", "docs", "-num", "5", "-top-k", "3"},
+			responses: map[string]string{
+				"POST /v1/vectors/docs/_debug/recall": `{"avg_recall":0.9,"avg_exhaustive_count":3,"avg_ann_count":3}`,
+			},
+			expectedRequests: []string{`POST /v1/vectors/docs/_debug/recall {"num":5,"top_k":3}`},
+			expectedStdout:   "{\n  \"avg_recall\": 0.9,\n  \"avg_exhaustive_count\": 3,\n  \"avg_ann_count\": 3\n}\n",
+		},
+		{
+			name:          "missing namespace",
+			args:          []string{"schema", "get"},
+			expectedError: "-namespace is required",
+		},
+		{
+			name:          "unknown command",
+			args:          []string{"frobnicate"},
+			expectedError: `unknown command "frobnicate", expected one of ["export" "namespaces delete" "namespaces list" "query" "recall" "schema get" "upsert"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TURBOPUFFER_API_KEY", "test-token")
+			var requests []string
+			var stdout, stderr bytes.Buffer
+			c := &cli{
+				stdin:  strings.NewReader(tt.stdin),
+				stdout: &stdout,
+				stderr: &stderr,
+				httpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "Bearer test-token", req.Header.Get("Authorization"), "unexpected Authorization header")
+						key := req.Method + " " + req.URL.RequestURI()
+						request := key
+						if req.Body != nil {
+							body, err := io.ReadAll(req.Body)
+							assert.NoError(t, err)
+							if len(body) > 0 {
+								request += " " + string(body)
+							}
+						}
+						requests = append(requests, request)
+						body, ok := tt.responses[key]
+						assert.True(t, ok, "unexpected request %s", key)
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(body)),
+						}, nil
+					},
+				},
+			}
+
+			err := c.run(context.Background(), tt.args)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, len(tt.expectedRequests), len(requests), "unexpected number of requests: %v", requests)
+			for i := range requests {
+				if i >= len(tt.expectedRequests) {
+					break
+				}
+				expectedLine, expectedBody, hasBody := strings.Cut(tt.expectedRequests[i], " {")
+				line, body, _ := strings.Cut(requests[i], " {")
+				assert.Equal(t, expectedLine, line, "unexpected request")
+				if hasBody {
+					assert.JSONEq(t, "{"+expectedBody, "{"+body, "unexpected request body")
+				}
+			}
+			assert.Equal(t, tt.expectedStdout, stdout.String())
+		})
+	}
+}
+
+func TestExportUpsertRoundTrip(t *testing.T) {
+	t.Setenv("TURBOPUFFER_API_KEY", "test-token")
+	var upserted string
+	newCLI := func(stdin io.Reader, stdout io.Writer) *cli {
+		return &cli{
+			stdin:  stdin,
+			stdout: stdout,
+			stderr: io.Discard,
+			httpClient: &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					body := `{"ids":[1,2],"vectors":[[0.1],[0.2]],"attributes":{"title":["a","b"]}}`
+					if req.Method == http.MethodPost {
+						data, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						upserted = string(data)
+						body = `{"status":"OK"}`
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+				},
+			},
+		}
+	}
+
+	var exported bytes.Buffer
+	assert.NoError(t, newCLI(strings.NewReader(""), &exported).run(context.Background(), []string{"export", "-namespace", "docs"}))
+	assert.NoError(t, newCLI(&exported, io.Discard).run(context.Background(), []string{"upsert", "-namespace", "copy", "-distance-metric", "cosine_distance"}))
+
+	assert.JSONEq(t, `{"distance_metric":"cosine_distance","upserts":[
+		{"id":1,"vector":[0.1],"attributes":{"title":"a"}},
+		{"id":2,"vector":[0.2],"attributes":{"title":"b"}}
+	]}`, upserted, "numeric IDs should be upserted as numbers")
+}
@@ -0,0 +1,166 @@
+package tpuf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipWriterPools holds a pool of gzip writers for each compression level, from gzip.HuffmanOnly to
+// gzip.BestCompression, since a writer's level can't be changed by Reset.
+var gzipWriterPools [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+
+// getGzipWriter returns a pooled gzip writer with the given compression level which writes to w.
+func getGzipWriter(w io.Writer, level int) *gzip.Writer {
+	pool := &gzipWriterPools[level-gzip.HuffmanOnly]
+	if zw, ok := pool.Get().(*gzip.Writer); ok {
+		zw.Reset(w)
+		return zw
+	}
+	zw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		// The level is validated by compressionLevel.
+		panic(err)
+	}
+	return zw
+}
+
+func putGzipWriter(zw *gzip.Writer, level int) {
+	// Detach the writer from its output so that the pool doesn't keep it alive.
+	zw.Reset(io.Discard)
+	gzipWriterPools[level-gzip.HuffmanOnly].Put(zw)
+}
+
+// gzipPooled compresses data into a pooled buffer using a pooled gzip writer.  Return the buffer with
+// putBuffer once the request using it has completed.
+func gzipPooled(data []byte, level int) (*bytes.Buffer, error) {
+	buf := getBuffer()
+	zw := getGzipWriter(buf, level)
+	_, err := zw.Write(data)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	putGzipWriter(zw, level)
+	if err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// compresses reports whether a request body of the given size, or -1 if it's streamed, should be compressed.
+func (c *Client) compresses(size int) bool {
+	switch {
+	case size == 0:
+		return false
+	case c.UseGzipEncoding:
+		return true
+	case c.CompressAboveBytes > 0:
+		return size < 0 || size >= c.CompressAboveBytes
+	}
+	return false
+}
+
+// compressionLevel returns the gzip compression level to use, or an error if CompressionLevel is out of range.
+func (c *Client) compressionLevel() (int, error) {
+	if c.CompressionLevel == nil {
+		return gzip.DefaultCompression, nil
+	}
+	level := *c.CompressionLevel
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return 0, fmt.Errorf("unsupported compression level %d", level)
+	}
+	return level, nil
+}
+
+// newRequestBody prepares a request body, compressing it if the client is configured to.
+func (c *Client) newRequestBody(payload requestPayload) (*requestBody, error) {
+	if payload.encode != nil {
+		body := &requestBody{encode: payload.encode}
+		if c.compresses(-1) {
+			level, err := c.compressionLevel()
+			if err != nil {
+				return nil, err
+			}
+			body.contentEncoding = "gzip"
+			body.compressionLevel = level
+		}
+		return body, nil
+	}
+	if !c.compresses(len(payload.data)) {
+		return newRequestBody(payload.data), nil
+	}
+	level, err := c.compressionLevel()
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := gzipPooled(payload.data, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress request: %w", err)
+	}
+	return &requestBody{data: compressed.Bytes(), contentEncoding: "gzip", pooled: compressed}, nil
+}
+
+// ContentDecoder decodes response bodies with a particular Content-Encoding.
+type ContentDecoder struct {
+	// Encoding is the Content-Encoding token, e.g. "br".
+	Encoding string
+	// NewReader returns a reader which decodes r.
+	NewReader func(r io.Reader) (io.Reader, error)
+}
+
+// acceptEncoding returns the Accept-Encoding header for clients with ResponseDecoders.  Setting the header
+// disables the HTTP transport's transparent gzip decoding, so gzip is advertised last and decoded by
+// decodeResponse instead.
+func (c *Client) acceptEncoding() string {
+	encodings := make([]string, 0, len(c.ResponseDecoders)+1)
+	for _, decoder := range c.ResponseDecoders {
+		encodings = append(encodings, decoder.Encoding)
+	}
+	return strings.Join(append(encodings, "gzip"), ", ")
+}
+
+// decodeResponse replaces the body of a response compressed with one of the client's ResponseDecoders, or
+// with gzip if the client advertised encodings itself, with a decoding reader.
+func (c *Client) decodeResponse(resp *http.Response) error {
+	encoding := resp.Header.Get("Content-Encoding")
+	if len(c.ResponseDecoders) == 0 || encoding == "" {
+		return nil
+	}
+	newReader := func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+	if !strings.EqualFold(encoding, "gzip") {
+		newReader = nil
+		for _, decoder := range c.ResponseDecoders {
+			if strings.EqualFold(decoder.Encoding, encoding) {
+				newReader = decoder.NewReader
+				break
+			}
+		}
+		if newReader == nil {
+			return fmt.Errorf("unsupported content encoding %q", encoding)
+		}
+	}
+	decoded, err := newReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	resp.Body = &decodedBody{Reader: decoded, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return nil
+}
+
+// decodedBody is a response body read through a decoder.
+type decodedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	return b.body.Close()
+}
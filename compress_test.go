@@ -0,0 +1,285 @@
+package tpuf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzipPooled(t *testing.T) {
+	for _, data := range []string{"", "hello", `{"upserts":[{"id":"1","vector":[0.1,0.2]}]}`} {
+		for i := 0; i < 2; i++ {
+			buf, err := gzipPooled([]byte(data), gzip.DefaultCompression)
+			assert.NoError(t, err)
+			assert.Equal(t, data, gunzip(t, buf.Bytes()))
+			putBuffer(buf)
+		}
+	}
+}
+
+func TestClientCompressionLevel(t *testing.T) {
+	data, err := marshalPooled(benchmarkUpsertRequest(20, 64))
+	assert.NoError(t, err)
+	defer putBuffer(data)
+
+	const defaultLevel = 100
+	sizes := map[int]int{}
+	for _, level := range []int{defaultLevel, gzip.NoCompression, gzip.HuffmanOnly, gzip.BestSpeed, gzip.BestCompression} {
+		var sent []byte
+		client := &Client{
+			ApiToken:        "test-token",
+			UseGzipEncoding: true,
+			HttpClient: &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					sent, _ = io.ReadAll(req.Body)
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`))}, nil
+				},
+			},
+		}
+		if level != defaultLevel {
+			client.CompressionLevel = &level
+		}
+
+		_, err := client.post(context.Background(), OperationUpsert, "/v1/vectors/test-ns", data.Bytes())
+
+		assert.NoError(t, err)
+		assert.Equal(t, data.String(), gunzip(t, sent), "level %d", level)
+		sizes[level] = len(sent)
+	}
+	assert.Less(t, sizes[gzip.BestCompression], sizes[gzip.BestSpeed])
+	assert.Less(t, sizes[gzip.BestSpeed], sizes[gzip.HuffmanOnly])
+	assert.Less(t, sizes[gzip.HuffmanOnly], sizes[gzip.NoCompression], "NoCompression should not be replaced by the default")
+	assert.Greater(t, sizes[gzip.NoCompression], data.Len(), "NoCompression should store the body uncompressed")
+	assert.LessOrEqual(t, sizes[defaultLevel], sizes[gzip.BestSpeed], "the default level should compress better than BestSpeed")
+}
+
+func TestClientUnsupportedCompressionLevel(t *testing.T) {
+	level := 12
+	client := &Client{
+		ApiToken:         "test-token",
+		UseGzipEncoding:  true,
+		CompressionLevel: &level,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				t.Fatal("a request with an unsupported compression level should not be sent")
+				return nil, nil
+			},
+		},
+	}
+
+	_, err := client.post(context.Background(), OperationUpsert, "/v1/vectors/test-ns", []byte(`{"upserts":[]}`))
+	assert.EqualError(t, err, "unsupported compression level 12")
+
+	_, err = client.newRequestBody(requestPayload{encode: func(w io.Writer) error { return nil }})
+	assert.EqualError(t, err, "unsupported compression level 12", "streamed bodies should be rejected too")
+}
+
+func TestClientGzipEncoding(t *testing.T) {
+	const requestBody = `{"upserts":[{"id":"1","vector":[0.1]}]}`
+
+	tests := []struct {
+		name               string
+		useGzip            bool
+		compressAboveBytes int
+		expectedEncoding   string
+	}{
+		{name: "uncompressed by default"},
+		{name: "gzip", useGzip: true, expectedEncoding: "gzip"},
+		{name: "above threshold", compressAboveBytes: len(requestBody), expectedEncoding: "gzip"},
+		{name: "below threshold", compressAboveBytes: len(requestBody) + 1},
+		{name: "gzip takes precedence over threshold", useGzip: true, compressAboveBytes: 1 << 20, expectedEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := &Client{
+				ApiToken:           "test-token",
+				UseGzipEncoding:    tt.useGzip,
+				CompressAboveBytes: tt.compressAboveBytes,
+				Timer:              &fakeTimer{},
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						calls++
+						assert.Equal(t, tt.expectedEncoding, req.Header.Get("Content-Encoding"))
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						if tt.expectedEncoding == "gzip" {
+							body = []byte(gunzip(t, body))
+						}
+						assert.Equal(t, requestBody, string(body), "each attempt should send the whole body")
+						status := http.StatusOK
+						if calls == 1 {
+							status = http.StatusServiceUnavailable
+						}
+						return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`))}, nil
+					},
+				},
+			}
+
+			_, err := client.post(context.Background(), OperationUpsert, "/v1/vectors/test-ns", []byte(requestBody))
+
+			assert.NoError(t, err)
+			assert.Equal(t, 2, calls, "unexpected number of calls")
+		})
+	}
+}
+
+func TestClientCompresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		client   *Client
+		size     int
+		expected bool
+	}{
+		{name: "disabled", client: &Client{}, size: 1 << 20, expected: false},
+		{name: "empty body", client: &Client{UseGzipEncoding: true}, size: 0, expected: false},
+		{name: "below threshold", client: &Client{CompressAboveBytes: 1024}, size: 1023, expected: false},
+		{name: "at threshold", client: &Client{CompressAboveBytes: 1024}, size: 1024, expected: true},
+		{name: "streamed with threshold", client: &Client{CompressAboveBytes: 1024}, size: -1, expected: true},
+		{name: "streamed without compression", client: &Client{}, size: -1, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.client.compresses(tt.size))
+		})
+	}
+}
+
+func TestClientResponseDecoders(t *testing.T) {
+	base64Decoder := &ContentDecoder{
+		Encoding: "b64",
+		NewReader: func(r io.Reader) (io.Reader, error) {
+			return base64.NewDecoder(base64.StdEncoding, r), nil
+		},
+	}
+	const results = `[{"id":"1"}]`
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, _ = io.WriteString(zw, results)
+	_ = zw.Close()
+
+	tests := []struct {
+		name                   string
+		decoders               []*ContentDecoder
+		contentEncoding        string
+		body                   string
+		expectedAcceptEncoding string
+		expectedError          string
+	}{
+		{
+			name: "no decoders",
+			body: results,
+		},
+		{
+			name:                   "custom encoding",
+			decoders:               []*ContentDecoder{base64Decoder},
+			contentEncoding:        "b64",
+			body:                   base64.StdEncoding.EncodeToString([]byte(results)),
+			expectedAcceptEncoding: "b64, gzip",
+		},
+		{
+			name:                   "gzip is still decoded",
+			decoders:               []*ContentDecoder{base64Decoder},
+			contentEncoding:        "gzip",
+			body:                   gzipped.String(),
+			expectedAcceptEncoding: "b64, gzip",
+		},
+		{
+			name:                   "identity",
+			decoders:               []*ContentDecoder{base64Decoder},
+			body:                   results,
+			expectedAcceptEncoding: "b64, gzip",
+		},
+		{
+			name:                   "unsupported encoding",
+			decoders:               []*ContentDecoder{base64Decoder},
+			contentEncoding:        "zstd",
+			body:                   "?",
+			expectedAcceptEncoding: "b64, gzip",
+			expectedError:          `failed to query documents: failed to decode zstd response: unsupported content encoding "zstd"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				ApiToken:         "test-token",
+				DisableRetry:     true,
+				ResponseDecoders: tt.decoders,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, tt.expectedAcceptEncoding, req.Header.Get("Accept-Encoding"))
+						header := http.Header{}
+						if tt.contentEncoding != "" {
+							header.Set("Content-Encoding", tt.contentEncoding)
+						}
+						return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewBufferString(tt.body))}, nil
+					},
+				},
+			}
+
+			results, err := client.Query(context.Background(), "test-ns", &QueryRequest{TopK: 1})
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, "1", results[0].ID)
+			}
+		})
+	}
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	decompressed, err := io.ReadAll(zr)
+	assert.NoError(t, err)
+	return string(decompressed)
+}
+
+// BenchmarkGzip compares allocating a gzip writer per request with reusing pooled writers.
+func BenchmarkGzip(b *testing.B) {
+	request, err := marshalPooled(benchmarkUpsertRequest(100, 256))
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := request.Bytes()
+
+	b.Run("new writer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, err := zw.Write(data); err != nil {
+				b.Fatal(err)
+			}
+			if err := zw.Close(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, err := gzipPooled(data, gzip.DefaultCompression)
+			if err != nil {
+				b.Fatal(err)
+			}
+			putBuffer(buf)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package tpuf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ContentHashAttribute is the attribute in which ChangedUpserts, and Upsert with SkipUnchanged, store a
+// hash of each document's other attributes.
+const ContentHashAttribute = "content_hash"
+
+// ContentHash returns the hash of an upsert's attributes, excluding ContentHashAttribute, which
+// ChangedUpserts compares against the stored document.  Vectors aren't hashed, since they are normally
+// derived from the attributes, e.g. by embedding a text attribute.
+func ContentHash(upsert *Upsert) (string, error) {
+	_, hash, err := hashAttributes(upsert.Attributes)
+	return hash, err
+}
+
+// hashAttributes returns the attributes as a JSON object, without ContentHashAttribute, and their hash.
+// Keys are sorted when the object is marshaled, so the hash doesn't depend on the order of map iteration.
+func hashAttributes(attributes Attributes) (map[string]json.RawMessage, string, error) {
+	fields, err := attributeFields(attributes)
+	if err != nil {
+		return nil, "", err
+	}
+	delete(fields, ContentHashAttribute)
+	canonical, err := json.Marshal(fields)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal attributes: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return fields, hex.EncodeToString(sum[:]), nil
+}
+
+// ChangedUpserts returns copies of the upserts which are new or whose attributes have changed, with
+// ContentHashAttribute set to their ContentHash.  Stored hashes are fetched with one query per
+// Client.MaxTopK documents.  Call it before computing embeddings, to avoid re-embedding unchanged documents,
+// then upsert the result; or set UpsertRequest.SkipUnchanged to do both in one call.
+func (c *Client) ChangedUpserts(ctx context.Context, namespace string, upserts []*Upsert) ([]*Upsert, error) {
+	hashed := make([]*Upsert, len(upserts))
+	hashes := make([]string, len(upserts))
+	for i, upsert := range upserts {
+		fields, hash, err := hashAttributes(upsert.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash document %s: %w", upsert.documentID().str, err)
+		}
+		fields[ContentHashAttribute], _ = json.Marshal(hash)
+		copied := *upsert
+		copied.Attributes = fields
+		hashed[i], hashes[i] = &copied, hash
+	}
+
+	stored, err := c.storedContentHashes(ctx, namespace, hashed)
+	if err != nil {
+		return nil, err
+	}
+	var changed []*Upsert
+	for i, upsert := range hashed {
+		if stored[upsert.documentID().key()] != hashes[i] {
+			changed = append(changed, upsert)
+		}
+	}
+	return changed, nil
+}
+
+// storedContentHashes returns the stored ContentHashAttribute of each of the upserts' documents which
+// exist, keyed by documentID.key.
+func (c *Client) storedContentHashes(ctx context.Context, namespace string, upserts []*Upsert) (map[interface{}]string, error) {
+	hashes := make(map[interface{}]string, len(upserts))
+	for start := 0; start < len(upserts); start += c.maxTopK() {
+		end := start + c.maxTopK()
+		if end > len(upserts) {
+			end = len(upserts)
+		}
+		ids := make([]interface{}, end-start)
+		for i, upsert := range upserts[start:end] {
+			ids[i] = upsert.documentID().key()
+		}
+		results, err := c.Query(ctx, namespace, &QueryRequest{
+			Filters:           In(IDAttribute, ids),
+			TopK:              len(ids),
+			IncludeAttributes: IncludeAttributeNames(ContentHashAttribute),
+		})
+		if isNotFound(err) {
+			// The namespace doesn't exist yet, so every document is new.
+			return hashes, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch content hashes: %w", err)
+		}
+		for _, result := range results {
+			var attributes map[string]json.RawMessage
+			var hash string
+			if json.Unmarshal(result.Attributes, &attributes) == nil && json.Unmarshal(attributes[ContentHashAttribute], &hash) == nil {
+				hashes[newDocumentID(result.ID, result.IDUint64).key()] = hash
+			}
+		}
+	}
+	return hashes, nil
+}
+
+// attributeFields returns a copy of the attributes as a JSON object, so that attributes can be added to
+// documents whatever type the caller used for them.
+func attributeFields(attributes Attributes) (map[string]json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if attributes != nil {
+		data, err := json.Marshal(attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal attributes: %w", err)
+		}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("attributes must be a JSON object: %w", err)
+		}
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+	return fields, nil
+}
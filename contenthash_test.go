@@ -0,0 +1,142 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentHash(t *testing.T) {
+	type attributes struct {
+		Title string `json:"title"`
+		Year  int    `json:"year"`
+	}
+	fromStruct, err := tpuf.ContentHash(&tpuf.Upsert{ID: "1", Attributes: attributes{Title: "a", Year: 2020}})
+	assert.NoError(t, err)
+	fromMap, err := tpuf.ContentHash(&tpuf.Upsert{ID: "1", Attributes: map[string]interface{}{"year": 2020, "title": "a"}})
+	assert.NoError(t, err)
+	withHash, err := tpuf.ContentHash(&tpuf.Upsert{ID: "1", Attributes: map[string]interface{}{"year": 2020, "title": "a", tpuf.ContentHashAttribute: "stale"}})
+	assert.NoError(t, err)
+	withVector, err := tpuf.ContentHash(&tpuf.Upsert{ID: "1", Vector: []float32{1}, Attributes: attributes{Title: "a", Year: 2020}})
+	assert.NoError(t, err)
+	changed, err := tpuf.ContentHash(&tpuf.Upsert{ID: "1", Attributes: attributes{Title: "b", Year: 2020}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, fromStruct, fromMap)
+	assert.Equal(t, fromStruct, withHash)
+	assert.Equal(t, fromStruct, withVector)
+	assert.NotEqual(t, fromStruct, changed)
+
+	_, err = tpuf.ContentHash(&tpuf.Upsert{ID: "1", Attributes: []string{"not", "an", "object"}})
+	assert.ErrorContains(t, err, "attributes must be a JSON object")
+}
+
+func TestUpsertSkipUnchanged(t *testing.T) {
+	upserts := []*tpuf.Upsert{
+		{ID: "same", Vector: []float32{1}, Attributes: map[string]string{"text": "unchanged"}},
+		{ID: "edited", Vector: []float32{2}, Attributes: map[string]string{"text": "new text"}},
+		{ID: "new", Vector: []float32{3}, Attributes: map[string]string{"text": "hello"}},
+	}
+	hash := func(upsert *tpuf.Upsert) string {
+		h, err := tpuf.ContentHash(upsert)
+		assert.NoError(t, err)
+		return h
+	}
+	storedResults := fmt.Sprintf(`[{"id":"same","attributes":{"content_hash":%q}},{"id":"edited","attributes":{"content_hash":"old"}}]`, hash(upserts[0]))
+
+	tests := []struct {
+		name            string
+		queryStatus     int
+		queryResponse   string
+		expectedUpserts string
+		expectedSkipped int
+	}{
+		{
+			name:          "skips unchanged documents",
+			queryStatus:   http.StatusOK,
+			queryResponse: storedResults,
+			expectedUpserts: fmt.Sprintf(`[{"id":"edited","vector":[2],"attributes":{"content_hash":%q,"text":"new text"}},{"id":"new","vector":[3],"attributes":{"content_hash":%q,"text":"hello"}}]`,
+				hash(upserts[1]), hash(upserts[2])),
+			expectedSkipped: 1,
+		},
+		{
+			name:          "new namespace",
+			queryStatus:   http.StatusNotFound,
+			queryResponse: `{"status":"error","error":"namespace not found"}`,
+			expectedUpserts: fmt.Sprintf(`[{"id":"same","vector":[1],"attributes":{"content_hash":%q,"text":"unchanged"}},{"id":"edited","vector":[2],"attributes":{"content_hash":%q,"text":"new text"}},{"id":"new","vector":[3],"attributes":{"content_hash":%q,"text":"hello"}}]`,
+				hash(upserts[0]), hash(upserts[1]), hash(upserts[2])),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentUpserts string
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						if strings.HasSuffix(req.URL.Path, "/query") {
+							assert.JSONEq(t, `{"top_k":3,"include_attributes":["content_hash"],"filters":["id","In",["same","edited","new"]]}`, string(body))
+							return &http.Response{StatusCode: tt.queryStatus, Body: io.NopCloser(bytes.NewBufferString(tt.queryResponse))}, nil
+						}
+						var request struct {
+							Upserts json.RawMessage `json:"upserts"`
+						}
+						assert.NoError(t, json.Unmarshal(body, &request))
+						sentUpserts = string(request.Upserts)
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`))}, nil
+					},
+				},
+			}
+
+			response, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				Upserts:        upserts,
+				SkipUnchanged:  true,
+			})
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expectedUpserts, sentUpserts)
+			assert.Equal(t, tt.expectedSkipped, response.Skipped)
+			assert.Equal(t, map[string]string{"text": "unchanged"}, upserts[0].Attributes, "caller's upserts must not be modified")
+		})
+	}
+}
+
+func TestUpsertSkipUnchangedAll(t *testing.T) {
+	upsert := &tpuf.Upsert{ID: "same", Vector: []float32{1}, Attributes: map[string]string{"text": "unchanged"}}
+	hash, err := tpuf.ContentHash(upsert)
+	assert.NoError(t, err)
+	requests := 0
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				requests++
+				assert.True(t, strings.HasSuffix(req.URL.Path, "/query"), "only the hash lookup should be sent")
+				body := fmt.Sprintf(`[{"id":"same","attributes":{"content_hash":%q}}]`, hash)
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+			},
+		},
+	}
+
+	response, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+		Upserts:       []*tpuf.Upsert{upsert},
+		SkipUnchanged: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 1, response.Skipped)
+}
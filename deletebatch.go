@@ -0,0 +1,129 @@
+package tpuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultDeleteBatchSize   = 10000
+	defaultDeleteConcurrency = 4
+)
+
+func (c *Client) deleteBatchSize() int {
+	if c.DeleteBatchSize <= 0 {
+		return defaultDeleteBatchSize
+	}
+	return c.DeleteBatchSize
+}
+
+func (c *Client) deleteConcurrency() int {
+	if c.DeleteConcurrency <= 0 {
+		return defaultDeleteConcurrency
+	}
+	return c.DeleteConcurrency
+}
+
+// DeleteReport describes the outcome of a Delete or DeleteUint64 call.
+type DeleteReport struct {
+	// Requested is the number of IDs passed to the call.
+	Requested int
+	// Deleted is the number of documents the server reported deleting across all successful batches, or -1
+	// if it didn't report the number for every one of them.
+	Deleted int
+	// Batches describes each request sent, in the order of the IDs.  There is a single batch unless the IDs
+	// were split; see Client.DeleteBatchSize.
+	Batches []*DeleteBatch
+}
+
+// DeleteBatch describes one request sent by Delete or DeleteUint64.
+type DeleteBatch struct {
+	// IDs is the number of IDs in the batch.
+	IDs int
+	// RowsAffected is the number of documents the server reported deleting, if the batch succeeded and the
+	// server reported it.
+	RowsAffected *int
+	// Err is the error with which the batch failed, if any.
+	Err error
+}
+
+// newDeleteReport summarizes the outcome of the given batches.
+func newDeleteReport(requested int, batches []*DeleteBatch) *DeleteReport {
+	report := &DeleteReport{Requested: requested, Batches: batches}
+	for _, batch := range batches {
+		switch {
+		case batch.Err != nil:
+		case batch.RowsAffected == nil || report.Deleted < 0:
+			report.Deleted = -1
+		default:
+			report.Deleted += *batch.RowsAffected
+		}
+	}
+	return report
+}
+
+// deleteBatch sends a single deletion request, recording its outcome in batch.
+func (c *Client) deleteBatch(ctx context.Context, namespace string, upserts []*Upsert, batch *DeleteBatch) {
+	batch.IDs = len(upserts)
+	response, err := c.upsert(ctx, namespace, &UpsertRequest{Upserts: upserts}, true)
+	if err != nil {
+		batch.Err = err
+		return
+	}
+	batch.RowsAffected = response.RowsAffected
+}
+
+// deleteDocuments deletes the documents identified by upserts, which have IDs only.  Lists longer than
+// Client.DeleteBatchSize are split into batches, sent with up to Client.DeleteConcurrency requests in flight.
+// Every batch is attempted, and the errors of those which fail are joined.
+func (c *Client) deleteDocuments(ctx context.Context, namespace string, upserts []*Upsert) (*DeleteReport, error) {
+	batchSize := c.deleteBatchSize()
+	if len(upserts) <= batchSize {
+		batch := &DeleteBatch{}
+		c.deleteBatch(ctx, namespace, upserts, batch)
+		return newDeleteReport(len(upserts), []*DeleteBatch{batch}), batch.Err
+	}
+
+	var chunks [][]*Upsert
+	for start := 0; start < len(upserts); start += batchSize {
+		end := start + batchSize
+		if end > len(upserts) {
+			end = len(upserts)
+		}
+		chunks = append(chunks, upserts[start:end])
+	}
+
+	batches := make([]*DeleteBatch, len(chunks))
+	sem := make(chan struct{}, c.deleteConcurrency())
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		batches[i] = &DeleteBatch{IDs: len(chunk)}
+		sem <- struct{}{}
+		if err := ctx.Err(); err != nil {
+			<-sem
+			batches[i].Err = err
+			continue
+		}
+		wg.Add(1)
+		go func(chunk []*Upsert, batch *DeleteBatch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.deleteBatch(ctx, namespace, chunk, batch)
+		}(chunk, batches[i])
+	}
+	wg.Wait()
+
+	var failed []error
+	for i, batch := range batches {
+		if batch.Err != nil {
+			failed = append(failed, fmt.Errorf("batch %d of %d: %w", i+1, len(batches), batch.Err))
+		}
+	}
+	report := newDeleteReport(len(upserts), batches)
+	if len(failed) > 0 {
+		return report, fmt.Errorf("failed to delete %d of %d batches: %w", len(failed), len(batches), errors.Join(failed...))
+	}
+	return report, nil
+}
@@ -0,0 +1,116 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteBatches(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]string
+	inFlight, maxInFlight := 0, 0
+	client := &tpuf.Client{
+		ApiToken:          "test-token",
+		DisableRetry:      true,
+		DeleteBatchSize:   10,
+		DeleteConcurrency: 2,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				var body struct {
+					Upserts []struct {
+						ID string `json:"id"`
+					} `json:"upserts"`
+				}
+				assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				var ids []string
+				for _, upsert := range body.Upserts {
+					ids = append(ids, upsert.ID)
+				}
+
+				mu.Lock()
+				batches = append(batches, ids)
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+
+				if ids[0] == "id-10" {
+					return &http.Response{
+						StatusCode: http.StatusBadRequest,
+						Body:       io.NopCloser(bytes.NewBufferString(`{"error":"boom","status":"error"}`)),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{"status":"OK","rows_affected":%d}`, len(ids)))),
+				}, nil
+			},
+		},
+	}
+	var ids []string
+	for i := 0; i < 25; i++ {
+		ids = append(ids, fmt.Sprintf("id-%02d", i))
+	}
+
+	report, err := client.Delete(context.Background(), "test-namespace", ids)
+
+	assert.EqualError(t, err, "failed to delete 1 of 3 batches: batch 2 of 3: failed to upsert documents: error: boom (HTTP 400)")
+	var apiErr tpuf.ApiError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 25, report.Requested)
+	assert.Equal(t, 15, report.Deleted, "only the successful batches should be counted")
+	if assert.Len(t, report.Batches, 3) {
+		assert.Equal(t, []int{10, 10, 5}, []int{report.Batches[0].IDs, report.Batches[1].IDs, report.Batches[2].IDs})
+		assert.Nil(t, report.Batches[0].Err)
+		assert.ErrorAs(t, report.Batches[1].Err, &apiErr)
+		assert.Nil(t, report.Batches[1].RowsAffected)
+	}
+	assert.LessOrEqual(t, maxInFlight, 2, "at most DeleteConcurrency requests should be in flight")
+	sort.Slice(batches, func(i, j int) bool { return batches[i][0] < batches[j][0] })
+	if assert.Len(t, batches, 3, "every batch should be attempted") {
+		assert.Equal(t, ids[:10], batches[0])
+		assert.Equal(t, ids[10:20], batches[1])
+		assert.Equal(t, ids[20:], batches[2])
+	}
+}
+
+func TestDeleteBatchesNegativeSettings(t *testing.T) {
+	var batches int
+	client := &tpuf.Client{
+		ApiToken:          "test-token",
+		DisableRetry:      true,
+		DeleteBatchSize:   -1,
+		DeleteConcurrency: -1,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				batches++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK","rows_affected":3}`)),
+				}, nil
+			},
+		},
+	}
+
+	report, err := client.Delete(context.Background(), "test-namespace", []string{"a", "b", "c"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Deleted)
+	assert.Equal(t, 1, batches, "negative settings should fall back to the defaults")
+}
@@ -0,0 +1,95 @@
+package tpuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DeleteWhereOptions configures Client.DeleteWhere.
+type DeleteWhereOptions struct {
+	// BatchSize is the number of documents deleted per request when deleting in batches.  Defaults to
+	// Client.MaxTopK.
+	BatchSize int
+	// MaxRate, if positive, limits deletion to this many documents per second.  Setting it forces deletion in
+	// batches, since a server-side deletion by filter can't be throttled.
+	MaxRate float64
+	// Progress, if set, is called with the total number of documents deleted so far after each batch, or
+	// once after a server-side deletion.
+	Progress func(deleted int)
+}
+
+// DeleteWhere deletes every document in a namespace matching filter, returning how many were deleted.
+//
+// Unless MaxRate is set, it deletes server-side with DeleteByFilter, falling back to batches if the server
+// rejects that request as invalid.  In that case the count is -1 if the server doesn't report one.  Batched
+// deletion pages through the matching documents in ID order, as Scan does, deleting each page by ID.  A
+// failed batched deletion may have deleted some documents; the returned count includes them.
+func (c *Client) DeleteWhere(ctx context.Context, namespace string, filter Filter, opts *DeleteWhereOptions) (int, error) {
+	if filter == nil {
+		return 0, fmt.Errorf("a filter is required; use DeleteNamespace to delete all documents")
+	}
+	var o DeleteWhereOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxRate <= 0 {
+		response, err := c.DeleteByFilter(ctx, namespace, filter)
+		if err == nil {
+			deleted := -1
+			if response.RowsAffected != nil {
+				deleted = *response.RowsAffected
+			}
+			if o.Progress != nil {
+				o.Progress(deleted)
+			}
+			return deleted, nil
+		}
+		var apiErr ApiError
+		if !errors.As(err, &apiErr) || apiErr.HttpStatus != http.StatusBadRequest {
+			return 0, err
+		}
+		c.log(ctx, LogLevelWarn, "delete by filter rejected, deleting in batches", "namespace", namespace, "error", err)
+	}
+	return c.deleteBatches(ctx, namespace, filter, o)
+}
+
+// deleteBatches deletes the documents matching filter a batch at a time, waiting between batches as needed
+// to stay under MaxRate.
+func (c *Client) deleteBatches(ctx context.Context, namespace string, filter Filter, o DeleteWhereOptions) (int, error) {
+	start := time.Now()
+	deleted := 0
+	it := c.Scan(ctx, namespace, filter, &ScanOptions{PageSize: o.BatchSize})
+	for {
+		var batch []*Upsert
+		for len(batch) < it.opts.PageSize && it.Next() {
+			result := it.Result()
+			batch = append(batch, &Upsert{ID: result.ID, IDUint64: result.IDUint64})
+		}
+		if err := it.Err(); err != nil {
+			return deleted, fmt.Errorf("failed to find documents to delete: %w", err)
+		}
+		if len(batch) == 0 {
+			return deleted, nil
+		}
+
+		if _, err := c.upsert(ctx, namespace, &UpsertRequest{Upserts: batch}, true); err != nil {
+			return deleted, err
+		}
+		deleted += len(batch)
+		if o.Progress != nil {
+			o.Progress(deleted)
+		}
+
+		if o.MaxRate > 0 {
+			due := time.Duration(float64(deleted) / o.MaxRate * float64(time.Second))
+			if wait := due - time.Since(start); wait > 0 {
+				if err := c.sleep(ctx, wait); err != nil {
+					return deleted, err
+				}
+			}
+		}
+	}
+}
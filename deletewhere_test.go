@@ -0,0 +1,159 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteWhere(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             *tpuf.DeleteWhereOptions
+		responses        []string
+		deleteByFilter   int
+		expectedBodies   []string
+		expectedDeleted  int
+		expectedProgress []int
+		expectedSleeps   int
+		expectedError    string
+	}{
+		{
+			name:             "server-side",
+			deleteByFilter:   http.StatusOK,
+			responses:        []string{`{"status":"OK","rows_affected":3}`},
+			expectedBodies:   []string{`{"delete_by_filter":["kind","Eq","a"]}`},
+			expectedDeleted:  3,
+			expectedProgress: []int{3},
+		},
+		{
+			name:             "server-side without a count",
+			deleteByFilter:   http.StatusOK,
+			responses:        []string{`{"status":"OK"}`},
+			expectedBodies:   []string{`{"delete_by_filter":["kind","Eq","a"]}`},
+			expectedDeleted:  -1,
+			expectedProgress: []int{-1},
+		},
+		{
+			name:           "server-side failure",
+			deleteByFilter: http.StatusInternalServerError,
+			responses:      []string{`{"status":"error","error":"boom"}`},
+			expectedBodies: []string{`{"delete_by_filter":["kind","Eq","a"]}`},
+			expectedError:  "failed to delete documents: error: boom (HTTP 500)",
+		},
+		{
+			name:           "falls back to batches",
+			opts:           &tpuf.DeleteWhereOptions{BatchSize: 2},
+			deleteByFilter: http.StatusBadRequest,
+			responses: []string{
+				`{"status":"error","error":"unsupported filter"}`,
+				`[{"id":"1"},{"id":"2"}]`,
+				`{"status":"OK"}`,
+				`[{"id":3}]`,
+				`{"status":"OK"}`,
+			},
+			expectedBodies: []string{
+				`{"delete_by_filter":["kind","Eq","a"]}`,
+				`{"top_k":2,"filters":["kind","Eq","a"]}`,
+				`{"upserts":[{"id":"1"},{"id":"2"}]}`,
+				`{"top_k":2,"filters":["And",[["kind","Eq","a"],["id","Gt","2"]]]}`,
+				`{"upserts":[{"id":3}]}`,
+			},
+			expectedDeleted:  3,
+			expectedProgress: []int{2, 3},
+		},
+		{
+			name: "throttled",
+			opts: &tpuf.DeleteWhereOptions{BatchSize: 1, MaxRate: 1},
+			responses: []string{
+				`[{"id":"1"}]`,
+				`{"status":"OK"}`,
+				`[]`,
+			},
+			expectedBodies: []string{
+				`{"top_k":1,"filters":["kind","Eq","a"]}`,
+				`{"upserts":[{"id":"1"}]}`,
+				`{"top_k":1,"filters":["And",[["kind","Eq","a"],["id","Gt","1"]]]}`,
+			},
+			expectedDeleted:  1,
+			expectedProgress: []int{1},
+			expectedSleeps:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			var progress []int
+			timer := &sleepRecorder{}
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				Timer:        timer,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						bodies = append(bodies, string(body))
+						status := http.StatusOK
+						if strings.Contains(string(body), "delete_by_filter") {
+							status = tt.deleteByFilter
+						}
+						response := tt.responses[len(bodies)-1]
+						return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(response))}, nil
+					},
+				},
+			}
+			opts := tt.opts
+			if opts == nil {
+				opts = &tpuf.DeleteWhereOptions{}
+			}
+			opts.Progress = func(deleted int) { progress = append(progress, deleted) }
+
+			deleted, err := client.DeleteWhere(context.Background(), "test-namespace", tpuf.Eq("kind", "a"), opts)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedDeleted, deleted)
+			assert.Equal(t, tt.expectedBodies, bodies)
+			assert.Equal(t, tt.expectedProgress, progress)
+			assert.Len(t, timer.durations, tt.expectedSleeps)
+		})
+	}
+}
+
+func TestDeleteWhereRequiresFilter(t *testing.T) {
+	client := &tpuf.Client{ApiToken: "test-token"}
+
+	_, err := client.DeleteWhere(context.Background(), "test-namespace", nil, nil)
+
+	assert.EqualError(t, err, "a filter is required; use DeleteNamespace to delete all documents")
+}
+
+// sleepRecorder is a backoff.Timer which fires immediately, recording each requested duration.
+type sleepRecorder struct {
+	durations []time.Duration
+	ch        chan time.Time
+}
+
+func (s *sleepRecorder) Start(duration time.Duration) {
+	s.durations = append(s.durations, duration)
+	s.ch = make(chan time.Time, 1)
+	s.ch <- time.Now()
+}
+
+func (s *sleepRecorder) Stop() {}
+
+func (s *sleepRecorder) C() <-chan time.Time {
+	return s.ch
+}
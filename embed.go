@@ -0,0 +1,17 @@
+package tpuf
+
+import "context"
+
+// Embedder converts texts into vector embeddings, typically by calling a hosted embedding model.
+// Implementations return one vector per text, in the same order as texts.
+// See the tpufembed package for implementations backed by embedding providers.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFunc adapts an ordinary function to the Embedder interface.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+func (f EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return f(ctx, texts)
+}
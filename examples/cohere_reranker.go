@@ -0,0 +1,100 @@
+package examples
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bamo/tpuf-go"
+)
+
+/**
+ * CohereReranker is an example tpuf.Reranker which calls Cohere's rerank API.
+ * Other hosted rerankers, such as Voyage AI's, follow the same shape: send the query and candidate
+ * document texts, and reorder the candidates by the returned indexes.
+ *
+ * To use it with hybrid search, set IncludeAttributes on the request so that the candidates carry
+ * the text attribute:
+ *
+ *   client.HybridQuery(ctx, namespace, &tpuf.HybridRequest{
+ *       ...
+ *       IncludeAttributes: tpuf.IncludeAttributeNames("text"),
+ *       Reranker:          &CohereReranker{ApiKey: os.Getenv("COHERE_API_KEY"), TextAttribute: "text"},
+ *   })
+ */
+type CohereReranker struct {
+	ApiKey string
+	// Model is the rerank model to use, e.g. "rerank-english-v3.0".
+	Model string
+	// TextAttribute is the attribute containing the text to rerank on.
+	TextAttribute string
+}
+
+func (r *CohereReranker) Rerank(ctx context.Context, query string, candidates []*tpuf.QueryResult) ([]*tpuf.QueryResult, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	documents := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		var attrs map[string]interface{}
+		if err := json.Unmarshal(candidate.Attributes, &attrs); err != nil {
+			return nil, fmt.Errorf("failed to decode attributes of %s: %w", candidate.ID, err)
+		}
+		text, _ := attrs[r.TextAttribute].(string)
+		documents[i] = text
+	}
+
+	model := r.Model
+	if model == "" {
+		model = "rerank-english-v3.0"
+	}
+	reqJson, err := json.Marshal(map[string]interface{}{
+		"model":     model,
+		"query":     query,
+		"documents": documents,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.cohere.com/v1/rerank", bytes.NewReader(reqJson))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.ApiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// Drain the body so that the connection can be reused.
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cohere rerank failed with HTTP %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Results []struct {
+			Index int `json:"index"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode rerank response: %w", err)
+	}
+
+	reranked := make([]*tpuf.QueryResult, 0, len(response.Results))
+	for _, result := range response.Results {
+		if result.Index < 0 || result.Index >= len(candidates) {
+			return nil, fmt.Errorf("rerank response index %d out of range", result.Index)
+		}
+		reranked = append(reranked, candidates[result.Index])
+	}
+	return reranked, nil
+}
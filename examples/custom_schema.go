@@ -90,7 +90,7 @@ func UpsertAndQueryWithCustomSchema(namespace string) error {
 	}
 
 	// Perform the upsert
-	err := client.Upsert(ctx, namespace, request)
+	_, err := client.Upsert(ctx, namespace, request)
 	if err != nil {
 		return fmt.Errorf("failed to upsert space colonies: %w", err)
 	}
@@ -112,7 +112,7 @@ func UpsertAndQueryWithCustomSchema(namespace string) error {
 	queryRequest := &tpuf.QueryRequest{
 		Vector:            queryEmbedding,
 		DistanceMetric:    tpuf.DistanceMetricCosine,
-		IncludeAttributes: true,
+		IncludeAttributes: tpuf.IncludeAllAttributes(),
 		TopK:              3,
 	}
 	results, err := client.Query(ctx, namespace, queryRequest)
@@ -11,7 +11,9 @@ import (
 
 /**
  * Here we use a filter-only query combined with pagination to delete documents matching a given
- * filter from an index.
+ * filter from an index.  Client.DeleteByFilter performs the same deletion in a single server-side
+ * call, and Client.DeleteWhere wraps both approaches, batching and throttling as needed; this pattern is
+ * useful when you need to inspect or log each batch as it is deleted.
  *
  * This example is runnable as-is, but you'll need to set the TPUF_API_TOKEN environment variable.
  */
@@ -76,7 +78,7 @@ func DeleteByFilter(namespace string) error {
 		},
 	}
 
-	err := client.Upsert(ctx, namespace, &tpuf.UpsertRequest{
+	_, err := client.Upsert(ctx, namespace, &tpuf.UpsertRequest{
 		Schema: tpuf.Schema{
 			"category": &tpuf.Attribute{
 				Type: tpuf.AttributeTypeString,
@@ -95,11 +97,7 @@ func DeleteByFilter(namespace string) error {
 	}
 
 	// Define a filter to delete incriminating evidence
-	baseFilter := &tpuf.BaseFilter{
-		Attribute: "category",
-		Operator:  tpuf.OpEq,
-		Value:     "incriminating",
-	}
+	baseFilter := tpuf.Eq("category", "incriminating")
 
 	var filter tpuf.Filter = baseFilter
 
@@ -132,7 +130,7 @@ func DeleteByFilter(namespace string) error {
 		}
 
 		// Delete this batch of documents by ID.
-		err = client.Delete(ctx, namespace, idsToDelete)
+		_, err = client.Delete(ctx, namespace, idsToDelete)
 		if err != nil {
 			return fmt.Errorf("failed to delete documents: %w", err)
 		}
@@ -141,22 +139,13 @@ func DeleteByFilter(namespace string) error {
 		fmt.Printf("Deleted %d documents\n", deletedCount)
 
 		// Update the filter to move on to the next batch of documents.
-		filter = &tpuf.AndFilter{
-			Filters: []tpuf.Filter{
-				baseFilter,
-				&tpuf.BaseFilter{
-					Attribute: "id",
-					Operator:  tpuf.OpGt,
-					Value:     results[len(results)-1].ID,
-				},
-			},
-		}
+		filter = tpuf.And(baseFilter, tpuf.IDGt(results[len(results)-1].ID))
 	}
 	fmt.Printf("Deletion complete. Total documents deleted: %d\n", deletedCount)
 
 	results, err := client.Query(ctx, namespace, &tpuf.QueryRequest{
 		TopK:              1000,
-		IncludeAttributes: true,
+		IncludeAttributes: tpuf.IncludeAllAttributes(),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to query documents: %w", err)
@@ -11,9 +11,10 @@ import (
 /**
  * This is an example of hybrid search using a combination of keyword search and semantic search.
  *
- * This example is missing some important parts, including populating the index, generating embeddings,
- * and reranking the results.  You'll need to fill in those parts to actually do hybrid search,
- * but this should get you started.
+ * HybridQuery runs both searches concurrently and fuses the results with reciprocal rank fusion.
+ * This example is missing some important parts, including populating the index and generating
+ * embeddings.  You'll need to fill in those parts to actually do hybrid search, but this should
+ * get you started.
  */
 func HybridSearch(namespace string) error {
 	ctx := context.Background()
@@ -26,31 +27,22 @@ func HybridSearch(namespace string) error {
 	// Replace with your favorite embedding model.
 	queryEmbedding := []float32{0.1, 0.2, 0.3}
 
-	// Retrieve 10 results using BM25 full-text search.
-	keywordResults, err := client.Query(ctx, namespace, &tpuf.QueryRequest{
-		RankBy: []interface{}{"text", "BM25", query},
-		TopK:   10,
-	})
-	if err != nil {
-		return err
-	}
-
-	// Retrieve 10 results using semantic search with cosine distance metric.
-	semanticResults, err := client.Query(ctx, namespace, &tpuf.QueryRequest{
+	// Retrieve 10 candidates from each of BM25 full-text search and semantic search, and fuse them.
+	results, err := client.HybridQuery(ctx, namespace, &tpuf.HybridRequest{
 		Vector:         queryEmbedding,
 		DistanceMetric: tpuf.DistanceMetricCosine,
+		TextAttribute:  "text",
+		Text:           query,
 		TopK:           10,
+		// Optionally weight keyword matches more heavily than semantic matches.
+		FusionRRF: &tpuf.FusionRRF{TextWeight: 1.5},
 	})
 	if err != nil {
 		return err
 	}
 
-	allResults := append(keywordResults, semanticResults...)
-
-	// Do some reranking here...
-
-	for _, result := range allResults {
-		fmt.Printf("%+v\n", result)
+	for _, result := range results {
+		fmt.Printf("%s (score: %f, vector rank: %d, text rank: %d)\n", result.ID, result.Score, result.VectorRank, result.TextRank)
 	}
 
 	return nil
@@ -1,31 +1,259 @@
 package tpuf
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
+	"time"
 )
 
 type ExportResponse struct {
-	IDs        []string                     `json:"ids"`
+	// IDs are the documents' IDs.  Numeric IDs are formatted in decimal.
+	IDs []string `json:"ids"`
+	// IDsUint64 holds the numeric IDs, if any, in the same positions as IDs.  It is nil if every ID is a string.
+	IDsUint64  []*uint64                    `json:"-"`
 	Vectors    [][]float32                  `json:"vectors"`
 	Attributes map[string][]json.RawMessage `json:"attributes"`
 	NextCursor string                       `json:"next_cursor"`
 }
 
+// exportResponseJSON is the wire format of an ExportResponse, whose IDs may be strings or numbers.
+type exportResponseJSON struct {
+	IDs        []documentID                 `json:"ids"`
+	Vectors    [][]float32                  `json:"vectors"`
+	Attributes map[string][]json.RawMessage `json:"attributes"`
+	NextCursor string                       `json:"next_cursor"`
+}
+
+func (r *ExportResponse) MarshalJSON() ([]byte, error) {
+	ids := make([]documentID, len(r.IDs))
+	for i, id := range r.IDs {
+		var idUint64 *uint64
+		if i < len(r.IDsUint64) {
+			idUint64 = r.IDsUint64[i]
+		}
+		ids[i] = newDocumentID(id, idUint64)
+	}
+	if r.IDs == nil {
+		ids = nil
+	}
+	return json.Marshal(&exportResponseJSON{IDs: ids, Vectors: r.Vectors, Attributes: r.Attributes, NextCursor: r.NextCursor})
+}
+
+func (r *ExportResponse) UnmarshalJSON(data []byte) error {
+	var aux exportResponseJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*r = ExportResponse{Vectors: aux.Vectors, Attributes: aux.Attributes, NextCursor: aux.NextCursor}
+	if aux.IDs != nil {
+		r.IDs = make([]string, len(aux.IDs))
+	}
+	for i, id := range aux.IDs {
+		r.IDs[i] = id.str
+		if id.num == nil {
+			continue
+		}
+		if r.IDsUint64 == nil {
+			r.IDsUint64 = make([]*uint64, len(aux.IDs))
+		}
+		r.IDsUint64[i] = id.num
+	}
+	return nil
+}
+
+// Document is a single exported document.
+type Document struct {
+	// ID is the document's ID.  Numeric IDs are formatted in decimal.
+	ID string `json:"id"`
+	// IDUint64 is set if the document has a numeric ID.
+	IDUint64   *uint64                    `json:"-"`
+	Vector     []float32                  `json:"vector,omitempty"`
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
+}
+
+// documentJSON is the wire format of a Document, whose ID may be a string or a number.
+type documentJSON struct {
+	ID         documentID                 `json:"id"`
+	Vector     []float32                  `json:"vector,omitempty"`
+	Attributes map[string]json.RawMessage `json:"attributes,omitempty"`
+}
+
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&documentJSON{ID: d.documentID(), Vector: d.Vector, Attributes: d.Attributes})
+}
+
+func (d *Document) UnmarshalJSON(data []byte) error {
+	var aux documentJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*d = Document{ID: aux.ID.str, IDUint64: aux.ID.num, Vector: aux.Vector, Attributes: aux.Attributes}
+	return nil
+}
+
+func (d *Document) documentID() documentID {
+	return newDocumentID(d.ID, d.IDUint64)
+}
+
+// Documents converts the column-oriented page into one Document per ID.
+// Attributes which are null for a document are omitted from its Attributes.
+func (r *ExportResponse) Documents() []*Document {
+	documents := make([]*Document, len(r.IDs))
+	for i, id := range r.IDs {
+		document := &Document{ID: id, Attributes: map[string]json.RawMessage{}}
+		if i < len(r.IDsUint64) {
+			document.IDUint64 = r.IDsUint64[i]
+		}
+		if i < len(r.Vectors) {
+			document.Vector = r.Vectors[i]
+		}
+		for name, values := range r.Attributes {
+			if i < len(values) && values[i] != nil && string(values[i]) != "null" {
+				document.Attributes[name] = values[i]
+			}
+		}
+		documents[i] = document
+	}
+	return documents
+}
+
+// TypedDocument is an exported document whose attributes have been decoded into T.
+type TypedDocument[T any] struct {
+	ID         string
+	IDUint64   *uint64
+	Vector     []float32
+	Attributes T
+}
+
+// DocumentsTyped converts the column-oriented page into one TypedDocument per ID, decoding each document's
+// attributes into T, which must be a type that encoding/json can unmarshal an object into, such as a struct.
+// Attributes which are null or missing for a document are left as their zero value.
+func DocumentsTyped[T any](r *ExportResponse) ([]TypedDocument[T], error) {
+	documents := r.Documents()
+	typed := make([]TypedDocument[T], len(documents))
+	for i, document := range documents {
+		typed[i] = TypedDocument[T]{ID: document.ID, IDUint64: document.IDUint64, Vector: document.Vector}
+		if len(document.Attributes) == 0 {
+			continue
+		}
+		data, err := json.Marshal(document.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode attributes of document %s: %w", document.ID, err)
+		}
+		if err := json.Unmarshal(data, &typed[i].Attributes); err != nil {
+			return nil, fmt.Errorf("failed to decode attributes of document %s: %w", document.ID, err)
+		}
+	}
+	return typed, nil
+}
+
+// ExportColumn decodes one attribute column of the page into a slice with an element per ID, in the same
+// order as IDs.  Elements are nil for documents where the attribute is null or missing.
+// For example, ExportColumn[int64](page, "count") decodes an int attribute.
+func ExportColumn[T any](r *ExportResponse, attribute string) ([]*T, error) {
+	values := make([]*T, len(r.IDs))
+	for i, raw := range r.Attributes[attribute] {
+		if i >= len(values) {
+			break
+		}
+		if raw == nil || string(raw) == "null" {
+			continue
+		}
+		var value T
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("failed to decode attribute %s of document %s: %w", attribute, r.IDs[i], err)
+		}
+		values[i] = &value
+	}
+	return values, nil
+}
+
+// ExportColumns decodes the page's attribute columns according to their types in schema, using ExportColumn.
+// The result maps each attribute name to a slice whose type depends on the attribute's type:
+//
+//	string, uuid            []*string
+//	int                     []*int64
+//	uint                    []*uint64
+//	float                   []*float64
+//	bool                    []*bool
+//	datetime                []*time.Time
+//	[]string, []uuid        []*[]string
+//	[]int                   []*[]int64
+//	[]uint                  []*[]uint64
+//	[]float                 []*[]float64
+//	[]datetime              []*[]time.Time
+//
+// Attributes which aren't in the schema, or whose type is unset, are not decoded.
+func ExportColumns(r *ExportResponse, schema Schema) (map[string]interface{}, error) {
+	columns := make(map[string]interface{}, len(schema))
+	for name, attribute := range schema {
+		if attribute == nil || attribute.Type == "" {
+			continue
+		}
+		var column interface{}
+		var err error
+		switch attribute.Type {
+		case AttributeTypeString, AttributeTypeUUID:
+			column, err = ExportColumn[string](r, name)
+		case AttributeTypeInt:
+			column, err = ExportColumn[int64](r, name)
+		case AttributeTypeUint:
+			column, err = ExportColumn[uint64](r, name)
+		case AttributeTypeFloat:
+			column, err = ExportColumn[float64](r, name)
+		case AttributeTypeBool:
+			column, err = ExportColumn[bool](r, name)
+		case AttributeTypeDatetime:
+			column, err = ExportColumn[time.Time](r, name)
+		case AttributeTypeStringArray, AttributeTypeUUIDArray:
+			column, err = ExportColumn[[]string](r, name)
+		case AttributeTypeIntArray:
+			column, err = ExportColumn[[]int64](r, name)
+		case AttributeTypeUintArray:
+			column, err = ExportColumn[[]uint64](r, name)
+		case AttributeTypeFloatArray:
+			column, err = ExportColumn[[]float64](r, name)
+		case AttributeTypeDatetimeArray:
+			column, err = ExportColumn[[]time.Time](r, name)
+		default:
+			return nil, fmt.Errorf("unsupported type %s for attribute %s", attribute.Type, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		columns[name] = column
+	}
+	return columns, nil
+}
+
+// upsert returns an Upsert which writes the document back unchanged.
+func (d *Document) upsert() *Upsert {
+	upsert := &Upsert{ID: d.ID, IDUint64: d.IDUint64, Vector: d.Vector}
+	if len(d.Attributes) > 0 {
+		upsert.Attributes = d.Attributes
+	}
+	return upsert
+}
+
 // Export paginates through all documents in a namespace.
 // It returns documents in a column-oriented layout.
-// Use the NextCursor from the response to retrieve the next page of results.
+// Use the NextCursor from the response to retrieve the next page of results, or use ExportIter
+// to iterate over every page.
 func (c *Client) Export(ctx context.Context, namespace string, cursor string) (*ExportResponse, error) {
-	path := fmt.Sprintf("/v1/vectors/%s", namespace)
+	path := c.namespacePath(namespace, "")
 
 	params := url.Values{}
 	if cursor != "" {
 		params.Set("cursor", string(cursor))
 	}
 
-	respData, err := c.get(ctx, path, params)
+	respData, err := c.get(ctx, OperationExport, path, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to export documents: %w", err)
 	}
@@ -37,3 +265,327 @@ func (c *Client) Export(ctx context.Context, namespace string, cursor string) (*
 
 	return &exportResp, nil
 }
+
+// ExportCursor records the position of an export so that it can be resumed.
+type ExportCursor struct {
+	// Namespace is the namespace being exported.
+	Namespace string `json:"namespace"`
+	// Cursor is the cursor of the next page to fetch, or "" once the export is complete.
+	Cursor string `json:"cursor"`
+	// LastID is the ID of the last document processed, used to check that the namespace hasn't been
+	// deleted and recreated before resuming.
+	LastID string `json:"last_id,omitempty"`
+	// LastIDUint64 is set instead of LastID when the last document processed has a numeric ID.
+	LastIDUint64 *uint64 `json:"last_id_uint64,omitempty"`
+}
+
+func (c *ExportCursor) lastID() documentID {
+	return newDocumentID(c.LastID, c.LastIDUint64)
+}
+
+// CursorStore persists export progress so that an interrupted export can resume.
+type CursorStore interface {
+	// Load returns the last saved cursor, or nil if there is none.
+	Load(ctx context.Context) (*ExportCursor, error)
+	// Save persists the given cursor, replacing any previous one.
+	Save(ctx context.Context, cursor *ExportCursor) error
+}
+
+// FileCursorStore is a CursorStore which keeps the cursor as JSON in a local file.
+type FileCursorStore struct {
+	// Path is the file in which the cursor is stored.  Required.
+	Path string
+}
+
+func (s *FileCursorStore) Load(ctx context.Context) (*ExportCursor, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cursor ExportCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+	return &cursor, nil
+}
+
+// Save replaces the file atomically, so that a crash mid-write never leaves a corrupt cursor behind.
+func (s *FileCursorStore) Save(ctx context.Context, cursor *ExportCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return writeFileAtomic(s.Path, data)
+}
+
+// ErrExportCursorStale is returned when resuming an export whose namespace appears to have been deleted
+// and recreated since the cursor was saved.  The API doesn't expose a namespace's identity, so this is
+// detected by checking that the last processed document still exists.  Delete the saved cursor to restart.
+var ErrExportCursorStale = errors.New("export cursor is stale")
+
+// ExportIterator iterates over the pages of an export.  Create one with Client.ExportIter.
+type ExportIterator struct {
+	// Cursors, if set, persists the position of the export after each page is processed, i.e. when Next
+	// is called again.  When an iterator is created with the same store after a crash, the export resumes
+	// after the last processed page rather than restarting.  Set it before the first call to Next.
+	Cursors CursorStore
+
+	client    *Client
+	ctx       context.Context
+	namespace string
+	cursor    string
+	page      *ExportResponse
+	resumed   bool
+	saved     bool
+	done      bool
+	err       error
+}
+
+// ExportIter returns an iterator over every page of documents in a namespace, following NextCursor until
+// the export is exhausted.  While the server is still preparing the export it responds with 202 Accepted,
+// which the client retries like any other retriable response, so the retry policy for OperationExport bounds
+// how long the iterator waits for each page.
+//
+//	it := client.ExportIter(ctx, namespace)
+//	for it.Next() {
+//		for _, document := range it.Documents() {
+//			...
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (c *Client) ExportIter(ctx context.Context, namespace string) *ExportIterator {
+	return &ExportIterator{client: c, ctx: ctx, namespace: namespace}
+}
+
+// Next fetches the next page, returning false once the export is exhausted or an error occurs.
+func (it *ExportIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.save(); err != nil {
+		return it.fail(err)
+	}
+	if err := it.resume(); err != nil {
+		return it.fail(err)
+	}
+	if it.done {
+		return false
+	}
+	page, err := it.client.Export(it.ctx, it.namespace, it.cursor)
+	if err != nil {
+		return it.fail(err)
+	}
+	it.page = page
+	it.saved = false
+	it.cursor = page.NextCursor
+	if page.NextCursor == "" {
+		it.done = true
+	}
+	return true
+}
+
+func (it *ExportIterator) fail(err error) bool {
+	it.err = err
+	it.done = true
+	it.page = nil
+	return false
+}
+
+// save persists the position after the current page, which the caller has finished processing.
+func (it *ExportIterator) save() error {
+	if it.Cursors == nil || it.page == nil || it.saved {
+		return nil
+	}
+	it.saved = true
+	cursor := &ExportCursor{Namespace: it.namespace, Cursor: it.cursor}
+	if n := len(it.page.IDs); n > 0 {
+		if it.page.IDsUint64 != nil && it.page.IDsUint64[n-1] != nil {
+			cursor.LastIDUint64 = it.page.IDsUint64[n-1]
+		} else {
+			cursor.LastID = it.page.IDs[n-1]
+		}
+	}
+	if err := it.Cursors.Save(it.ctx, cursor); err != nil {
+		return fmt.Errorf("failed to save export cursor: %w", err)
+	}
+	return nil
+}
+
+// resume loads the saved position, if any, before the first page is fetched.
+func (it *ExportIterator) resume() error {
+	if it.Cursors == nil || it.resumed {
+		return nil
+	}
+	it.resumed = true
+	saved, err := it.Cursors.Load(it.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load export cursor: %w", err)
+	}
+	if saved == nil {
+		return nil
+	}
+	if saved.Namespace != it.namespace {
+		return fmt.Errorf("export cursor was saved for namespace %s, not %s", saved.Namespace, it.namespace)
+	}
+	if lastID := saved.lastID(); lastID.str != "" {
+		results, err := it.client.Query(it.ctx, it.namespace, &QueryRequest{Filters: Eq(IDAttribute, lastID.key()), TopK: 1})
+		if err != nil {
+			return fmt.Errorf("failed to validate export cursor: %w", err)
+		}
+		if len(results) == 0 {
+			return fmt.Errorf("%w: document %s is missing", ErrExportCursorStale, lastID.str)
+		}
+	}
+	it.cursor = saved.Cursor
+	if saved.Cursor == "" {
+		it.done = true
+	}
+	return nil
+}
+
+// Page returns the current page.
+func (it *ExportIterator) Page() *ExportResponse {
+	return it.page
+}
+
+// Documents returns the documents of the current page.
+func (it *ExportIterator) Documents() []*Document {
+	if it.page == nil {
+		return nil
+	}
+	return it.page.Documents()
+}
+
+// Cursor returns the cursor of the next page to fetch, or "" if the first page hasn't been fetched
+// or the export is exhausted.
+func (it *ExportIterator) Cursor() string {
+	return it.cursor
+}
+
+// Err returns the error which stopped iteration, if any.
+func (it *ExportIterator) Err() error {
+	return it.err
+}
+
+// ExportAllOptions configures ExportAll.
+type ExportAllOptions struct {
+	// ExcludeVectors omits vectors from the output, e.g. for offline analysis of attributes only.
+	ExcludeVectors bool
+	// Progress, if set, is called with the total number of documents written after each page.
+	Progress func(documents int64)
+}
+
+// ExportAll writes every document in a namespace to w as newline-delimited JSON, one Document per line,
+// following cursors and polling while the export is prepared as ExportIter does.  It returns the number of
+// documents written.  opts may be nil.
+func (c *Client) ExportAll(ctx context.Context, namespace string, w io.Writer, opts *ExportAllOptions) (int64, error) {
+	if opts == nil {
+		opts = &ExportAllOptions{}
+	}
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	var count int64
+	it := c.ExportIter(ctx, namespace)
+	for it.Next() {
+		for _, document := range it.Documents() {
+			if opts.ExcludeVectors {
+				document.Vector = nil
+			}
+			if err := encoder.Encode(document); err != nil {
+				return count, fmt.Errorf("failed to write document %s: %w", document.ID, err)
+			}
+			count++
+		}
+		if opts.Progress != nil {
+			opts.Progress(count)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return count, err
+	}
+	if err := buffered.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write documents: %w", err)
+	}
+	return count, nil
+}
+
+// ExportChan streams every document in a namespace over a channel, fetching the next page in the background
+// while the current one is consumed, so that processing overlaps with network requests.  Pages are fetched
+// as by ExportIter.  The document channel is closed once the export is exhausted or fails, after which the
+// error channel yields the error, if any, and is closed.  Consumers which stop early must cancel ctx.
+func (c *Client) ExportChan(ctx context.Context, namespace string) (<-chan Document, <-chan error) {
+	documents := make(chan Document)
+	errs := make(chan error, 1)
+	pages := make(chan []*Document, 1)
+	it := c.ExportIter(ctx, namespace)
+
+	go func() {
+		defer close(pages)
+		for it.Next() {
+			select {
+			case pages <- it.Documents():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer close(errs)
+		defer close(documents)
+		for page := range pages {
+			for _, document := range page {
+				select {
+				case documents <- *document:
+				case <-ctx.Done():
+					errs <- ctx.Err()
+					return
+				}
+			}
+		}
+		if err := it.Err(); err != nil {
+			errs <- err
+		} else if err := ctx.Err(); err != nil {
+			errs <- err
+		}
+	}()
+
+	return documents, errs
+}
+
+// NamespaceExport is everything needed to recreate a namespace elsewhere.
+type NamespaceExport struct {
+	// Namespace is the exported namespace.
+	Namespace string `json:"namespace"`
+	// DistanceMetric is the namespace's distance metric, if known.  The API doesn't report a namespace's
+	// distance metric, so Client.ExportNamespace leaves it empty and NamespaceClient.ExportNamespace
+	// fills it from the handle's DistanceMetric.
+	DistanceMetric DistanceMetric `json:"distance_metric,omitempty"`
+	// Schema is the namespace's schema, fetched before the documents.
+	Schema Schema `json:"schema,omitempty"`
+	// Documents is every document in the namespace.
+	Documents []*Document `json:"documents"`
+}
+
+// ExportNamespace fetches a namespace's schema and every document in a single call, following cursors and
+// polling as ExportIter does.  All documents are held in memory; use Backup or ExportIter for large namespaces.
+func (c *Client) ExportNamespace(ctx context.Context, namespace string) (*NamespaceExport, error) {
+	schema, err := c.Schema(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export schema: %w", err)
+	}
+	export := &NamespaceExport{Namespace: namespace, Schema: schema, Documents: []*Document{}}
+	it := c.ExportIter(ctx, namespace)
+	for it.Next() {
+		export.Documents = append(export.Documents, it.Documents()...)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
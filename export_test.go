@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -191,9 +193,674 @@ func (f *fakeTimer) Start(duration time.Duration) {
 func (f *fakeTimer) Stop() {
 	if f.ch != nil {
 		close(f.ch)
+		f.ch = nil
 	}
 }
 
 func (f *fakeTimer) C() <-chan time.Time {
 	return f.ch
 }
+
+func TestExportResponseDocuments(t *testing.T) {
+	response := &tpuf.ExportResponse{
+		IDs:     []string{"1", "2"},
+		Vectors: [][]float32{{0.1, 0.1}, {0.2, 0.2}},
+		Attributes: map[string][]json.RawMessage{
+			"key1": {json.RawMessage(`"one"`), json.RawMessage(`null`)},
+			"key2": {json.RawMessage(`1`), json.RawMessage(`2`)},
+		},
+	}
+
+	assert.Equal(t, []*tpuf.Document{
+		{
+			ID:     "1",
+			Vector: []float32{0.1, 0.1},
+			Attributes: map[string]json.RawMessage{
+				"key1": json.RawMessage(`"one"`),
+				"key2": json.RawMessage(`1`),
+			},
+		},
+		{
+			ID:     "2",
+			Vector: []float32{0.2, 0.2},
+			Attributes: map[string]json.RawMessage{
+				"key2": json.RawMessage(`2`),
+			},
+		},
+	}, response.Documents())
+}
+
+func TestExportColumns(t *testing.T) {
+	response := &tpuf.ExportResponse{
+		IDs: []string{"1", "2", "3"},
+		Attributes: map[string][]json.RawMessage{
+			"title":   {json.RawMessage(`"one"`), json.RawMessage(`null`), json.RawMessage(`"three"`)},
+			"count":   {json.RawMessage(`1`), json.RawMessage(`2`)},
+			"created": {json.RawMessage(`"2024-01-02T03:04:05Z"`), nil, json.RawMessage(`null`)},
+			"tags":    {json.RawMessage(`["a","b"]`), json.RawMessage(`[]`), json.RawMessage(`null`)},
+		},
+	}
+	schema := tpuf.Schema{
+		"title":   &tpuf.Attribute{Type: tpuf.AttributeTypeString},
+		"count":   &tpuf.Attribute{Type: tpuf.AttributeTypeUint},
+		"created": &tpuf.Attribute{Type: tpuf.AttributeTypeDatetime},
+		"tags":    &tpuf.Attribute{Type: tpuf.AttributeTypeStringArray},
+		"missing": &tpuf.Attribute{Type: tpuf.AttributeTypeBool},
+		"untyped": &tpuf.Attribute{},
+	}
+
+	columns, err := tpuf.ExportColumns(response, schema)
+	assert.NoError(t, err)
+	str := func(s string) *string { return &s }
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Equal(t, map[string]interface{}{
+		"title":   []*string{str("one"), nil, str("three")},
+		"count":   []*uint64{tpuf.Uint64(1), tpuf.Uint64(2), nil},
+		"created": []*time.Time{&created, nil, nil},
+		"tags":    []*[]string{{"a", "b"}, {}, nil},
+		"missing": []*bool{nil, nil, nil},
+	}, columns)
+
+	_, err = tpuf.ExportColumns(response, tpuf.Schema{"title": &tpuf.Attribute{Type: tpuf.AttributeTypeInt}})
+	assert.EqualError(t, err, "failed to decode attribute title of document 1: json: cannot unmarshal string into Go value of type int64")
+
+	_, err = tpuf.ExportColumns(response, tpuf.Schema{"title": &tpuf.Attribute{Type: "vector"}})
+	assert.EqualError(t, err, "unsupported type vector for attribute title")
+}
+
+func TestDocumentsTyped(t *testing.T) {
+	type attrs struct {
+		Title string   `json:"title"`
+		Count *int     `json:"count"`
+		Tags  []string `json:"tags"`
+	}
+	response := &tpuf.ExportResponse{
+		IDs:       []string{"1", "2", "3"},
+		IDsUint64: []*uint64{nil, nil, tpuf.Uint64(3)},
+		Vectors:   [][]float32{{0.1}, {0.2}, {0.3}},
+		Attributes: map[string][]json.RawMessage{
+			"title": {json.RawMessage(`"one"`), json.RawMessage(`null`), json.RawMessage(`"three"`)},
+			"count": {json.RawMessage(`1`), json.RawMessage(`null`), json.RawMessage(`null`)},
+			"other": {json.RawMessage(`true`), json.RawMessage(`null`), json.RawMessage(`null`)},
+		},
+	}
+
+	documents, err := tpuf.DocumentsTyped[attrs](response)
+	assert.NoError(t, err)
+	count := 1
+	assert.Equal(t, []tpuf.TypedDocument[attrs]{
+		{ID: "1", Vector: []float32{0.1}, Attributes: attrs{Title: "one", Count: &count}},
+		{ID: "2", Vector: []float32{0.2}},
+		{ID: "3", IDUint64: tpuf.Uint64(3), Vector: []float32{0.3}, Attributes: attrs{Title: "three"}},
+	}, documents)
+
+	_, err = tpuf.DocumentsTyped[map[string]int](response)
+	assert.ErrorContains(t, err, "failed to decode attributes of document 1")
+}
+
+func TestExportResponseNumericIDs(t *testing.T) {
+	var response tpuf.ExportResponse
+	err := json.Unmarshal([]byte(`{"ids":[1,18446744073709551615],"vectors":[[0.1],[0.2]],"attributes":{},"next_cursor":""}`), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "18446744073709551615"}, response.IDs)
+	assert.Equal(t, []*uint64{tpuf.Uint64(1), tpuf.Uint64(18446744073709551615)}, response.IDsUint64)
+
+	documents := response.Documents()
+	assert.Equal(t, tpuf.Uint64(1), documents[0].IDUint64)
+	data, err := json.Marshal(documents[0])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"vector":[0.1]}`, string(data))
+
+	var document tpuf.Document
+	assert.NoError(t, json.Unmarshal(data, &document))
+	assert.Equal(t, &tpuf.Document{ID: "1", IDUint64: tpuf.Uint64(1), Vector: []float32{0.1}}, &document)
+
+	data, err = json.Marshal(&response)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ids":[1,18446744073709551615],"vectors":[[0.1],[0.2]],"attributes":{},"next_cursor":""}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"ids":["a","b"]}`), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, response.IDs)
+	assert.Nil(t, response.IDsUint64)
+
+	err = json.Unmarshal([]byte(`{"ids":[-1]}`), &response)
+	assert.EqualError(t, err, "document id must be a string or an unsigned integer, got -1")
+}
+
+func TestExportIter(t *testing.T) {
+	accepted := func() *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusAccepted,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"status":"ACCEPTED","error":"export in progress"}`)),
+		}
+	}
+	ok := func(body string) *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		httpResponses []*http.Response
+		expectedURLs  []string
+		expectedIDs   [][]string
+		expectedError string
+	}{
+		{
+			name: "follows cursors",
+			httpResponses: []*http.Response{
+				ok(`{"ids":["1","2"],"next_cursor":"page2"}`),
+				ok(`{"ids":["3"],"next_cursor":"page3"}`),
+				ok(`{"ids":["4"]}`),
+			},
+			expectedURLs: []string{
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace?cursor=page2",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace?cursor=page3",
+			},
+			expectedIDs: [][]string{{"1", "2"}, {"3"}, {"4"}},
+		},
+		{
+			name: "polls while export is being prepared",
+			httpResponses: []*http.Response{
+				accepted(),
+				accepted(),
+				accepted(),
+				ok(`{"ids":["1"],"next_cursor":"page2"}`),
+				accepted(),
+				ok(`{"ids":["2"]}`),
+			},
+			expectedURLs: []string{
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace?cursor=page2",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace?cursor=page2",
+			},
+			expectedIDs: [][]string{{"1"}, {"2"}},
+		},
+		{
+			name: "gives up once retries are exhausted",
+			httpResponses: []*http.Response{
+				accepted(),
+				accepted(),
+				accepted(),
+				accepted(),
+			},
+			expectedURLs: []string{
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+			},
+			expectedError: "failed to export documents: ACCEPTED: export in progress (HTTP 202)",
+		},
+		{
+			name: "stops on error",
+			httpResponses: []*http.Response{
+				ok(`{"ids":["1"],"next_cursor":"page2"}`),
+				{
+					StatusCode: http.StatusBadRequest,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid cursor","status":"error"}`)),
+				},
+			},
+			expectedURLs: []string{
+				"https://api.turbopuffer.com/v1/vectors/test-namespace",
+				"https://api.turbopuffer.com/v1/vectors/test-namespace?cursor=page2",
+			},
+			expectedIDs:   [][]string{{"1"}},
+			expectedError: "failed to export documents: error: Invalid cursor (HTTP 400)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var urls []string
+			client := &tpuf.Client{
+				ApiToken:   "test-token",
+				MaxRetries: 3,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						urls = append(urls, req.URL.String())
+						return tt.httpResponses[len(urls)-1], nil
+					},
+				},
+				Timer: &fakeTimer{},
+			}
+
+			var ids [][]string
+			it := client.ExportIter(context.Background(), "test-namespace")
+			for it.Next() {
+				var pageIDs []string
+				for _, document := range it.Documents() {
+					pageIDs = append(pageIDs, document.ID)
+				}
+				ids = append(ids, pageIDs)
+			}
+
+			if tt.expectedError == "" {
+				assert.NoError(t, it.Err())
+			} else {
+				assert.EqualError(t, it.Err(), tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedURLs, urls, "unexpected request URLs")
+			assert.Equal(t, tt.expectedIDs, ids, "unexpected exported IDs")
+			assert.False(t, it.Next(), "iterator continued after finishing")
+		})
+	}
+}
+
+func TestExportIterCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		Timer:    &fakeTimer{},
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				if err := req.Context().Err(); err != nil {
+					return nil, err
+				}
+				cancel()
+				return &http.Response{
+					StatusCode: http.StatusAccepted,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"ACCEPTED","error":"export in progress"}`)),
+				}, nil
+			},
+		},
+	}
+
+	it := client.ExportIter(ctx, "test-namespace")
+
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), context.Canceled)
+}
+
+func TestExportAll(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             *tpuf.ExportAllOptions
+		expectedOutput   string
+		expectedProgress []int64
+	}{
+		{
+			name: "writes every page as JSON lines",
+			expectedOutput: `{"id":"1","vector":[0.1,0.2],"attributes":{"title":"one"}}
+{"id":"2","vector":[0.3,0.4]}
+{"id":"3","vector":[0.5,0.6],"attributes":{"title":"three"}}
+`,
+		},
+		{
+			name: "excludes vectors and reports progress",
+			opts: &tpuf.ExportAllOptions{ExcludeVectors: true},
+			expectedOutput: `{"id":"1","attributes":{"title":"one"}}
+{"id":"2"}
+{"id":"3","attributes":{"title":"three"}}
+`,
+			expectedProgress: []int64{2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := []*http.Response{
+				{
+					StatusCode: http.StatusAccepted,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"ACCEPTED","error":"export in progress"}`)),
+				},
+				{
+					StatusCode: http.StatusOK,
+					Body: io.NopCloser(bytes.NewBufferString(
+						`{"ids":["1","2"],"vectors":[[0.1,0.2],[0.3,0.4]],"attributes":{"title":["one",null]},"next_cursor":"page2"}`)),
+				},
+				{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"ids":["3"],"vectors":[[0.5,0.6]],"attributes":{"title":["three"]}}`)),
+				},
+			}
+			calls := 0
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						calls++
+						return responses[calls-1], nil
+					},
+				},
+				Timer: &fakeTimer{},
+			}
+
+			var progress []int64
+			opts := tt.opts
+			if opts != nil {
+				opts.Progress = func(documents int64) {
+					progress = append(progress, documents)
+				}
+			}
+
+			var out bytes.Buffer
+			count, err := client.ExportAll(context.Background(), "test-namespace", &out, opts)
+
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), count)
+			assert.Equal(t, tt.expectedOutput, out.String())
+			assert.Equal(t, tt.expectedProgress, progress)
+		})
+	}
+}
+
+func TestExportIterCursorStore(t *testing.T) {
+	pages := map[string]string{
+		"/v1/vectors/test-namespace":              `{"ids":["1","2"],"next_cursor":"page2"}`,
+		"/v1/vectors/test-namespace?cursor=page2": `{"ids":["3"]}`,
+	}
+
+	tests := []struct {
+		name           string
+		saved          *tpuf.ExportCursor
+		queryResponse  string
+		expectedIDs    []string
+		expectedSaved  *tpuf.ExportCursor
+		expectedError  string
+		expectedStale  bool
+		expectedQuery  string
+		expectedExport []string
+	}{
+		{
+			name:           "fresh export saves progress",
+			expectedIDs:    []string{"1", "2", "3"},
+			expectedSaved:  &tpuf.ExportCursor{Namespace: "test-namespace", LastID: "3"},
+			expectedExport: []string{"/v1/vectors/test-namespace", "/v1/vectors/test-namespace?cursor=page2"},
+		},
+		{
+			name:           "resumes after last processed page",
+			saved:          &tpuf.ExportCursor{Namespace: "test-namespace", Cursor: "page2", LastID: "2"},
+			queryResponse:  `[{"id":"2"}]`,
+			expectedIDs:    []string{"3"},
+			expectedSaved:  &tpuf.ExportCursor{Namespace: "test-namespace", LastID: "3"},
+			expectedQuery:  `{"filters":["id","Eq","2"],"top_k":1}`,
+			expectedExport: []string{"/v1/vectors/test-namespace?cursor=page2"},
+		},
+		{
+			name:          "completed export",
+			saved:         &tpuf.ExportCursor{Namespace: "test-namespace", LastID: "3"},
+			queryResponse: `[{"id":"3"}]`,
+			expectedSaved: &tpuf.ExportCursor{Namespace: "test-namespace", LastID: "3"},
+			expectedQuery: `{"filters":["id","Eq","3"],"top_k":1}`,
+		},
+		{
+			name:          "namespace recreated",
+			saved:         &tpuf.ExportCursor{Namespace: "test-namespace", Cursor: "page2", LastID: "2"},
+			queryResponse: `[]`,
+			expectedSaved: &tpuf.ExportCursor{Namespace: "test-namespace", Cursor: "page2", LastID: "2"},
+			expectedError: "export cursor is stale: document 2 is missing",
+			expectedStale: true,
+			expectedQuery: `{"filters":["id","Eq","2"],"top_k":1}`,
+		},
+		{
+			name:          "different namespace",
+			saved:         &tpuf.ExportCursor{Namespace: "other-namespace", Cursor: "page2", LastID: "2"},
+			expectedSaved: &tpuf.ExportCursor{Namespace: "other-namespace", Cursor: "page2", LastID: "2"},
+			expectedError: "export cursor was saved for namespace other-namespace, not test-namespace",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &tpuf.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+			if tt.saved != nil {
+				assert.NoError(t, store.Save(context.Background(), tt.saved))
+			}
+
+			var exports []string
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						if req.URL.Path == "/v1/vectors/test-namespace/query" {
+							body, err := io.ReadAll(req.Body)
+							assert.NoError(t, err)
+							assert.JSONEq(t, tt.expectedQuery, string(body), "unexpected validation query")
+							return &http.Response{
+								StatusCode: http.StatusOK,
+								Body:       io.NopCloser(bytes.NewBufferString(tt.queryResponse)),
+							}, nil
+						}
+						exports = append(exports, req.URL.RequestURI())
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(pages[req.URL.RequestURI()])),
+						}, nil
+					},
+				},
+			}
+
+			var ids []string
+			it := client.ExportIter(context.Background(), "test-namespace")
+			it.Cursors = store
+			for it.Next() {
+				for _, document := range it.Documents() {
+					ids = append(ids, document.ID)
+				}
+			}
+
+			if tt.expectedError == "" {
+				assert.NoError(t, it.Err())
+			} else {
+				assert.EqualError(t, it.Err(), tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedStale, errors.Is(it.Err(), tpuf.ErrExportCursorStale))
+			assert.Equal(t, tt.expectedIDs, ids, "unexpected exported IDs")
+			assert.Equal(t, tt.expectedExport, exports, "unexpected export requests")
+
+			saved, err := store.Load(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSaved, saved, "unexpected saved cursor")
+		})
+	}
+}
+
+func TestExportIterCursorStoreNumericIDs(t *testing.T) {
+	store := &tpuf.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body := `{"ids":[1,2],"next_cursor":"page2"}`
+				switch {
+				case req.URL.Path == "/v1/vectors/test-namespace/query":
+					query, err := io.ReadAll(req.Body)
+					assert.NoError(t, err)
+					assert.JSONEq(t, `{"filters":["id","Eq",2],"top_k":1}`, string(query), "the ID should be filtered as a number")
+					body = `[{"id":2}]`
+				case req.URL.Query().Get("cursor") == "page2":
+					body = `{"ids":[3]}`
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+			},
+		},
+	}
+
+	it := client.ExportIter(context.Background(), "test-namespace")
+	it.Cursors = store
+	assert.True(t, it.Next())
+	assert.True(t, it.Next())
+
+	saved, err := store.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &tpuf.ExportCursor{Namespace: "test-namespace", Cursor: "page2", LastIDUint64: tpuf.Uint64(2)}, saved)
+
+	var ids []uint64
+	it = client.ExportIter(context.Background(), "test-namespace")
+	it.Cursors = store
+	for it.Next() {
+		for _, document := range it.Documents() {
+			ids = append(ids, *document.IDUint64)
+		}
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []uint64{3}, ids, "the export should resume after the last processed page")
+}
+
+func TestExportIterCursorStoreSavesOnlyProcessedPages(t *testing.T) {
+	store := &tpuf.FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor.json")}
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"ids":["1","2"],"next_cursor":"page2"}`)),
+				}, nil
+			},
+		},
+	}
+
+	it := client.ExportIter(context.Background(), "test-namespace")
+	it.Cursors = store
+	assert.True(t, it.Next())
+
+	saved, err := store.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, saved, "page was saved before it was processed")
+
+	assert.True(t, it.Next())
+
+	saved, err = store.Load(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &tpuf.ExportCursor{Namespace: "test-namespace", Cursor: "page2", LastID: "2"}, saved)
+}
+
+func TestExportChan(t *testing.T) {
+	tests := []struct {
+		name          string
+		responses     map[string]string
+		expectedIDs   []string
+		expectedError string
+	}{
+		{
+			name: "streams every page",
+			responses: map[string]string{
+				"/v1/vectors/test-namespace":              `{"ids":["1","2"],"attributes":{"title":["a","b"]},"next_cursor":"page2"}`,
+				"/v1/vectors/test-namespace?cursor=page2": `{"ids":["3"],"attributes":{"title":["c"]}}`,
+			},
+			expectedIDs: []string{"1", "2", "3"},
+		},
+		{
+			name: "reports errors after streamed documents",
+			responses: map[string]string{
+				"/v1/vectors/test-namespace": `{"ids":["1"],"next_cursor":"page2"}`,
+			},
+			expectedIDs:   []string{"1"},
+			expectedError: "failed to export documents: error: Invalid cursor (HTTP 400)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, ok := tt.responses[req.URL.RequestURI()]
+						if !ok {
+							return &http.Response{
+								StatusCode: http.StatusBadRequest,
+								Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid cursor","status":"error"}`)),
+							}, nil
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(body)),
+						}, nil
+					},
+				},
+			}
+
+			documents, errs := client.ExportChan(context.Background(), "test-namespace")
+
+			var ids []string
+			for document := range documents {
+				ids = append(ids, document.ID)
+			}
+			err := <-errs
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedIDs, ids, "unexpected exported IDs")
+		})
+	}
+}
+
+func TestExportChanCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"ids":["1","2"],"next_cursor":"next"}`)),
+				}, nil
+			},
+		},
+	}
+
+	documents, errs := client.ExportChan(ctx, "test-namespace")
+	<-documents
+	cancel()
+	for range documents {
+	}
+
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
+
+func TestExportNamespace(t *testing.T) {
+	responses := map[string]string{
+		"/v1/vectors/test-namespace/schema":       `{"title":{"type":"string"}}`,
+		"/v1/vectors/test-namespace":              `{"ids":["1"],"vectors":[[0.1]],"attributes":{"title":["a"]},"next_cursor":"page2"}`,
+		"/v1/vectors/test-namespace?cursor=page2": `{"ids":["2"],"vectors":[[0.2]]}`,
+	}
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body, ok := responses[req.URL.RequestURI()]
+				assert.True(t, ok, "unexpected request %s", req.URL.RequestURI())
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(body)),
+				}, nil
+			},
+		},
+	}
+	ns := client.Namespace("test-namespace")
+	ns.DistanceMetric = tpuf.DistanceMetricCosine
+
+	export, err := ns.ExportNamespace(context.Background())
+
+	assert.NoError(t, err)
+	result, err := json.Marshal(export)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"namespace": "test-namespace",
+		"distance_metric": "cosine_distance",
+		"schema": {"title": {"type": "string"}},
+		"documents": [
+			{"id": "1", "vector": [0.1], "attributes": {"title": "a"}},
+			{"id": "2", "vector": [0.2]}
+		]
+	}`, string(result))
+}
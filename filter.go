@@ -2,6 +2,11 @@ package tpuf
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"time"
 )
 
 // Supported operators for filtering.
@@ -21,6 +26,24 @@ const (
 	OpNotGlob  Operator = "NotGlob"
 	OpIGlob    Operator = "IGlob"
 	OpNotIGlob Operator = "NotIGlob"
+	// OpContains matches array attributes containing the value as an element.
+	// For substring matching on string attributes, use OpGlob with a pattern such as "*foo*".
+	OpContains Operator = "Contains"
+	// OpNotContains matches array attributes which don't contain the value as an element.
+	OpNotContains Operator = "NotContains"
+	// OpContainsAny matches array attributes containing any of the values, which should be a list.
+	OpContainsAny Operator = "ContainsAny"
+	// OpNotContainsAny matches array attributes containing none of the values, which should be a list.
+	OpNotContainsAny Operator = "NotContainsAny"
+	// OpContainsAllTokens matches full-text searchable attributes containing every token of the value,
+	// which should be a string, or a list of tokens for attributes using TokenizerPreTokenizedArray.
+	// Tokenization follows the attribute's full-text search settings.
+	OpContainsAllTokens Operator = "ContainsAllTokens"
+	// OpRegex matches string attributes against a regular expression, which should be a string.
+	// The attribute must have regex matching enabled in the schema; see Attribute.Regex.
+	OpRegex Operator = "Regex"
+	// OpNotRegex matches string attributes which don't match a regular expression.
+	OpNotRegex Operator = "NotRegex"
 )
 
 // Filter represents a Turbopuffer filter.
@@ -43,10 +66,294 @@ func (bf *BaseFilter) tpuf_SerializeFilter() interface{} {
 	return []interface{}{bf.Attribute, bf.Operator, bf.Value}
 }
 
+// MarshalJSON serializes the filter, returning an error if the value can't be represented in JSON,
+// such as a channel, a function, or a NaN or infinite float.
 func (f *BaseFilter) MarshalJSON() ([]byte, error) {
+	if err := validateFilterValues(f); err != nil {
+		return nil, err
+	}
 	return json.Marshal(f.tpuf_SerializeFilter())
 }
 
+// validateFilterValues checks that the values of the filter and all of its sub-filters can be serialized.
+func validateFilterValues(filter Filter) error {
+	switch f := filter.(type) {
+	case *AndFilter:
+		for _, sub := range f.Filters {
+			if err := validateFilterValues(sub); err != nil {
+				return err
+			}
+		}
+	case *OrFilter:
+		for _, sub := range f.Filters {
+			if err := validateFilterValues(sub); err != nil {
+				return err
+			}
+		}
+	case *BaseFilter:
+		value := reflect.ValueOf(f.Value)
+		if err := validateFilterValue(value); err != nil {
+			return fmt.Errorf("invalid value for %s filter on attribute %q: %w", f.Operator, f.Attribute, err)
+		}
+		if f.Operator == OpRegex || f.Operator == OpNotRegex {
+			if err := validateRegex(f.Value); err != nil {
+				return fmt.Errorf("invalid value for %s filter on attribute %q: %w", f.Operator, f.Attribute, err)
+			}
+		}
+		if f.Attribute == IDAttribute && f.Operator == OpIn && value.Kind() == reflect.Slice && value.Len() == 0 {
+			return fmt.Errorf("invalid value for %s filter on attribute %q: at least one ID is required", f.Operator, f.Attribute)
+		}
+	}
+	return nil
+}
+
+// validateRegex checks that the pattern compiles, so that invalid patterns fail before a request is sent.
+// Go's RE2 syntax is largely compatible with the server's regex syntax.
+func validateRegex(pattern interface{}) error {
+	s, ok := pattern.(string)
+	if !ok {
+		return fmt.Errorf("pattern of type %T is not a string", pattern)
+	}
+	if _, err := regexp.Compile(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateFilterValue(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return fmt.Errorf("values of type %s are not supported", v.Type())
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%v is not supported", f)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateFilterValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateFilterValue(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return validateFilterValue(v.Elem())
+		}
+	}
+	return nil
+}
+
+// Eq matches documents where the attribute equals value.
+func Eq(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpEq, Value: value}
+}
+
+// NotEq matches documents where the attribute does not equal value.
+func NotEq(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpNotEq, Value: value}
+}
+
+// In matches documents where the attribute equals any of values, which should be a slice.
+func In(attribute string, values interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpIn, Value: values}
+}
+
+// NotIn matches documents where the attribute equals none of values, which should be a slice.
+func NotIn(attribute string, values interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpNotIn, Value: values}
+}
+
+// Lt matches documents where the attribute is less than value.
+func Lt(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpLt, Value: value}
+}
+
+// Lte matches documents where the attribute is less than or equal to value.
+func Lte(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpLte, Value: value}
+}
+
+// Gt matches documents where the attribute is greater than value.
+func Gt(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpGt, Value: value}
+}
+
+// Gte matches documents where the attribute is greater than or equal to value.
+func Gte(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpGte, Value: value}
+}
+
+// Glob matches documents where the attribute matches a Unix-style glob pattern.
+func Glob(attribute string, pattern string) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpGlob, Value: pattern}
+}
+
+// NotGlob matches documents where the attribute does not match a Unix-style glob pattern.
+func NotGlob(attribute string, pattern string) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpNotGlob, Value: pattern}
+}
+
+// IGlob matches documents where the attribute matches a case-insensitive Unix-style glob pattern.
+func IGlob(attribute string, pattern string) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpIGlob, Value: pattern}
+}
+
+// NotIGlob matches documents where the attribute does not match a case-insensitive Unix-style glob pattern.
+func NotIGlob(attribute string, pattern string) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpNotIGlob, Value: pattern}
+}
+
+// EqString matches documents where the string attribute equals value.
+func EqString(attribute string, value string) *BaseFilter {
+	return Eq(attribute, value)
+}
+
+// EqInt matches documents where the int attribute equals value.
+func EqInt(attribute string, value int64) *BaseFilter {
+	return Eq(attribute, value)
+}
+
+// EqUint matches documents where the uint attribute equals value.
+func EqUint(attribute string, value uint64) *BaseFilter {
+	return Eq(attribute, value)
+}
+
+// EqFloat matches documents where the float attribute equals value.
+func EqFloat(attribute string, value float64) *BaseFilter {
+	return Eq(attribute, value)
+}
+
+// EqBool matches documents where the bool attribute equals value.
+func EqBool(attribute string, value bool) *BaseFilter {
+	return Eq(attribute, value)
+}
+
+// InString matches documents where the string attribute equals any of values.
+func InString(attribute string, values ...string) *BaseFilter {
+	return In(attribute, values)
+}
+
+// InInt matches documents where the int attribute equals any of values.
+func InInt(attribute string, values ...int64) *BaseFilter {
+	return In(attribute, values)
+}
+
+// InUint matches documents where the uint attribute equals any of values.
+func InUint(attribute string, values ...uint64) *BaseFilter {
+	return In(attribute, values)
+}
+
+// LtTime matches documents where the datetime attribute is before t.
+func LtTime(attribute string, t time.Time) *BaseFilter {
+	return Lt(attribute, t)
+}
+
+// LteTime matches documents where the datetime attribute is at or before t.
+func LteTime(attribute string, t time.Time) *BaseFilter {
+	return Lte(attribute, t)
+}
+
+// GtTime matches documents where the datetime attribute is after t.
+func GtTime(attribute string, t time.Time) *BaseFilter {
+	return Gt(attribute, t)
+}
+
+// GteTime matches documents where the datetime attribute is at or after t.
+func GteTime(attribute string, t time.Time) *BaseFilter {
+	return Gte(attribute, t)
+}
+
+// Regex matches documents where the string attribute matches the regular expression pattern.
+// Marshaling the filter fails if the pattern doesn't compile.
+func Regex(attribute string, pattern string) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpRegex, Value: pattern}
+}
+
+// NotRegex matches documents where the string attribute does not match the regular expression pattern.
+// Marshaling the filter fails if the pattern doesn't compile.
+func NotRegex(attribute string, pattern string) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpNotRegex, Value: pattern}
+}
+
+// IDAttribute is the name of the special attribute holding each document's ID.
+const IDAttribute = "id"
+
+// IDEq matches the document with the given ID.
+func IDEq(id string) *BaseFilter {
+	return Eq(IDAttribute, id)
+}
+
+// IDIn matches the documents with any of the given IDs.  At least one ID is required; marshaling the
+// filter fails if the list is empty, since that is almost always a bug that would otherwise match nothing.
+func IDIn(ids ...string) *BaseFilter {
+	return In(IDAttribute, ids)
+}
+
+// IDGt matches documents with IDs greater than id.  This is typically used to paginate through documents
+// ordered by ID, starting each page after the last ID of the previous page.
+func IDGt(id string) *BaseFilter {
+	return Gt(IDAttribute, id)
+}
+
+// Contains matches documents where the array attribute contains value as an element.
+func Contains(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpContains, Value: value}
+}
+
+// NotContains matches documents where the array attribute does not contain value as an element.
+func NotContains(attribute string, value interface{}) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpNotContains, Value: value}
+}
+
+// ContainsAny matches documents where the array attribute contains any of values, e.g. any of a set of tags.
+func ContainsAny[T any](attribute string, values []T) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpContainsAny, Value: values}
+}
+
+// NotContainsAny matches documents where the array attribute contains none of values.
+func NotContainsAny[T any](attribute string, values []T) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpNotContainsAny, Value: values}
+}
+
+// ContainsAll matches documents where the array attribute contains every one of values.
+// The API has no single operator for this, so it is expressed as an And of Contains filters.
+func ContainsAll[T any](attribute string, values []T) *AndFilter {
+	filters := make([]Filter, len(values))
+	for i, value := range values {
+		filters[i] = Contains(attribute, value)
+	}
+	return And(filters...)
+}
+
+// ContainsAllTokens matches documents where the full-text searchable attribute contains every token of text.
+// Unlike ranking by BM25, this strictly requires all keywords to be present, so it can be combined with
+// vector or BM25 ranking to only return documents mentioning each keyword.
+func ContainsAllTokens(attribute string, text string) *BaseFilter {
+	return &BaseFilter{Attribute: attribute, Operator: OpContainsAllTokens, Value: text}
+}
+
+// ContainsAllTokensArray matches documents where the pre-tokenized attribute contains every one of tokens.
+// See TokenizerPreTokenizedArray.
+func ContainsAllTokensArray(attribute string, tokens ...string) *BaseFilter {
+	if tokens == nil {
+		tokens = []string{}
+	}
+	return &BaseFilter{Attribute: attribute, Operator: OpContainsAllTokens, Value: tokens}
+}
+
+// RefNew references the value of an attribute in the document being written, for use as the
+// Value of a BaseFilter in conditional writes.
+// See https://turbopuffer.com/docs/write#conditional-writes
+func RefNew(attribute string) map[string]string {
+	return map[string]string{"$ref_new": attribute}
+}
+
 // AndFilter represents a filter that requires all of its sub-filters to be true.
 type AndFilter struct {
 	Filters []Filter
@@ -64,9 +371,17 @@ func (af *AndFilter) tpuf_SerializeFilter() interface{} {
 }
 
 func (f *AndFilter) MarshalJSON() ([]byte, error) {
+	if err := validateFilterValues(f); err != nil {
+		return nil, err
+	}
 	return json.Marshal(f.tpuf_SerializeFilter())
 }
 
+// And matches documents which match all of the given filters.
+func And(filters ...Filter) *AndFilter {
+	return &AndFilter{Filters: filters}
+}
+
 // OrFilter represents a filter that requires at least one of its sub-filters to be true.
 type OrFilter struct {
 	Filters []Filter
@@ -84,5 +399,13 @@ func (of *OrFilter) tpuf_SerializeFilter() interface{} {
 }
 
 func (f *OrFilter) MarshalJSON() ([]byte, error) {
+	if err := validateFilterValues(f); err != nil {
+		return nil, err
+	}
 	return json.Marshal(f.tpuf_SerializeFilter())
 }
+
+// Or matches documents which match at least one of the given filters.
+func Or(filters ...Filter) *OrFilter {
+	return &OrFilter{Filters: filters}
+}
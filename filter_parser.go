@@ -0,0 +1,392 @@
+package tpuf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FilterSyntaxError is returned by ParseFilter when an expression is malformed.
+type FilterSyntaxError struct {
+	// Offset is the byte offset in the expression at which the error was detected.
+	Offset int
+	// Line is the 1-based line of the error.
+	Line int
+	// Column is the 1-based column of the error, counted in characters.
+	Column int
+	// Msg describes the error.
+	Msg string
+}
+
+func (e *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("filter syntax error at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseFilter parses a filter from a text expression, for use where filters can't be constructed in Go,
+// such as admin tools and config files.  For example:
+//
+//	category = "electronics" AND (price >= 100 OR title GLOB "*.pdf")
+//
+// Comparisons take the form `attribute operator value`, where the operator is one of =, !=, <, <=, >, >=,
+// IN, NOT IN, GLOB, NOT GLOB, IGLOB, NOT IGLOB, REGEX, NOT REGEX, CONTAINS, NOT CONTAINS, CONTAINS ANY, NOT CONTAINS ANY,
+// or CONTAINS ALL TOKENS.  Values are double-quoted strings, numbers, true, false, null, or lists of values
+// in square brackets.  Comparisons are combined with AND and OR, where AND binds more tightly, and may be
+// grouped with parentheses.  Keywords are case-insensitive.
+// Malformed expressions return a *FilterSyntaxError.
+func ParseFilter(expr string) (Filter, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{expr: expr, tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t.pos, "expected AND, OR or end of expression, found %s", t)
+	}
+	return filter, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+var punctuation = map[rune]tokenKind{
+	'(': tokenLParen,
+	')': tokenRParen,
+	'[': tokenLBracket,
+	']': tokenRBracket,
+	',': tokenComma,
+}
+
+func lexFilter(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		r, size := utf8.DecodeRuneInString(expr[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case punctuation[r] != 0:
+			i++
+			tokens = append(tokens, token{punctuation[r], expr[start:i], start})
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			i++
+			if i < len(expr) && expr[i] == '=' {
+				i++
+			}
+			if expr[start:i] == "!" {
+				return nil, newFilterSyntaxError(expr, start, "unexpected character '!'")
+			}
+			tokens = append(tokens, token{tokenOperator, expr[start:i], start})
+		case r == '"':
+			i++
+			for i < len(expr) && expr[i] != '"' {
+				if expr[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(expr) {
+				return nil, newFilterSyntaxError(expr, start, "unterminated string")
+			}
+			i++
+			value, err := strconv.Unquote(expr[start:i])
+			if err != nil {
+				return nil, newFilterSyntaxError(expr, start, fmt.Sprintf("invalid string %s", expr[start:i]))
+			}
+			tokens = append(tokens, token{tokenString, value, start})
+		case r == '-' || r == '.' || (r >= '0' && r <= '9'):
+			i++
+			for i < len(expr) {
+				c := expr[i]
+				if (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E' ||
+					((c == '+' || c == '-') && (expr[i-1] == 'e' || expr[i-1] == 'E')) {
+					i++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, token{tokenNumber, expr[start:i], start})
+		case r == '_' || unicode.IsLetter(r):
+			i += size
+			for i < len(expr) {
+				r, size := utf8.DecodeRuneInString(expr[i:])
+				if r != '_' && r != '.' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{tokenIdent, expr[start:i], start})
+		default:
+			return nil, newFilterSyntaxError(expr, start, fmt.Sprintf("unexpected character %q", r))
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+func newFilterSyntaxError(expr string, offset int, msg string) *FilterSyntaxError {
+	line := strings.Count(expr[:offset], "\n") + 1
+	lineStart := strings.LastIndex(expr[:offset], "\n") + 1
+	return &FilterSyntaxError{
+		Offset: offset,
+		Line:   line,
+		Column: utf8.RuneCountInString(expr[lineStart:offset]) + 1,
+		Msg:    msg,
+	}
+}
+
+type filterParser struct {
+	expr   string
+	tokens []token
+	i      int
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *filterParser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+// acceptKeyword consumes the next token if it is the given case-insensitive keyword.
+func (p *filterParser) acceptKeyword(keyword string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.text, keyword) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) errorf(pos int, format string, args ...interface{}) error {
+	return newFilterSyntaxError(p.expr, pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	filters := []Filter{first}
+	for p.acceptKeyword("OR") {
+		filter, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	if len(filters) == 1 {
+		return first, nil
+	}
+	return Or(filters...), nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	first, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	filters := []Filter{first}
+	for p.acceptKeyword("AND") {
+		filter, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	if len(filters) == 1 {
+		return first, nil
+	}
+	return And(filters...), nil
+}
+
+func (p *filterParser) parsePrimary() (Filter, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing.pos, "expected ), found %s", closing)
+		}
+		return filter, nil
+	case tokenIdent:
+		return p.parseComparison(t.text)
+	default:
+		return nil, p.errorf(t.pos, "expected attribute or (, found %s", t)
+	}
+}
+
+func (p *filterParser) parseComparison(attribute string) (Filter, error) {
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+	valueToken := p.peek()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	_, isList := value.([]interface{})
+	switch op {
+	case OpIn, OpNotIn, OpContainsAny, OpNotContainsAny:
+		if !isList {
+			return nil, p.errorf(valueToken.pos, "%s requires a list value, found %s", op, valueToken)
+		}
+	case OpGlob, OpNotGlob, OpIGlob, OpNotIGlob, OpRegex, OpNotRegex, OpContainsAllTokens:
+		if _, ok := value.(string); !ok {
+			return nil, p.errorf(valueToken.pos, "%s requires a string value, found %s", op, valueToken)
+		}
+	}
+	return &BaseFilter{Attribute: attribute, Operator: op, Value: value}, nil
+}
+
+var symbolOperators = map[string]Operator{
+	"=":  OpEq,
+	"==": OpEq,
+	"!=": OpNotEq,
+	"<":  OpLt,
+	"<=": OpLte,
+	">":  OpGt,
+	">=": OpGte,
+}
+
+func (p *filterParser) parseOperator() (Operator, error) {
+	t := p.next()
+	if t.kind == tokenOperator {
+		return symbolOperators[t.text], nil
+	}
+	if t.kind == tokenIdent {
+		negated := strings.EqualFold(t.text, "NOT")
+		keyword := t
+		if negated {
+			keyword = p.next()
+		}
+		if keyword.kind == tokenIdent {
+			switch strings.ToUpper(keyword.text) {
+			case "IN":
+				return negate(OpIn, OpNotIn, negated), nil
+			case "GLOB":
+				return negate(OpGlob, OpNotGlob, negated), nil
+			case "IGLOB":
+				return negate(OpIGlob, OpNotIGlob, negated), nil
+			case "REGEX":
+				return negate(OpRegex, OpNotRegex, negated), nil
+			case "CONTAINS":
+				if p.acceptKeyword("ANY") {
+					return negate(OpContainsAny, OpNotContainsAny, negated), nil
+				}
+				if !negated && p.acceptKeyword("ALL") {
+					if !p.acceptKeyword("TOKENS") {
+						next := p.peek()
+						return "", p.errorf(next.pos, "expected TOKENS, found %s", next)
+					}
+					return OpContainsAllTokens, nil
+				}
+				return negate(OpContains, OpNotContains, negated), nil
+			}
+		}
+		if negated {
+			return "", p.errorf(keyword.pos, "expected IN, GLOB, IGLOB, REGEX or CONTAINS after NOT, found %s", keyword)
+		}
+	}
+	return "", p.errorf(t.pos, "expected operator, found %s", t)
+}
+
+func negate(op Operator, negatedOp Operator, negated bool) Operator {
+	if negated {
+		return negatedOp
+	}
+	return op
+}
+
+func (p *filterParser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return i, nil
+		}
+		if u, err := strconv.ParseUint(t.text, 10, 64); err == nil {
+			return u, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t.pos, "invalid number %s", t)
+		}
+		return f, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	case tokenLBracket:
+		values := []interface{}{}
+		if p.peek().kind == tokenRBracket {
+			p.next()
+			return values, nil
+		}
+		for {
+			elem := p.peek()
+			if elem.kind == tokenLBracket {
+				return nil, p.errorf(elem.pos, "nested lists are not supported")
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			switch sep := p.next(); sep.kind {
+			case tokenComma:
+			case tokenRBracket:
+				return values, nil
+			default:
+				return nil, p.errorf(sep.pos, "expected , or ], found %s", sep)
+			}
+		}
+	}
+	return nil, p.errorf(t.pos, "expected value, found %s", t)
+}
@@ -0,0 +1,186 @@
+package tpuf_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{
+			name:     "single comparison",
+			expr:     `category = "electronics"`,
+			expected: `["category","Eq","electronics"]`,
+		},
+		{
+			name:     "AND binds more tightly than OR",
+			expr:     `a = 1 OR b = 2 AND c = 3`,
+			expected: `["Or",[["a","Eq",1],["And",[["b","Eq",2],["c","Eq",3]]]]]`,
+		},
+		{
+			name:     "parentheses",
+			expr:     `category = "electronics" AND (price >= 100 OR title GLOB "*.pdf")`,
+			expected: `["And",[["category","Eq","electronics"],["Or",[["price","Gte",100],["title","Glob","*.pdf"]]]]]`,
+		},
+		{
+			name:     "symbol operators",
+			expr:     `a == 1 and b != 2 and c < 3 and d <= 4.5 and e > -6 and f >= 1e3`,
+			expected: `["And",[["a","Eq",1],["b","NotEq",2],["c","Lt",3],["d","Lte",4.5],["e","Gt",-6],["f","Gte",1000]]]`,
+		},
+		{
+			name:     "keyword operators",
+			expr:     `a IN [1, 2] AND b not in ["x"] AND c IGLOB "*.GO" AND d NOT GLOB "/vendor/**" AND e NOT IGLOB "*.MD"`,
+			expected: `["And",[["a","In",[1,2]],["b","NotIn",["x"]],["c","IGlob","*.GO"],["d","NotGlob","/vendor/**"],["e","NotIGlob","*.MD"]]]`,
+		},
+		{
+			name:     "contains operators",
+			expr:     `tags CONTAINS "go" OR tags NOT CONTAINS "old" OR tags CONTAINS ANY ["a", "b"] OR tags NOT CONTAINS ANY [] OR body CONTAINS ALL TOKENS "quick fox"`,
+			expected: `["Or",[["tags","Contains","go"],["tags","NotContains","old"],["tags","ContainsAny",["a","b"]],["tags","NotContainsAny",[]],["body","ContainsAllTokens","quick fox"]]]`,
+		},
+		{
+			name:     "regex operators",
+			expr:     `title REGEX "^v[0-9]+" AND title NOT REGEX "draft$"`,
+			expected: `["And",[["title","Regex","^v[0-9]+"],["title","NotRegex","draft$"]]]`,
+		},
+		{
+			name:     "literals and escapes",
+			expr:     `a = true AND b = FALSE AND c = null AND d = "say \"hi\"\n"`,
+			expected: `["And",[["a","Eq",true],["b","Eq",false],["c","Eq",null],["d","Eq","say \"hi\"\n"]]]`,
+		},
+		{
+			name:     "attribute named like a keyword",
+			expr:     `and = 1`,
+			expected: `["and","Eq",1]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tpuf.ParseFilter(tt.expr)
+			assert.NoError(t, err)
+
+			result, err := json.Marshal(filter)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+func TestParseFilterLargeUint(t *testing.T) {
+	filter, err := tpuf.ParseFilter(`id = 18446744073709551615`)
+	assert.NoError(t, err)
+	assert.Equal(t, &tpuf.BaseFilter{Attribute: "id", Operator: tpuf.OpEq, Value: uint64(18446744073709551615)}, filter)
+
+	result, err := json.Marshal(filter)
+	assert.NoError(t, err)
+	assert.Equal(t, `["id","Eq",18446744073709551615]`, string(result))
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		expr           string
+		expectedError  string
+		expectedOffset int
+	}{
+		{
+			name:           "empty expression",
+			expr:           ``,
+			expectedError:  `filter syntax error at line 1, column 1: expected attribute or (, found end of expression`,
+			expectedOffset: 0,
+		},
+		{
+			name:           "missing value",
+			expr:           `price >=`,
+			expectedError:  `filter syntax error at line 1, column 9: expected value, found end of expression`,
+			expectedOffset: 8,
+		},
+		{
+			name:           "unknown operator",
+			expr:           `price LIKE 5`,
+			expectedError:  `filter syntax error at line 1, column 7: expected operator, found "LIKE"`,
+			expectedOffset: 6,
+		},
+		{
+			name:           "bad operator after NOT",
+			expr:           `a NOT = 1`,
+			expectedError:  `filter syntax error at line 1, column 7: expected IN, GLOB, IGLOB, REGEX or CONTAINS after NOT, found "="`,
+			expectedOffset: 6,
+		},
+		{
+			name:           "unclosed parenthesis",
+			expr:           `(a = 1 OR b = 2`,
+			expectedError:  `filter syntax error at line 1, column 16: expected ), found end of expression`,
+			expectedOffset: 15,
+		},
+		{
+			name:           "trailing tokens",
+			expr:           `a = 1 b = 2`,
+			expectedError:  `filter syntax error at line 1, column 7: expected AND, OR or end of expression, found "b"`,
+			expectedOffset: 6,
+		},
+		{
+			name:           "unterminated string",
+			expr:           `a = "abc`,
+			expectedError:  `filter syntax error at line 1, column 5: unterminated string`,
+			expectedOffset: 4,
+		},
+		{
+			name:           "IN without list",
+			expr:           `a IN 1`,
+			expectedError:  `filter syntax error at line 1, column 6: In requires a list value, found "1"`,
+			expectedOffset: 5,
+		},
+		{
+			name:           "GLOB without string",
+			expr:           `a GLOB 1`,
+			expectedError:  `filter syntax error at line 1, column 8: Glob requires a string value, found "1"`,
+			expectedOffset: 7,
+		},
+		{
+			name:           "unclosed list",
+			expr:           `a IN [1, 2`,
+			expectedError:  `filter syntax error at line 1, column 11: expected , or ], found end of expression`,
+			expectedOffset: 10,
+		},
+		{
+			name:           "invalid number",
+			expr:           `a = 1.2.3`,
+			expectedError:  `filter syntax error at line 1, column 5: invalid number "1.2.3"`,
+			expectedOffset: 4,
+		},
+		{
+			name:           "unexpected character",
+			expr:           `a = 1 && b = 2`,
+			expectedError:  `filter syntax error at line 1, column 7: unexpected character '&'`,
+			expectedOffset: 6,
+		},
+		{
+			name:           "position on later line",
+			expr:           "a = 1 AND\n  (b = 2 OR\n   c = )",
+			expectedError:  `filter syntax error at line 3, column 8: expected value, found ")"`,
+			expectedOffset: 29,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tpuf.ParseFilter(tt.expr)
+			assert.Nil(t, filter)
+			assert.EqualError(t, err, tt.expectedError)
+
+			var syntaxErr *tpuf.FilterSyntaxError
+			if assert.True(t, errors.As(err, &syntaxErr), "expected a FilterSyntaxError") {
+				assert.Equal(t, tt.expectedOffset, syntaxErr.Offset, "unexpected error offset")
+			}
+		})
+	}
+}
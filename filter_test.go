@@ -2,7 +2,9 @@ package tpuf_test
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/bamo/tpuf-go"
 	"github.com/stretchr/testify/assert"
@@ -105,6 +107,83 @@ func TestMarshalFilter(t *testing.T) {
 			},
 			expected: `["And",[["id","In",[1,2,3]],["key1","Eq","one"],["filename","NotGlob","/vendor/**"],["Or",[["filename","Glob","**.tsx"],["filename","Glob","**.js"]]]]]`,
 		},
+		{
+			name: "Nested compound filter using constructors",
+			filter: tpuf.And(
+				tpuf.In("id", []int{1, 2, 3}),
+				tpuf.Eq("key1", "one"),
+				tpuf.NotGlob("filename", "/vendor/**"),
+				tpuf.Or(
+					tpuf.Glob("filename", "**.tsx"),
+					tpuf.Glob("filename", "**.js"),
+				),
+			),
+			expected: `["And",[["id","In",[1,2,3]],["key1","Eq","one"],["filename","NotGlob","/vendor/**"],["Or",[["filename","Glob","**.tsx"],["filename","Glob","**.js"]]]]]`,
+		},
+		{
+			name: "Comparison constructors",
+			filter: tpuf.Or(
+				tpuf.NotEq("a", "x"),
+				tpuf.NotIn("b", []string{"y", "z"}),
+				tpuf.Lt("c", 1),
+				tpuf.Lte("d", 2),
+				tpuf.Gt("e", 3),
+				tpuf.Gte("f", 4),
+				tpuf.IGlob("g", "*.GO"),
+				tpuf.NotIGlob("h", "*.MD"),
+			),
+			expected: `["Or",[["a","NotEq","x"],["b","NotIn",["y","z"]],["c","Lt",1],["d","Lte",2],["e","Gt",3],["f","Gte",4],["g","IGlob","*.GO"],["h","NotIGlob","*.MD"]]]`,
+		},
+		{
+			name:     "Contains and NotContains",
+			filter:   tpuf.And(tpuf.Contains("tags", "go"), tpuf.NotContains("tags", "deprecated")),
+			expected: `["And",[["tags","Contains","go"],["tags","NotContains","deprecated"]]]`,
+		},
+		{
+			name:     "ContainsAllTokens",
+			filter:   tpuf.ContainsAllTokens("body", "quick fox"),
+			expected: `["body","ContainsAllTokens","quick fox"]`,
+		},
+		{
+			name:     "ContainsAllTokensArray",
+			filter:   tpuf.ContainsAllTokensArray("tokens", "quick", "fox"),
+			expected: `["tokens","ContainsAllTokens",["quick","fox"]]`,
+		},
+		{
+			name: "ContainsAny, NotContainsAny and ContainsAll",
+			filter: tpuf.And(
+				tpuf.ContainsAny("tags", []string{"go", "rust"}),
+				tpuf.NotContainsAny("ids", []uint{1, 2}),
+				tpuf.ContainsAll("tags", []string{"cli", "library"}),
+			),
+			expected: `["And",[["tags","ContainsAny",["go","rust"]],["ids","NotContainsAny",[1,2]],["And",[["tags","Contains","cli"],["tags","Contains","library"]]]]]`,
+		},
+		{
+			name:     "Regex constructors",
+			filter:   tpuf.And(tpuf.Regex("title", `^v\d+\.`), tpuf.NotRegex("title", "(?i)draft")),
+			expected: `["And",[["title","Regex","^v\\d+\\."],["title","NotRegex","(?i)draft"]]]`,
+		},
+		{
+			name:     "ID constructors",
+			filter:   tpuf.Or(tpuf.IDEq("a"), tpuf.IDIn("b", "c"), tpuf.IDGt("d")),
+			expected: `["Or",[["id","Eq","a"],["id","In",["b","c"]],["id","Gt","d"]]]`,
+		},
+		{
+			name: "Typed constructors",
+			filter: tpuf.And(
+				tpuf.EqString("a", "x"),
+				tpuf.EqInt("b", -1),
+				tpuf.EqUint("c", 2),
+				tpuf.EqFloat("d", 0.5),
+				tpuf.EqBool("e", true),
+				tpuf.InString("f", "y", "z"),
+				tpuf.InInt("g", 1, 2),
+				tpuf.InUint("h", 3),
+				tpuf.GteTime("i", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+				tpuf.LtTime("i", time.Date(2024, 2, 1, 12, 30, 0, 0, time.UTC)),
+			),
+			expected: `["And",[["a","Eq","x"],["b","Eq",-1],["c","Eq",2],["d","Eq",0.5],["e","Eq",true],["f","In",["y","z"]],["g","In",[1,2]],["h","In",[3]],["i","Gte","2024-01-01T00:00:00Z"],["i","Lt","2024-02-01T12:30:00Z"]]]`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -141,3 +220,57 @@ func TestMarshalFilter(t *testing.T) {
 		assert.Equal(t, `{"filter":["id","In",[1,2,3]]}`, string(result))
 	})
 }
+
+func TestMarshalFilterInvalidValue(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        tpuf.Filter
+		expectedError string
+	}{
+		{
+			name:          "NaN",
+			filter:        tpuf.Eq("score", math.NaN()),
+			expectedError: `invalid value for Eq filter on attribute "score": NaN is not supported`,
+		},
+		{
+			name:          "infinity in list",
+			filter:        tpuf.In("score", []float64{1, math.Inf(1)}),
+			expectedError: `invalid value for In filter on attribute "score": +Inf is not supported`,
+		},
+		{
+			name:          "channel",
+			filter:        tpuf.Eq("attr", make(chan int)),
+			expectedError: `invalid value for Eq filter on attribute "attr": values of type chan int are not supported`,
+		},
+		{
+			name:          "function nested in compound filter",
+			filter:        tpuf.Or(tpuf.Eq("a", 1), tpuf.And(tpuf.Eq("b", func() {}))),
+			expectedError: `invalid value for Eq filter on attribute "b": values of type func() are not supported`,
+		},
+		{
+			name:          "invalid regex",
+			filter:        tpuf.Regex("title", "v[0-9"),
+			expectedError: `invalid value for Regex filter on attribute "title": error parsing regexp: missing closing ]: ` + "`[0-9`",
+		},
+		{
+			name:          "non-string regex",
+			filter:        &tpuf.BaseFilter{Attribute: "title", Operator: tpuf.OpNotRegex, Value: 42},
+			expectedError: `invalid value for NotRegex filter on attribute "title": pattern of type int is not a string`,
+		},
+		{
+			name:          "empty ID list",
+			filter:        tpuf.And(tpuf.Eq("category", "a"), tpuf.IDIn()),
+			expectedError: `invalid value for In filter on attribute "id": at least one ID is required`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.filter.MarshalJSON()
+			assert.EqualError(t, err, tt.expectedError)
+
+			_, err = json.Marshal(&tpuf.QueryRequest{Filters: tt.filter})
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
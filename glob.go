@@ -0,0 +1,62 @@
+package tpuf
+
+import "strings"
+
+// globSpecialChars are the characters with special meaning in glob patterns.
+const globSpecialChars = `\*?[]{}`
+
+// EscapeGlob escapes the special characters in s, so that it matches only itself when used in a
+// Glob or IGlob pattern.  Always escape user input before embedding it in a pattern.
+func EscapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(globSpecialChars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// GlobPrefix matches documents where the attribute starts with prefix.
+func GlobPrefix(attribute string, prefix string) *BaseFilter {
+	return Glob(attribute, EscapeGlob(prefix)+"**")
+}
+
+// IGlobPrefix matches documents where the attribute starts with prefix, ignoring case.
+func IGlobPrefix(attribute string, prefix string) *BaseFilter {
+	return IGlob(attribute, EscapeGlob(prefix)+"**")
+}
+
+// GlobContains matches documents where the attribute contains substring.
+func GlobContains(attribute string, substring string) *BaseFilter {
+	return Glob(attribute, "**"+EscapeGlob(substring)+"**")
+}
+
+// IGlobContains matches documents where the attribute contains substring, ignoring case.
+func IGlobContains(attribute string, substring string) *BaseFilter {
+	return IGlob(attribute, "**"+EscapeGlob(substring)+"**")
+}
+
+// IEq matches documents where the attribute equals value, ignoring case.
+func IEq(attribute string, value string) *BaseFilter {
+	return IGlob(attribute, EscapeGlob(value))
+}
+
+// GlobDirectory matches documents where the attribute is a path within the directory dir, at any depth.
+func GlobDirectory(attribute string, dir string) *BaseFilter {
+	return Glob(attribute, EscapeGlob(strings.TrimSuffix(dir, "/"))+"/**")
+}
+
+// GlobExtensions matches documents where the attribute is a path ending in any of the file extensions,
+// which may be given with or without a leading dot, e.g. GlobExtensions("path", "go", ".md").
+func GlobExtensions(attribute string, extensions ...string) Filter {
+	filters := make([]Filter, len(extensions))
+	for i, extension := range extensions {
+		filters[i] = Glob(attribute, "**."+EscapeGlob(strings.TrimPrefix(extension, ".")))
+	}
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return Or(filters...)
+}
@@ -0,0 +1,85 @@
+package tpuf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeGlob(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "plain.txt", expected: "plain.txt"},
+		{input: "what?*", expected: `what\?\*`},
+		{input: "[draft] {v2}", expected: `\[draft\] \{v2\}`},
+		{input: `C:\dir`, expected: `C:\\dir`},
+		{input: "ünïcode", expected: "ünïcode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tpuf.EscapeGlob(tt.input))
+		})
+	}
+}
+
+func TestGlobHelpers(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   tpuf.Filter
+		expected string
+	}{
+		{
+			name:     "prefix",
+			filter:   tpuf.GlobPrefix("title", "Report [2024]"),
+			expected: `["title","Glob","Report \\[2024\\]**"]`,
+		},
+		{
+			name:     "case-insensitive prefix",
+			filter:   tpuf.IGlobPrefix("title", "report"),
+			expected: `["title","IGlob","report**"]`,
+		},
+		{
+			name:     "contains",
+			filter:   tpuf.GlobContains("title", "50% off*"),
+			expected: `["title","Glob","**50% off\\***"]`,
+		},
+		{
+			name:     "case-insensitive contains",
+			filter:   tpuf.IGlobContains("title", "why?"),
+			expected: `["title","IGlob","**why\\?**"]`,
+		},
+		{
+			name:     "case-insensitive equality",
+			filter:   tpuf.IEq("email", "Alice@Example.com"),
+			expected: `["email","IGlob","Alice@Example.com"]`,
+		},
+		{
+			name:     "directory",
+			filter:   tpuf.GlobDirectory("path", "/src/vendor/"),
+			expected: `["path","Glob","/src/vendor/**"]`,
+		},
+		{
+			name:     "single extension",
+			filter:   tpuf.GlobExtensions("path", ".go"),
+			expected: `["path","Glob","**.go"]`,
+		},
+		{
+			name:     "multiple extensions",
+			filter:   tpuf.GlobExtensions("path", "tsx", ".js"),
+			expected: `["Or",[["path","Glob","**.tsx"],["path","Glob","**.js"]]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := json.Marshal(tt.filter)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
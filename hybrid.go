@@ -0,0 +1,154 @@
+package tpuf
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+const defaultRRFK = 60
+
+// FusionRRF configures reciprocal rank fusion, which scores each document as the weighted sum of
+// 1 / (K + rank) over the result lists it appears in.
+type FusionRRF struct {
+	// K dampens the influence of top-ranked results.  Defaults to 60.
+	K float64
+	// VectorWeight is the weight of the vector search ranking.  Defaults to 1.
+	VectorWeight float64
+	// TextWeight is the weight of the BM25 full-text search ranking.  Defaults to 1.
+	TextWeight float64
+}
+
+type HybridRequest struct {
+	// Vector is the vector to search for.  Required.
+	Vector []float32
+	// DistanceMetric is the distance metric to use for vector search.  Required.
+	DistanceMetric DistanceMetric
+	// TextAttribute is the full-text searchable attribute to search.  Required.
+	TextAttribute string
+	// Text is the BM25 full-text query.  Required.
+	Text string
+	// TopK is the maximum number of fused results to return.  Default 10.
+	TopK int
+	// CandidateK is the number of results to retrieve from each search before fusion.  Defaults to TopK.
+	CandidateK int
+	// Filters is an optional filter applied to both searches.
+	Filters Filter
+	// IncludeAttributes specifies which attributes to include in the results.
+	IncludeAttributes *IncludeAttributes
+	// FusionRRF configures how the two rankings are fused.  Optional.
+	FusionRRF *FusionRRF
+	// Reranker, if set, reorders all fused candidates by relevance to Text before the top TopK are returned.
+	// Set IncludeAttributes so that the reranker has document text to work with.
+	Reranker Reranker
+}
+
+// HybridResult is a single result of a hybrid query.
+type HybridResult struct {
+	*QueryResult
+	// Score is the fused reciprocal rank score.  Higher is better.
+	Score float64
+	// VectorRank is the 1-based rank of this document in the vector search results, or 0 if absent.
+	VectorRank int
+	// TextRank is the 1-based rank of this document in the full-text search results, or 0 if absent.
+	TextRank int
+}
+
+// HybridQuery runs a vector search and a BM25 full-text search concurrently, then fuses the two rankings
+// using reciprocal rank fusion, deduplicating documents by ID.
+// The Dist of each result is the distance or BM25 score from whichever search returned it, preferring
+// the vector search; use Score to order results.
+func (c *Client) HybridQuery(ctx context.Context, namespace string, request *HybridRequest) ([]*HybridResult, error) {
+	topK := request.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+	candidateK := request.CandidateK
+	if candidateK <= 0 {
+		candidateK = topK
+	}
+	fusion := FusionRRF{K: defaultRRFK, VectorWeight: 1, TextWeight: 1}
+	if request.FusionRRF != nil {
+		if request.FusionRRF.K > 0 {
+			fusion.K = request.FusionRRF.K
+		}
+		if request.FusionRRF.VectorWeight > 0 {
+			fusion.VectorWeight = request.FusionRRF.VectorWeight
+		}
+		if request.FusionRRF.TextWeight > 0 {
+			fusion.TextWeight = request.FusionRRF.TextWeight
+		}
+	}
+
+	results, err := c.MultiQuery(ctx, namespace, []*QueryRequest{
+		{
+			Vector:            request.Vector,
+			DistanceMetric:    request.DistanceMetric,
+			TopK:              candidateK,
+			Filters:           request.Filters,
+			IncludeAttributes: request.IncludeAttributes,
+		},
+		{
+			RankBy:            BM25(request.TextAttribute, request.Text),
+			TopK:              candidateK,
+			Filters:           request.Filters,
+			IncludeAttributes: request.IncludeAttributes,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run hybrid query: %w", err)
+	}
+
+	byID := map[string]*HybridResult{}
+	var fused []*HybridResult
+	for i, result := range results[0] {
+		hybrid := &HybridResult{QueryResult: result, VectorRank: i + 1}
+		hybrid.Score += fusion.VectorWeight / (fusion.K + float64(i+1))
+		byID[result.ID] = hybrid
+		fused = append(fused, hybrid)
+	}
+	for i, result := range results[1] {
+		hybrid, ok := byID[result.ID]
+		if !ok {
+			hybrid = &HybridResult{QueryResult: result}
+			byID[result.ID] = hybrid
+			fused = append(fused, hybrid)
+		}
+		hybrid.TextRank = i + 1
+		hybrid.Score += fusion.TextWeight / (fusion.K + float64(i+1))
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	if request.Reranker != nil {
+		fused, err = rerankHybrid(ctx, request.Reranker, request.Text, fused, byID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(fused) > topK {
+		fused = fused[:topK]
+	}
+	return fused, nil
+}
+
+func rerankHybrid(ctx context.Context, reranker Reranker, query string, fused []*HybridResult, byID map[string]*HybridResult) ([]*HybridResult, error) {
+	candidates := make([]*QueryResult, len(fused))
+	for i, result := range fused {
+		candidates[i] = result.QueryResult
+	}
+	reranked, err := reranker.Rerank(ctx, query, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank results: %w", err)
+	}
+	results := make([]*HybridResult, 0, len(reranked))
+	for _, result := range reranked {
+		hybrid, ok := byID[result.ID]
+		if !ok {
+			return nil, fmt.Errorf("reranker returned unknown document %s", result.ID)
+		}
+		results = append(results, hybrid)
+	}
+	return results, nil
+}
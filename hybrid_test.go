@@ -0,0 +1,148 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHybridQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       *tpuf.HybridRequest
+		vectorResults string
+		textResults   string
+		expectedError string
+		expectedIDs   []string
+		expectedRanks [][2]int
+		reranker      tpuf.Reranker
+	}{
+		{
+			name: "fuses and deduplicates",
+			request: &tpuf.HybridRequest{
+				Vector:         []float32{0.1, 0.2},
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				TextAttribute:  "text",
+				Text:           "moon",
+				TopK:           3,
+			},
+			vectorResults: `[{"id":"a","dist":0.1},{"id":"b","dist":0.2},{"id":"c","dist":0.3}]`,
+			textResults:   `[{"id":"c","dist":5},{"id":"a","dist":4},{"id":"d","dist":3}]`,
+			expectedIDs:   []string{"a", "c", "b"},
+			expectedRanks: [][2]int{{1, 2}, {3, 1}, {2, 0}},
+		},
+		{
+			name: "text weight favours keyword matches",
+			request: &tpuf.HybridRequest{
+				Vector:         []float32{0.1, 0.2},
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				TextAttribute:  "text",
+				Text:           "moon",
+				TopK:           2,
+				FusionRRF:      &tpuf.FusionRRF{K: 1, TextWeight: 10},
+			},
+			vectorResults: `[{"id":"a","dist":0.1},{"id":"b","dist":0.2}]`,
+			textResults:   `[{"id":"d","dist":5},{"id":"e","dist":4}]`,
+			expectedIDs:   []string{"d", "e"},
+			expectedRanks: [][2]int{{0, 1}, {0, 2}},
+		},
+		{
+			name: "reranks fused candidates",
+			request: &tpuf.HybridRequest{
+				Vector:         []float32{0.1, 0.2},
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				TextAttribute:  "text",
+				Text:           "moon",
+				TopK:           2,
+			},
+			reranker: tpuf.RerankerFunc(func(ctx context.Context, query string, candidates []*tpuf.QueryResult) ([]*tpuf.QueryResult, error) {
+				// Reverse the fused order.
+				reversed := make([]*tpuf.QueryResult, len(candidates))
+				for i, candidate := range candidates {
+					reversed[len(candidates)-1-i] = candidate
+				}
+				return reversed, nil
+			}),
+			vectorResults: `[{"id":"a","dist":0.1},{"id":"b","dist":0.2}]`,
+			textResults:   `[{"id":"a","dist":5},{"id":"c","dist":4}]`,
+			expectedIDs:   []string{"c", "b"},
+			expectedRanks: [][2]int{{0, 2}, {2, 0}},
+		},
+		{
+			name: "reranker failure",
+			request: &tpuf.HybridRequest{
+				Vector:         []float32{0.1, 0.2},
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				TextAttribute:  "text",
+				Text:           "moon",
+			},
+			reranker: tpuf.RerankerFunc(func(ctx context.Context, query string, candidates []*tpuf.QueryResult) ([]*tpuf.QueryResult, error) {
+				return nil, errors.New("rate limited")
+			}),
+			vectorResults: `[{"id":"a","dist":0.1}]`,
+			textResults:   `[{"id":"a","dist":5}]`,
+			expectedError: "failed to rerank results: rate limited",
+		},
+		{
+			name: "query failure",
+			request: &tpuf.HybridRequest{
+				Vector:         []float32{0.1, 0.2},
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				TextAttribute:  "text",
+				Text:           "moon",
+			},
+			vectorResults: `[]`,
+			expectedError: "failed to run hybrid query: query 1 failed: failed to query documents: error: attribute not full-text searchable (HTTP 400)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						var request map[string]interface{}
+						assert.NoError(t, json.NewDecoder(req.Body).Decode(&request))
+						if _, ok := request["rank_by"]; !ok {
+							return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(tt.vectorResults))}, nil
+						}
+						if tt.textResults == "" {
+							return &http.Response{
+								StatusCode: http.StatusBadRequest,
+								Body:       io.NopCloser(bytes.NewBufferString(`{"error":"attribute not full-text searchable","status":"error"}`)),
+							}, nil
+						}
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(tt.textResults))}, nil
+					},
+				},
+			}
+
+			tt.request.Reranker = tt.reranker
+			results, err := client.HybridQuery(context.Background(), "test-namespace", tt.request)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, results)
+				return
+			}
+			assert.NoError(t, err)
+			var ids []string
+			var ranks [][2]int
+			for _, result := range results {
+				ids = append(ids, result.ID)
+				ranks = append(ranks, [2]int{result.VectorRank, result.TextRank})
+			}
+			assert.Equal(t, tt.expectedIDs, ids, "unexpected result order")
+			assert.Equal(t, tt.expectedRanks, ranks, "unexpected result ranks")
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package tpuf
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// documentID is a document ID as the API encodes it: either a string, such as a UUID, or an unsigned integer.
+// str is always set, holding numeric IDs in decimal, so that it can be used as a key and in messages.
+type documentID struct {
+	str string
+	num *uint64
+}
+
+func newDocumentID(id string, idUint64 *uint64) documentID {
+	if idUint64 != nil {
+		return documentID{str: strconv.FormatUint(*idUint64, 10), num: idUint64}
+	}
+	return documentID{str: id}
+}
+
+// key returns a comparable value which distinguishes a numeric ID from the string of its digits.
+// It is also the value to compare the id attribute against in filters.
+func (id documentID) key() interface{} {
+	if id.num != nil {
+		return *id.num
+	}
+	return id.str
+}
+
+func (id documentID) MarshalJSON() ([]byte, error) {
+	if id.num != nil {
+		return strconv.AppendUint(nil, *id.num, 10), nil
+	}
+	return json.Marshal(id.str)
+}
+
+func (id *documentID) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*id = documentID{}
+		return json.Unmarshal(data, &id.str)
+	}
+	num, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("document id must be a string or an unsigned integer, got %s", data)
+	}
+	*id = newDocumentID("", &num)
+	return nil
+}
+
+// Uint64 returns a pointer to n, for setting the IDUint64 field of an Upsert.
+func Uint64(n uint64) *uint64 {
+	return &n
+}
+
+// NewDocumentID returns a new random UUIDv7, e.g. "01890a5d-ac96-774b-bcce-b302099a8057", for use as a
+// document ID.  UUIDv7s begin with a millisecond timestamp, so IDs sort in the order they were generated,
+// to the millisecond, which keeps writes and ID-ordered exports local.  The rest is random.
+// Use AttributeTypeUUID for attributes holding these IDs so that the server stores them compactly.
+// NewDocumentID panics if the system's random number generator fails.
+func NewDocumentID() string {
+	id, err := newUUIDv7(time.Now(), rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("failed to generate document id: %v", err))
+	}
+	return id
+}
+
+// NewULID returns a new random ULID, e.g. "01ARYZ6S41TSV4RRFFQ69G5FAV", for use as a document ID.
+// Like NewDocumentID, ULIDs begin with a millisecond timestamp, but they are shorter and case-insensitive.
+// ULIDs are not UUIDs, so they must be stored as strings.
+// NewULID panics if the system's random number generator fails.
+func NewULID() string {
+	id, err := newULID(time.Now(), rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("failed to generate document id: %v", err))
+	}
+	return id
+}
+
+// timestampedID returns 16 bytes starting with the 48-bit big-endian Unix millisecond timestamp of t,
+// followed by random bytes.
+func timestampedID(t time.Time, random io.Reader) ([16]byte, error) {
+	var id [16]byte
+	if _, err := io.ReadFull(random, id[6:]); err != nil {
+		return id, err
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(id[:6], ms[2:])
+	return id, nil
+}
+
+func newUUIDv7(t time.Time, random io.Reader) (string, error) {
+	id, err := timestampedID(t, random)
+	if err != nil {
+		return "", err
+	}
+	id[6] = id[6]&0x0f | 0x70 // version 7
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+	return formatUUID(id), nil
+}
+
+func formatUUID(id [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf[:])
+}
+
+// crockfordBase32 is the alphabet used by ULIDs, which omits I, L, O and U to avoid confusion.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func newULID(t time.Time, random io.Reader) (string, error) {
+	id, err := timestampedID(t, random)
+	if err != nil {
+		return "", err
+	}
+	// Each character encodes 5 bits of the 128-bit ID, padded with 2 leading zero bits to 130 bits.
+	var buf [26]byte
+	for i := range buf {
+		var v byte
+		for j := 0; j < 5; j++ {
+			bit := i*5 + j - 2
+			v <<= 1
+			if bit >= 0 && id[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		buf[i] = crockfordBase32[v]
+	}
+	return string(buf[:]), nil
+}
+
+// ValidUUID reports whether s is a UUID in the hyphenated form accepted by the API for attributes of
+// type AttributeTypeUUID, e.g. "01890a5d-ac96-774b-bcce-b302099a8057".  Hex digits may be either case.
+func ValidUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateUUIDAttributes checks that every attribute which schema declares as AttributeTypeUUID or
+// AttributeTypeUUIDArray holds valid UUIDs, so that a malformed value is reported with its document ID
+// before the upsert is sent, rather than failing the whole request server-side.
+func ValidateUUIDAttributes(schema Schema, upserts []*Upsert) error {
+	var uuidAttributes []string
+	for name, attribute := range schema {
+		if attribute != nil && (attribute.Type == AttributeTypeUUID || attribute.Type == AttributeTypeUUIDArray) {
+			uuidAttributes = append(uuidAttributes, name)
+		}
+	}
+	if len(uuidAttributes) == 0 {
+		return nil
+	}
+	sort.Strings(uuidAttributes)
+	for _, upsert := range upserts {
+		if upsert.Attributes == nil {
+			continue
+		}
+		data, err := json.Marshal(upsert.Attributes)
+		if err != nil {
+			return fmt.Errorf("failed to marshal attributes of document %s: %w", upsert.documentID().str, err)
+		}
+		var attributes map[string]json.RawMessage
+		if err := json.Unmarshal(data, &attributes); err != nil {
+			return fmt.Errorf("attributes of document %s must be an object: %w", upsert.documentID().str, err)
+		}
+		for _, name := range uuidAttributes {
+			value, ok := attributes[name]
+			if !ok || string(value) == "null" {
+				continue
+			}
+			var values []string
+			if schema[name].Type == AttributeTypeUUIDArray {
+				err = json.Unmarshal(value, &values)
+			} else {
+				values = make([]string, 1)
+				err = json.Unmarshal(value, &values[0])
+			}
+			if err != nil {
+				return fmt.Errorf("attribute %s of document %s must be of type %s: %s", name, upsert.documentID().str, schema[name].Type, value)
+			}
+			for _, v := range values {
+				if !ValidUUID(v) {
+					return fmt.Errorf("attribute %s of document %s is not a valid UUID: %q", name, upsert.documentID().str, v)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package tpuf
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUUIDv7(t *testing.T) {
+	random := bytes.NewReader(bytes.Repeat([]byte{0xff}, 10))
+	id, err := newUUIDv7(time.UnixMilli(0x01890a5dac96), random)
+	assert.NoError(t, err)
+	assert.Equal(t, "01890a5d-ac96-7fff-bfff-ffffffffffff", id)
+
+	_, err = newUUIDv7(time.Now(), bytes.NewReader(nil))
+	assert.Error(t, err)
+}
+
+func TestNewULID(t *testing.T) {
+	id, err := newULID(time.UnixMilli(0), bytes.NewReader(make([]byte, 10)))
+	assert.NoError(t, err)
+	assert.Equal(t, "00000000000000000000000000", id)
+
+	id, err = newULID(time.UnixMilli(1<<48-1), bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)))
+	assert.NoError(t, err)
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", id)
+
+	id, err = newULID(time.UnixMilli(1469918176385), bytes.NewReader(make([]byte, 10)))
+	assert.NoError(t, err)
+	assert.Equal(t, "01ARYZ6S410000000000000000", id)
+}
+
+func TestNewDocumentIDsAreOrdered(t *testing.T) {
+	var uuids, ulids []string
+	for i := 0; i < 3; i++ {
+		uuids = append(uuids, NewDocumentID())
+		ulids = append(ulids, NewULID())
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.True(t, sort.StringsAreSorted(uuids), "UUIDs should sort by creation time: %v", uuids)
+	assert.True(t, sort.StringsAreSorted(ulids), "ULIDs should sort by creation time: %v", ulids)
+	for _, id := range uuids {
+		assert.True(t, ValidUUID(id), id)
+		assert.Equal(t, byte('7'), id[14], "unexpected version in %s", id)
+	}
+	assert.NotEqual(t, NewDocumentID(), NewDocumentID())
+}
+
+func TestValidUUID(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"01890a5d-ac96-774b-bcce-b302099a8057":   true,
+		"01890A5D-AC96-774B-BCCE-B302099A8057":   true,
+		"01890a5dac96774bbcceb302099a8057":       false,
+		"01890a5d-ac96-774b-bcce-b302099a805":    false,
+		"01890a5d-ac96-774b-bcce-b302099a8057a":  false,
+		"01890a5d-ac96-774b-bcce_b302099a8057":   false,
+		"01890a5g-ac96-774b-bcce-b302099a8057":   false,
+		"{01890a5d-ac96-774b-bcce-b302099a8057}": false,
+		"":                                       false,
+	} {
+		assert.Equal(t, valid, ValidUUID(id), id)
+	}
+}
+
+func TestValidateUUIDAttributes(t *testing.T) {
+	schema := Schema{
+		"owner": &Attribute{Type: AttributeTypeUUID},
+		"tags":  &Attribute{Type: AttributeTypeUUIDArray},
+		"title": &Attribute{Type: AttributeTypeString},
+	}
+	valid := "01890a5d-ac96-774b-bcce-b302099a8057"
+	tests := []struct {
+		name          string
+		upserts       []*Upsert
+		expectedError string
+	}{
+		{
+			name: "valid",
+			upserts: []*Upsert{
+				{ID: "1", Attributes: map[string]interface{}{"owner": valid, "tags": []string{valid}, "title": "not a uuid"}},
+				{ID: "2", Attributes: map[string]interface{}{"owner": nil}},
+				{ID: "3"},
+			},
+		},
+		{
+			name:          "invalid uuid",
+			upserts:       []*Upsert{{ID: "1", Attributes: map[string]interface{}{"owner": "nope"}}},
+			expectedError: `attribute owner of document 1 is not a valid UUID: "nope"`,
+		},
+		{
+			name: "invalid uuid in array",
+			upserts: []*Upsert{{IDUint64: Uint64(2), Attributes: struct {
+				Tags []string `json:"tags"`
+			}{[]string{valid, "nope"}}}},
+			expectedError: `attribute tags of document 2 is not a valid UUID: "nope"`,
+		},
+		{
+			name:          "wrong type",
+			upserts:       []*Upsert{{ID: "1", Attributes: map[string]interface{}{"tags": valid}}},
+			expectedError: `attribute tags of document 1 must be of type []uuid: "` + valid + `"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUUIDAttributes(schema, tt.upserts)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
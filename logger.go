@@ -0,0 +1,105 @@
+package tpuf
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// Logger receives the client's log messages.  keysAndValues alternate between string keys and arbitrary
+// values, as in logr and zap's SugaredLogger.  Implementations must be safe for concurrent use.
+// The tpuflog module provides adapters for zap, logrus and logr.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{})
+}
+
+// LoggerFunc adapts an ordinary function to the Logger interface.
+type LoggerFunc func(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{})
+
+func (f LoggerFunc) Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+	f(ctx, level, msg, keysAndValues...)
+}
+
+// StdLogger returns a Logger which writes messages at or above minLevel to a standard library logger,
+// formatted as `LEVEL msg key=value ...`.  A nil logger uses the standard library's default logger.
+func StdLogger(logger *log.Logger, minLevel LogLevel) Logger {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return LoggerFunc(func(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+		if level < minLevel {
+			return
+		}
+		var b strings.Builder
+		b.WriteString(level.String())
+		b.WriteString(" ")
+		b.WriteString(msg)
+		for i := 0; i < len(keysAndValues); i += 2 {
+			var value interface{} = "(missing)"
+			if i+1 < len(keysAndValues) {
+				value = keysAndValues[i+1]
+			}
+			fmt.Fprintf(&b, " %v=%v", keysAndValues[i], value)
+		}
+		logger.Print(b.String())
+	})
+}
+
+func (c *Client) log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Log(ctx, level, msg, keysAndValues...)
+	}
+}
+
+// logRetry returns a backoff notify function which logs each retry of a request.
+func (c *Client) logRetry(ctx context.Context, stats *RequestStats) func(error, time.Duration) {
+	if c.Logger == nil {
+		return nil
+	}
+	return func(err error, delay time.Duration) {
+		c.log(ctx, LogLevelWarn, "retrying request",
+			"operation", stats.Operation, "namespace", stats.Namespace, "attempt", stats.Attempts, "delay", delay, "error", err)
+	}
+}
+
+// logRequest logs the outcome of a completed request.
+func (c *Client) logRequest(ctx context.Context, stats *RequestStats, err error) {
+	if c.Logger == nil {
+		return
+	}
+	if err != nil {
+		c.log(ctx, LogLevelWarn, "request failed",
+			"operation", stats.Operation, "namespace", stats.Namespace, "attempts", stats.Attempts,
+			"duration", time.Since(stats.Start), "error", err)
+		return
+	}
+	c.log(ctx, LogLevelDebug, "request completed",
+		"operation", stats.Operation, "namespace", stats.Namespace, "attempts", stats.Attempts,
+		"duration", time.Since(stats.Start))
+}
@@ -0,0 +1,96 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type logEntry struct {
+	level  tpuf.LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+func TestClientLogger(t *testing.T) {
+	tests := []struct {
+		name            string
+		statuses        []int
+		expectedEntries []logEntry
+	}{
+		{
+			name:     "success",
+			statuses: []int{http.StatusOK},
+			expectedEntries: []logEntry{
+				{tpuf.LogLevelDebug, "request completed", map[string]interface{}{"operation": tpuf.OperationQuery, "namespace": "docs", "attempts": 1}},
+			},
+		},
+		{
+			name:     "retry",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedEntries: []logEntry{
+				{tpuf.LogLevelWarn, "retrying request", map[string]interface{}{"operation": tpuf.OperationQuery, "namespace": "docs", "attempt": 1}},
+				{tpuf.LogLevelDebug, "request completed", map[string]interface{}{"operation": tpuf.OperationQuery, "namespace": "docs", "attempts": 2}},
+			},
+		},
+		{
+			name:     "failure",
+			statuses: []int{http.StatusBadRequest},
+			expectedEntries: []logEntry{
+				{tpuf.LogLevelWarn, "request failed", map[string]interface{}{"operation": tpuf.OperationQuery, "namespace": "docs", "attempts": 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []logEntry
+			calls := 0
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				Timer:    &fakeTimer{},
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						status := tt.statuses[calls]
+						calls++
+						body := `[{"id":"1"}]`
+						if status != http.StatusOK {
+							body = `{"status":"error","error":"oops"}`
+						}
+						return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+					},
+				},
+				Logger: tpuf.LoggerFunc(func(ctx context.Context, level tpuf.LogLevel, msg string, keysAndValues ...interface{}) {
+					fields := map[string]interface{}{}
+					for i := 0; i+1 < len(keysAndValues); i += 2 {
+						switch key := keysAndValues[i].(string); key {
+						case "operation", "namespace", "attempt", "attempts":
+							fields[key] = keysAndValues[i+1]
+						}
+					}
+					entries = append(entries, logEntry{level, msg, fields})
+				}),
+			}
+
+			_, _ = client.Query(context.Background(), "docs", &tpuf.QueryRequest{TopK: 1})
+
+			assert.Equal(t, tt.expectedEntries, entries)
+		})
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := tpuf.StdLogger(log.New(&buf, "", 0), tpuf.LogLevelInfo)
+
+	logger.Log(context.Background(), tpuf.LogLevelDebug, "dropped")
+	logger.Log(context.Background(), tpuf.LogLevelWarn, "retrying request", "namespace", "docs", "attempt", 2, "dangling")
+
+	assert.Equal(t, "WARN retrying request namespace=docs attempt=2 dangling=(missing)\n", buf.String())
+}
@@ -0,0 +1,69 @@
+package tpuf
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryMetadata describes how the server executed a query, as reported in the response headers.
+// Fields are left at their zero value when the server doesn't report them.
+type QueryMetadata struct {
+	// CacheTemperature is the cache state of the namespace, e.g. "hot", "warm", or "cold".
+	CacheTemperature string
+	// CacheHitRatio is the fraction of data served from cache.
+	CacheHitRatio float64
+	// ProcessingTimeMs is the total server-side processing time, in milliseconds.
+	ProcessingTimeMs float64
+	// QueryExecutionTimeMs is the time spent executing the query, in milliseconds.
+	QueryExecutionTimeMs float64
+	// ExhaustiveSearchCount is the number of documents searched exhaustively rather than via the index.
+	ExhaustiveSearchCount int64
+	// ApproxNamespaceSize is the approximate number of documents in the namespace.
+	ApproxNamespaceSize int64
+	// RateLimit is the rate limit reported with the response, or nil if none was.
+	RateLimit *RateLimit
+	// ServerTiming holds every metric from the Server-Timing header, keyed by metric name and then parameter.
+	// This includes any metrics not otherwise parsed above.
+	ServerTiming map[string]map[string]string
+}
+
+// parseQueryMetadata parses the Server-Timing and X-turbopuffer-* headers returned with query responses.
+// Server-Timing looks like: "cache;hit_ratio=0.5;temperature=warm, processing_time;dur=12.3"
+func parseQueryMetadata(header http.Header) *QueryMetadata {
+	metadata := &QueryMetadata{ServerTiming: map[string]map[string]string{}}
+	for _, value := range header.Values("Server-Timing") {
+		for _, metric := range strings.Split(value, ",") {
+			parts := strings.Split(strings.TrimSpace(metric), ";")
+			if parts[0] == "" {
+				continue
+			}
+			params := map[string]string{}
+			for _, part := range parts[1:] {
+				key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+				params[key] = strings.Trim(val, `"`)
+			}
+			metadata.ServerTiming[parts[0]] = params
+		}
+	}
+
+	if cache, ok := metadata.ServerTiming["cache"]; ok {
+		metadata.CacheTemperature = cache["temperature"]
+		metadata.CacheHitRatio, _ = strconv.ParseFloat(cache["hit_ratio"], 64)
+	}
+	if processing, ok := metadata.ServerTiming["processing_time"]; ok {
+		metadata.ProcessingTimeMs, _ = strconv.ParseFloat(processing["dur"], 64)
+	}
+	if execution, ok := metadata.ServerTiming["query_execution_time"]; ok {
+		metadata.QueryExecutionTimeMs, _ = strconv.ParseFloat(execution["dur"], 64)
+	}
+	if exhaustive, ok := metadata.ServerTiming["exhaustive_search"]; ok {
+		metadata.ExhaustiveSearchCount, _ = strconv.ParseInt(exhaustive["count"], 10, 64)
+	}
+	if size := header.Get("X-turbopuffer-Approx-Namespace-Size"); size != "" {
+		metadata.ApproxNamespaceSize, _ = strconv.ParseInt(size, 10, 64)
+	}
+	metadata.RateLimit = parseRateLimit(header, time.Now())
+	return metadata
+}
@@ -0,0 +1,74 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryWithMetadata(t *testing.T) {
+	tests := []struct {
+		name             string
+		header           http.Header
+		expectedMetadata *tpuf.QueryMetadata
+	}{
+		{
+			name: "full metadata",
+			header: http.Header{
+				"Server-Timing": []string{
+					`cache;hit_ratio=0.75;temperature=warm, processing_time;dur=12.5, query_execution_time;dur=10.25`,
+					`exhaustive_search;count=120`,
+				},
+				"X-Turbopuffer-Approx-Namespace-Size": []string{"5000"},
+			},
+			expectedMetadata: &tpuf.QueryMetadata{
+				CacheTemperature:      "warm",
+				CacheHitRatio:         0.75,
+				ProcessingTimeMs:      12.5,
+				QueryExecutionTimeMs:  10.25,
+				ExhaustiveSearchCount: 120,
+				ApproxNamespaceSize:   5000,
+				ServerTiming: map[string]map[string]string{
+					"cache":                {"hit_ratio": "0.75", "temperature": "warm"},
+					"processing_time":      {"dur": "12.5"},
+					"query_execution_time": {"dur": "10.25"},
+					"exhaustive_search":    {"count": "120"},
+				},
+			},
+		},
+		{
+			name:   "no metadata",
+			header: http.Header{},
+			expectedMetadata: &tpuf.QueryMetadata{
+				ServerTiming: map[string]map[string]string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Header:     tt.header,
+							Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0.1}]`)),
+						}, nil
+					},
+				},
+			}
+
+			results, metadata, err := client.QueryWithMetadata(context.Background(), "test-namespace", &tpuf.QueryRequest{TopK: 1})
+			assert.NoError(t, err)
+			assert.Equal(t, []*tpuf.QueryResult{{ID: "1", Dist: 0.1}}, results)
+			assert.Equal(t, tt.expectedMetadata, metadata, "unexpected metadata")
+		})
+	}
+}
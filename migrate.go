@@ -0,0 +1,246 @@
+package tpuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultVerifySamples = 100
+
+// MigrateOptions configures Migrate.
+type MigrateOptions struct {
+	// Transform, if set, is applied to every document before it is written to the destination, e.g. to rename
+	// or convert attributes.  The document may be modified in place.  Returning nil skips the document.
+	Transform func(document *Document) (*Document, error)
+	// Schema is the destination namespace's schema.  Defaults to the destination handle's Schema.  The source
+	// schema is not carried over, since migrations typically change it incompatibly.
+	Schema Schema
+	// DistanceMetric is the distance metric for the destination namespace.  Defaults to the destination
+	// handle's DistanceMetric.  Required if the documents have vectors.
+	DistanceMetric DistanceMetric
+	// BatchSize is the number of documents per upsert request.  Defaults to 1000.
+	BatchSize int
+	// VerifySamples is the number of written documents, sampled uniformly, which are read back from the
+	// destination and compared with what was written once the migration is complete.  Defaults to 100.
+	VerifySamples int
+	// SkipVerify disables verification.
+	SkipVerify bool
+	// Progress, if set, is called after each exported page has been written to the destination.
+	Progress func(*MigrateProgress)
+}
+
+// MigrateProgress reports how much of a namespace has been migrated.
+type MigrateProgress struct {
+	// Pages is the number of export pages written.
+	Pages int
+	// Documents is the number of documents read from the source.
+	Documents int64
+	// Written is the number of documents written to the destination.
+	Written int64
+	// Skipped is the number of documents for which Transform returned nil.
+	Skipped int64
+	// Verified is the number of sampled documents which matched in the destination.
+	Verified int
+}
+
+// MigrateVerifyError is returned by Migrate when sampled documents don't match in the destination.
+type MigrateVerifyError struct {
+	// Sampled is the number of documents compared.
+	Sampled int
+	// Missing are the IDs of sampled documents which weren't found in the destination.
+	Missing []string
+	// Mismatched are the IDs of sampled documents whose vector or attributes differ in the destination.
+	Mismatched []string
+}
+
+func (e *MigrateVerifyError) Error() string {
+	var problems []string
+	if len(e.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%d missing (%s)", len(e.Missing), strings.Join(e.Missing, ", ")))
+	}
+	if len(e.Mismatched) > 0 {
+		problems = append(problems, fmt.Sprintf("%d mismatched (%s)", len(e.Mismatched), strings.Join(e.Mismatched, ", ")))
+	}
+	return fmt.Sprintf("migration verification failed for %d sampled documents: %s", e.Sampled, strings.Join(problems, ", "))
+}
+
+// Migrate copies every document from the source namespace into the destination namespace, applying an
+// optional transform to each, and then verifies a sample of the written documents by reading them back
+// from the destination.  Like TransferNamespace, the source and destination may use different clients.
+// Use Migrate when a schema changes incompatibly: migrate into a new namespace with the new schema, then
+// switch readers over.  opts may be nil.
+func Migrate(ctx context.Context, src *NamespaceClient, dst *NamespaceClient, opts *MigrateOptions) (*MigrateProgress, error) {
+	if opts == nil {
+		opts = &MigrateOptions{}
+	}
+	distanceMetric := opts.DistanceMetric
+	if distanceMetric == "" {
+		distanceMetric = dst.DistanceMetric
+	}
+	schema := opts.Schema
+	if schema == nil {
+		schema = dst.Schema
+	}
+	verifySamples := opts.VerifySamples
+	if verifySamples <= 0 {
+		verifySamples = defaultVerifySamples
+	}
+
+	upserter := &BulkUpserter{
+		Client:         dst.Client,
+		Namespace:      dst.Name,
+		BatchSize:      opts.BatchSize,
+		DistanceMetric: distanceMetric,
+		Schema:         schema,
+		AllowNoVector:  true,
+	}
+	sampler := &documentSampler{size: verifySamples, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	progress := &MigrateProgress{}
+	it := src.Client.ExportIter(ctx, src.Name)
+	for it.Next() {
+		for _, document := range it.Documents() {
+			progress.Documents++
+			if opts.Transform != nil {
+				id := document.ID
+				var err error
+				document, err = opts.Transform(document)
+				if err != nil {
+					return progress, fmt.Errorf("failed to transform document %s: %w", id, err)
+				}
+				if document == nil {
+					progress.Skipped++
+					continue
+				}
+			}
+			if len(document.Vector) > 0 && distanceMetric == "" {
+				return progress, fmt.Errorf("a distance metric is required to migrate documents with vectors")
+			}
+			if err := upserter.Add(ctx, document.upsert()); err != nil {
+				return progress, fmt.Errorf("failed to migrate page %d: %w", progress.Pages+1, err)
+			}
+			progress.Written++
+			if !opts.SkipVerify {
+				sampler.add(document)
+			}
+		}
+		if err := upserter.Flush(ctx); err != nil {
+			return progress, fmt.Errorf("failed to migrate page %d: %w", progress.Pages+1, err)
+		}
+		progress.Pages++
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return progress, fmt.Errorf("failed to migrate page %d: %w", progress.Pages+1, err)
+	}
+
+	if opts.SkipVerify || len(sampler.documents) == 0 {
+		return progress, nil
+	}
+	verified, err := verifyDocuments(ctx, dst, sampler.documents)
+	progress.Verified = verified
+	return progress, err
+}
+
+// documentSampler keeps a uniform random sample of at most size documents using reservoir sampling.
+type documentSampler struct {
+	size      int
+	rand      *rand.Rand
+	seen      int64
+	documents []*Document
+}
+
+func (s *documentSampler) add(document *Document) {
+	s.seen++
+	if len(s.documents) < s.size {
+		s.documents = append(s.documents, document)
+		return
+	}
+	if i := s.rand.Int63n(s.seen); i < int64(s.size) {
+		s.documents[i] = document
+	}
+}
+
+// verifyDocuments reads the given documents back from the namespace and compares them with what was written,
+// returning the number which matched.
+func verifyDocuments(ctx context.Context, namespace *NamespaceClient, expected []*Document) (int, error) {
+	ids := make([]interface{}, len(expected))
+	for i, document := range expected {
+		ids[i] = document.documentID().key()
+	}
+	results, err := namespace.Client.Query(ctx, namespace.Name, &QueryRequest{
+		Filters:           In(IDAttribute, ids),
+		TopK:              len(ids),
+		IncludeVectors:    true,
+		IncludeAttributes: IncludeAllAttributes(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to verify migration: %w", err)
+	}
+	byID := make(map[interface{}]*QueryResult, len(results))
+	for _, result := range results {
+		byID[newDocumentID(result.ID, result.IDUint64).key()] = result
+	}
+
+	verifyErr := &MigrateVerifyError{Sampled: len(expected)}
+	verified := 0
+	for _, document := range expected {
+		result, ok := byID[document.documentID().key()]
+		if !ok {
+			verifyErr.Missing = append(verifyErr.Missing, document.ID)
+			continue
+		}
+		if !documentMatches(document, result) {
+			verifyErr.Mismatched = append(verifyErr.Mismatched, document.ID)
+			continue
+		}
+		verified++
+	}
+	if len(verifyErr.Missing) > 0 || len(verifyErr.Mismatched) > 0 {
+		sort.Strings(verifyErr.Missing)
+		sort.Strings(verifyErr.Mismatched)
+		return verified, verifyErr
+	}
+	return verified, nil
+}
+
+func documentMatches(document *Document, result *QueryResult) bool {
+	if len(document.Vector) != len(result.Vector) {
+		return false
+	}
+	for i := range document.Vector {
+		if document.Vector[i] != result.Vector[i] {
+			return false
+		}
+	}
+
+	expected := map[string]interface{}{}
+	for name, value := range document.Attributes {
+		var decoded interface{}
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			return false
+		}
+		if decoded != nil {
+			expected[name] = decoded
+		}
+	}
+	actual := map[string]interface{}{}
+	if len(result.Attributes) > 0 {
+		if err := json.Unmarshal(result.Attributes, &actual); err != nil {
+			return false
+		}
+	}
+	for name, value := range actual {
+		if value == nil {
+			delete(actual, name)
+		}
+	}
+	return reflect.DeepEqual(expected, actual)
+}
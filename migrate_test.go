@@ -0,0 +1,161 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	renameTitle := func(document *tpuf.Document) (*tpuf.Document, error) {
+		if document.ID == "3" {
+			return nil, nil
+		}
+		document.Attributes["name"] = document.Attributes["title"]
+		delete(document.Attributes, "title")
+		return document, nil
+	}
+
+	tests := []struct {
+		name             string
+		opts             *tpuf.MigrateOptions
+		verifyResponse   string
+		expectedUpserts  []string
+		expectedQuery    string
+		expectedProgress *tpuf.MigrateProgress
+		expectedError    string
+	}{
+		{
+			name: "transforms, skips and verifies",
+			opts: &tpuf.MigrateOptions{
+				Transform: renameTitle,
+				Schema:    tpuf.Schema{"name": &tpuf.Attribute{Type: tpuf.AttributeTypeString}},
+			},
+			verifyResponse: `[{"id":"1","vector":[0.1],"attributes":{"name":"a"}},{"id":"2","vector":[0.2],"attributes":{"name":"b","other":null}}]`,
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","schema":{"name":{"type":"string"}},"upserts":[{"id":"1","vector":[0.1],"attributes":{"name":"a"}}]}`,
+				`{"distance_metric":"cosine_distance","schema":{"name":{"type":"string"}},"upserts":[{"id":"2","vector":[0.2],"attributes":{"name":"b"}}]}`,
+			},
+			expectedQuery:    `{"filters":["id","In",["1","2"]],"top_k":2,"include_vectors":true,"include_attributes":true}`,
+			expectedProgress: &tpuf.MigrateProgress{Pages: 2, Documents: 3, Written: 2, Skipped: 1, Verified: 2},
+		},
+		{
+			name:           "reports mismatched and missing documents",
+			opts:           &tpuf.MigrateOptions{Transform: renameTitle},
+			verifyResponse: `[{"id":"2","vector":[0.2],"attributes":{"title":"b"}}]`,
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","upserts":[{"id":"1","vector":[0.1],"attributes":{"name":"a"}}]}`,
+				`{"distance_metric":"cosine_distance","upserts":[{"id":"2","vector":[0.2],"attributes":{"name":"b"}}]}`,
+			},
+			expectedQuery:    `{"filters":["id","In",["1","2"]],"top_k":2,"include_vectors":true,"include_attributes":true}`,
+			expectedProgress: &tpuf.MigrateProgress{Pages: 2, Documents: 3, Written: 2, Skipped: 1},
+			expectedError:    "migration verification failed for 2 sampled documents: 1 missing (1), 1 mismatched (2)",
+		},
+		{
+			name: "without transform or verification",
+			opts: &tpuf.MigrateOptions{SkipVerify: true},
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","upserts":[{"id":"1","vector":[0.1],"attributes":{"title":"a"}}]}`,
+				`{"distance_metric":"cosine_distance","upserts":[{"id":"2","vector":[0.2],"attributes":{"title":"b"}},{"id":"3","vector":[0.3]}]}`,
+			},
+			expectedProgress: &tpuf.MigrateProgress{Pages: 2, Documents: 3, Written: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &tpuf.Client{
+				ApiToken:     "src-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body := `{"ids":["1"],"vectors":[[0.1]],"attributes":{"title":["a"]},"next_cursor":"page2"}`
+						if req.URL.Query().Get("cursor") == "page2" {
+							body = `{"ids":["2","3"],"vectors":[[0.2],[0.3]],"attributes":{"title":["b",null]}}`
+						}
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+					},
+				},
+			}
+			var upserts []string
+			var query string
+			dst := &tpuf.Client{
+				ApiToken:     "dst-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						response := `{"status":"OK"}`
+						if strings.HasSuffix(req.URL.Path, "/query") {
+							query = string(body)
+							response = tt.verifyResponse
+						} else {
+							upserts = append(upserts, string(body))
+						}
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(response))}, nil
+					},
+				},
+			}
+			dstNamespace := dst.Namespace("dst-ns")
+			dstNamespace.DistanceMetric = tpuf.DistanceMetricCosine
+
+			var progressPages []int
+			tt.opts.Progress = func(progress *tpuf.MigrateProgress) {
+				progressPages = append(progressPages, progress.Pages)
+			}
+			progress, err := tpuf.Migrate(context.Background(), src.Namespace("src-ns"), dstNamespace, tt.opts)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				var verifyErr *tpuf.MigrateVerifyError
+				assert.ErrorAs(t, err, &verifyErr)
+			}
+			assert.Equal(t, tt.expectedProgress, progress)
+			assert.Equal(t, []int{1, 2}, progressPages)
+			assert.Equal(t, len(tt.expectedUpserts), len(upserts), "unexpected number of upserts")
+			for i := range upserts {
+				if i < len(tt.expectedUpserts) {
+					assert.JSONEq(t, tt.expectedUpserts[i], upserts[i], "unexpected upsert body")
+				}
+			}
+			if tt.expectedQuery == "" {
+				assert.Empty(t, query, "unexpected verification query")
+			} else {
+				assert.JSONEq(t, tt.expectedQuery, query, "unexpected verification query")
+			}
+		})
+	}
+}
+
+func TestMigrateTransformError(t *testing.T) {
+	src := &tpuf.Client{
+		ApiToken:     "src-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"ids":["1"],"attributes":{"price":["abc"]}}`))}, nil
+			},
+		},
+	}
+	_, err := tpuf.Migrate(context.Background(), src.Namespace("src-ns"), src.Namespace("dst-ns"), &tpuf.MigrateOptions{
+		Transform: func(document *tpuf.Document) (*tpuf.Document, error) {
+			var price float64
+			if err := json.Unmarshal(document.Attributes["price"], &price); err != nil {
+				return nil, err
+			}
+			return document, nil
+		},
+	})
+
+	assert.EqualError(t, err, "failed to transform document 1: json: cannot unmarshal string into Go value of type float64")
+}
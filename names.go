@@ -0,0 +1,228 @@
+package tpuf
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidName is wrapped by the errors returned for namespace and attribute names which the API would reject.
+var ErrInvalidName = errors.New("invalid name")
+
+// maxNameLength is the maximum length of namespace and attribute names.
+const maxNameLength = 128
+
+// reservedAttributeNames are the top-level document fields, which can't be used as attribute names.
+var reservedAttributeNames = map[string]bool{
+	IDAttribute:     true,
+	VectorAttribute: true,
+}
+
+// ValidateNamespaceName checks that name is a valid namespace name: between 1 and 128 characters, each of
+// which is an ASCII letter or digit, '-', '_' or '.'.
+func ValidateNamespaceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: namespace name is required", ErrInvalidName)
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("%w: namespace %q is longer than %d characters", ErrInvalidName, name, maxNameLength)
+	}
+	for _, r := range name {
+		if !isNamespaceRune(r) {
+			return fmt.Errorf("%w: namespace %q contains %q; only letters, digits, '-', '_' and '.' are allowed", ErrInvalidName, name, r)
+		}
+	}
+	return nil
+}
+
+func isNamespaceRune(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '-' || r == '_' || r == '.'
+}
+
+// ValidateAttributeName checks that name is a valid attribute name: between 1 and 128 bytes, not starting
+// with '$', which is reserved for the API's own fields, and not a reserved name such as "id" or "vector".
+func ValidateAttributeName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: attribute name is required", ErrInvalidName)
+	case len(name) > maxNameLength:
+		return fmt.Errorf("%w: attribute %q is longer than %d characters", ErrInvalidName, name, maxNameLength)
+	case strings.HasPrefix(name, "$"):
+		return fmt.Errorf("%w: attribute %q must not start with '$'", ErrInvalidName, name)
+	case reservedAttributeNames[name]:
+		return fmt.Errorf("%w: attribute %q is reserved", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// ValidateNames checks every attribute name in the schema with ValidateAttributeName, reporting the first
+// invalid name in sorted order.  The reserved "vector" attribute is allowed only with a vector type, which
+// configures the vector field itself.
+func (s Schema) ValidateNames() error {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == VectorAttribute && s[name] != nil {
+			if _, _, ok := s[name].Type.VectorDimensions(); !ok {
+				return fmt.Errorf("invalid schema: %w: attribute %q must have a vector type such as %q, not %q", ErrInvalidName, name, VectorType(1536, VectorDTypeF32), s[name].Type)
+			}
+			continue
+		}
+		if err := ValidateAttributeName(name); err != nil {
+			return fmt.Errorf("invalid schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValidateAttributeNames checks the names of the document's attributes with ValidateAttributeName.  An
+// attribute named like a top-level field, such as "id" or "vector", would otherwise conflict with that field.
+// The first invalid name in sorted order is reported.
+//
+// Attributes given as a struct are checked once per type, from the names its fields are encoded with, so
+// every field is checked even if it's omitted when empty.  Types which implement json.Marshaler are
+// marshaled instead.
+func (u *Upsert) ValidateAttributeNames() error {
+	var names []string
+	switch attributes := u.Attributes.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for name := range attributes {
+			names = append(names, name)
+		}
+	default:
+		value := reflect.ValueOf(attributes)
+		if value.Kind() == reflect.Pointer && value.IsNil() {
+			return nil
+		}
+		if t := reflect.Indirect(value).Type(); t.Kind() == reflect.Struct {
+			if result, ok := structNameValidations.Load(t); ok {
+				return result.(*structNameValidation).err
+			}
+			if names, ok := structAttributeNames(t); ok {
+				result := &structNameValidation{err: validateAttributeNames(names)}
+				structNameValidations.Store(t, result)
+				return result.err
+			}
+		}
+		fields, err := attributeFields(attributes)
+		if err != nil {
+			return err
+		}
+		for name := range fields {
+			names = append(names, name)
+		}
+	}
+	return validateAttributeNames(names)
+}
+
+func validateAttributeNames(names []string) error {
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ValidateAttributeName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structNameValidation is the result of validating the attribute names of a struct type.
+type structNameValidation struct {
+	err error
+}
+
+// structNameValidations caches a *structNameValidation for each struct type used for attributes.
+var structNameValidations sync.Map
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// structAttributeNames returns the names encoding/json encodes a struct type's fields with, including those
+// of embedded structs.  It reports false if the type or an embedded struct marshals itself, in which case
+// its fields don't determine the names.
+func structAttributeNames(t reflect.Type) ([]string, bool) {
+	if implementsMarshaler(t) {
+		return nil, false
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				embeddedNames, ok := structAttributeNames(embedded)
+				if !ok {
+					return nil, false
+				}
+				names = append(names, embeddedNames...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
+
+func implementsMarshaler(t reflect.Type) bool {
+	pointer := reflect.PointerTo(t)
+	return t.Implements(jsonMarshalerType) || pointer.Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || pointer.Implements(textMarshalerType)
+}
+
+// validateUpsertNames checks the attribute names of every document, unless name validation is disabled.
+func (c *Client) validateUpsertNames(upserts []*Upsert) error {
+	if c.DisableNameValidation {
+		return nil
+	}
+	for _, upsert := range upserts {
+		if err := upsert.ValidateAttributeNames(); err != nil {
+			return fmt.Errorf("invalid document %s: %w", upsert.documentID().str, err)
+		}
+	}
+	return nil
+}
+
+// validatePath checks the namespace in an API path, such as /v1/vectors/{namespace}/query, unless name
+// validation is disabled.
+func (c *Client) validatePath(path string) error {
+	if c.DisableNameValidation {
+		return nil
+	}
+	if namespace, ok := cutNamespace(path); ok {
+		return ValidateNamespaceName(namespace)
+	}
+	return nil
+}
+
+// validateSchemaNames checks the attribute names of a schema, unless name validation is disabled.
+func (c *Client) validateSchemaNames(schema Schema) error {
+	if c.DisableNameValidation {
+		return nil
+	}
+	return schema.ValidateNames()
+}
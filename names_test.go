@@ -0,0 +1,180 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateNamespaceName(t *testing.T) {
+	for name, expectedError := range map[string]string{
+		"docs":                   "",
+		"Tenant_42.docs-v2":      "",
+		strings.Repeat("a", 128): "",
+		"":                       "invalid name: namespace name is required",
+		strings.Repeat("a", 129): `invalid name: namespace "` + strings.Repeat("a", 129) + `" is longer than 128 characters`,
+		"my docs":                `invalid name: namespace "my docs" contains ' '; only letters, digits, '-', '_' and '.' are allowed`,
+		"tenant/docs":            `invalid name: namespace "tenant/docs" contains '/'; only letters, digits, '-', '_' and '.' are allowed`,
+		"café":                   `invalid name: namespace "café" contains 'é'; only letters, digits, '-', '_' and '.' are allowed`,
+	} {
+		err := tpuf.ValidateNamespaceName(name)
+		if expectedError == "" {
+			assert.NoError(t, err, name)
+		} else {
+			assert.EqualError(t, err, expectedError, name)
+			assert.ErrorIs(t, err, tpuf.ErrInvalidName)
+		}
+	}
+}
+
+func TestValidateAttributeName(t *testing.T) {
+	for name, expectedError := range map[string]string{
+		"title":                     "",
+		"my attribute.with-ünïcode": "",
+		"identifier":                "",
+		"":                          "invalid name: attribute name is required",
+		strings.Repeat("a", 129):    `invalid name: attribute "` + strings.Repeat("a", 129) + `" is longer than 128 characters`,
+		"$dist":                     `invalid name: attribute "$dist" must not start with '$'`,
+		"id":                        `invalid name: attribute "id" is reserved`,
+		"vector":                    `invalid name: attribute "vector" is reserved`,
+	} {
+		err := tpuf.ValidateAttributeName(name)
+		if expectedError == "" {
+			assert.NoError(t, err, name)
+		} else {
+			assert.EqualError(t, err, expectedError, name)
+		}
+	}
+
+	err := tpuf.Schema{"title": {}, "vector": {}, "$b": {}}.ValidateNames()
+	assert.EqualError(t, err, `invalid schema: invalid name: attribute "$b" must not start with '$'`)
+}
+
+type embeddedAttributes struct {
+	Category string `json:"$category"`
+}
+
+type marshaledAttributes struct {
+	Title string
+}
+
+func (a marshaledAttributes) MarshalJSON() ([]byte, error) {
+	return []byte(`{"id":"1"}`), nil
+}
+
+func TestUpsertValidateAttributeNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		attributes    tpuf.Attributes
+		expectedError string
+	}{
+		{name: "no attributes"},
+		{name: "valid map", attributes: map[string]interface{}{"title": "a"}},
+		{name: "invalid map", attributes: map[string]interface{}{"title": "a", "vector": "b"}, expectedError: `invalid name: attribute "vector" is reserved`},
+		{
+			name: "valid struct",
+			attributes: struct {
+				Title   string `json:"title"`
+				ID      string `json:"-"`
+				Count   int
+				private string
+			}{Title: "a"},
+		},
+		{
+			name: "omitted field of struct",
+			attributes: &struct {
+				Title string `json:"title"`
+				ID    string `json:"id,omitempty"`
+			}{Title: "a"},
+			expectedError: `invalid name: attribute "id" is reserved`,
+		},
+		{
+			name: "embedded struct",
+			attributes: struct {
+				embeddedAttributes
+				Title string `json:"title"`
+			}{},
+			expectedError: `invalid name: attribute "$category" must not start with '$'`,
+		},
+		{name: "nil struct pointer", attributes: (*embeddedAttributes)(nil)},
+		{name: "struct marshaling itself", attributes: marshaledAttributes{}, expectedError: `invalid name: attribute "id" is reserved`},
+		{name: "other map", attributes: map[string]string{"$x": "a"}, expectedError: `invalid name: attribute "$x" must not start with '$'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upsert := &tpuf.Upsert{ID: "1", Attributes: tt.attributes}
+			for i := 0; i < 2; i++ {
+				err := upsert.ValidateAttributeNames()
+				if tt.expectedError != "" {
+					assert.EqualError(t, err, tt.expectedError)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+		})
+	}
+}
+
+func TestClientValidatesNames(t *testing.T) {
+	requests := 0
+	newClient := func(opts ...tpuf.Option) *tpuf.Client {
+		client, err := tpuf.NewClient("test-token", append(opts, tpuf.WithHttpClient(&fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				requests++
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`))}, nil
+			},
+		}))...)
+		assert.NoError(t, err)
+		return client
+	}
+	ctx := context.Background()
+	client := newClient()
+
+	_, err := client.Query(ctx, "my docs", &tpuf.QueryRequest{})
+	assert.ErrorIs(t, err, tpuf.ErrInvalidName)
+	err = client.QueryStream(ctx, "", &tpuf.QueryRequest{}, func(*tpuf.QueryResult) error { return nil })
+	assert.EqualError(t, err, "failed to query documents: invalid name: namespace name is required")
+	_, err = client.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+		Schema:  tpuf.Schema{"id": &tpuf.Attribute{Type: tpuf.AttributeTypeString}},
+		Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{1}}},
+	})
+	assert.EqualError(t, err, `invalid schema: invalid name: attribute "id" is reserved`)
+	_, err = client.UpdateSchema(ctx, "docs", tpuf.Schema{"vector": &tpuf.Attribute{Type: tpuf.AttributeTypeFloatArray}})
+	assert.EqualError(t, err, `invalid schema: invalid name: attribute "vector" must have a vector type such as "[1536]f32", not "[]float"`)
+	_, err = client.UpdateSchema(ctx, "docs", tpuf.Schema{"$x": &tpuf.Attribute{}})
+	assert.True(t, errors.Is(err, tpuf.ErrInvalidName))
+	_, err = client.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+		Upserts: []*tpuf.Upsert{
+			{ID: "1", Vector: []float32{1}, Attributes: map[string]interface{}{"title": "ok"}},
+			{ID: "2", Vector: []float32{1}, Attributes: map[string]interface{}{"title": "bad", "id": "3"}},
+		},
+	})
+	assert.EqualError(t, err, `invalid document 2: invalid name: attribute "id" is reserved`)
+	_, err = client.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+		Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{1}, Attributes: struct {
+			Vector []float32 `json:"vector"`
+		}{Vector: []float32{2}}}},
+	})
+	assert.EqualError(t, err, `invalid document 1: invalid name: attribute "vector" is reserved`)
+	assert.Equal(t, 0, requests, "invalid requests should not be sent")
+
+	_, err = client.Namespaces(ctx, &tpuf.NamespacesRequest{})
+	assert.NoError(t, err, "requests which don't target a namespace should not be validated")
+	assert.Equal(t, 1, requests)
+
+	client = newClient(tpuf.WithoutNameValidation())
+	_, err = client.Upsert(ctx, "my docs", &tpuf.UpsertRequest{
+		Schema:  tpuf.Schema{"$x": &tpuf.Attribute{}},
+		Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{1}, Attributes: map[string]interface{}{"id": "1"}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,274 @@
+package tpuf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// NamespaceClient is a handle to a single namespace, whose methods mirror those of Client without the
+// namespace parameter.  It may also carry defaults applied to requests which don't set them.
+// Create one with Client.Namespace.
+type NamespaceClient struct {
+	// Client is the client used to make requests.
+	Client *Client
+	// Name is the name of the namespace.
+	Name string
+
+	// DistanceMetric is the namespace's distance metric, used for upserts and vector queries which don't set
+	// one.  Requests which set a different metric fail with ErrDistanceMetricMismatch.
+	DistanceMetric DistanceMetric
+	// Schema is the default schema sent with upserts, and the desired schema for Ensure.
+	Schema Schema
+	// IncludeAttributes is the default set of attributes to include in query results.
+	IncludeAttributes *IncludeAttributes
+}
+
+// Namespace returns a handle to the named namespace.  Set fields on the returned handle to configure
+// per-namespace defaults, e.g.
+//
+//	docs := client.Namespace("docs-prod")
+//	docs.DistanceMetric = tpuf.DistanceMetricCosine
+//	results, err := docs.Query(ctx, &tpuf.QueryRequest{Vector: vector, TopK: 10})
+func (c *Client) Namespace(name string) *NamespaceClient {
+	return &NamespaceClient{Client: c, Name: name}
+}
+
+// Upsert creates or updates documents in the namespace.  See Client.Upsert.
+func (n *NamespaceClient) Upsert(ctx context.Context, request *UpsertRequest) (*UpsertResponse, error) {
+	request, err := n.upsertRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	return n.Client.Upsert(ctx, n.Name, request)
+}
+
+// Delete deletes documents by ID.  See Client.Delete.
+func (n *NamespaceClient) Delete(ctx context.Context, ids []string) (*DeleteReport, error) {
+	return n.Client.Delete(ctx, n.Name, ids)
+}
+
+// DeleteUint64 deletes documents by numeric ID.  See Client.DeleteUint64.
+func (n *NamespaceClient) DeleteUint64(ctx context.Context, ids []uint64) (*DeleteReport, error) {
+	return n.Client.DeleteUint64(ctx, n.Name, ids)
+}
+
+// DeleteIf deletes the given documents which match condition.  See Client.DeleteIf.
+func (n *NamespaceClient) DeleteIf(ctx context.Context, ids []string, condition Filter) (*DeleteResponse, error) {
+	return n.Client.DeleteIf(ctx, n.Name, ids, condition)
+}
+
+// DeleteByFilter deletes all documents matching filter.  See Client.DeleteByFilter.
+func (n *NamespaceClient) DeleteByFilter(ctx context.Context, filter Filter) (*DeleteResponse, error) {
+	return n.Client.DeleteByFilter(ctx, n.Name, filter)
+}
+
+// PreviewDeleteByFilter returns documents which DeleteByFilter would delete.  See Client.PreviewDeleteByFilter.
+func (n *NamespaceClient) PreviewDeleteByFilter(ctx context.Context, filter Filter, limit int) ([]*QueryResult, error) {
+	return n.Client.PreviewDeleteByFilter(ctx, n.Name, filter, limit)
+}
+
+// Query queries documents in the namespace.  See Client.Query.
+func (n *NamespaceClient) Query(ctx context.Context, request *QueryRequest) ([]*QueryResult, error) {
+	request, err := n.queryRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	return n.Client.Query(ctx, n.Name, request)
+}
+
+// QueryWithMetadata is like Query, but also returns performance metadata.  See Client.QueryWithMetadata.
+func (n *NamespaceClient) QueryWithMetadata(ctx context.Context, request *QueryRequest) ([]*QueryResult, *QueryMetadata, error) {
+	request, err := n.queryRequest(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n.Client.QueryWithMetadata(ctx, n.Name, request)
+}
+
+// QueryStream runs a query, calling fn for each result.  See Client.QueryStream.
+func (n *NamespaceClient) QueryStream(ctx context.Context, request *QueryRequest, fn func(*QueryResult) error) error {
+	request, err := n.queryRequest(request)
+	if err != nil {
+		return err
+	}
+	return n.Client.QueryStream(ctx, n.Name, request, fn)
+}
+
+// QueryWarm warms the namespace cache and then queries it.  See Client.QueryWarm.
+func (n *NamespaceClient) QueryWarm(ctx context.Context, request *QueryRequest, opts *QueryWarmOptions) ([]*QueryResult, error) {
+	request, err := n.queryRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	return n.Client.QueryWarm(ctx, n.Name, request, opts)
+}
+
+// MultiQuery runs several queries concurrently.  See Client.MultiQuery.
+func (n *NamespaceClient) MultiQuery(ctx context.Context, requests []*QueryRequest) ([][]*QueryResult, error) {
+	withDefaults := make([]*QueryRequest, len(requests))
+	for i, request := range requests {
+		withDefault, err := n.queryRequest(request)
+		if err != nil {
+			return nil, err
+		}
+		withDefaults[i] = withDefault
+	}
+	return n.Client.MultiQuery(ctx, n.Name, withDefaults)
+}
+
+// HybridQuery runs a fused vector and full-text search.  See Client.HybridQuery.
+func (n *NamespaceClient) HybridQuery(ctx context.Context, request *HybridRequest) ([]*HybridResult, error) {
+	withDefaults := *request
+	metric, err := n.distanceMetric(withDefaults.DistanceMetric)
+	if err != nil {
+		return nil, err
+	}
+	withDefaults.DistanceMetric = metric
+	if withDefaults.IncludeAttributes == nil {
+		withDefaults.IncludeAttributes = n.IncludeAttributes
+	}
+	return n.Client.HybridQuery(ctx, n.Name, &withDefaults)
+}
+
+// Aggregate computes aggregations over documents in the namespace.  See Client.Aggregate.
+func (n *NamespaceClient) Aggregate(ctx context.Context, request *AggregateRequest) (*AggregateResult, error) {
+	return n.Client.Aggregate(ctx, n.Name, request)
+}
+
+// Count returns the number of documents matching filter.  See Client.Count.
+func (n *NamespaceClient) Count(ctx context.Context, filter Filter) (int64, error) {
+	return n.Client.Count(ctx, n.Name, filter)
+}
+
+// WaitFor waits until at least expectedMinCount documents match the filter.  See Client.WaitFor.
+func (n *NamespaceClient) WaitFor(ctx context.Context, filter Filter, expectedMinCount int64, timeout time.Duration) (int64, error) {
+	return n.Client.WaitFor(ctx, n.Name, filter, expectedMinCount, timeout)
+}
+
+// Export exports a page of documents.  See Client.Export.
+func (n *NamespaceClient) Export(ctx context.Context, cursor string) (*ExportResponse, error) {
+	return n.Client.Export(ctx, n.Name, cursor)
+}
+
+// ExportIter returns an iterator over every page of documents.  See Client.ExportIter.
+func (n *NamespaceClient) ExportIter(ctx context.Context) *ExportIterator {
+	return n.Client.ExportIter(ctx, n.Name)
+}
+
+// ExportNamespace fetches the schema and every document, with DistanceMetric set to the handle's default.
+// See Client.ExportNamespace.
+func (n *NamespaceClient) ExportNamespace(ctx context.Context) (*NamespaceExport, error) {
+	export, err := n.Client.ExportNamespace(ctx, n.Name)
+	if err != nil {
+		return nil, err
+	}
+	export.DistanceMetric = n.DistanceMetric
+	return export, nil
+}
+
+// ExportChan streams every document over a channel.  See Client.ExportChan.
+func (n *NamespaceClient) ExportChan(ctx context.Context) (<-chan Document, <-chan error) {
+	return n.Client.ExportChan(ctx, n.Name)
+}
+
+// ExportAll writes every document as newline-delimited JSON.  See Client.ExportAll.
+func (n *NamespaceClient) ExportAll(ctx context.Context, w io.Writer, opts *ExportAllOptions) (int64, error) {
+	return n.Client.ExportAll(ctx, n.Name, w, opts)
+}
+
+// Recall evaluates the recall of the namespace's index.  See Client.Recall.
+func (n *NamespaceClient) Recall(ctx context.Context, request *RecallRequest) (*RecallResponse, error) {
+	return n.Client.Recall(ctx, n.Name, request)
+}
+
+// RecallSweep measures recall across several TopK values and filters.  See Client.RecallSweep.
+func (n *NamespaceClient) RecallSweep(ctx context.Context, topKs []int, numQueries int, filters ...Filter) ([]*RecallSweepResult, error) {
+	return n.Client.RecallSweep(ctx, n.Name, topKs, numQueries, filters...)
+}
+
+// WarmCache hints that the namespace is about to be queried.  See Client.WarmCache.
+func (n *NamespaceClient) WarmCache(ctx context.Context) (*WarmCacheResponse, error) {
+	return n.Client.WarmCache(ctx, n.Name)
+}
+
+// GetSchema fetches the live schema of the namespace.  See Client.Schema.
+func (n *NamespaceClient) GetSchema(ctx context.Context) (Schema, error) {
+	return n.Client.Schema(ctx, n.Name)
+}
+
+// UpdateSchema changes the schema of the namespace.  See Client.UpdateSchema.
+func (n *NamespaceClient) UpdateSchema(ctx context.Context, schema Schema) (Schema, error) {
+	return n.Client.UpdateSchema(ctx, n.Name, schema)
+}
+
+// Ensure brings the namespace in line with the handle's Schema and DistanceMetric.  See Client.EnsureNamespace.
+func (n *NamespaceClient) Ensure(ctx context.Context, dryRun bool) (*EnsureNamespaceResult, error) {
+	return n.Client.EnsureNamespace(ctx, n.Name, n.Schema, &EnsureNamespaceOptions{
+		DistanceMetric: n.DistanceMetric,
+		DryRun:         dryRun,
+	})
+}
+
+// CopyFrom copies all documents from the source namespace into this one.  See Client.CopyNamespace.
+func (n *NamespaceClient) CopyFrom(ctx context.Context, src string, opts *CopyNamespaceOptions) error {
+	return n.Client.CopyNamespace(ctx, n.Name, src, opts)
+}
+
+// DeleteNamespace deletes the namespace entirely, including all documents.  See Client.DeleteNamespace.
+func (n *NamespaceClient) DeleteNamespace(ctx context.Context) error {
+	return n.Client.DeleteNamespace(ctx, n.Name)
+}
+
+// BulkUpserter returns a BulkUpserter for the namespace, configured with the handle's defaults.
+func (n *NamespaceClient) BulkUpserter() *BulkUpserter {
+	return &BulkUpserter{
+		Client:         n.Client,
+		Namespace:      n.Name,
+		DistanceMetric: n.DistanceMetric,
+		Schema:         n.Schema,
+	}
+}
+
+// upsertRequest returns the request with the handle's defaults applied, without modifying it.
+func (n *NamespaceClient) upsertRequest(request *UpsertRequest) (*UpsertRequest, error) {
+	withDefaults := *request
+	metric, err := n.distanceMetric(withDefaults.DistanceMetric)
+	if err != nil {
+		return nil, err
+	}
+	withDefaults.DistanceMetric = metric
+	if withDefaults.Schema == nil {
+		withDefaults.Schema = n.Schema
+	}
+	return &withDefaults, nil
+}
+
+// queryRequest returns the request with the handle's defaults applied, without modifying it.
+func (n *NamespaceClient) queryRequest(request *QueryRequest) (*QueryRequest, error) {
+	withDefaults := *request
+	if len(withDefaults.Vector) > 0 {
+		metric, err := n.distanceMetric(withDefaults.DistanceMetric)
+		if err != nil {
+			return nil, err
+		}
+		withDefaults.DistanceMetric = metric
+	}
+	if withDefaults.IncludeAttributes == nil {
+		withDefaults.IncludeAttributes = n.IncludeAttributes
+	}
+	return &withDefaults, nil
+}
+
+// distanceMetric returns the metric to use for a request which specified requested, which may be empty,
+// failing if it differs from the handle's DistanceMetric.
+func (n *NamespaceClient) distanceMetric(requested DistanceMetric) (DistanceMetric, error) {
+	switch {
+	case requested == "":
+		return n.DistanceMetric, nil
+	case n.DistanceMetric != "" && requested != n.DistanceMetric:
+		return "", fmt.Errorf("%w: request uses %s, but namespace %s is configured with %s", ErrDistanceMetricMismatch, requested, n.Name, n.DistanceMetric)
+	}
+	return requested, nil
+}
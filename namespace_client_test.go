@@ -0,0 +1,158 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceClient(t *testing.T) {
+	tests := []struct {
+		name         string
+		call         func(ns *tpuf.NamespaceClient) error
+		expectedURL  string
+		expectedBody string
+	}{
+		{
+			name: "upsert applies defaults",
+			call: func(ns *tpuf.NamespaceClient) error {
+				_, err := ns.Upsert(context.Background(), &tpuf.UpsertRequest{
+					Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1}}},
+				})
+				return err
+			},
+			expectedURL:  "https://api.turbopuffer.com/v1/vectors/docs-prod",
+			expectedBody: `{"distance_metric":"cosine_distance","schema":{"title":{"type":"string"}},"upserts":[{"id":"1","vector":[0.1]}]}`,
+		},
+		{
+			name: "upsert keeps explicit values",
+			call: func(ns *tpuf.NamespaceClient) error {
+				_, err := ns.Upsert(context.Background(), &tpuf.UpsertRequest{
+					DistanceMetric: tpuf.DistanceMetricCosine,
+					Schema:         tpuf.Schema{},
+					Upserts:        []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1}}},
+				})
+				return err
+			},
+			expectedURL:  "https://api.turbopuffer.com/v1/vectors/docs-prod",
+			expectedBody: `{"distance_metric":"cosine_distance","upserts":[{"id":"1","vector":[0.1]}]}`,
+		},
+		{
+			name: "vector query applies defaults",
+			call: func(ns *tpuf.NamespaceClient) error {
+				_, err := ns.Query(context.Background(), &tpuf.QueryRequest{Vector: []float32{0.1}, TopK: 5})
+				return err
+			},
+			expectedURL:  "https://api.turbopuffer.com/v1/vectors/docs-prod/query",
+			expectedBody: `{"vector":[0.1],"distance_metric":"cosine_distance","top_k":5,"include_attributes":["title"]}`,
+		},
+		{
+			name: "filter-only query omits distance metric",
+			call: func(ns *tpuf.NamespaceClient) error {
+				_, err := ns.Query(context.Background(), &tpuf.QueryRequest{
+					Filters:           tpuf.Eq("title", "a"),
+					IncludeAttributes: tpuf.IncludeAllAttributes(),
+				})
+				return err
+			},
+			expectedURL:  "https://api.turbopuffer.com/v1/vectors/docs-prod/query",
+			expectedBody: `{"filters":["title","Eq","a"],"include_attributes":true}`,
+		},
+		{
+			name: "count",
+			call: func(ns *tpuf.NamespaceClient) error {
+				_, err := ns.Count(context.Background(), nil)
+				return err
+			},
+			expectedURL:  "https://api.turbopuffer.com/v1/vectors/docs-prod/query",
+			expectedBody: `{"aggregate_by":{"count":["Count"]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, tt.expectedURL, req.URL.String(), "unexpected request URL")
+						body, _ := io.ReadAll(req.Body)
+						assert.JSONEq(t, tt.expectedBody, string(body), "unexpected request body")
+
+						response := `{"status":"OK"}`
+						if strings.HasSuffix(req.URL.Path, "/query") {
+							response = `[]`
+							if strings.Contains(string(body), "aggregate_by") {
+								response = `{"aggregations":{"count":0}}`
+							}
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(response)),
+						}, nil
+					},
+				},
+			}
+
+			ns := client.Namespace("docs-prod")
+			ns.DistanceMetric = tpuf.DistanceMetricCosine
+			ns.Schema = tpuf.Schema{"title": &tpuf.Attribute{Type: tpuf.AttributeTypeString}}
+			ns.IncludeAttributes = tpuf.IncludeAttributeNames("title")
+
+			assert.NoError(t, tt.call(ns))
+		})
+	}
+}
+
+func TestNamespaceClientDistanceMetricMismatch(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				t.Fatal("no request should be sent")
+				return nil, nil
+			},
+		},
+	}
+	ns := client.Namespace("docs-prod")
+	ns.DistanceMetric = tpuf.DistanceMetricCosine
+
+	_, queryErr := ns.Query(context.Background(), &tpuf.QueryRequest{Vector: []float32{0.1}, DistanceMetric: tpuf.DistanceMetricEuclidean})
+	_, upsertErr := ns.Upsert(context.Background(), &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricEuclidean,
+		Upserts:        []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1}}},
+	})
+
+	for _, err := range []error{queryErr, upsertErr} {
+		assert.ErrorIs(t, err, tpuf.ErrDistanceMetricMismatch)
+		assert.EqualError(t, err, "distance metric mismatch: request uses euclidean_squared, but namespace docs-prod is configured with cosine_distance")
+	}
+}
+
+func TestNamespaceClientDoesNotModifyRequest(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`[]`)),
+				}, nil
+			},
+		},
+	}
+	ns := client.Namespace("docs-prod")
+	ns.DistanceMetric = tpuf.DistanceMetricCosine
+
+	request := &tpuf.QueryRequest{Vector: []float32{0.1}}
+	_, err := ns.Query(context.Background(), request)
+
+	assert.NoError(t, err)
+	assert.Equal(t, tpuf.DistanceMetric(""), request.DistanceMetric, "request was modified")
+}
@@ -3,9 +3,14 @@ package tpuf
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type NamespaceCursor string
@@ -17,6 +22,16 @@ type NamespacesRequest struct {
 	PageSize int `json:"page_size,omitempty"`
 	// Cursor the cursor to use for pagination.  Omit to get the first page.
 	Cursor NamespaceCursor `json:"cursor,omitempty"`
+	// Suffix, if set, drops namespaces which don't end with it.  Unlike Prefix, this is applied client-side,
+	// so pages may have fewer than PageSize namespaces, or none, even when NextCursor is set.
+	Suffix string `json:"-"`
+	// Match, if set, drops namespaces whose names it doesn't match.  Like Suffix, this is applied client-side.
+	Match *regexp.Regexp `json:"-"`
+}
+
+// matches reports whether the namespace passes the request's client-side filters.
+func (r *NamespacesRequest) matches(namespace *Namespace) bool {
+	return strings.HasSuffix(namespace.ID, r.Suffix) && (r.Match == nil || r.Match.MatchString(namespace.ID))
 }
 
 type Namespace struct {
@@ -30,11 +45,12 @@ type NamespacesResponse struct {
 	NextCursor NamespaceCursor `json:"next_cursor,omitempty"`
 }
 
-// Namespaces lists all namespaces, optionally filtered by prefix.
+// Namespaces lists all namespaces, optionally filtered by prefix, suffix or pattern.
 // This query is paginated according to the input page size.  The returned NextCursor may be used to fetch the next page.
+// Namespaces are returned in the server's order; the API doesn't support choosing a sort order.
 // See https://turbopuffer.com/docs/namespaces for more details.
 func (c *Client) Namespaces(ctx context.Context, request *NamespacesRequest) (*NamespacesResponse, error) {
-	path := "/v1/vectors"
+	path := c.namespacesPath()
 	params := url.Values{}
 	if request.PageSize > 0 {
 		params.Set("page_size", strconv.Itoa(request.PageSize))
@@ -46,7 +62,7 @@ func (c *Client) Namespaces(ctx context.Context, request *NamespacesRequest) (*N
 		params.Set("cursor", string(request.Cursor))
 	}
 
-	respData, err := c.get(ctx, path, params)
+	respData, err := c.get(ctx, OperationNamespaces, path, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
@@ -55,18 +71,291 @@ func (c *Client) Namespaces(ctx context.Context, request *NamespacesRequest) (*N
 	if err := json.Unmarshal(respData, &response); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	if request.Suffix != "" || request.Match != nil {
+		matching := response.Namespaces[:0]
+		for _, namespace := range response.Namespaces {
+			if request.matches(namespace) {
+				matching = append(matching, namespace)
+			}
+		}
+		response.Namespaces = matching
+	}
 
 	return &response, nil
 }
 
+// NamespaceIDs returns the names of all namespaces matching the request, following cursors from
+// request.Cursor until the last page.  The request is not modified.
+// For accounts with many namespaces, set a Prefix where possible, since Suffix and Match are applied client-side
+// and so still page through every namespace.
+func (c *Client) NamespaceIDs(ctx context.Context, request *NamespacesRequest) ([]string, error) {
+	page := *request
+	var ids []string
+	for {
+		response, err := c.Namespaces(ctx, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, namespace := range response.Namespaces {
+			ids = append(ids, namespace.ID)
+		}
+		if response.NextCursor == "" {
+			return ids, nil
+		}
+		page.Cursor = response.NextCursor
+	}
+}
+
 // DeleteNamespace deletes a namespace entirely, including all documents.
 // See https://turbopuffer.com/docs/delete-namespace for more details.
 func (c *Client) DeleteNamespace(ctx context.Context, namespace string) error {
-	path := fmt.Sprintf("/v1/vectors/%s", namespace)
-	_, err := c.delete(ctx, path)
+	path := c.namespacePath(namespace, "")
+	event := &AuditEvent{Operation: OperationDeleteNamespace, Namespace: namespace, Time: time.Now()}
+	_, err := c.delete(ctx, OperationDeleteNamespace, path)
+	if err != nil {
+		err = fmt.Errorf("failed to delete namespace: %w", err)
+	}
+	c.audit(ctx, event, nil, err)
+	return err
+}
+
+const defaultPollInterval = time.Second
+
+type CopyNamespaceOptions struct {
+	// AllowNonEmpty skips the check that the destination namespace is empty.
+	// Documents in the source namespace overwrite documents in the destination with the same ID.
+	AllowNonEmpty bool
+	// WaitUntilQueryable polls the destination namespace after the copy is issued until it returns documents.
+	// Use the context to bound how long to wait.  Note that this never completes if the source is empty.
+	WaitUntilQueryable bool
+	// PollInterval is the time between polls while waiting.  Defaults to 1 second.
+	PollInterval time.Duration
+}
+
+// ErrNamespaceNotEmpty is returned by CopyNamespace when the destination namespace already contains documents.
+var ErrNamespaceNotEmpty = errors.New("destination namespace is not empty")
+
+// CopyNamespace copies all documents from the source namespace into the destination namespace
+// server-side, using copy_from_namespace.  opts may be nil.
+// By default, the destination must be empty or not yet exist.
+// See https://turbopuffer.com/docs/upsert for more details.
+func (c *Client) CopyNamespace(ctx context.Context, dst string, src string, opts *CopyNamespaceOptions) error {
+	if opts == nil {
+		opts = &CopyNamespaceOptions{}
+	}
+
+	if !opts.AllowNonEmpty {
+		empty, err := c.isEmpty(ctx, dst)
+		if err != nil {
+			return fmt.Errorf("failed to check destination namespace: %w", err)
+		}
+		if !empty {
+			return fmt.Errorf("failed to copy namespace %s to %s: %w", src, dst, ErrNamespaceNotEmpty)
+		}
+	}
+
+	_, err := c.Upsert(ctx, dst, &UpsertRequest{CopyFromNamespace: src})
+	if err != nil {
+		return fmt.Errorf("failed to copy namespace %s to %s: %w", src, dst, err)
+	}
+
+	if !opts.WaitUntilQueryable {
+		return nil
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	for {
+		empty, err := c.isEmpty(ctx, dst)
+		if err != nil {
+			return fmt.Errorf("failed to wait for copy to complete: %w", err)
+		}
+		if !empty {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for copy to complete: %w", ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// isEmpty reports whether the namespace has no documents, treating a missing namespace as empty.
+func (c *Client) isEmpty(ctx context.Context, namespace string) (bool, error) {
+	results, err := c.Query(ctx, namespace, &QueryRequest{TopK: 1})
+	if isNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(results) == 0, nil
+}
+
+type EnsureNamespaceOptions struct {
+	// DistanceMetric is the distance metric to configure if the namespace is created.
+	DistanceMetric DistanceMetric
+	// DryRun computes the changes which would be made without making them.
+	DryRun bool
+}
+
+// EnsureNamespaceResult describes the changes made by EnsureNamespace.
+type EnsureNamespaceResult struct {
+	// Created is true if the namespace didn't exist and was created with the desired schema.
+	Created bool
+	// Added lists the desired attributes which were missing from the live schema, sorted by name.
+	Added []string
+	// Updated lists the attributes whose filterable or full-text search settings were changed, sorted by name.
+	Updated []string
+}
+
+// SchemaConflict describes a desired attribute which can't be applied to the live schema.
+type SchemaConflict struct {
+	Attribute string
+	Live      *Attribute
+	Desired   *Attribute
+	Reason    string
+}
+
+// SchemaConflictError is returned by EnsureNamespace when the live schema can't be migrated to the
+// desired schema.  No changes are made when this is returned.
+type SchemaConflictError struct {
+	Namespace string
+	Conflicts []*SchemaConflict
+}
+
+func (e *SchemaConflictError) Error() string {
+	reasons := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		reasons[i] = fmt.Sprintf("%s: %s", conflict.Attribute, conflict.Reason)
+	}
+	return fmt.Sprintf("incompatible schema for namespace %s: %s", e.Namespace, strings.Join(reasons, "; "))
+}
+
+// EnsureNamespace idempotently brings a namespace in line with the desired schema, for use on service startup.
+// If the namespace doesn't exist, it is created by a write carrying only the schema.  Otherwise, the live schema
+// is compared against the desired one: missing attributes are added and filterable and full-text search settings
+// are updated.  Attributes in the live schema but not the desired one are left alone.  If any desired attribute
+// has a different type than the live attribute, or a different tokenizer or language for full-text search,
+// which would require reindexing, a *SchemaConflictError is returned and nothing is changed.
+// opts may be nil.
+func (c *Client) EnsureNamespace(ctx context.Context, namespace string, desired Schema, opts *EnsureNamespaceOptions) (*EnsureNamespaceResult, error) {
+	if opts == nil {
+		opts = &EnsureNamespaceOptions{}
+	}
+
+	live, err := c.Schema(ctx, namespace)
+	if isNotFound(err) {
+		if !opts.DryRun {
+			_, err := c.Upsert(ctx, namespace, &UpsertRequest{DistanceMetric: opts.DistanceMetric, Schema: desired})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create namespace: %w", err)
+			}
+		}
+		return &EnsureNamespaceResult{Created: true}, nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete namespace: %w", err)
+		return nil, err
+	}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &EnsureNamespaceResult{}
+	changes := Schema{}
+	var conflicts []*SchemaConflict
+	for _, name := range names {
+		want := desired[name]
+		if want == nil {
+			continue
+		}
+		have, ok := live[name]
+		if !ok || have == nil {
+			result.Added = append(result.Added, name)
+			changes[name] = want
+			continue
+		}
+		if reason := attributeConflict(have, want); reason != "" {
+			conflicts = append(conflicts, &SchemaConflict{
+				Attribute: name,
+				Live:      have,
+				Desired:   want,
+				Reason:    reason,
+			})
+			continue
+		}
+		if !attributeSatisfies(have, want) {
+			result.Updated = append(result.Updated, name)
+			changes[name] = want
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &SchemaConflictError{Namespace: namespace, Conflicts: conflicts}
 	}
 
-	return nil
+	if len(changes) > 0 && !opts.DryRun {
+		if _, err := c.UpdateSchema(ctx, namespace, changes); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// attributeConflict describes why the live attribute can't be changed to the desired one, or returns "" if it
+// can.  Types can't be changed, nor can the tokenizer or language of a full-text searchable attribute.
+func attributeConflict(live *Attribute, desired *Attribute) string {
+	if desired.Type != "" && desired.Type != live.Type {
+		liveDims, _, liveVector := live.Type.VectorDimensions()
+		desiredDims, _, desiredVector := desired.Type.VectorDimensions()
+		if liveVector && desiredVector && liveDims != desiredDims {
+			return fmt.Sprintf("vector dimensions can't be changed from %d to %d", liveDims, desiredDims)
+		}
+		return fmt.Sprintf("type can't be changed from %s to %s", live.Type, desired.Type)
+	}
+	if live.FullTextSearch == nil || desired.FullTextSearch == nil {
+		return ""
+	}
+	have, want := live.FullTextSearch, desired.FullTextSearch
+	if want.Tokenizer != "" && want.Tokenizer != have.tokenizer() {
+		return fmt.Sprintf("tokenizer can't be changed from %s to %s", have.tokenizer(), want.Tokenizer)
+	}
+	if want.Language != "" && want.Language != have.language() {
+		return fmt.Sprintf("language can't be changed from %s to %s", have.language(), want.Language)
+	}
+	return ""
+}
+
+// attributeSatisfies reports whether the live attribute already has every setting specified by the desired one.
+func attributeSatisfies(live *Attribute, desired *Attribute) bool {
+	if !boolSatisfies(live.Filterable, desired.Filterable) || !boolSatisfies(live.ANN, desired.ANN) ||
+		!boolSatisfies(live.Regex, desired.Regex) {
+		return false
+	}
+	if desired.FullTextSearch == nil {
+		return true
+	}
+	if live.FullTextSearch == nil {
+		return false
+	}
+	have, want := live.FullTextSearch, desired.FullTextSearch
+	return (want.Language == "" || want.Language == have.language()) &&
+		(want.Tokenizer == "" || want.Tokenizer == have.tokenizer()) &&
+		boolSatisfies(have.Stemming, want.Stemming) &&
+		boolSatisfies(have.RemoveStopWords, want.RemoveStopWords) &&
+		boolSatisfies(have.CaseSensitive, want.CaseSensitive) &&
+		floatSatisfies(have.K1, want.K1) &&
+		floatSatisfies(have.B, want.B)
+}
+
+func boolSatisfies(live *bool, desired *bool) bool {
+	return desired == nil || (live != nil && *live == *desired)
+}
+
+func floatSatisfies(live *float64, desired *float64) bool {
+	return desired == nil || (live != nil && *live == *desired)
 }
@@ -5,7 +5,9 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/bamo/tpuf-go"
 	"github.com/stretchr/testify/assert"
@@ -75,6 +77,35 @@ func TestNamespaces(t *testing.T) {
 				NextCursor: "",
 			},
 		},
+		{
+			name: "filter namespaces client-side",
+			request: &tpuf.NamespacesRequest{
+				Prefix: "tenant-",
+				Suffix: "-prod",
+				Match:  regexp.MustCompile(`^tenant-[0-9]+-`),
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(bytes.NewBufferString(`{
+					"namespaces": [
+						{"id": "tenant-1-prod"},
+						{"id": "tenant-1-staging"},
+						{"id": "tenant-abc-prod"},
+						{"id": "tenant-2-prod"}
+					],
+					"next_cursor": "next_page_cursor"
+				}`)),
+			},
+			expectedMethod: http.MethodGet,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors?prefix=tenant-",
+			expectedResult: &tpuf.NamespacesResponse{
+				Namespaces: []*tpuf.Namespace{
+					{ID: "tenant-1-prod"},
+					{ID: "tenant-2-prod"},
+				},
+				NextCursor: "next_page_cursor",
+			},
+		},
 		{
 			name: "list namespaces error",
 			request: &tpuf.NamespacesRequest{
@@ -120,6 +151,34 @@ func TestNamespaces(t *testing.T) {
 	}
 }
 
+func TestNamespaceIDs(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"namespaces":[{"id":"a-prod"},{"id":"b-staging"}],"next_cursor":"c1"}`,
+		"c1": `{"namespaces":[{"id":"c-staging"}],"next_cursor":"c2"}`,
+		"c2": `{"namespaces":[{"id":"d-prod"}]}`,
+	}
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body, ok := pages[req.URL.Query().Get("cursor")]
+				assert.True(t, ok, "unexpected cursor in %s", req.URL)
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+			},
+		},
+	}
+
+	request := &tpuf.NamespacesRequest{Suffix: "-prod"}
+	ids, err := client.NamespaceIDs(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a-prod", "d-prod"}, ids)
+	assert.Equal(t, tpuf.NamespaceCursor(""), request.Cursor, "request should not be modified")
+
+	ids, err = client.NamespaceIDs(context.Background(), &tpuf.NamespacesRequest{Cursor: "c1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c-staging", "d-prod"}, ids)
+}
+
 func TestDeleteNamespace(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -180,3 +239,294 @@ func TestDeleteNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyNamespace(t *testing.T) {
+	type request struct {
+		method string
+		url    string
+		body   string
+	}
+	queryURL := "https://api.turbopuffer.com/v1/vectors/dst-namespace/query"
+	upsertURL := "https://api.turbopuffer.com/v1/vectors/dst-namespace"
+	notFound := `{"error":"Namespace not found","status":"error"}`
+
+	tests := []struct {
+		name             string
+		opts             *tpuf.CopyNamespaceOptions
+		httpStatuses     []int
+		httpBodies       []string
+		expectedError    string
+		expectedRequests []request
+	}{
+		{
+			name:         "copy into missing namespace",
+			httpStatuses: []int{http.StatusNotFound, http.StatusOK},
+			httpBodies:   []string{notFound, `{"status":"OK"}`},
+			expectedRequests: []request{
+				{http.MethodPost, queryURL, `{"top_k":1}`},
+				{http.MethodPost, upsertURL, `{"copy_from_namespace":"src-namespace"}`},
+			},
+		},
+		{
+			name:          "destination not empty",
+			httpStatuses:  []int{http.StatusOK},
+			httpBodies:    []string{`[{"id":"1","dist":0}]`},
+			expectedError: "failed to copy namespace src-namespace to dst-namespace: destination namespace is not empty",
+			expectedRequests: []request{
+				{http.MethodPost, queryURL, `{"top_k":1}`},
+			},
+		},
+		{
+			name:         "allow non-empty destination",
+			opts:         &tpuf.CopyNamespaceOptions{AllowNonEmpty: true},
+			httpStatuses: []int{http.StatusOK},
+			httpBodies:   []string{`{"status":"OK"}`},
+			expectedRequests: []request{
+				{http.MethodPost, upsertURL, `{"copy_from_namespace":"src-namespace"}`},
+			},
+		},
+		{
+			name:         "wait until queryable",
+			opts:         &tpuf.CopyNamespaceOptions{WaitUntilQueryable: true, PollInterval: time.Millisecond},
+			httpStatuses: []int{http.StatusOK, http.StatusOK, http.StatusNotFound, http.StatusOK, http.StatusOK},
+			httpBodies:   []string{`[]`, `{"status":"OK"}`, notFound, `[]`, `[{"id":"1","dist":0}]`},
+			expectedRequests: []request{
+				{http.MethodPost, queryURL, `{"top_k":1}`},
+				{http.MethodPost, upsertURL, `{"copy_from_namespace":"src-namespace"}`},
+				{http.MethodPost, queryURL, `{"top_k":1}`},
+				{http.MethodPost, queryURL, `{"top_k":1}`},
+				{http.MethodPost, queryURL, `{"top_k":1}`},
+			},
+		},
+		{
+			name:          "copy failure",
+			opts:          &tpuf.CopyNamespaceOptions{AllowNonEmpty: true},
+			httpStatuses:  []int{http.StatusBadRequest},
+			httpBodies:    []string{`{"error":"source namespace not found","status":"error"}`},
+			expectedError: "failed to copy namespace src-namespace to dst-namespace: failed to upsert documents: error: source namespace not found (HTTP 400)",
+			expectedRequests: []request{
+				{http.MethodPost, upsertURL, `{"copy_from_namespace":"src-namespace"}`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []request
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, _ := io.ReadAll(req.Body)
+						requests = append(requests, request{req.Method, req.URL.String(), string(body)})
+						i := len(requests) - 1
+						return &http.Response{
+							StatusCode: tt.httpStatuses[i],
+							Body:       io.NopCloser(bytes.NewBufferString(tt.httpBodies[i])),
+						}, nil
+					},
+				},
+			}
+
+			err := client.CopyNamespace(context.Background(), "dst-namespace", "src-namespace", tt.opts)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedRequests, requests, "unexpected requests")
+		})
+	}
+}
+
+func TestEnsureNamespace(t *testing.T) {
+	type request struct {
+		method string
+		url    string
+		body   string
+	}
+	schemaURL := "https://api.turbopuffer.com/v1/vectors/test-namespace/schema"
+	upsertURL := "https://api.turbopuffer.com/v1/vectors/test-namespace"
+	notFound := `{"error":"Namespace not found","status":"error"}`
+	liveSchema := `{
+		"title": {"type": "string", "filterable": true, "full_text_search": false},
+		"count": {"type": "int", "filterable": true},
+		"legacy": {"type": "string", "filterable": true}
+	}`
+	desired := tpuf.Schema{
+		"title":  &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{Language: "english"}},
+		"count":  &tpuf.Attribute{Type: tpuf.AttributeTypeInt},
+		"author": &tpuf.Attribute{Type: tpuf.AttributeTypeString, Filterable: boolPtr(true)},
+	}
+
+	tests := []struct {
+		name             string
+		desired          tpuf.Schema
+		opts             *tpuf.EnsureNamespaceOptions
+		httpStatuses     []int
+		httpBodies       []string
+		expectedError    string
+		expectedResult   *tpuf.EnsureNamespaceResult
+		expectedRequests []request
+	}{
+		{
+			name:           "create missing namespace",
+			desired:        tpuf.Schema{"count": &tpuf.Attribute{Type: tpuf.AttributeTypeInt}},
+			opts:           &tpuf.EnsureNamespaceOptions{DistanceMetric: tpuf.DistanceMetricCosine},
+			httpStatuses:   []int{http.StatusNotFound, http.StatusOK},
+			httpBodies:     []string{notFound, `{"status":"OK"}`},
+			expectedResult: &tpuf.EnsureNamespaceResult{Created: true},
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+				{http.MethodPost, upsertURL, `{"distance_metric":"cosine_distance","schema":{"count":{"type":"int"}}}`},
+			},
+		},
+		{
+			name:           "add and update attributes",
+			desired:        desired,
+			httpStatuses:   []int{http.StatusOK, http.StatusOK},
+			httpBodies:     []string{liveSchema, `{}`},
+			expectedResult: &tpuf.EnsureNamespaceResult{Added: []string{"author"}, Updated: []string{"title"}},
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+				{http.MethodPost, schemaURL, `{"author":{"type":"string","filterable":true},"title":{"type":"string","full_text_search":{"language":"english"}}}`},
+			},
+		},
+		{
+			name:           "dry run",
+			desired:        desired,
+			opts:           &tpuf.EnsureNamespaceOptions{DryRun: true},
+			httpStatuses:   []int{http.StatusOK},
+			httpBodies:     []string{liveSchema},
+			expectedResult: &tpuf.EnsureNamespaceResult{Added: []string{"author"}, Updated: []string{"title"}},
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+			},
+		},
+		{
+			name:           "already up to date",
+			desired:        tpuf.Schema{"count": &tpuf.Attribute{Type: tpuf.AttributeTypeInt, Filterable: boolPtr(true)}},
+			httpStatuses:   []int{http.StatusOK},
+			httpBodies:     []string{liveSchema},
+			expectedResult: &tpuf.EnsureNamespaceResult{},
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+			},
+		},
+		{
+			name: "update BM25 parameters",
+			desired: tpuf.Schema{"body": &tpuf.Attribute{
+				Type:           tpuf.AttributeTypeString,
+				FullTextSearch: &tpuf.FullTextSearchParams{Tokenizer: tpuf.TokenizerWordV1, K1: float64Ptr(1.5)},
+			}},
+			httpStatuses:   []int{http.StatusOK, http.StatusOK},
+			httpBodies:     []string{`{"body": {"type": "string", "full_text_search": {"tokenizer": "word_v1", "k1": 1.2}}}`, `{}`},
+			expectedResult: &tpuf.EnsureNamespaceResult{Updated: []string{"body"}},
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+				{http.MethodPost, schemaURL, `{"body":{"type":"string","full_text_search":{"tokenizer":"word_v1","k1":1.5}}}`},
+			},
+		},
+		{
+			name:          "incompatible vector dimensions",
+			desired:       tpuf.Schema{"vector": &tpuf.Attribute{Type: tpuf.VectorType(1536, tpuf.VectorDTypeF32)}},
+			httpStatuses:  []int{http.StatusOK},
+			httpBodies:    []string{`{"vector": {"type": "[768]f32", "ann": true}}`},
+			expectedError: "incompatible schema for namespace test-namespace: vector: vector dimensions can't be changed from 768 to 1536",
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+			},
+		},
+		{
+			name: "incompatible full-text search settings",
+			desired: tpuf.Schema{
+				"body":  &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{Tokenizer: tpuf.TokenizerWordV0}},
+				"title": &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{Language: "french"}},
+			},
+			httpStatuses: []int{http.StatusOK},
+			httpBodies: []string{`{
+				"body": {"type": "string", "full_text_search": {"tokenizer": "word_v1"}},
+				"title": {"type": "string", "full_text_search": true}
+			}`},
+			expectedError: "incompatible schema for namespace test-namespace: body: tokenizer can't be changed from word_v1 to word_v0; title: language can't be changed from english to french",
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+			},
+		},
+		{
+			name:           "full-text search settings matching the defaults",
+			desired:        tpuf.Schema{"title": &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{Language: "english", Tokenizer: tpuf.TokenizerWordV1}}},
+			httpStatuses:   []int{http.StatusOK},
+			httpBodies:     []string{`{"title": {"type": "string", "full_text_search": true}}`},
+			expectedResult: &tpuf.EnsureNamespaceResult{},
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+			},
+		},
+		{
+			name: "incompatible types",
+			desired: tpuf.Schema{
+				"author": &tpuf.Attribute{Type: tpuf.AttributeTypeString},
+				"count":  &tpuf.Attribute{Type: tpuf.AttributeTypeFloat},
+				"legacy": &tpuf.Attribute{Type: tpuf.AttributeTypeStringArray},
+			},
+			httpStatuses:  []int{http.StatusOK},
+			httpBodies:    []string{liveSchema},
+			expectedError: "incompatible schema for namespace test-namespace: count: type can't be changed from int to float; legacy: type can't be changed from string to []string",
+			expectedRequests: []request{
+				{http.MethodGet, schemaURL, ``},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []request
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						var body []byte
+						if req.Body != nil {
+							body, _ = io.ReadAll(req.Body)
+						}
+						requests = append(requests, request{req.Method, req.URL.String(), string(body)})
+						i := len(requests) - 1
+						return &http.Response{
+							StatusCode: tt.httpStatuses[i],
+							Body:       io.NopCloser(bytes.NewBufferString(tt.httpBodies[i])),
+						}, nil
+					},
+				},
+			}
+
+			result, err := client.EnsureNamespace(context.Background(), "test-namespace", tt.desired, tt.opts)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result, "unexpected result")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				var conflictErr *tpuf.SchemaConflictError
+				assert.ErrorAs(t, err, &conflictErr)
+				assert.Nil(t, result)
+			}
+			assert.Equal(t, len(tt.expectedRequests), len(requests), "unexpected number of requests")
+			for i := range requests {
+				if i >= len(tt.expectedRequests) {
+					break
+				}
+				assert.Equal(t, tt.expectedRequests[i].method, requests[i].method, "unexpected request method")
+				assert.Equal(t, tt.expectedRequests[i].url, requests[i].url, "unexpected request URL")
+				if tt.expectedRequests[i].body == "" {
+					assert.Empty(t, requests[i].body, "unexpected request body")
+				} else {
+					assert.JSONEq(t, tt.expectedRequests[i].body, requests[i].body, "unexpected request body")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package tpuf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestStats describes a completed API request, including all of its retries.
+type RequestStats struct {
+	// Operation is the kind of API call.
+	Operation Operation
+	// Namespace is the namespace the request targeted, or "" for requests which don't target one.
+	Namespace string
+	// Start is when the first attempt was sent.
+	Start time.Time
+	// Duration is the total time taken, including retries and the backoff between them.
+	Duration time.Duration
+	// Attempts is the number of times the request was sent.  Attempts - 1 is the number of retries.
+	Attempts int
+	// StatusCode is the HTTP status of the last response, or 0 if no response was received.
+	StatusCode int
+	// RequestBytes is the size of the request body before compression, or -1 if it was streamed.
+	RequestBytes int
+	// ResponseBytes is the size of the successful response body, or -1 if it was streamed to the caller.
+	ResponseBytes int
+	// Err is the error which failed the request, if any.
+	Err error
+	// RateLimit is the rate limit reported by the last response which included one, or nil if none did.
+	RateLimit *RateLimit
+}
+
+// RequestObserver is notified of every API request made by a Client once the request completes.
+// Implementations must be safe for concurrent use.
+type RequestObserver interface {
+	ObserveRequest(ctx context.Context, stats *RequestStats)
+}
+
+// RequestObserverFunc adapts an ordinary function to the RequestObserver interface.
+type RequestObserverFunc func(ctx context.Context, stats *RequestStats)
+
+func (f RequestObserverFunc) ObserveRequest(ctx context.Context, stats *RequestStats) {
+	f(ctx, stats)
+}
+
+func (c *Client) startStats(op Operation, path string, body requestPayload) *RequestStats {
+	requestBytes := len(body.data)
+	if body.encode != nil {
+		requestBytes = -1
+	}
+	return &RequestStats{
+		Operation:    op,
+		Namespace:    namespaceFromPath(path),
+		Start:        time.Now(),
+		RequestBytes: requestBytes,
+	}
+}
+
+func (c *Client) observe(ctx context.Context, stats *RequestStats, err error) {
+	if c.Observer == nil {
+		return
+	}
+	stats.Duration = time.Since(stats.Start)
+	stats.Err = err
+	var apiErr ApiError
+	switch {
+	case err == nil:
+		stats.StatusCode = http.StatusOK
+	case errors.As(err, &apiErr):
+		stats.StatusCode = apiErr.HttpStatus
+	}
+	if err != nil {
+		stats.ResponseBytes = 0
+	}
+	c.Observer.ObserveRequest(ctx, stats)
+}
+
+// namespaceFromPath returns the namespace of an API path such as /v1/vectors/{namespace}/query.
+func namespaceFromPath(path string) string {
+	namespace, _ := cutNamespace(path)
+	return namespace
+}
+
+// cutNamespace returns the namespace of an API path, and whether the path targets a namespace at all.
+func cutNamespace(path string) (string, bool) {
+	for _, prefix := range []string{"/v1/vectors/", "/v1/namespaces/"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			namespace, _, _ := strings.Cut(rest, "/")
+			return namespace, true
+		}
+	}
+	return "", false
+}
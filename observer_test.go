@@ -0,0 +1,172 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestObserver(t *testing.T) {
+	tests := []struct {
+		name          string
+		call          func(client *tpuf.Client) error
+		statuses      []int
+		responseBody  string
+		expectedStats tpuf.RequestStats
+		expectError   bool
+	}{
+		{
+			name: "query",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Query(context.Background(), "docs", &tpuf.QueryRequest{TopK: 1})
+				return err
+			},
+			statuses:     []int{http.StatusOK},
+			responseBody: `[{"id":"1"}]`,
+			expectedStats: tpuf.RequestStats{
+				Operation:     tpuf.OperationQuery,
+				Namespace:     "docs",
+				Attempts:      1,
+				StatusCode:    http.StatusOK,
+				RequestBytes:  len(`{"top_k":1}`),
+				ResponseBytes: len(`[{"id":"1"}]`),
+			},
+		},
+		{
+			name: "retried upsert",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "docs", &tpuf.UpsertRequest{
+					Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{1}}},
+				})
+				return err
+			},
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			responseBody: `{"status":"OK"}`,
+			expectedStats: tpuf.RequestStats{
+				Operation:     tpuf.OperationUpsert,
+				Namespace:     "docs",
+				Attempts:      2,
+				StatusCode:    http.StatusOK,
+				RequestBytes:  len(`{"upserts":[{"id":"1","vector":[1]}]}`),
+				ResponseBytes: len(`{"status":"OK"}`),
+			},
+		},
+		{
+			name: "failed namespaces listing",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Namespaces(context.Background(), &tpuf.NamespacesRequest{})
+				return err
+			},
+			statuses:     []int{http.StatusForbidden},
+			responseBody: `{"status":"error","error":"forbidden"}`,
+			expectedStats: tpuf.RequestStats{
+				Operation:  tpuf.OperationNamespaces,
+				Attempts:   1,
+				StatusCode: http.StatusForbidden,
+			},
+			expectError: true,
+		},
+		{
+			name: "cache warming uses the namespaces path",
+			call: func(client *tpuf.Client) error {
+				_, err := client.WarmCache(context.Background(), "docs")
+				return err
+			},
+			statuses:     []int{http.StatusOK},
+			responseBody: `{"status":"OK"}`,
+			expectedStats: tpuf.RequestStats{
+				Operation:     tpuf.OperationWarmCache,
+				Namespace:     "docs",
+				Attempts:      1,
+				StatusCode:    http.StatusOK,
+				ResponseBytes: len(`{"status":"OK"}`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var observed []*tpuf.RequestStats
+			calls := 0
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				Timer:    &fakeTimer{},
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						status := tt.statuses[calls]
+						calls++
+						body := tt.responseBody
+						if status == http.StatusServiceUnavailable {
+							body = `{"status":"error","error":"unavailable"}`
+						}
+						return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+					},
+				},
+				Observer: tpuf.RequestObserverFunc(func(ctx context.Context, stats *tpuf.RequestStats) {
+					mu.Lock()
+					defer mu.Unlock()
+					observed = append(observed, stats)
+				}),
+			}
+
+			err := tt.call(client)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if assert.Len(t, observed, 1) {
+				stats := *observed[0]
+				assert.False(t, stats.Start.IsZero(), "missing start time")
+				assert.GreaterOrEqual(t, int64(stats.Duration), int64(0))
+				assert.Equal(t, tt.expectError, stats.Err != nil, "unexpected error in stats")
+				stats.Start, stats.Duration, stats.Err = tt.expectedStats.Start, 0, nil
+				assert.Equal(t, tt.expectedStats, stats)
+			}
+		})
+	}
+}
+
+func TestRequestObserverRateLimit(t *testing.T) {
+	calls := 0
+	var observed *tpuf.RequestStats
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		Timer:    &fakeTimer{},
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				calls++
+				if calls == 1 {
+					header := http.Header{}
+					header.Set("X-RateLimit-Limit", "100")
+					header.Set("X-RateLimit-Remaining", "0")
+					header.Set("Retry-After", "3")
+					return &http.Response{
+						StatusCode: http.StatusTooManyRequests,
+						Header:     header,
+						Body:       io.NopCloser(bytes.NewBufferString(`{"status":"error","error":"slow down"}`)),
+					}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`[]`))}, nil
+			},
+		},
+		Observer: tpuf.RequestObserverFunc(func(ctx context.Context, stats *tpuf.RequestStats) {
+			observed = stats
+		}),
+	}
+
+	_, err := client.Query(context.Background(), "docs", &tpuf.QueryRequest{TopK: 1})
+	assert.NoError(t, err)
+	if assert.NotNil(t, observed) && assert.NotNil(t, observed.RateLimit, "rate limit from the retried response should be kept") {
+		assert.Equal(t, &tpuf.RateLimit{Limit: 100, Remaining: 0, RetryAfter: 3 * time.Second}, observed.RateLimit)
+	}
+}
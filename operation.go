@@ -0,0 +1,28 @@
+package tpuf
+
+// Operation identifies the kind of API call a request performs.
+type Operation string
+
+const (
+	OperationQuery           Operation = "query"
+	OperationRecall          Operation = "recall"
+	OperationExport          Operation = "export"
+	OperationNamespaces      Operation = "namespaces"
+	OperationWarmCache       Operation = "warm_cache"
+	OperationSchema          Operation = "schema"
+	OperationUpsert          Operation = "upsert"
+	OperationDelete          Operation = "delete"
+	OperationDeleteNamespace Operation = "delete_namespace"
+	OperationUpdateSchema    Operation = "update_schema"
+	OperationPing            Operation = "ping"
+)
+
+// IsWrite reports whether the operation modifies a namespace.  Writes are not safe to blindly resend
+// when a request fails without a response, since the server may already have applied them.
+func (o Operation) IsWrite() bool {
+	switch o {
+	case OperationUpsert, OperationDelete, OperationDeleteNamespace, OperationUpdateSchema:
+		return true
+	}
+	return false
+}
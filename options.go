@@ -0,0 +1,251 @@
+package tpuf
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// Option configures a Client created with NewClient.
+type Option func(*Client)
+
+// NewClient returns a client authenticated with the given API token, with defaults applied and its
+// configuration validated.  The token may be empty if WithAuth is used instead.  Constructing a Client
+// directly remains supported, but doesn't catch mistakes such as an unparseable BaseURL until the first
+// request.
+func NewClient(apiToken string, opts ...Option) (*Client, error) {
+	c := &Client{ApiToken: apiToken}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.BaseURL == "" {
+		c.BaseURL = defaultBaseURL
+	}
+	if c.MaxRetries == 0 && !c.DisableRetry {
+		c.MaxRetries = defaultMaxRetries
+	}
+	if c.VectorEncoding == "" {
+		c.VectorEncoding = VectorEncodingFloat
+	}
+	return c, nil
+}
+
+// Validate reports whether the client's configuration is usable, returning an error describing the first
+// problem found otherwise.
+func (c *Client) Validate() error {
+	if c.ApiToken == "" && c.Auth == nil {
+		return errors.New("an API token is required")
+	}
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("invalid base URL %q: scheme must be http or https", c.BaseURL)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid base URL %q: missing host", c.BaseURL)
+		}
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("max retries must not be negative, got %d", c.MaxRetries)
+	}
+	if c.DisableRetry && c.MaxRetries > 0 {
+		return errors.New("max retries can't be set when retries are disabled")
+	}
+	if c.RequestTimeout < 0 {
+		return fmt.Errorf("request timeout must not be negative, got %v", c.RequestTimeout)
+	}
+	if c.MaxTopK < 0 {
+		return fmt.Errorf("max top k must not be negative, got %d", c.MaxTopK)
+	}
+	if c.DeleteBatchSize < 0 {
+		return fmt.Errorf("delete batch size must not be negative, got %d", c.DeleteBatchSize)
+	}
+	if c.DeleteConcurrency < 0 {
+		return fmt.Errorf("delete concurrency must not be negative, got %d", c.DeleteConcurrency)
+	}
+	if c.CompressAboveBytes < 0 {
+		return fmt.Errorf("compression threshold must not be negative, got %d", c.CompressAboveBytes)
+	}
+	if _, err := c.compressionLevel(); err != nil {
+		return err
+	}
+	for _, decoder := range c.ResponseDecoders {
+		if decoder == nil || decoder.Encoding == "" || decoder.NewReader == nil {
+			return errors.New("response decoders require an encoding and a reader")
+		}
+	}
+	switch c.VectorEncoding {
+	case "", VectorEncodingFloat, VectorEncodingBase64:
+	default:
+		return fmt.Errorf("unsupported vector encoding %q", c.VectorEncoding)
+	}
+	switch c.PathStyle {
+	case "", PathStyleVectors, PathStyleNamespaces:
+	default:
+		return fmt.Errorf("unsupported path style %q", c.PathStyle)
+	}
+	switch c.Consistency {
+	case "", ConsistencyStrong, ConsistencyEventual:
+	default:
+		return fmt.Errorf("unsupported consistency level %q", c.Consistency)
+	}
+	for op, policy := range c.RetryPolicies {
+		if policy == nil {
+			continue
+		}
+		if policy.MaxRetries < 0 {
+			return fmt.Errorf("max retries for %s must not be negative, got %d", op, policy.MaxRetries)
+		}
+		if policy.Disable && policy.MaxRetries > 0 {
+			return fmt.Errorf("max retries for %s can't be set when its retries are disabled", op)
+		}
+		if policy.InitialInterval < 0 || policy.MaxInterval < 0 {
+			return fmt.Errorf("retry intervals for %s must not be negative", op)
+		}
+	}
+	return nil
+}
+
+// WithAuth sets Client.Auth.
+func WithAuth(auth Authenticator) Option {
+	return func(c *Client) { c.Auth = auth }
+}
+
+// WithBaseURL sets Client.BaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) { c.BaseURL = baseURL }
+}
+
+// WithMaxRetries sets Client.MaxRetries.
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *Client) { c.MaxRetries = maxRetries }
+}
+
+// WithoutRetries sets Client.DisableRetry.
+func WithoutRetries() Option {
+	return func(c *Client) { c.DisableRetry = true }
+}
+
+// WithRequestTimeout sets Client.RequestTimeout.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.RequestTimeout = timeout }
+}
+
+// WithNotFoundGracePeriod sets Client.NotFoundGracePeriod.
+func WithNotFoundGracePeriod(period time.Duration) Option {
+	return func(c *Client) { c.NotFoundGracePeriod = period }
+}
+
+// WithRetryPolicy sets the retry policy for an operation in Client.RetryPolicies.
+func WithRetryPolicy(op Operation, policy *RetryPolicy) Option {
+	return func(c *Client) {
+		if c.RetryPolicies == nil {
+			c.RetryPolicies = map[Operation]*RetryPolicy{}
+		}
+		c.RetryPolicies[op] = policy
+	}
+}
+
+// WithRetryOnNetworkError sets Client.RetryOnNetworkError.
+func WithRetryOnNetworkError(policy *NetworkErrorPolicy) Option {
+	return func(c *Client) { c.RetryOnNetworkError = policy }
+}
+
+// WithRetryWritesOnNetworkError sets Client.RetryWritesOnNetworkError.
+func WithRetryWritesOnNetworkError() Option {
+	return func(c *Client) { c.RetryWritesOnNetworkError = true }
+}
+
+// WithHttpClient sets Client.HttpClient.
+func WithHttpClient(httpClient HttpClient) Option {
+	return func(c *Client) { c.HttpClient = httpClient }
+}
+
+// WithTimer sets Client.Timer.
+func WithTimer(timer backoff.Timer) Option {
+	return func(c *Client) { c.Timer = timer }
+}
+
+// WithGzipEncoding sets Client.UseGzipEncoding.
+func WithGzipEncoding() Option {
+	return func(c *Client) { c.UseGzipEncoding = true }
+}
+
+// WithCompressAboveBytes sets Client.CompressAboveBytes.
+func WithCompressAboveBytes(threshold int) Option {
+	return func(c *Client) { c.CompressAboveBytes = threshold }
+}
+
+// WithCompressionLevel sets Client.CompressionLevel.
+func WithCompressionLevel(level int) Option {
+	return func(c *Client) { c.CompressionLevel = &level }
+}
+
+// WithResponseDecoder adds a decoder to Client.ResponseDecoders.
+func WithResponseDecoder(decoder *ContentDecoder) Option {
+	return func(c *Client) { c.ResponseDecoders = append(c.ResponseDecoders, decoder) }
+}
+
+// WithStreamRequestBodies sets Client.StreamRequestBodies.
+func WithStreamRequestBodies() Option {
+	return func(c *Client) { c.StreamRequestBodies = true }
+}
+
+// WithVectorEncoding sets Client.VectorEncoding.
+func WithVectorEncoding(encoding VectorEncoding) Option {
+	return func(c *Client) { c.VectorEncoding = encoding }
+}
+
+// WithPathStyle sets Client.PathStyle.
+func WithPathStyle(style PathStyle) Option {
+	return func(c *Client) { c.PathStyle = style }
+}
+
+// WithMaxTopK sets Client.MaxTopK.
+func WithMaxTopK(maxTopK int) Option {
+	return func(c *Client) { c.MaxTopK = maxTopK }
+}
+
+// WithDeleteBatchSize sets Client.DeleteBatchSize.
+func WithDeleteBatchSize(batchSize int) Option {
+	return func(c *Client) { c.DeleteBatchSize = batchSize }
+}
+
+// WithDeleteConcurrency sets Client.DeleteConcurrency.
+func WithDeleteConcurrency(concurrency int) Option {
+	return func(c *Client) { c.DeleteConcurrency = concurrency }
+}
+
+// WithConsistency sets Client.Consistency.
+func WithConsistency(level ConsistencyLevel) Option {
+	return func(c *Client) { c.Consistency = level }
+}
+
+// WithLogger sets Client.Logger.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) { c.Logger = logger }
+}
+
+// WithObserver sets Client.Observer.
+func WithObserver(observer RequestObserver) Option {
+	return func(c *Client) { c.Observer = observer }
+}
+
+// WithoutNameValidation sets Client.DisableNameValidation.
+func WithoutNameValidation() Option {
+	return func(c *Client) { c.DisableNameValidation = true }
+}
+
+// WithAuditHook sets Client.AuditHook.
+func WithAuditHook(hook AuditHook) Option {
+	return func(c *Client) { c.AuditHook = hook }
+}
@@ -0,0 +1,165 @@
+package tpuf_test
+
+import (
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		opts          []tpuf.Option
+		expected      *tpuf.Client
+		expectedError string
+	}{
+		{
+			name:  "defaults",
+			token: "test-token",
+			expected: &tpuf.Client{
+				ApiToken:       "test-token",
+				BaseURL:        "https://api.turbopuffer.com",
+				MaxRetries:     6,
+				VectorEncoding: tpuf.VectorEncodingFloat,
+			},
+		},
+		{
+			name:  "options",
+			token: "test-token",
+			opts: []tpuf.Option{
+				tpuf.WithBaseURL("http://localhost:8080"),
+				tpuf.WithMaxRetries(2),
+				tpuf.WithRequestTimeout(time.Minute),
+				tpuf.WithRetryPolicy(tpuf.OperationUpsert, &tpuf.RetryPolicy{MaxRetries: 1}),
+				tpuf.WithGzipEncoding(),
+				tpuf.WithCompressionLevel(gzip.NoCompression),
+				tpuf.WithVectorEncoding(tpuf.VectorEncodingBase64),
+				tpuf.WithConsistency(tpuf.ConsistencyEventual),
+				tpuf.WithPathStyle(tpuf.PathStyleNamespaces),
+			},
+			expected: &tpuf.Client{
+				ApiToken:         "test-token",
+				BaseURL:          "http://localhost:8080",
+				MaxRetries:       2,
+				RequestTimeout:   time.Minute,
+				RetryPolicies:    map[tpuf.Operation]*tpuf.RetryPolicy{tpuf.OperationUpsert: {MaxRetries: 1}},
+				UseGzipEncoding:  true,
+				CompressionLevel: intPtr(gzip.NoCompression),
+				VectorEncoding:   tpuf.VectorEncodingBase64,
+				Consistency:      tpuf.ConsistencyEventual,
+				PathStyle:        tpuf.PathStyleNamespaces,
+			},
+		},
+		{
+			name:  "retries disabled",
+			token: "test-token",
+			opts:  []tpuf.Option{tpuf.WithoutRetries()},
+			expected: &tpuf.Client{
+				ApiToken:       "test-token",
+				BaseURL:        "https://api.turbopuffer.com",
+				DisableRetry:   true,
+				VectorEncoding: tpuf.VectorEncodingFloat,
+			},
+		},
+		{
+			name:          "missing token",
+			expectedError: "an API token is required",
+		},
+		{
+			name:          "unparseable base URL",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithBaseURL("http://[::1")},
+			expectedError: `invalid base URL: parse "http://[::1": missing ']' in host`,
+		},
+		{
+			name:          "base URL without scheme",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithBaseURL("api.turbopuffer.com")},
+			expectedError: `invalid base URL "api.turbopuffer.com": scheme must be http or https`,
+		},
+		{
+			name:          "max retries with retries disabled",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithoutRetries(), tpuf.WithMaxRetries(3)},
+			expectedError: "max retries can't be set when retries are disabled",
+		},
+		{
+			name:          "negative max retries",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithMaxRetries(-1)},
+			expectedError: "max retries must not be negative, got -1",
+		},
+		{
+			name:          "negative request timeout",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithRequestTimeout(-time.Second)},
+			expectedError: "request timeout must not be negative, got -1s",
+		},
+		{
+			name:          "negative max top k",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithMaxTopK(-1)},
+			expectedError: "max top k must not be negative, got -1",
+		},
+		{
+			name:          "negative delete batch size",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithDeleteBatchSize(-1)},
+			expectedError: "delete batch size must not be negative, got -1",
+		},
+		{
+			name:          "negative delete concurrency",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithDeleteConcurrency(-2)},
+			expectedError: "delete concurrency must not be negative, got -2",
+		},
+		{
+			name:          "negative compression threshold",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithCompressAboveBytes(-1)},
+			expectedError: "compression threshold must not be negative, got -1",
+		},
+		{
+			name:          "unsupported compression level",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithCompressionLevel(12)},
+			expectedError: "unsupported compression level 12",
+		},
+		{
+			name:          "unsupported vector encoding",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithVectorEncoding("float16")},
+			expectedError: `unsupported vector encoding "float16"`,
+		},
+		{
+			name:          "unsupported path style",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithPathStyle("v3")},
+			expectedError: `unsupported path style "v3"`,
+		},
+		{
+			name:          "contradictory retry policy",
+			token:         "test-token",
+			opts:          []tpuf.Option{tpuf.WithRetryPolicy(tpuf.OperationUpsert, &tpuf.RetryPolicy{Disable: true, MaxRetries: 2})},
+			expectedError: "max retries for upsert can't be set when its retries are disabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := tpuf.NewClient(tt.token, tt.opts...)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, client)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, client)
+			}
+		})
+	}
+}
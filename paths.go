@@ -0,0 +1,27 @@
+package tpuf
+
+import "fmt"
+
+// PathStyle selects the family of API paths which a Client sends namespace requests to.
+type PathStyle string
+
+const (
+	// PathStyleVectors sends requests to the original /v1/vectors/{namespace} paths.  This is the default.
+	PathStyleVectors PathStyle = "vectors"
+	// PathStyleNamespaces sends requests to the consolidated /v1/namespaces/{namespace} paths, which the API
+	// is unifying its endpoints under.  Request and response bodies are the same as for PathStyleVectors.
+	PathStyleNamespaces PathStyle = "namespaces"
+)
+
+// namespacesPath returns the path for listing namespaces.
+func (c *Client) namespacesPath() string {
+	if c.PathStyle == PathStyleNamespaces {
+		return "/v1/namespaces"
+	}
+	return "/v1/vectors"
+}
+
+// namespacePath returns the path of an endpoint of a namespace, e.g. "/query", or "" for the namespace itself.
+func (c *Client) namespacePath(namespace, endpoint string) string {
+	return fmt.Sprintf("%s/%s%s", c.namespacesPath(), namespace, endpoint)
+}
@@ -0,0 +1,104 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathStyle(t *testing.T) {
+	calls := []struct {
+		name     string
+		call     func(client *tpuf.Client) error
+		path     string
+		response string
+	}{
+		{
+			name: "namespaces",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Namespaces(context.Background(), &tpuf.NamespacesRequest{})
+				return err
+			},
+		},
+		{
+			name: "query",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Query(context.Background(), "docs", &tpuf.QueryRequest{TopK: 1})
+				return err
+			},
+			path:     "/docs/query",
+			response: `[]`,
+		},
+		{
+			name: "upsert",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "docs", &tpuf.UpsertRequest{Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{1}}}, DistanceMetric: tpuf.DistanceMetricCosine})
+				return err
+			},
+			path: "/docs",
+		},
+		{
+			name: "schema",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Schema(context.Background(), "docs")
+				return err
+			},
+			path: "/docs/schema",
+		},
+		{
+			name: "recall",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Recall(context.Background(), "docs", &tpuf.RecallRequest{})
+				return err
+			},
+			path: "/docs/_debug/recall",
+		},
+		{
+			name: "delete namespace",
+			call: func(client *tpuf.Client) error {
+				return client.DeleteNamespace(context.Background(), "docs")
+			},
+			path: "/docs",
+		},
+	}
+	styles := []struct {
+		style  tpuf.PathStyle
+		prefix string
+	}{
+		{style: "", prefix: "/v1/vectors"},
+		{style: tpuf.PathStyleVectors, prefix: "/v1/vectors"},
+		{style: tpuf.PathStyleNamespaces, prefix: "/v1/namespaces"},
+	}
+
+	for _, style := range styles {
+		for _, tt := range calls {
+			t.Run(string(style.style)+"/"+tt.name, func(t *testing.T) {
+				var requested string
+				response := tt.response
+				if response == "" {
+					response = `{}`
+				}
+				client := &tpuf.Client{
+					ApiToken:  "test-token",
+					PathStyle: style.style,
+					HttpClient: &fakeHttpClient{
+						doFunc: func(req *http.Request) (*http.Response, error) {
+							requested = req.URL.Path
+							return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(response))}, nil
+						},
+					},
+				}
+
+				err := tt.call(client)
+
+				assert.NoError(t, err)
+				assert.Equal(t, style.prefix+tt.path, requested)
+			})
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package tpuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrUnauthorized is wrapped by the error Ping returns when the server rejects the client's credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrUnreachable is wrapped by the error Ping returns when no response was received from the server,
+	// e.g. because BaseURL is wrong, the network is down, or the context expired.
+	ErrUnreachable = errors.New("unreachable")
+)
+
+// Ping checks that the server is reachable and accepts the client's credentials, by listing a single
+// namespace, and returns how long that took.  Use it for readiness probes and to validate configuration on
+// startup.  Errors wrap ErrUnauthorized or ErrUnreachable where applicable, which can be tested with errors.Is.
+// Ping isn't retried unless a policy is set for OperationPing in RetryPolicies, so that probes fail fast.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	_, err := c.get(ctx, OperationPing, c.namespacesPath(), url.Values{"page_size": {"1"}})
+	latency := time.Since(start)
+	if err == nil {
+		return latency, nil
+	}
+
+	var apiErr ApiError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &apiErr) && (apiErr.HttpStatus == http.StatusUnauthorized || apiErr.HttpStatus == http.StatusForbidden):
+		return latency, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	case errors.As(err, &urlErr):
+		return latency, fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+	return latency, fmt.Errorf("failed to ping: %w", err)
+}
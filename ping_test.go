@@ -0,0 +1,118 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		doErr         error
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "ok",
+			status:        http.StatusOK,
+			body:          `{"namespaces":[{"id":"a"}]}`,
+			expectedCalls: 1,
+		},
+		{
+			name:          "unauthorized",
+			status:        http.StatusUnauthorized,
+			body:          `{"status":"error","error":"invalid token"}`,
+			expectedCalls: 1,
+			expectedErr:   tpuf.ErrUnauthorized,
+		},
+		{
+			name:          "forbidden",
+			status:        http.StatusForbidden,
+			body:          `{"status":"error","error":"forbidden"}`,
+			expectedCalls: 1,
+			expectedErr:   tpuf.ErrUnauthorized,
+		},
+		{
+			name:          "unreachable",
+			doErr:         &url.Error{Op: "Get", URL: "https://api.turbopuffer.com/v1/vectors", Err: errors.New("no such host")},
+			expectedCalls: 1,
+			expectedErr:   tpuf.ErrUnreachable,
+		},
+		{
+			name:          "server error is not retried",
+			status:        http.StatusServiceUnavailable,
+			body:          `{"status":"error","error":"unavailable"}`,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				Timer:    &fakeTimer{},
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						calls++
+						assert.Equal(t, http.MethodGet, req.Method)
+						assert.Equal(t, "/v1/vectors", req.URL.Path)
+						assert.Equal(t, "1", req.URL.Query().Get("page_size"))
+						if tt.doErr != nil {
+							return nil, tt.doErr
+						}
+						return &http.Response{StatusCode: tt.status, Body: io.NopCloser(bytes.NewBufferString(tt.body))}, nil
+					},
+				},
+			}
+
+			latency, err := client.Ping(context.Background())
+
+			assert.Equal(t, tt.expectedCalls, calls)
+			assert.GreaterOrEqual(t, latency, time.Duration(0))
+			switch {
+			case tt.status == http.StatusOK:
+				assert.NoError(t, err)
+			case tt.expectedErr != nil:
+				assert.ErrorIs(t, err, tt.expectedErr)
+			default:
+				assert.Error(t, err)
+				assert.False(t, errors.Is(err, tpuf.ErrUnauthorized) || errors.Is(err, tpuf.ErrUnreachable))
+			}
+		})
+	}
+}
+
+func TestPingRetryPolicyOverride(t *testing.T) {
+	calls := 0
+	client := &tpuf.Client{
+		ApiToken:      "test-token",
+		Timer:         &fakeTimer{},
+		RetryPolicies: map[tpuf.Operation]*tpuf.RetryPolicy{tpuf.OperationPing: {MaxRetries: 2}},
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				calls++
+				if calls < 3 {
+					return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(bytes.NewBufferString(`{}`))}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"namespaces":[]}`))}, nil
+			},
+		},
+	}
+
+	_, err := client.Ping(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
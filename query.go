@@ -4,36 +4,152 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sync"
 )
 
+// ConsistencyLevel determines whether a query must reflect all prior writes.
+// See https://turbopuffer.com/docs/query#consistency
+type ConsistencyLevel string
+
+const (
+	// ConsistencyStrong queries reflect all writes which completed before the query.  This is the server default.
+	ConsistencyStrong ConsistencyLevel = "strong"
+	// ConsistencyEventual queries may miss very recent writes, in exchange for lower latency.
+	ConsistencyEventual ConsistencyLevel = "eventual"
+)
+
+type Consistency struct {
+	Level ConsistencyLevel `json:"level"`
+}
+
 type QueryRequest struct {
 	// Vector is the vector to search for.
 	Vector []float32 `json:"vector,omitempty"`
 	// DistanceMetric is the distance metric to use for vector search.
 	// Required if Vector is set.
 	DistanceMetric DistanceMetric `json:"distance_metric,omitempty"`
-	// RankBy is the fields to rank by for BM25 search.
+	// RankBy is the expression to rank results by, e.g. BM25("text", "query") for full-text search.
+	// See rankby.go for more details.
 	// Either Vector or RankBy, but not both, may be set.
-	RankBy []interface{} `json:"rank_by,omitempty"`
-	// TopK is the maximum number of results to return.  Default 10.
+	RankBy RankBy `json:"rank_by,omitempty"`
+	// TopK is the maximum number of results to return.  Default 10.  Queries ordered by ID with a TopK above
+	// Client.MaxTopK are split into several requests; see Client.MaxTopK.
 	TopK int `json:"top_k,omitempty"`
 	// IncludeVectors includes the vectors of the results.  Default false.
 	IncludeVectors bool `json:"include_vectors,omitempty"`
 	// IncludeAttributes specifies which attributes to include in the results.
-	// May be a list of specific attribute names, or true to include all attributes.
-	IncludeAttributes interface{} `json:"include_attributes,omitempty"`
+	// Use IncludeAllAttributes() or IncludeAttributeNames(...).  Default is no attributes.
+	IncludeAttributes *IncludeAttributes `json:"include_attributes,omitempty"`
 	// Filters is the filter to apply to the query, which may be a basic or compound filter.
 	// See filter.go for more details.
 	Filters Filter `json:"filters,omitempty"`
+	// Consistency is the consistency level of the query.  Defaults to the Client's Consistency,
+	// or the server default if neither is set.
+	Consistency *Consistency `json:"consistency,omitempty"`
+	// MaxDistance, if set, drops vector search results whose distance is greater than this threshold.
+	// This is applied client-side after the TopK results are returned, so fewer than TopK results may be returned.
+	// Not meaningful for BM25 search, where Dist is a relevance score rather than a distance.
+	MaxDistance *float64 `json:"-"`
+	// NormalizeVector scales Vector to unit length before sending it.  It requires DistanceMetricCosine and
+	// fails for a zero vector.  See UpsertRequest.NormalizeVectors.
+	NormalizeVector bool `json:"-"`
+}
+
+// IncludeAttributes selects which attributes are returned with query results: either all of them,
+// or a list of specific attribute names.
+type IncludeAttributes struct {
+	all   bool
+	names []string
+}
+
+// IncludeAllAttributes includes all attributes in query results.
+func IncludeAllAttributes() *IncludeAttributes {
+	return &IncludeAttributes{all: true}
+}
+
+// IncludeAttributeNames includes only the named attributes in query results.
+func IncludeAttributeNames(names ...string) *IncludeAttributes {
+	return &IncludeAttributes{names: names}
+}
+
+// All reports whether all attributes are included.
+func (i *IncludeAttributes) All() bool {
+	return i.all
+}
+
+// Names returns the names of the included attributes, or nil if all attributes are included.
+func (i *IncludeAttributes) Names() []string {
+	return i.names
+}
+
+func (i *IncludeAttributes) MarshalJSON() ([]byte, error) {
+	if i.all {
+		return json.Marshal(true)
+	}
+	if len(i.names) == 0 {
+		return json.Marshal(false)
+	}
+	return json.Marshal(i.names)
+}
+
+func (i *IncludeAttributes) UnmarshalJSON(data []byte) error {
+	var all bool
+	if err := json.Unmarshal(data, &all); err == nil {
+		*i = IncludeAttributes{all: all}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("include_attributes must be a bool or a list of attribute names: %w", err)
+	}
+	*i = IncludeAttributes{names: names}
+	return nil
 }
 
 type QueryResult struct {
-	Dist       float64         `json:"dist"`
-	ID         string          `json:"id"`
+	// Dist is the document's distance from the query vector, or its BM25 score.  It is zero when HasDist is false.
+	Dist float64 `json:"dist"`
+	// HasDist reports whether Dist is meaningful, i.e. whether the query was ranked by vector or by BM25.
+	// Filter-only queries and queries ordered by an attribute report a zero Dist, which HasDist tells apart
+	// from a genuine zero-distance match.
+	HasDist bool `json:"-"`
+	// ID is the document's ID.  Numeric IDs are formatted in decimal.
+	ID string `json:"id"`
+	// IDUint64 is set if the document has a numeric ID.
+	IDUint64   *uint64         `json:"-"`
 	Vector     []float32       `json:"vector,omitempty"`
 	Attributes json.RawMessage `json:"attributes,omitempty"`
 }
 
+// queryResult has the fields but not the methods of QueryResult, for use in its UnmarshalJSON.
+type queryResult QueryResult
+
+func (r *QueryResult) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*queryResult
+		ID documentID `json:"id"`
+	}{queryResult: (*queryResult)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.ID, r.IDUint64 = aux.ID.str, aux.ID.num
+	return nil
+}
+
+// hasDist reports whether the results of a query have a distance or score, which they don't when the query
+// is filter-only or ordered by an attribute.
+func hasDist(request *QueryRequest) bool {
+	if request.Vector != nil {
+		return true
+	}
+	switch request.RankBy.(type) {
+	case nil, *AttributeRankBy:
+		return false
+	}
+	return true
+}
+
 // Query queries documents in the given namespace.
 // See https://turbopuffer.com/docs/query
 // Supports vector search, BM25 full-text search, and filter-only search.
@@ -41,21 +157,189 @@ type QueryResult struct {
 // For BM25 search, provide RankBy.
 // For filter-only search, omit both Vector and RankBy.
 func (c *Client) Query(ctx context.Context, namespace string, request *QueryRequest) ([]*QueryResult, error) {
-	path := fmt.Sprintf("/v1/vectors/%s/query", namespace)
-	reqJson, err := json.Marshal(request)
+	results, _, err := c.QueryWithMetadata(ctx, namespace, request)
+	return results, err
+}
+
+// QueryWithMetadata is like Query, but also returns performance metadata reported by the server.
+// For queries split into several requests, the metadata is that of the last request.
+// This is the only query diagnostic information the API exposes; in particular, a non-zero
+// ExhaustiveSearchCount indicates that recently written documents were searched without the index.
+func (c *Client) QueryWithMetadata(ctx context.Context, namespace string, request *QueryRequest) ([]*QueryResult, *QueryMetadata, error) {
+	if direction, ok := idOrder(request); ok && request.TopK > c.maxTopK() {
+		return c.queryPages(ctx, namespace, request, direction)
+	}
+	return c.queryOnce(ctx, namespace, request)
+}
+
+func (c *Client) queryOnce(ctx context.Context, namespace string, request *QueryRequest) ([]*QueryResult, *QueryMetadata, error) {
+	path := c.namespacePath(namespace, "/query")
+	payload, release, err := c.queryRequestPayload(request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, nil, err
 	}
+	defer release()
 
-	respData, err := c.post(ctx, path, reqJson)
+	respData, header, err := c.doWithHeader(ctx, OperationQuery, http.MethodPost, path, nil, payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query documents: %w", err)
+		return nil, nil, fmt.Errorf("failed to query documents: %w", err)
 	}
 
 	var results []*QueryResult
 	if err := json.Unmarshal(respData, &results); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	for _, result := range results {
+		result.HasDist = hasDist(request)
+	}
+	if request.MaxDistance != nil {
+		withinDistance := results[:0]
+		for _, result := range results {
+			if result.Dist <= *request.MaxDistance {
+				withinDistance = append(withinDistance, result)
+			}
+		}
+		results = withinDistance
 	}
 
+	return results, parseQueryMetadata(header), nil
+}
+
+// QueryStream runs a query and decodes results incrementally, calling fn for each result in order
+// rather than materializing the full result set.  This bounds memory for queries with a large TopK,
+// particularly when vectors are included.  If fn returns an error, decoding stops and that error is returned.
+func (c *Client) QueryStream(ctx context.Context, namespace string, request *QueryRequest, fn func(*QueryResult) error) error {
+	path := c.namespacePath(namespace, "/query")
+	payload, release, err := c.queryRequestPayload(request)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	body, err := c.doStream(ctx, OperationQuery, http.MethodPost, path, nil, payload)
+	if err != nil {
+		return fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	if err := expectDelim(decoder, '['); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	for i := 0; decoder.More(); i++ {
+		var result QueryResult
+		if err := decoder.Decode(&result); err != nil {
+			return fmt.Errorf("failed to decode result %d: %w", i, err)
+		}
+		result.HasDist = hasDist(request)
+		if request.MaxDistance != nil && result.Dist > *request.MaxDistance {
+			continue
+		}
+		if err := fn(&result); err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(decoder, ']'); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+func (c *Client) queryRequestPayload(request *QueryRequest) (requestPayload, func(), error) {
+	request, err := request.prepareVector()
+	if err != nil {
+		return requestPayload{}, nil, err
+	}
+	if request.Consistency == nil && c.Consistency != "" {
+		withDefaults := *request
+		withDefaults.Consistency = &Consistency{Level: c.Consistency}
+		request = &withDefaults
+	}
+	payload, release, err := c.jsonPayload(c.queryRequestJSON(request))
+	if err != nil {
+		return requestPayload{}, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return payload, release, nil
+}
+
+// MultiQuery runs several queries against the same namespace concurrently, returning one result set per
+// request in the same order.  If any query fails, the remaining queries are cancelled and the first error is returned.
+func (c *Client) MultiQuery(ctx context.Context, namespace string, requests []*QueryRequest) ([][]*QueryResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]*QueryResult, len(requests))
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, request *QueryRequest) {
+			defer wg.Done()
+			result, err := c.Query(ctx, namespace, request)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("query %d failed: %w", i, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}(i, request)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
 	return results, nil
 }
+
+// TypedResult is a query result whose attributes have been decoded into T.
+type TypedResult[T any] struct {
+	Dist       float64
+	HasDist    bool
+	ID         string
+	IDUint64   *uint64
+	Vector     []float32
+	Attributes T
+}
+
+// QueryTyped runs a query and decodes each result's attributes into T, which must be a type that
+// encoding/json can unmarshal into.  Results without attributes are left as the zero value of T.
+// Remember to set IncludeAttributes on the request.
+func QueryTyped[T any](ctx context.Context, client *Client, namespace string, request *QueryRequest) ([]TypedResult[T], error) {
+	results, err := client.Query(ctx, namespace, request)
+	if err != nil {
+		return nil, err
+	}
+
+	typed := make([]TypedResult[T], len(results))
+	for i, result := range results {
+		typed[i] = TypedResult[T]{
+			Dist:     result.Dist,
+			HasDist:  result.HasDist,
+			ID:       result.ID,
+			IDUint64: result.IDUint64,
+			Vector:   result.Vector,
+		}
+		if len(result.Attributes) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(result.Attributes, &typed[i].Attributes); err != nil {
+			return nil, fmt.Errorf("failed to decode attributes of result %d (id %s): %w", i, result.ID, err)
+		}
+	}
+	return typed, nil
+}
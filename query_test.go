@@ -3,6 +3,9 @@ package tpuf_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"testing"
@@ -23,6 +26,7 @@ func TestQuery(t *testing.T) {
 		expectedURL    string
 		expectedBody   string
 		expectedResult []*tpuf.QueryResult
+		consistency    tpuf.ConsistencyLevel
 	}{
 		{
 			name:      "vector search",
@@ -44,15 +48,15 @@ func TestQuery(t *testing.T) {
 			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace/query",
 			expectedBody:   `{"vector":[0.1,0.2,0.3],"distance_metric":"cosine_distance","top_k":5,"include_vectors":true}`,
 			expectedResult: []*tpuf.QueryResult{
-				{ID: "1", Dist: 0.1, Vector: []float32{0.11, 0.21, 0.31}},
-				{ID: "2", Dist: 0.2, Vector: []float32{0.12, 0.22, 0.32}},
+				{ID: "1", Dist: 0.1, HasDist: true, Vector: []float32{0.11, 0.21, 0.31}},
+				{ID: "2", Dist: 0.2, HasDist: true, Vector: []float32{0.12, 0.22, 0.32}},
 			},
 		},
 		{
 			name:      "BM25 text search",
 			namespace: "test-namespace",
 			request: &tpuf.QueryRequest{
-				RankBy: []interface{}{"description", "BM25", "fox jumping"},
+				RankBy: tpuf.BM25("description", "fox jumping"),
 				TopK:   3,
 			},
 			httpResponse: &http.Response{
@@ -67,9 +71,9 @@ func TestQuery(t *testing.T) {
 			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace/query",
 			expectedBody:   `{"rank_by":["description","BM25","fox jumping"],"top_k":3}`,
 			expectedResult: []*tpuf.QueryResult{
-				{ID: "1", Dist: 1.5},
-				{ID: "2", Dist: 1.2},
-				{ID: "3", Dist: 0.8},
+				{ID: "1", Dist: 1.5, HasDist: true},
+				{ID: "2", Dist: 1.2, HasDist: true},
+				{ID: "3", Dist: 0.8, HasDist: true},
 			},
 		},
 		{
@@ -99,6 +103,71 @@ func TestQuery(t *testing.T) {
 				{ID: "2", Dist: 0},
 			},
 		},
+		{
+			name:      "max distance",
+			namespace: "test-namespace",
+			request: &tpuf.QueryRequest{
+				Vector:         []float32{0.1, 0.2, 0.3},
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				TopK:           3,
+				MaxDistance:    float64Ptr(0.3),
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0.1},{"id":"2","dist":0.3},{"id":"3","dist":0.5}]`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace/query",
+			expectedBody:   `{"vector":[0.1,0.2,0.3],"distance_metric":"cosine_distance","top_k":3}`,
+			expectedResult: []*tpuf.QueryResult{{ID: "1", Dist: 0.1, HasDist: true}, {ID: "2", Dist: 0.3, HasDist: true}},
+		},
+		{
+			name:      "explicit consistency",
+			namespace: "test-namespace",
+			request: &tpuf.QueryRequest{
+				TopK:        1,
+				Consistency: &tpuf.Consistency{Level: tpuf.ConsistencyStrong},
+			},
+			consistency: tpuf.ConsistencyEventual,
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0}]`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace/query",
+			expectedBody:   `{"top_k":1,"consistency":{"level":"strong"}}`,
+			expectedResult: []*tpuf.QueryResult{{ID: "1", Dist: 0}},
+		},
+		{
+			name:        "client default consistency",
+			namespace:   "test-namespace",
+			request:     &tpuf.QueryRequest{TopK: 1},
+			consistency: tpuf.ConsistencyEventual,
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0}]`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace/query",
+			expectedBody:   `{"top_k":1,"consistency":{"level":"eventual"}}`,
+			expectedResult: []*tpuf.QueryResult{{ID: "1", Dist: 0}},
+		},
+		{
+			name:      "include specific attributes",
+			namespace: "test-namespace",
+			request: &tpuf.QueryRequest{
+				TopK:              1,
+				IncludeAttributes: tpuf.IncludeAttributeNames("title", "category"),
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0,"attributes":{"title":"one","category":"a"}}]`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace/query",
+			expectedBody:   `{"top_k":1,"include_attributes":["title","category"]}`,
+			expectedResult: []*tpuf.QueryResult{{ID: "1", Dist: 0, Attributes: json.RawMessage(`{"title":"one","category":"a"}`)}},
+		},
 		{
 			name:      "query error",
 			namespace: "test-namespace",
@@ -117,7 +186,8 @@ func TestQuery(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := &tpuf.Client{
-				ApiToken: "test-token",
+				ApiToken:    "test-token",
+				Consistency: tt.consistency,
 				HttpClient: &fakeHttpClient{
 					doFunc: func(req *http.Request) (*http.Response, error) {
 						assert.Equal(t, tt.expectedMethod, req.Method, "unexpected request method")
@@ -147,3 +217,375 @@ func TestQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiQuery(t *testing.T) {
+	tests := []struct {
+		name           string
+		requests       []*tpuf.QueryRequest
+		expectedError  string
+		expectedResult [][]*tpuf.QueryResult
+	}{
+		{
+			name:     "results returned in request order",
+			requests: []*tpuf.QueryRequest{{TopK: 1}, {TopK: 2}, {TopK: 3}},
+			expectedResult: [][]*tpuf.QueryResult{
+				{{ID: "1"}},
+				{{ID: "2"}},
+				{{ID: "3"}},
+			},
+		},
+		{
+			name:          "one query fails",
+			requests:      []*tpuf.QueryRequest{{TopK: 1}, {TopK: 99}},
+			expectedError: "query 1 failed: failed to query documents: error: Invalid query (HTTP 400)",
+		},
+		{
+			name:           "no queries",
+			expectedResult: [][]*tpuf.QueryResult{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						var request tpuf.QueryRequest
+						assert.NoError(t, json.NewDecoder(req.Body).Decode(&request))
+						if request.TopK == 99 {
+							return &http.Response{
+								StatusCode: http.StatusBadRequest,
+								Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid query","status":"error"}`)),
+							}, nil
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[{"id":"%d"}]`, request.TopK))),
+						}, nil
+					},
+				},
+			}
+
+			results, err := client.MultiQuery(context.Background(), "test-namespace", tt.requests)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, results, "unexpected query results")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, results)
+			}
+		})
+	}
+}
+
+func TestQueryTyped(t *testing.T) {
+	type attrs struct {
+		Title string `json:"title"`
+		Count int    `json:"count"`
+	}
+
+	tests := []struct {
+		name           string
+		responseBody   string
+		expectedError  string
+		expectedResult []tpuf.TypedResult[attrs]
+	}{
+		{
+			name:         "decodes attributes",
+			responseBody: `[{"id":"1","dist":0.1,"attributes":{"title":"one","count":1}},{"id":"2","dist":0.2}]`,
+			expectedResult: []tpuf.TypedResult[attrs]{
+				{ID: "1", Dist: 0.1, Attributes: attrs{Title: "one", Count: 1}},
+				{ID: "2", Dist: 0.2},
+			},
+		},
+		{
+			name:          "reports failing document",
+			responseBody:  `[{"id":"1","dist":0.1,"attributes":{"title":"one"}},{"id":"2","dist":0.2,"attributes":{"count":"two"}}]`,
+			expectedError: "failed to decode attributes of result 1 (id 2): json: cannot unmarshal string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(tt.responseBody)),
+						}, nil
+					},
+				},
+			}
+
+			results, err := tpuf.QueryTyped[attrs](context.Background(), client, "test-namespace", &tpuf.QueryRequest{
+				TopK:              2,
+				IncludeAttributes: tpuf.IncludeAllAttributes(),
+			})
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, results, "unexpected query results")
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Nil(t, results)
+			}
+		})
+	}
+}
+
+func TestQueryResultNumericIDs(t *testing.T) {
+	var results []*tpuf.QueryResult
+	err := json.Unmarshal([]byte(`[{"id":42,"dist":0.5},{"id":"42","dist":0.6,"attributes":{"a":1}}]`), &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []*tpuf.QueryResult{
+		{ID: "42", IDUint64: tpuf.Uint64(42), Dist: 0.5},
+		{ID: "42", Dist: 0.6, Attributes: json.RawMessage(`{"a":1}`)},
+	}, results)
+}
+
+func TestQueryHasDist(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *tpuf.QueryRequest
+		expected bool
+	}{
+		{name: "vector search", request: &tpuf.QueryRequest{Vector: []float32{0.1}, DistanceMetric: tpuf.DistanceMetricCosine}, expected: true},
+		{name: "BM25 search", request: &tpuf.QueryRequest{RankBy: tpuf.BM25("text", "fox")}, expected: true},
+		{name: "ordered by attribute", request: &tpuf.QueryRequest{RankBy: tpuf.Asc("price")}, expected: false},
+		{name: "filter only", request: &tpuf.QueryRequest{Filters: tpuf.Eq("category", "a")}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0}]`)),
+						}, nil
+					},
+				},
+			}
+
+			results, err := client.Query(context.Background(), "test-namespace", tt.request)
+			assert.NoError(t, err)
+			assert.Equal(t, []*tpuf.QueryResult{{ID: "1", HasDist: tt.expected}}, results)
+
+			err = client.QueryStream(context.Background(), "test-namespace", tt.request, func(result *tpuf.QueryResult) error {
+				assert.Equal(t, tt.expected, result.HasDist, "unexpected HasDist when streaming")
+				return nil
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIncludeAttributesJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  *tpuf.IncludeAttributes
+		expected string
+	}{
+		{name: "all attributes", include: tpuf.IncludeAllAttributes(), expected: `true`},
+		{name: "named attributes", include: tpuf.IncludeAttributeNames("a", "b"), expected: `["a","b"]`},
+		{name: "no attributes", include: tpuf.IncludeAttributeNames(), expected: `false`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marshaled, err := json.Marshal(tt.include)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(marshaled))
+
+			var unmarshaled tpuf.IncludeAttributes
+			assert.NoError(t, json.Unmarshal(marshaled, &unmarshaled))
+			assert.Equal(t, tt.include.All(), unmarshaled.All())
+			assert.Equal(t, len(tt.include.Names()), len(unmarshaled.Names()))
+		})
+	}
+
+	var invalid tpuf.IncludeAttributes
+	assert.Error(t, json.Unmarshal([]byte(`"title"`), &invalid))
+}
+
+func TestQueryStream(t *testing.T) {
+	stopErr := errors.New("stop")
+
+	tests := []struct {
+		name          string
+		httpResponse  *http.Response
+		stopAfter     int
+		expectedError string
+		expectedIDs   []string
+	}{
+		{
+			name: "streams all results",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0.1,"vector":[0.1,0.2]},{"id":"2","dist":0.2},{"id":"3","dist":0.3}]`)),
+			},
+			expectedIDs: []string{"1", "2", "3"},
+		},
+		{
+			name: "empty results",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[]`)),
+			},
+		},
+		{
+			name: "callback stops decoding",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0.1},{"id":"2","dist":0.2},{"id":"3"`)),
+			},
+			stopAfter:     2,
+			expectedError: "stop",
+			expectedIDs:   []string{"1", "2"},
+		},
+		{
+			name: "truncated response",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0.1},{"id":"2","di`)),
+			},
+			expectedError: "failed to decode result 1: unexpected EOF",
+			expectedIDs:   []string{"1"},
+		},
+		{
+			name: "not an array",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"rows":[]}`)),
+			},
+			expectedError: "failed to decode response: expected [, got {",
+		},
+		{
+			name: "query error",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid query","status":"error"}`)),
+			},
+			expectedError: "failed to query documents: error: Invalid query (HTTP 400)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "https://api.turbopuffer.com/v1/vectors/test-namespace/query", req.URL.String(), "unexpected request URL")
+						return tt.httpResponse, nil
+					},
+				},
+			}
+
+			var ids []string
+			err := client.QueryStream(context.Background(), "test-namespace", &tpuf.QueryRequest{TopK: 1000}, func(result *tpuf.QueryResult) error {
+				ids = append(ids, result.ID)
+				if len(ids) == tt.stopAfter {
+					return stopErr
+				}
+				return nil
+			})
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedIDs, ids, "unexpected streamed results")
+		})
+	}
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestQueryPagination(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *tpuf.QueryRequest
+		pages          []string
+		expectedBodies []string
+		expectedIDs    []string
+	}{
+		{
+			name:    "filter-only query split into pages",
+			request: &tpuf.QueryRequest{TopK: 5, Filters: tpuf.Eq("kind", "a")},
+			pages:   []string{`[{"id":"1"},{"id":"2"}]`, `[{"id":"3"},{"id":"4"}]`, `[{"id":"5"}]`},
+			expectedBodies: []string{
+				`{"top_k":2,"filters":["kind","Eq","a"]}`,
+				`{"top_k":2,"filters":["And",[["kind","Eq","a"],["id","Gt","2"]]]}`,
+				`{"top_k":1,"filters":["And",[["kind","Eq","a"],["id","Gt","4"]]]}`,
+			},
+			expectedIDs: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name:    "stops when results run out",
+			request: &tpuf.QueryRequest{TopK: 5},
+			pages:   []string{`[{"id":1},{"id":2}]`, `[{"id":3}]`},
+			expectedBodies: []string{
+				`{"top_k":2}`,
+				`{"top_k":2,"filters":["id","Gt",2]}`,
+			},
+			expectedIDs: []string{"1", "2", "3"},
+		},
+		{
+			name:    "descending ID order",
+			request: &tpuf.QueryRequest{TopK: 3, RankBy: tpuf.Desc(tpuf.IDAttribute)},
+			pages:   []string{`[{"id":"c"},{"id":"b"}]`, `[{"id":"a"}]`},
+			expectedBodies: []string{
+				`{"rank_by":["id","desc"],"top_k":2}`,
+				`{"rank_by":["id","desc"],"top_k":1,"filters":["id","Lt","b"]}`,
+			},
+			expectedIDs: []string{"c", "b", "a"},
+		},
+		{
+			name:           "vector query sent unchanged",
+			request:        &tpuf.QueryRequest{TopK: 3, Vector: []float32{1}, DistanceMetric: tpuf.DistanceMetricCosine},
+			pages:          []string{`[{"id":"a","dist":0.1}]`},
+			expectedBodies: []string{`{"vector":[1],"distance_metric":"cosine_distance","top_k":3}`},
+			expectedIDs:    []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				MaxTopK:  2,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						bodies = append(bodies, string(body))
+						page := tt.pages[len(bodies)-1]
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(page))}, nil
+					},
+				},
+			}
+
+			results, err := client.Query(context.Background(), "test-namespace", tt.request)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBodies, bodies)
+			var ids []string
+			for _, result := range results {
+				ids = append(ids, result.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package tpuf
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// RankBy is an expression determining how query results are ranked, such as BM25 full-text relevance
+// or ordering by an attribute.  Expressions may be composed with Sum, Max, and Product.
+// See https://turbopuffer.com/docs/query#parameters
+type RankBy interface {
+	tpuf_SerializeRankBy() interface{}
+	json.Marshaler
+}
+
+// BM25RankBy ranks results by BM25 relevance of a full-text searchable attribute to a query string, or to
+// a list of tokens for attributes using TokenizerPreTokenizedArray.
+type BM25RankBy struct {
+	Attribute string
+	Query     string
+	// Tokens, if non-nil, is sent in place of Query.
+	Tokens []string
+}
+
+// BM25 ranks results by BM25 relevance of the given full-text searchable attribute to the query string.
+func BM25(attribute string, query string) *BM25RankBy {
+	return &BM25RankBy{Attribute: attribute, Query: query}
+}
+
+// BM25Tokens ranks results by BM25 relevance of the given pre-tokenized attribute to the query tokens,
+// which must be produced by the same tokenizer as the attribute's values.
+// See TokenizerPreTokenizedArray.
+func BM25Tokens(attribute string, tokens ...string) *BM25RankBy {
+	if tokens == nil {
+		tokens = []string{}
+	}
+	return &BM25RankBy{Attribute: attribute, Tokens: tokens}
+}
+
+func (r *BM25RankBy) tpuf_SerializeRankBy() interface{} {
+	if r.Tokens != nil {
+		return []interface{}{r.Attribute, "BM25", r.Tokens}
+	}
+	return []interface{}{r.Attribute, "BM25", r.Query}
+}
+
+func (r *BM25RankBy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.tpuf_SerializeRankBy())
+}
+
+// SumRankBy ranks results by the sum of the scores of its sub-expressions.
+type SumRankBy struct {
+	Exprs []RankBy
+}
+
+// Sum ranks results by the sum of the scores of the given expressions.
+func Sum(exprs ...RankBy) *SumRankBy {
+	return &SumRankBy{Exprs: exprs}
+}
+
+func (r *SumRankBy) tpuf_SerializeRankBy() interface{} {
+	return []interface{}{"Sum", serializeRankBys(r.Exprs)}
+}
+
+func (r *SumRankBy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.tpuf_SerializeRankBy())
+}
+
+// MaxRankBy ranks results by the maximum of the scores of its sub-expressions.
+type MaxRankBy struct {
+	Exprs []RankBy
+}
+
+// Max ranks results by the maximum of the scores of the given expressions.
+func Max(exprs ...RankBy) *MaxRankBy {
+	return &MaxRankBy{Exprs: exprs}
+}
+
+func (r *MaxRankBy) tpuf_SerializeRankBy() interface{} {
+	return []interface{}{"Max", serializeRankBys(r.Exprs)}
+}
+
+func (r *MaxRankBy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.tpuf_SerializeRankBy())
+}
+
+// ProductRankBy ranks results by the score of its sub-expression multiplied by a constant weight.
+type ProductRankBy struct {
+	Weight float64
+	Expr   RankBy
+}
+
+// Product ranks results by the score of the given expression multiplied by weight.
+// This is typically used to boost one field relative to others within a Sum.
+func Product(weight float64, expr RankBy) *ProductRankBy {
+	return &ProductRankBy{Weight: weight, Expr: expr}
+}
+
+func (r *ProductRankBy) tpuf_SerializeRankBy() interface{} {
+	return []interface{}{"Product", []interface{}{r.Weight, r.Expr.tpuf_SerializeRankBy()}}
+}
+
+func (r *ProductRankBy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.tpuf_SerializeRankBy())
+}
+
+// MultiFieldBM25 ranks results by BM25 relevance of the query across several full-text searchable
+// attributes, weighting each attribute's score by its boost.  For example, to weight title matches twice
+// as heavily as body matches:
+//
+//	MultiFieldBM25("fox jumping", map[string]float64{"title": 2, "body": 1})
+//
+// BM25 k1 and b are configured per attribute in the namespace schema, not per query.
+func MultiFieldBM25(query string, boosts map[string]float64) RankBy {
+	attributes := make([]string, 0, len(boosts))
+	for attribute := range boosts {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+
+	exprs := make([]RankBy, len(attributes))
+	for i, attribute := range attributes {
+		if boost := boosts[attribute]; boost == 1 {
+			exprs[i] = BM25(attribute, query)
+		} else {
+			exprs[i] = Product(boost, BM25(attribute, query))
+		}
+	}
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return Sum(exprs...)
+}
+
+// SortDirection is the direction in which to order results by an attribute.
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// AttributeRankBy orders results by the value of an attribute.
+type AttributeRankBy struct {
+	Attribute string
+	Direction SortDirection
+}
+
+// Asc orders results by the given attribute, ascending.
+func Asc(attribute string) *AttributeRankBy {
+	return &AttributeRankBy{Attribute: attribute, Direction: SortAsc}
+}
+
+// Desc orders results by the given attribute, descending.
+func Desc(attribute string) *AttributeRankBy {
+	return &AttributeRankBy{Attribute: attribute, Direction: SortDesc}
+}
+
+func (r *AttributeRankBy) tpuf_SerializeRankBy() interface{} {
+	return []interface{}{r.Attribute, r.Direction}
+}
+
+func (r *AttributeRankBy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.tpuf_SerializeRankBy())
+}
+
+func serializeRankBys(exprs []RankBy) []interface{} {
+	serialized := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		serialized[i] = expr.tpuf_SerializeRankBy()
+	}
+	return serialized
+}
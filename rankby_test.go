@@ -0,0 +1,79 @@
+package tpuf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalRankBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		rankBy   tpuf.RankBy
+		expected string
+	}{
+		{
+			name:     "BM25",
+			rankBy:   tpuf.BM25("description", "fox jumping"),
+			expected: `["description","BM25","fox jumping"]`,
+		},
+		{
+			name:     "BM25 with tokens",
+			rankBy:   tpuf.BM25Tokens("tokens", "fox", "jump"),
+			expected: `["tokens","BM25",["fox","jump"]]`,
+		},
+		{
+			name:     "Sum",
+			rankBy:   tpuf.Sum(tpuf.BM25("title", "fox"), tpuf.BM25("description", "fox")),
+			expected: `["Sum",[["title","BM25","fox"],["description","BM25","fox"]]]`,
+		},
+		{
+			name:     "Max",
+			rankBy:   tpuf.Max(tpuf.BM25("title", "fox"), tpuf.BM25("description", "fox")),
+			expected: `["Max",[["title","BM25","fox"],["description","BM25","fox"]]]`,
+		},
+		{
+			name:     "Product",
+			rankBy:   tpuf.Product(2.5, tpuf.BM25("title", "fox")),
+			expected: `["Product",[2.5,["title","BM25","fox"]]]`,
+		},
+		{
+			name: "Nested",
+			rankBy: tpuf.Sum(
+				tpuf.Product(2, tpuf.BM25("title", "fox")),
+				tpuf.Max(tpuf.BM25("description", "fox"), tpuf.BM25("body", "fox")),
+			),
+			expected: `["Sum",[["Product",[2,["title","BM25","fox"]]],["Max",[["description","BM25","fox"],["body","BM25","fox"]]]]]`,
+		},
+		{
+			name:     "Multi-field with boosts",
+			rankBy:   tpuf.MultiFieldBM25("fox", map[string]float64{"title": 2, "body": 1, "tags": 0.5}),
+			expected: `["Sum",[["body","BM25","fox"],["Product",[0.5,["tags","BM25","fox"]]],["Product",[2,["title","BM25","fox"]]]]]`,
+		},
+		{
+			name:     "Multi-field with single field",
+			rankBy:   tpuf.MultiFieldBM25("fox", map[string]float64{"title": 3}),
+			expected: `["Product",[3,["title","BM25","fox"]]]`,
+		},
+		{
+			name:     "Attribute ascending",
+			rankBy:   tpuf.Asc("timestamp"),
+			expected: `["timestamp","asc"]`,
+		},
+		{
+			name:     "Attribute descending",
+			rankBy:   tpuf.Desc("timestamp"),
+			expected: `["timestamp","desc"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := json.Marshal(tt.rankBy)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
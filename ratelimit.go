@@ -0,0 +1,58 @@
+package tpuf
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit is the rate limit state reported in a response's X-RateLimit-* and Retry-After headers.
+// Use it to reduce concurrency before requests start failing with 429 Too Many Requests.
+type RateLimit struct {
+	// Limit is the number of requests allowed in the current window, or -1 if not reported.
+	Limit int
+	// Remaining is the number of requests left in the current window, or -1 if not reported.
+	Remaining int
+	// Reset is when the current window ends, or the zero time if not reported.
+	Reset time.Time
+	// RetryAfter is how long the server asked clients to wait before retrying, or 0 if not reported.
+	RetryAfter time.Duration
+}
+
+// resetEpochThreshold separates the two conventions for X-RateLimit-Reset: values below it are a number of
+// seconds until the reset, and values above it are a Unix timestamp.
+const resetEpochThreshold = 1_000_000_000
+
+// parseRateLimit returns the rate limit reported in the headers, or nil if the response has none.
+func parseRateLimit(header http.Header, now time.Time) *RateLimit {
+	rateLimit := &RateLimit{Limit: -1, Remaining: -1}
+	reported := false
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		rateLimit.Limit, reported = limit, true
+	}
+	if remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		rateLimit.Remaining, reported = remaining, true
+	}
+	if reset, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset"), 64); err == nil && reset >= 0 {
+		if reset >= resetEpochThreshold {
+			rateLimit.Reset = time.Unix(0, int64(reset*float64(time.Second)))
+		} else {
+			rateLimit.Reset = now.Add(time.Duration(reset * float64(time.Second)))
+		}
+		reported = true
+	}
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			rateLimit.RetryAfter, reported = time.Duration(seconds)*time.Second, true
+		} else if date, err := http.ParseTime(retryAfter); err == nil {
+			if date.After(now) {
+				rateLimit.RetryAfter = date.Sub(now)
+			}
+			reported = true
+		}
+	}
+	if !reported {
+		return nil
+	}
+	return rateLimit
+}
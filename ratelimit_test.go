@@ -0,0 +1,74 @@
+package tpuf
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected *RateLimit
+	}{
+		{
+			name: "no headers",
+		},
+		{
+			name:     "unparseable headers",
+			headers:  map[string]string{"X-RateLimit-Remaining": "lots", "Retry-After": "soon"},
+			expected: nil,
+		},
+		{
+			name: "reset in seconds",
+			headers: map[string]string{
+				"X-RateLimit-Limit":     "1000",
+				"X-RateLimit-Remaining": "12",
+				"X-RateLimit-Reset":     "1.5",
+			},
+			expected: &RateLimit{Limit: 1000, Remaining: 12, Reset: now.Add(1500 * time.Millisecond)},
+		},
+		{
+			name:     "reset as unix timestamp",
+			headers:  map[string]string{"X-RateLimit-Reset": "1704164700"},
+			expected: &RateLimit{Limit: -1, Remaining: -1, Reset: time.Unix(1704164700, 0)},
+		},
+		{
+			name:     "retry after seconds",
+			headers:  map[string]string{"Retry-After": "7"},
+			expected: &RateLimit{Limit: -1, Remaining: -1, RetryAfter: 7 * time.Second},
+		},
+		{
+			name:     "retry after date",
+			headers:  map[string]string{"Retry-After": now.Add(time.Minute).Format(http.TimeFormat)},
+			expected: &RateLimit{Limit: -1, Remaining: -1, RetryAfter: time.Minute},
+		},
+		{
+			name:     "retry after date in the past",
+			headers:  map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)},
+			expected: &RateLimit{Limit: -1, Remaining: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tt.headers {
+				header.Set(key, value)
+			}
+			rateLimit := parseRateLimit(header, now)
+			if tt.expected == nil {
+				assert.Nil(t, rateLimit)
+				return
+			}
+			if assert.NotNil(t, rateLimit) {
+				assert.True(t, tt.expected.Reset.Equal(rateLimit.Reset), "expected reset %v, got %v", tt.expected.Reset, rateLimit.Reset)
+				rateLimit.Reset = tt.expected.Reset
+				assert.Equal(t, tt.expected, rateLimit)
+			}
+		})
+	}
+}
@@ -26,13 +26,13 @@ type RecallResponse struct {
 // Recall tests the ANN search algorithm compared to exhaustive search.
 // See https://turbopuffer.com/docs/recall for more details.
 func (c *Client) Recall(ctx context.Context, namespace string, request *RecallRequest) (*RecallResponse, error) {
-	path := fmt.Sprintf("/v1/vectors/%s/_debug/recall", namespace)
+	path := c.namespacePath(namespace, "/_debug/recall")
 	reqJson, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	respData, err := c.post(ctx, path, reqJson)
+	respData, err := c.post(ctx, OperationRecall, path, reqJson)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform recall: %w", err)
 	}
@@ -43,3 +43,35 @@ func (c *Client) Recall(ctx context.Context, namespace string, request *RecallRe
 	}
 	return &response, nil
 }
+
+// RecallSweepResult is the recall measured for one combination of TopK and filter in a sweep.
+type RecallSweepResult struct {
+	TopK   int
+	Filter Filter
+	*RecallResponse
+}
+
+// RecallSweep measures recall for each TopK value, unfiltered and then under each of the given filters,
+// running numQueries sampled queries per combination.  Results are ordered by filter and then TopK.
+// Combinations are measured one at a time, since each recall measurement is expensive.
+func (c *Client) RecallSweep(ctx context.Context, namespace string, topKs []int, numQueries int, filters ...Filter) ([]*RecallSweepResult, error) {
+	configs := append([]Filter{nil}, filters...)
+	results := make([]*RecallSweepResult, 0, len(configs)*len(topKs))
+	for i, filter := range configs {
+		for _, topK := range topKs {
+			response, err := c.Recall(ctx, namespace, &RecallRequest{
+				Num:     numQueries,
+				TopK:    topK,
+				Filters: filter,
+			})
+			if err != nil {
+				if filter == nil {
+					return nil, fmt.Errorf("recall sweep failed for top_k %d without filter: %w", topK, err)
+				}
+				return nil, fmt.Errorf("recall sweep failed for top_k %d with filter %d: %w", topK, i, err)
+			}
+			results = append(results, &RecallSweepResult{TopK: topK, Filter: filter, RecallResponse: response})
+		}
+	}
+	return results, nil
+}
@@ -104,3 +104,73 @@ func TestRecall(t *testing.T) {
 		})
 	}
 }
+
+func TestRecallSweep(t *testing.T) {
+	var bodies []string
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) == 4 {
+					return &http.Response{
+						StatusCode: http.StatusBadRequest,
+						Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid filter","status":"error"}`)),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"avg_recall":0.9,"avg_exhaustive_count":10,"avg_ann_count":9}`)),
+				}, nil
+			},
+		},
+	}
+	filter := tpuf.Eq("category", "electronics")
+
+	results, err := client.RecallSweep(context.Background(), "test-namespace", []int{10, 100}, 5, filter)
+	assert.EqualError(t, err, "recall sweep failed for top_k 100 with filter 1: failed to perform recall: error: Invalid filter (HTTP 400)")
+	assert.Nil(t, results)
+
+	bodies = nil
+	results, err = client.RecallSweep(context.Background(), "test-namespace", []int{10, 100}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"num":5,"top_k":10}`, `{"num":5,"top_k":100}`}, bodies, "unexpected request bodies")
+	response := &tpuf.RecallResponse{AvgRecall: 0.9, AvgExhaustiveCount: 10, AvgAnnCount: 9}
+	assert.Equal(t, []*tpuf.RecallSweepResult{
+		{TopK: 10, RecallResponse: response},
+		{TopK: 100, RecallResponse: response},
+	}, results)
+}
+
+func TestRecallSweepWithFilters(t *testing.T) {
+	var bodies []string
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(body))
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"avg_recall":1}`)),
+				}, nil
+			},
+		},
+	}
+	filter := tpuf.Eq("category", "electronics")
+
+	results, err := client.RecallSweep(context.Background(), "test-namespace", []int{10}, 5, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`{"num":5,"top_k":10}`,
+		`{"num":5,"top_k":10,"filters":["category","Eq","electronics"]}`,
+	}, bodies, "unexpected request bodies")
+	if assert.Len(t, results, 2) {
+		assert.Nil(t, results[0].Filter)
+		assert.Equal(t, filter, results[1].Filter)
+		assert.Equal(t, 1.0, results[1].AvgRecall)
+	}
+}
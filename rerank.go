@@ -0,0 +1,17 @@
+package tpuf
+
+import "context"
+
+// Reranker reorders query results by relevance to a text query, typically using a cross-encoder model.
+// Implementations may drop candidates, but must not return results which were not among the candidates.
+// See examples/cohere_reranker.go for an example implementation.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, candidates []*QueryResult) ([]*QueryResult, error)
+}
+
+// RerankerFunc adapts an ordinary function to the Reranker interface.
+type RerankerFunc func(ctx context.Context, query string, candidates []*QueryResult) ([]*QueryResult, error)
+
+func (f RerankerFunc) Rerank(ctx context.Context, query string, candidates []*QueryResult) ([]*QueryResult, error) {
+	return f(ctx, query, candidates)
+}
@@ -0,0 +1,163 @@
+package tpuf
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultMaxRetries           = 6
+	defaultInitialRetryInterval = 2 * time.Second
+	defaultMaxRetryInterval     = 64 * time.Second
+)
+
+// RetryPolicy configures how an operation is retried.  Zero fields use the client's settings.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of times to retry the operation.  Defaults to the client's MaxRetries.
+	MaxRetries int
+	// Disable disables retries of the operation.
+	Disable bool
+	// InitialInterval is the delay before the first retry, which doubles with each further retry.
+	// Defaults to 2 seconds.
+	InitialInterval time.Duration
+	// MaxInterval is the maximum delay between retries.  Defaults to 64 seconds.
+	MaxInterval time.Duration
+	// RetryOnNetworkError selects which kinds of network errors are retried.  Defaults to the client's
+	// RetryOnNetworkError.
+	RetryOnNetworkError *NetworkErrorPolicy
+}
+
+func (c *Client) retryPolicy(op Operation) RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries:      c.MaxRetries,
+		InitialInterval: defaultInitialRetryInterval,
+		MaxInterval:     defaultMaxRetryInterval,
+	}
+	if op == OperationPing && c.RetryPolicies[op] == nil {
+		// Pings back readiness probes, which should fail fast rather than wait out a backoff.
+		policy.Disable = true
+	}
+	if override := c.RetryPolicies[op]; override != nil {
+		policy.Disable = override.Disable
+		if override.MaxRetries != 0 {
+			policy.MaxRetries = override.MaxRetries
+		}
+		if override.InitialInterval != 0 {
+			policy.InitialInterval = override.InitialInterval
+		}
+		if override.MaxInterval != 0 {
+			policy.MaxInterval = override.MaxInterval
+		}
+		policy.RetryOnNetworkError = override.RetryOnNetworkError
+	}
+	if policy.RetryOnNetworkError == nil {
+		policy.RetryOnNetworkError = c.RetryOnNetworkError
+	}
+	if policy.RetryOnNetworkError == nil {
+		policy.RetryOnNetworkError = noNetworkErrors
+		if !op.IsWrite() || c.RetryWritesOnNetworkError {
+			policy.RetryOnNetworkError = allNetworkErrors
+		}
+	}
+	if policy.MaxRetries == 0 {
+		policy.MaxRetries = defaultMaxRetries
+	}
+	if c.DisableRetry || policy.Disable {
+		policy.MaxRetries = 0
+	}
+	return policy
+}
+
+// NetworkErrorPolicy selects which network errors, i.e. failures to get a response from the server, are
+// retried.  HTTP errors such as 5xx responses are retried regardless.  Requests which fail because their
+// context is done are never retried.
+type NetworkErrorPolicy struct {
+	// DNS retries failures to resolve the API's host name.
+	DNS bool
+	// ConnectionRefused retries failures to connect.  The request was never sent, so this is safe for writes.
+	ConnectionRefused bool
+	// ConnectionReset retries requests whose connection was reset or broken while in flight.
+	ConnectionReset bool
+	// EOF retries requests whose connection was closed before a response was received.
+	EOF bool
+	// Other retries any other network errors, such as timeouts and TLS errors.
+	Other bool
+}
+
+var (
+	allNetworkErrors = &NetworkErrorPolicy{DNS: true, ConnectionRefused: true, ConnectionReset: true, EOF: true, Other: true}
+	noNetworkErrors  = &NetworkErrorPolicy{}
+)
+
+// retries reports whether the policy retries a request which failed with the given network error.
+func (p *NetworkErrorPolicy) retries(err error) bool {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return p.DNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return p.ConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE):
+		return p.ConnectionReset
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return p.EOF
+	}
+	return p.Other
+}
+
+// writeTracker records when a client last wrote to each namespace, for NotFoundGracePeriod.
+type writeTracker struct {
+	mu     sync.Mutex
+	writes map[string]time.Time
+}
+
+// writeTrackerInit guards the lazy initialization of Client.writes, so that Client needn't contain a lock.
+var writeTrackerInit sync.Mutex
+
+func (c *Client) writeTracker() *writeTracker {
+	writeTrackerInit.Lock()
+	defer writeTrackerInit.Unlock()
+	if c.writes == nil {
+		c.writes = &writeTracker{writes: map[string]time.Time{}}
+	}
+	return c.writes
+}
+
+// recordWrite notes a successful write to a namespace.  Deleting a namespace forgets it, since reads of a
+// deleted namespace are expected to fail.
+func (c *Client) recordWrite(op Operation, namespace string) {
+	if c.NotFoundGracePeriod <= 0 || !op.IsWrite() || namespace == "" {
+		return
+	}
+	tracker := c.writeTracker()
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if op == OperationDeleteNamespace {
+		delete(tracker.writes, namespace)
+		return
+	}
+	tracker.writes[namespace] = time.Now()
+	for name, written := range tracker.writes {
+		if time.Since(written) > c.NotFoundGracePeriod {
+			delete(tracker.writes, name)
+		}
+	}
+}
+
+// inNotFoundGracePeriod reports whether a read which failed with the given status should be retried because
+// the client wrote to the namespace within NotFoundGracePeriod.
+func (c *Client) inNotFoundGracePeriod(op Operation, namespace string, statusCode int) bool {
+	if c.NotFoundGracePeriod <= 0 || op.IsWrite() || statusCode != http.StatusNotFound || namespace == "" {
+		return false
+	}
+	tracker := c.writeTracker()
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	written, ok := tracker.writes[namespace]
+	return ok && time.Since(written) <= c.NotFoundGracePeriod
+}
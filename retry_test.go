@@ -0,0 +1,255 @@
+package tpuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    *Client
+		operation Operation
+		expected  RetryPolicy
+	}{
+		{
+			name:     "defaults",
+			client:   &Client{},
+			expected: RetryPolicy{MaxRetries: 6, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: allNetworkErrors},
+		},
+		{
+			name:     "client max retries",
+			client:   &Client{MaxRetries: 3},
+			expected: RetryPolicy{MaxRetries: 3, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: allNetworkErrors},
+		},
+		{
+			name: "operation overrides",
+			client: &Client{MaxRetries: 3, RetryPolicies: map[Operation]*RetryPolicy{
+				OperationQuery: {MaxRetries: 10, InitialInterval: time.Second, MaxInterval: 8 * time.Second, RetryOnNetworkError: allNetworkErrors},
+			}},
+			expected: RetryPolicy{MaxRetries: 10, InitialInterval: time.Second, MaxInterval: 8 * time.Second, RetryOnNetworkError: allNetworkErrors},
+		},
+		{
+			name: "partial override",
+			client: &Client{MaxRetries: 3, RetryPolicies: map[Operation]*RetryPolicy{
+				OperationQuery: {MaxInterval: 8 * time.Second, RetryOnNetworkError: allNetworkErrors},
+			}},
+			expected: RetryPolicy{MaxRetries: 3, InitialInterval: 2 * time.Second, MaxInterval: 8 * time.Second, RetryOnNetworkError: allNetworkErrors},
+		},
+		{
+			name: "client disable wins",
+			client: &Client{DisableRetry: true, RetryPolicies: map[Operation]*RetryPolicy{
+				OperationQuery: {MaxRetries: 10},
+			}},
+			expected: RetryPolicy{MaxRetries: 0, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: allNetworkErrors},
+		},
+		{
+			name: "operation disabled",
+			client: &Client{RetryPolicies: map[Operation]*RetryPolicy{
+				OperationQuery: {Disable: true},
+			}},
+			expected: RetryPolicy{MaxRetries: 0, Disable: true, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: allNetworkErrors},
+		},
+		{
+			name:      "writes don't retry network errors by default",
+			client:    &Client{},
+			operation: OperationUpsert,
+			expected:  RetryPolicy{MaxRetries: 6, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: noNetworkErrors},
+		},
+		{
+			name:      "writes retry network errors when enabled",
+			client:    &Client{RetryWritesOnNetworkError: true},
+			operation: OperationUpsert,
+			expected:  RetryPolicy{MaxRetries: 6, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: allNetworkErrors},
+		},
+		{
+			name:      "client network error policy",
+			client:    &Client{RetryOnNetworkError: &NetworkErrorPolicy{DNS: true}},
+			operation: OperationUpsert,
+			expected:  RetryPolicy{MaxRetries: 6, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: &NetworkErrorPolicy{DNS: true}},
+		},
+		{
+			name: "operation network error policy",
+			client: &Client{
+				RetryOnNetworkError: &NetworkErrorPolicy{DNS: true},
+				RetryPolicies:       map[Operation]*RetryPolicy{OperationUpsert: {RetryOnNetworkError: &NetworkErrorPolicy{EOF: true}}},
+			},
+			operation: OperationUpsert,
+			expected:  RetryPolicy{MaxRetries: 6, InitialInterval: 2 * time.Second, MaxInterval: 64 * time.Second, RetryOnNetworkError: &NetworkErrorPolicy{EOF: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation := tt.operation
+			if operation == "" {
+				operation = OperationQuery
+			}
+			assert.Equal(t, tt.expected, tt.client.retryPolicy(operation))
+		})
+	}
+}
+
+func TestNetworkErrorPolicy(t *testing.T) {
+	dnsErr := &url.Error{Op: "Post", URL: "https://api.turbopuffer.com", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "api.turbopuffer.com"}}}
+	refusedErr := &url.Error{Op: "Post", URL: "https://api.turbopuffer.com", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}
+	resetErr := &url.Error{Op: "Post", URL: "https://api.turbopuffer.com", Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}
+	eofErr := &url.Error{Op: "Post", URL: "https://api.turbopuffer.com", Err: io.EOF}
+	otherErr := &url.Error{Op: "Post", URL: "https://api.turbopuffer.com", Err: errors.New("tls: handshake failure")}
+
+	tests := []struct {
+		name     string
+		policy   *NetworkErrorPolicy
+		err      error
+		expected bool
+	}{
+		{name: "dns", policy: &NetworkErrorPolicy{DNS: true}, err: dnsErr, expected: true},
+		{name: "dns not retried", policy: &NetworkErrorPolicy{ConnectionRefused: true, ConnectionReset: true, EOF: true, Other: true}, err: dnsErr, expected: false},
+		{name: "connection refused", policy: &NetworkErrorPolicy{ConnectionRefused: true}, err: refusedErr, expected: true},
+		{name: "connection refused not retried", policy: &NetworkErrorPolicy{ConnectionReset: true}, err: refusedErr, expected: false},
+		{name: "connection reset", policy: &NetworkErrorPolicy{ConnectionReset: true}, err: resetErr, expected: true},
+		{name: "connection reset not retried", policy: &NetworkErrorPolicy{Other: true}, err: resetErr, expected: false},
+		{name: "eof", policy: &NetworkErrorPolicy{EOF: true}, err: eofErr, expected: true},
+		{name: "wrapped unexpected eof", policy: &NetworkErrorPolicy{EOF: true}, err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), expected: true},
+		{name: "other", policy: &NetworkErrorPolicy{Other: true}, err: otherErr, expected: true},
+		{name: "other not retried", policy: &NetworkErrorPolicy{DNS: true, EOF: true}, err: otherErr, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.retries(tt.err))
+		})
+	}
+}
+
+func TestClientDoesNotRetryCanceledRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	client := &Client{
+		ApiToken: "test-token",
+		Timer:    &fakeTimer{},
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				calls++
+				cancel()
+				return nil, context.Canceled
+			},
+		},
+	}
+
+	_, err := client.do(ctx, OperationQuery, http.MethodGet, "/test", nil, nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls, "unexpected number of calls")
+}
+
+func TestNotFoundGracePeriod(t *testing.T) {
+	tests := []struct {
+		name          string
+		gracePeriod   time.Duration
+		before        func(client *Client) error
+		namespace     string
+		expectedCalls int
+		expectError   bool
+	}{
+		{
+			name:        "query after upsert is retried",
+			gracePeriod: time.Minute,
+			before: func(client *Client) error {
+				_, err := client.Upsert(context.Background(), "test-ns", &UpsertRequest{Upserts: []*Upsert{{ID: "1", Vector: []float32{1}}}})
+				return err
+			},
+			namespace:     "test-ns",
+			expectedCalls: 3,
+		},
+		{
+			name:          "no recent write",
+			gracePeriod:   time.Minute,
+			namespace:     "test-ns",
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name: "disabled",
+			before: func(client *Client) error {
+				_, err := client.Upsert(context.Background(), "test-ns", &UpsertRequest{Upserts: []*Upsert{{ID: "1", Vector: []float32{1}}}})
+				return err
+			},
+			namespace:     "test-ns",
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name:        "write to another namespace",
+			gracePeriod: time.Minute,
+			before: func(client *Client) error {
+				_, err := client.Upsert(context.Background(), "other-ns", &UpsertRequest{Upserts: []*Upsert{{ID: "1", Vector: []float32{1}}}})
+				return err
+			},
+			namespace:     "test-ns",
+			expectedCalls: 1,
+			expectError:   true,
+		},
+		{
+			name:        "deleted namespace",
+			gracePeriod: time.Minute,
+			before: func(client *Client) error {
+				_, err := client.Upsert(context.Background(), "test-ns", &UpsertRequest{Upserts: []*Upsert{{ID: "1", Vector: []float32{1}}}})
+				if err != nil {
+					return err
+				}
+				return client.DeleteNamespace(context.Background(), "test-ns")
+			},
+			namespace:     "test-ns",
+			expectedCalls: 1,
+			expectError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := 0
+			client := &Client{
+				ApiToken:            "test-token",
+				NotFoundGracePeriod: tt.gracePeriod,
+				Timer:               &fakeTimer{},
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						if !strings.HasSuffix(req.URL.Path, "/query") {
+							return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"status":"OK"}`))}, nil
+						}
+						queries++
+						if queries < 3 {
+							return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{"status":"error","error":"namespace not found"}`))}, nil
+						}
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+					},
+				},
+			}
+			if tt.before != nil {
+				assert.NoError(t, tt.before(client))
+			}
+
+			_, err := client.Query(context.Background(), tt.namespace, &QueryRequest{TopK: 1})
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCalls, queries, "unexpected number of queries")
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package tpuf
+
+import "context"
+
+// ScanOptions configures Client.Scan.
+type ScanOptions struct {
+	// PageSize is the number of documents fetched per request.  Defaults to Client.MaxTopK.
+	PageSize int
+	// IncludeVectors includes the vectors of the documents.
+	IncludeVectors bool
+	// IncludeAttributes specifies which attributes to include.  Default is no attributes.
+	IncludeAttributes *IncludeAttributes
+	// Consistency is the consistency level of each request.  Defaults to the Client's Consistency.
+	Consistency *Consistency
+}
+
+// ScanIterator iterates over every document matching a filter, in ascending order of ID.  Create one with
+// Client.Scan.
+type ScanIterator struct {
+	client    *Client
+	ctx       context.Context
+	namespace string
+	filter    Filter
+	opts      ScanOptions
+	cursor    *QueryResult
+	page      []*QueryResult
+	result    *QueryResult
+	done      bool
+	err       error
+}
+
+// Scan returns an iterator over every document in a namespace matching filter, or every document if filter
+// is nil.  Documents are fetched a page at a time with filter-only queries ordered by ID, each continuing
+// after the last ID of the previous page, so documents written during the scan may or may not be seen.
+//
+//	it := client.Scan(ctx, namespace, tpuf.Eq("category", "docs"), &tpuf.ScanOptions{PageSize: 500})
+//	for it.Next() {
+//		result := it.Result()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (c *Client) Scan(ctx context.Context, namespace string, filter Filter, opts *ScanOptions) *ScanIterator {
+	it := &ScanIterator{client: c, ctx: ctx, namespace: namespace, filter: filter}
+	if opts != nil {
+		it.opts = *opts
+	}
+	if it.opts.PageSize <= 0 {
+		it.opts.PageSize = c.maxTopK()
+	}
+	return it
+}
+
+// Next advances to the next document, fetching another page when the current one is exhausted, and returns
+// false once every matching document has been returned or an error occurs.
+func (it *ScanIterator) Next() bool {
+	if len(it.page) == 0 {
+		if it.done || it.err != nil {
+			it.result = nil
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			it.result = nil
+			return false
+		}
+		if len(it.page) == 0 {
+			it.result = nil
+			return false
+		}
+	}
+	it.result = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+// fetch fetches the page after the last document returned.
+func (it *ScanIterator) fetch() error {
+	filter := it.filter
+	if it.cursor != nil {
+		cursor := idCursor(it.cursor, SortAsc)
+		filter = cursor
+		if it.filter != nil {
+			filter = And(it.filter, cursor)
+		}
+	}
+	page, err := it.client.Query(it.ctx, it.namespace, &QueryRequest{
+		TopK:              it.opts.PageSize,
+		Filters:           filter,
+		IncludeVectors:    it.opts.IncludeVectors,
+		IncludeAttributes: it.opts.IncludeAttributes,
+		Consistency:       it.opts.Consistency,
+	})
+	if err != nil {
+		return err
+	}
+	it.page = page
+	if len(page) < it.opts.PageSize {
+		it.done = true
+	}
+	if len(page) > 0 {
+		it.cursor = page[len(page)-1]
+	}
+	return nil
+}
+
+// Result returns the current document.
+func (it *ScanIterator) Result() *QueryResult {
+	return it.result
+}
+
+// Err returns the error which stopped iteration, if any.
+func (it *ScanIterator) Err() error {
+	return it.err
+}
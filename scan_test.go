@@ -0,0 +1,85 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name           string
+		filter         tpuf.Filter
+		opts           *tpuf.ScanOptions
+		pages          []string
+		expectedBodies []string
+		expectedIDs    []string
+		expectedError  string
+	}{
+		{
+			name:   "pages until a short page",
+			filter: tpuf.Eq("kind", "a"),
+			opts:   &tpuf.ScanOptions{PageSize: 2, IncludeAttributes: tpuf.IncludeAttributeNames("kind")},
+			pages:  []string{`[{"id":"1"},{"id":"2"}]`, `[{"id":"3"}]`},
+			expectedBodies: []string{
+				`{"top_k":2,"include_attributes":["kind"],"filters":["kind","Eq","a"]}`,
+				`{"top_k":2,"include_attributes":["kind"],"filters":["And",[["kind","Eq","a"],["id","Gt","2"]]]}`,
+			},
+			expectedIDs: []string{"1", "2", "3"},
+		},
+		{
+			name:  "pages until an empty page without a filter",
+			opts:  &tpuf.ScanOptions{PageSize: 2, IncludeVectors: true},
+			pages: []string{`[{"id":1},{"id":2}]`, `[]`},
+			expectedBodies: []string{
+				`{"top_k":2,"include_vectors":true}`,
+				`{"top_k":2,"include_vectors":true,"filters":["id","Gt",2]}`,
+			},
+			expectedIDs: []string{"1", "2"},
+		},
+		{
+			name:           "error",
+			pages:          []string{`not json`},
+			expectedBodies: []string{`{"top_k":1200}`},
+			expectedError:  "failed to decode response: invalid character 'o' in literal null (expecting 'u')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						bodies = append(bodies, string(body))
+						page := tt.pages[len(bodies)-1]
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(page))}, nil
+					},
+				},
+			}
+
+			it := client.Scan(context.Background(), "test-namespace", tt.filter, tt.opts)
+			var ids []string
+			for it.Next() {
+				ids = append(ids, it.Result().ID)
+			}
+
+			assert.Equal(t, tt.expectedBodies, bodies)
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Nil(t, it.Result())
+			if tt.expectedError == "" {
+				assert.NoError(t, it.Err())
+			} else {
+				assert.EqualError(t, it.Err(), tt.expectedError)
+			}
+		})
+	}
+}
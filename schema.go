@@ -0,0 +1,48 @@
+package tpuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Schema fetches the schema of a namespace, including the types of attributes inferred from upserted documents.
+// See https://turbopuffer.com/docs/schema
+func (c *Client) Schema(ctx context.Context, namespace string) (Schema, error) {
+	path := c.namespacePath(namespace, "/schema")
+	respData, err := c.get(ctx, OperationSchema, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(respData, &schema); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return schema, nil
+}
+
+// UpdateSchema adds attributes to the schema of a namespace or changes the indexing of existing attributes,
+// returning the updated schema.  Only the attributes to change need to be specified.  The type of an existing
+// attribute can't be changed.
+// See https://turbopuffer.com/docs/schema
+func (c *Client) UpdateSchema(ctx context.Context, namespace string, schema Schema) (Schema, error) {
+	if err := c.validateSchemaNames(schema); err != nil {
+		return nil, err
+	}
+	path := c.namespacePath(namespace, "/schema")
+	reqJson, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	respData, err := c.post(ctx, OperationUpdateSchema, path, reqJson)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schema: %w", err)
+	}
+
+	var updated Schema
+	if err := json.Unmarshal(respData, &updated); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return updated, nil
+}
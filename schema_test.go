@@ -0,0 +1,113 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		name           string
+		httpResponse   *http.Response
+		expectedError  string
+		expectedResult tpuf.Schema
+	}{
+		{
+			name: "full text search as bool and object",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(bytes.NewBufferString(`{
+					"title": {"type": "string", "filterable": false, "full_text_search": true},
+					"body": {"type": "string", "filterable": false, "full_text_search": {"language": "english", "stemming": true}},
+					"category": {"type": "string", "filterable": true, "full_text_search": false}
+				}`)),
+			},
+			expectedResult: tpuf.Schema{
+				"title": &tpuf.Attribute{
+					Type:           tpuf.AttributeTypeString,
+					Filterable:     boolPtr(false),
+					FullTextSearch: &tpuf.FullTextSearchParams{},
+				},
+				"body": &tpuf.Attribute{
+					Type:           tpuf.AttributeTypeString,
+					Filterable:     boolPtr(false),
+					FullTextSearch: &tpuf.FullTextSearchParams{Language: "english", Stemming: boolPtr(true)},
+				},
+				"category": &tpuf.Attribute{
+					Type:       tpuf.AttributeTypeString,
+					Filterable: boolPtr(true),
+				},
+			},
+		},
+		{
+			name: "namespace not found",
+			httpResponse: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Namespace not found","status":"error"}`)),
+			},
+			expectedError: "failed to get schema: error: Namespace not found (HTTP 404)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, http.MethodGet, req.Method, "unexpected request method")
+						assert.Equal(t, "https://api.turbopuffer.com/v1/vectors/test-namespace/schema", req.URL.String(), "unexpected request URL")
+						return tt.httpResponse, nil
+					},
+				},
+			}
+
+			schema, err := client.Schema(context.Background(), "test-namespace")
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, schema, "unexpected schema")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, schema)
+			}
+		})
+	}
+}
+
+func TestUpdateSchema(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPost, req.Method, "unexpected request method")
+				assert.Equal(t, "https://api.turbopuffer.com/v1/vectors/test-namespace/schema", req.URL.String(), "unexpected request URL")
+				body, _ := io.ReadAll(req.Body)
+				assert.JSONEq(t, `{"title":{"type":"string","full_text_search":{}}}`, string(body), "unexpected request body")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"title":{"type":"string","filterable":false,"full_text_search":true}}`)),
+				}, nil
+			},
+		},
+	}
+
+	schema, err := client.UpdateSchema(context.Background(), "test-namespace", tpuf.Schema{
+		"title": &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, tpuf.Schema{
+		"title": &tpuf.Attribute{
+			Type:           tpuf.AttributeTypeString,
+			Filterable:     boolPtr(false),
+			FullTextSearch: &tpuf.FullTextSearchParams{},
+		},
+	}, schema)
+}
@@ -0,0 +1,73 @@
+package tpuf
+
+import "context"
+
+// defaultMaxTopK is the largest TopK the API accepts in a single query.
+const defaultMaxTopK = 1200
+
+func (c *Client) maxTopK() int {
+	if c.MaxTopK == 0 {
+		return defaultMaxTopK
+	}
+	return c.MaxTopK
+}
+
+// idOrder reports whether a query's results are ordered by ID, and in which direction, in which case it can
+// be continued after the last ID of a page.  Filter-only queries without a RankBy are ordered by ascending ID.
+func idOrder(request *QueryRequest) (SortDirection, bool) {
+	if request.Vector != nil {
+		return "", false
+	}
+	switch rankBy := request.RankBy.(type) {
+	case nil:
+		return SortAsc, true
+	case *AttributeRankBy:
+		if rankBy.Attribute == IDAttribute {
+			return rankBy.Direction, true
+		}
+	}
+	return "", false
+}
+
+// queryPages runs a query ordered by ID as a series of requests for at most MaxTopK results each, continuing
+// each request after the last ID of the previous one, until TopK results have been returned or the results
+// run out.
+func (c *Client) queryPages(ctx context.Context, namespace string, request *QueryRequest, direction SortDirection) ([]*QueryResult, *QueryMetadata, error) {
+	var results []*QueryResult
+	var metadata *QueryMetadata
+	page := *request
+	for len(results) < request.TopK {
+		page.TopK = request.TopK - len(results)
+		if page.TopK > c.maxTopK() {
+			page.TopK = c.maxTopK()
+		}
+		pageResults, pageMetadata, err := c.queryOnce(ctx, namespace, &page)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, pageResults...)
+		metadata = pageMetadata
+		if len(pageResults) < page.TopK {
+			break
+		}
+
+		cursor := idCursor(pageResults[len(pageResults)-1], direction)
+		page.Filters = cursor
+		if request.Filters != nil {
+			page.Filters = And(request.Filters, cursor)
+		}
+	}
+	return results, metadata, nil
+}
+
+// idCursor matches the documents after result in the given order of IDs.
+func idCursor(result *QueryResult, direction SortDirection) *BaseFilter {
+	var id interface{} = result.ID
+	if result.IDUint64 != nil {
+		id = *result.IDUint64
+	}
+	if direction == SortDesc {
+		return Lt(IDAttribute, id)
+	}
+	return Gt(IDAttribute, id)
+}
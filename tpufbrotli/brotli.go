@@ -0,0 +1,22 @@
+// Package tpufbrotli decodes brotli-compressed API responses, which are typically smaller than gzip for
+// large query and export responses.
+//
+//	client := &tpuf.Client{ApiToken: token, ResponseDecoders: []*tpuf.ContentDecoder{tpufbrotli.Decoder}}
+//
+// It is a separate module so that the tpuf package doesn't depend on a brotli implementation.
+package tpufbrotli
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/bamo/tpuf-go"
+)
+
+// Decoder decodes responses with Content-Encoding: br.
+var Decoder = &tpuf.ContentDecoder{
+	Encoding: "br",
+	NewReader: func(r io.Reader) (io.Reader, error) {
+		return brotli.NewReader(r), nil
+	},
+}
@@ -0,0 +1,53 @@
+package tpufbrotli_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpufbrotli"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHttpClient struct {
+	doFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (f *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	return f.doFunc(req)
+}
+
+func TestDecoder(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := brotli.NewWriter(&compressed)
+	_, err := writer.Write([]byte(`[{"id":"1","dist":0.5}]`))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	client := &tpuf.Client{
+		ApiToken:         "test-token",
+		ResponseDecoders: []*tpuf.ContentDecoder{tpufbrotli.Decoder},
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Contains(t, req.Header.Get("Accept-Encoding"), "br")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Encoding": []string{"br"}},
+					Body:       io.NopCloser(bytes.NewReader(compressed.Bytes())),
+				}, nil
+			},
+		},
+	}
+
+	results, err := client.Query(context.Background(), "docs", &tpuf.QueryRequest{})
+
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "1", results[0].ID)
+		assert.Equal(t, 0.5, results[0].Dist)
+	}
+}
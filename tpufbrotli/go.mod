@@ -0,0 +1,18 @@
+module github.com/bamo/tpuf-go/tpufbrotli
+
+go 1.20
+
+require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/bamo/tpuf-go v0.0.0
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/cenkalti/backoff/v4 v4.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/bamo/tpuf-go => ../
@@ -0,0 +1,238 @@
+// Package tpufchunk splits text into overlapping chunks for ingestion, e.g. for retrieval-augmented
+// generation, and converts the chunks into upserts carrying metadata about where each chunk came from.
+//
+//	chunks, err := (&tpufchunk.SentenceChunker{MaxChars: 1000, Overlap: 1}).Chunk(text)
+//	upserts, err := tpufchunk.Upserts(ctx, embedder, "doc-1", chunks, nil)
+//	for _, upsert := range upserts {
+//		err := bulkUpserter.Add(ctx, upsert)
+//		...
+//	}
+package tpufchunk
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk is a contiguous piece of a source text.
+type Chunk struct {
+	// Text is the chunk's text.
+	Text string
+	// Index is the 0-based position of the chunk among the chunks of its source.
+	Index int
+	// Start is the byte offset in the source text at which the chunk starts.
+	Start int
+	// End is the byte offset in the source text at which the chunk ends, exclusive.
+	End int
+}
+
+// Chunker splits text into chunks.
+type Chunker interface {
+	Chunk(text string) ([]Chunk, error)
+}
+
+// Span is a range of byte offsets in a text, with End exclusive.
+type Span struct {
+	Start int
+	End   int
+}
+
+func validate(size int, overlap int) error {
+	if size <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", size)
+	}
+	if overlap < 0 || overlap >= size {
+		return fmt.Errorf("overlap must be at least 0 and less than the chunk size %d, got %d", size, overlap)
+	}
+	return nil
+}
+
+// windows groups units into windows of at most size units, each overlapping the previous by overlap units,
+// and returns a chunk for each window spanning from the start of its first unit to the end of its last.
+func windows(text string, units []Span, size int, overlap int) []Chunk {
+	var chunks []Chunk
+	for start := 0; start < len(units); start += size - overlap {
+		end := start + size
+		if end > len(units) {
+			end = len(units)
+		}
+		span := Span{Start: units[start].Start, End: units[end-1].End}
+		chunks = append(chunks, Chunk{Text: text[span.Start:span.End], Index: len(chunks), Start: span.Start, End: span.End})
+		if end == len(units) {
+			break
+		}
+	}
+	return chunks
+}
+
+// CharacterChunker splits text into chunks of Size characters, each overlapping the previous by Overlap
+// characters.  Characters are Unicode code points, so multi-byte characters are never split.
+type CharacterChunker struct {
+	// Size is the maximum number of characters per chunk.  Required.
+	Size int
+	// Overlap is the number of characters repeated from the end of the previous chunk.
+	Overlap int
+}
+
+func (c *CharacterChunker) Chunk(text string) ([]Chunk, error) {
+	if err := validate(c.Size, c.Overlap); err != nil {
+		return nil, err
+	}
+	units := make([]Span, 0, utf8.RuneCountInString(text))
+	for i, r := range text {
+		units = append(units, Span{Start: i, End: i + utf8.RuneLen(r)})
+	}
+	return windows(text, units, c.Size, c.Overlap), nil
+}
+
+// TokenChunker splits text into chunks of Size tokens, each overlapping the previous by Overlap tokens.
+// Chunks span from the start of their first token to the end of their last, so they keep the original
+// whitespace and punctuation between tokens.
+type TokenChunker struct {
+	// Size is the maximum number of tokens per chunk.  Required.
+	Size int
+	// Overlap is the number of tokens repeated from the end of the previous chunk.
+	Overlap int
+	// Tokenize returns the spans of the tokens in text, in order.  Use it to count tokens with the
+	// embedding model's tokenizer.  Defaults to Words.
+	Tokenize func(text string) []Span
+}
+
+func (c *TokenChunker) Chunk(text string) ([]Chunk, error) {
+	if err := validate(c.Size, c.Overlap); err != nil {
+		return nil, err
+	}
+	tokenize := c.Tokenize
+	if tokenize == nil {
+		tokenize = Words
+	}
+	return windows(text, tokenize(text), c.Size, c.Overlap), nil
+}
+
+// Words returns the spans of the whitespace-separated words in text.
+func Words(text string) []Span {
+	var spans []Span
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				spans = append(spans, Span{Start: start, End: i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, Span{Start: start, End: len(text)})
+	}
+	return spans
+}
+
+// SentenceChunker splits text into chunks of whole sentences, packing as many consecutive sentences into
+// each chunk as fit in MaxChars.  A sentence longer than MaxChars becomes a chunk of its own.
+type SentenceChunker struct {
+	// MaxChars is the maximum number of characters per chunk.  Required.
+	MaxChars int
+	// Overlap is the number of sentences repeated from the end of the previous chunk.
+	Overlap int
+}
+
+func (c *SentenceChunker) Chunk(text string) ([]Chunk, error) {
+	if c.MaxChars <= 0 {
+		return nil, fmt.Errorf("max chars must be positive, got %d", c.MaxChars)
+	}
+	if c.Overlap < 0 {
+		return nil, fmt.Errorf("overlap must not be negative, got %d", c.Overlap)
+	}
+	sentences := Sentences(text)
+	var chunks []Chunk
+	for start := 0; start < len(sentences); {
+		end := start + 1
+		for end < len(sentences) && utf8.RuneCountInString(text[sentences[start].Start:sentences[end].End]) <= c.MaxChars {
+			end++
+		}
+		span := Span{Start: sentences[start].Start, End: sentences[end-1].End}
+		chunks = append(chunks, Chunk{Text: text[span.Start:span.End], Index: len(chunks), Start: span.Start, End: span.End})
+		if end == len(sentences) {
+			break
+		}
+		next := end - c.Overlap
+		if next <= start {
+			next = start + 1
+		}
+		start = next
+	}
+	return chunks, nil
+}
+
+// Sentences returns the spans of the sentences in text, excluding surrounding whitespace.  A sentence ends
+// at a '.', '!' or '?', along with any closing quotes or brackets, which is followed by whitespace, or at
+// a blank line.
+func Sentences(text string) []Span {
+	var spans []Span
+	start := -1
+	end := func(i int) {
+		if start >= 0 {
+			spans = append(spans, Span{Start: start, End: i})
+			start = -1
+		}
+	}
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == '\n' && start >= 0 && isBlankLine(text[i+size:]):
+			end(trimRight(text, start, i))
+		case unicode.IsSpace(r):
+		case start < 0:
+			start = i
+		}
+		i += size
+		if r == '.' || r == '!' || r == '?' {
+			for i < len(text) && isClosing(text[i]) {
+				i++
+			}
+			if next, _ := utf8.DecodeRuneInString(text[i:]); i == len(text) || unicode.IsSpace(next) {
+				end(i)
+			}
+		}
+	}
+	end(trimRight(text, maxInt(start, 0), len(text)))
+	return spans
+}
+
+func isClosing(b byte) bool {
+	return b == '"' || b == '\'' || b == ')' || b == ']'
+}
+
+// isBlankLine reports whether rest starts with a line containing only whitespace.
+func isBlankLine(rest string) bool {
+	for _, r := range rest {
+		if r == '\n' {
+			return true
+		}
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return false
+}
+
+func trimRight(text string, start int, end int) int {
+	for end > start {
+		r, size := utf8.DecodeLastRuneInString(text[:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+	return end
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
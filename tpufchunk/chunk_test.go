@@ -0,0 +1,127 @@
+package tpufchunk_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go/tpufchunk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkers(t *testing.T) {
+	tests := []struct {
+		name          string
+		chunker       tpufchunk.Chunker
+		text          string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "characters with overlap",
+			chunker:  &tpufchunk.CharacterChunker{Size: 4, Overlap: 1},
+			text:     "abcdefghij",
+			expected: []string{"abcd", "defg", "ghij"},
+		},
+		{
+			name:     "characters never split multi-byte runes",
+			chunker:  &tpufchunk.CharacterChunker{Size: 2},
+			text:     "héllo",
+			expected: []string{"hé", "ll", "o"},
+		},
+		{
+			name:     "characters of empty text",
+			chunker:  &tpufchunk.CharacterChunker{Size: 2},
+			text:     "",
+			expected: nil,
+		},
+		{
+			name:          "overlap not less than size",
+			chunker:       &tpufchunk.CharacterChunker{Size: 2, Overlap: 2},
+			expectedError: "overlap must be at least 0 and less than the chunk size 2, got 2",
+		},
+		{
+			name:          "missing size",
+			chunker:       &tpufchunk.TokenChunker{},
+			expectedError: "chunk size must be positive, got 0",
+		},
+		{
+			name:     "words with overlap keep inner whitespace",
+			chunker:  &tpufchunk.TokenChunker{Size: 3, Overlap: 1},
+			text:     "  the quick\tbrown fox jumps over  ",
+			expected: []string{"the quick\tbrown", "brown fox jumps", "jumps over"},
+		},
+		{
+			name: "custom tokenizer",
+			chunker: &tpufchunk.TokenChunker{Size: 2, Tokenize: func(text string) []tpufchunk.Span {
+				var spans []tpufchunk.Span
+				for i := 0; i < len(text); i += 3 {
+					end := i + 3
+					if end > len(text) {
+						end = len(text)
+					}
+					spans = append(spans, tpufchunk.Span{Start: i, End: end})
+				}
+				return spans
+			}},
+			text:     "abcdefghijklm",
+			expected: []string{"abcdef", "ghijkl", "m"},
+		},
+		{
+			name:     "sentences packed up to max chars",
+			chunker:  &tpufchunk.SentenceChunker{MaxChars: 30},
+			text:     "One fish. Two fish! Red fish? Blue fish.",
+			expected: []string{"One fish. Two fish! Red fish?", "Blue fish."},
+		},
+		{
+			name:     "sentences with overlap",
+			chunker:  &tpufchunk.SentenceChunker{MaxChars: 20, Overlap: 1},
+			text:     "One fish. Two fish. Red fish.",
+			expected: []string{"One fish. Two fish.", "Two fish. Red fish."},
+		},
+		{
+			name:     "long sentence becomes its own chunk",
+			chunker:  &tpufchunk.SentenceChunker{MaxChars: 5, Overlap: 2},
+			text:     "Hi. This sentence is long. Ok.",
+			expected: []string{"Hi.", "This sentence is long.", "Ok."},
+		},
+		{
+			name:     "sentence boundaries",
+			chunker:  &tpufchunk.SentenceChunker{MaxChars: 1},
+			text:     "He said \"stop.\" Then left (quickly.) Version 1.2 shipped\n\nNew paragraph",
+			expected: []string{"He said \"stop.\"", "Then left (quickly.)", "Version 1.2 shipped", "New paragraph"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := tt.chunker.Chunk(tt.text)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			var texts []string
+			for i, chunk := range chunks {
+				texts = append(texts, chunk.Text)
+				assert.Equal(t, i, chunk.Index, "unexpected chunk index")
+				assert.Equal(t, tt.text[chunk.Start:chunk.End], chunk.Text, "offsets don't match chunk text")
+			}
+			assert.Equal(t, tt.expected, texts)
+		})
+	}
+}
+
+func TestSentenceChunkerLongText(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 100)
+	chunks, err := (&tpufchunk.SentenceChunker{MaxChars: 200, Overlap: 1}).Chunk(text)
+
+	assert.NoError(t, err)
+	for i, chunk := range chunks {
+		assert.LessOrEqual(t, len(chunk.Text), 200)
+		if i > 0 {
+			assert.Less(t, chunk.Start, chunks[i-1].End, "chunk %d doesn't overlap the previous chunk", i)
+		}
+	}
+	assert.Equal(t, len(strings.TrimSpace(text)), chunks[len(chunks)-1].End, "last chunk doesn't reach the end of the text")
+}
@@ -0,0 +1,80 @@
+package tpufchunk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bamo/tpuf-go"
+)
+
+// Attributes set on every upsert produced by Upserts.
+const (
+	AttributeSourceID   = "source_id"
+	AttributeChunkIndex = "chunk_index"
+	AttributeStart      = "start_offset"
+	AttributeEnd        = "end_offset"
+	AttributeText       = "text"
+)
+
+// UpsertOptions configures Upserts.
+type UpsertOptions struct {
+	// ID returns the document ID of a chunk.  Defaults to "<sourceID>#<index>", so that re-ingesting
+	// a source overwrites its previous chunks.
+	ID func(sourceID string, chunk Chunk) string
+	// ExcludeText omits the chunk's text from its attributes.
+	ExcludeText bool
+	// Attributes are copied onto every chunk, e.g. the source's title or URL.
+	Attributes map[string]interface{}
+}
+
+// Upserts converts the chunks of a source into upserts, with attributes recording the source ID, the chunk
+// index, the byte offsets of the chunk in the source, and the chunk's text.  If embedder is set, the chunks'
+// texts are embedded in a single call and set as the upserts' vectors; otherwise the upserts have no vectors,
+// e.g. for full-text search, and must be written with AllowNoVector.  opts may be nil.
+func Upserts(ctx context.Context, embedder tpuf.Embedder, sourceID string, chunks []Chunk, opts *UpsertOptions) ([]*tpuf.Upsert, error) {
+	if opts == nil {
+		opts = &UpsertOptions{}
+	}
+	id := opts.ID
+	if id == nil {
+		id = func(sourceID string, chunk Chunk) string {
+			return fmt.Sprintf("%s#%d", sourceID, chunk.Index)
+		}
+	}
+
+	var vectors [][]float32
+	if embedder != nil && len(chunks) > 0 {
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Text
+		}
+		var err error
+		vectors, err = embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed chunks of %s: %w", sourceID, err)
+		}
+		if len(vectors) != len(chunks) {
+			return nil, fmt.Errorf("failed to embed chunks of %s: expected %d embeddings, got %d", sourceID, len(chunks), len(vectors))
+		}
+	}
+
+	upserts := make([]*tpuf.Upsert, len(chunks))
+	for i, chunk := range chunks {
+		attributes := make(map[string]interface{}, len(opts.Attributes)+5)
+		for name, value := range opts.Attributes {
+			attributes[name] = value
+		}
+		attributes[AttributeSourceID] = sourceID
+		attributes[AttributeChunkIndex] = chunk.Index
+		attributes[AttributeStart] = chunk.Start
+		attributes[AttributeEnd] = chunk.End
+		if !opts.ExcludeText {
+			attributes[AttributeText] = chunk.Text
+		}
+		upserts[i] = &tpuf.Upsert{ID: id(sourceID, chunk), Attributes: attributes}
+		if vectors != nil {
+			upserts[i].Vector = vectors[i]
+		}
+	}
+	return upserts, nil
+}
@@ -0,0 +1,80 @@
+package tpufchunk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpufchunk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpserts(t *testing.T) {
+	chunks := []tpufchunk.Chunk{
+		{Text: "Hello.", Index: 0, Start: 0, End: 6},
+		{Text: "World.", Index: 1, Start: 7, End: 13},
+	}
+	embedder := tpuf.EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		vectors := make([][]float32, len(texts))
+		for i, text := range texts {
+			vectors[i] = []float32{float32(len(text)), float32(i)}
+		}
+		return vectors, nil
+	})
+
+	tests := []struct {
+		name          string
+		embedder      tpuf.Embedder
+		opts          *tpufchunk.UpsertOptions
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "embeds chunks with metadata",
+			embedder: embedder,
+			expected: `[
+				{"id":"doc-1#0","vector":[6,0],"attributes":{"source_id":"doc-1","chunk_index":0,"start_offset":0,"end_offset":6,"text":"Hello."}},
+				{"id":"doc-1#1","vector":[6,1],"attributes":{"source_id":"doc-1","chunk_index":1,"start_offset":7,"end_offset":13,"text":"World."}}
+			]`,
+		},
+		{
+			name: "without embedder, with options",
+			opts: &tpufchunk.UpsertOptions{
+				ID: func(sourceID string, chunk tpufchunk.Chunk) string {
+					return fmt.Sprintf("%s-%d", sourceID, chunk.Start)
+				},
+				ExcludeText: true,
+				Attributes:  map[string]interface{}{"title": "Greeting"},
+			},
+			expected: `[
+				{"id":"doc-1-0","attributes":{"source_id":"doc-1","chunk_index":0,"start_offset":0,"end_offset":6,"title":"Greeting"}},
+				{"id":"doc-1-7","attributes":{"source_id":"doc-1","chunk_index":1,"start_offset":7,"end_offset":13,"title":"Greeting"}}
+			]`,
+		},
+		{
+			name: "embedding failure",
+			embedder: tpuf.EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+				return nil, errors.New("rate limited")
+			}),
+			expectedError: "failed to embed chunks of doc-1: rate limited",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upserts, err := tpufchunk.Upserts(context.Background(), tt.embedder, "doc-1", chunks, tt.opts)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			result, err := json.Marshal(upserts)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tpufembed
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bamo/tpuf-go"
+)
+
+const (
+	defaultCohereBaseURL   = "https://api.cohere.com"
+	defaultCohereModel     = "embed-english-v3.0"
+	defaultCohereInputType = "search_document"
+	defaultCohereBatchSize = 96
+)
+
+// CohereEmbedder is a tpuf.Embedder which calls Cohere's embed API.
+// Texts are sent in batches of up to BatchSize per request.
+// See https://docs.cohere.com/reference/embed
+type CohereEmbedder struct {
+	// ApiKey is the Cohere API key.  Required.
+	ApiKey string
+	// Model is the embedding model to use.  Defaults to embed-english-v3.0.
+	Model string
+	// InputType tells the model how the embeddings will be used.  Use "search_document" when embedding
+	// documents to upsert, and "search_query" when embedding queries.  Defaults to "search_document".
+	InputType string
+	// BatchSize is the maximum number of texts per request.  Defaults to 96, the API's limit.
+	BatchSize int
+	// BaseURL is the base URL of the API.  Defaults to https://api.cohere.com
+	BaseURL string
+	Transport
+}
+
+var _ tpuf.Embedder = (*CohereEmbedder)(nil)
+
+type cohereRequest struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"`
+	EmbeddingTypes []string `json:"embedding_types"`
+}
+
+type cohereResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+func (e *CohereEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCohereBatchSize
+	}
+	return embedInBatches(texts, batchSize, func(batch []string) ([][]float32, error) {
+		return e.embedBatch(ctx, batch)
+	})
+}
+
+func (e *CohereEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	request := &cohereRequest{
+		Model:          e.Model,
+		Texts:          texts,
+		InputType:      e.InputType,
+		EmbeddingTypes: []string{"float"},
+	}
+	if request.Model == "" {
+		request.Model = defaultCohereModel
+	}
+	if request.InputType == "" {
+		request.InputType = defaultCohereInputType
+	}
+	baseURL := e.BaseURL
+	if baseURL == "" {
+		baseURL = defaultCohereBaseURL
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+e.ApiKey)
+
+	var response cohereResponse
+	if err := e.postJSON(ctx, baseURL+"/v2/embed", header, request, &response); err != nil {
+		return nil, err
+	}
+	return response.Embeddings.Float, nil
+}
@@ -0,0 +1,80 @@
+package tpufembed_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go/tpufembed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCohereEmbedder(t *testing.T) {
+	tests := []struct {
+		name               string
+		embedder           *tpufembed.CohereEmbedder
+		texts              []string
+		responses          []string
+		expectedRequests   []string
+		expectedEmbeddings [][]float32
+	}{
+		{
+			name:     "defaults",
+			embedder: &tpufembed.CohereEmbedder{ApiKey: "test-key"},
+			texts:    []string{"a", "b"},
+			responses: []string{
+				`{"embeddings":{"float":[[0.1,0.2],[0.3,0.4]]}}`,
+			},
+			expectedRequests: []string{
+				`{"model":"embed-english-v3.0","texts":["a","b"],"input_type":"search_document","embedding_types":["float"]}`,
+			},
+			expectedEmbeddings: [][]float32{{0.1, 0.2}, {0.3, 0.4}},
+		},
+		{
+			name:     "batches queries",
+			embedder: &tpufembed.CohereEmbedder{ApiKey: "test-key", Model: "embed-multilingual-v3.0", InputType: "search_query", BatchSize: 1},
+			texts:    []string{"a", "b"},
+			responses: []string{
+				`{"embeddings":{"float":[[0.1]]}}`,
+				`{"embeddings":{"float":[[0.2]]}}`,
+			},
+			expectedRequests: []string{
+				`{"model":"embed-multilingual-v3.0","texts":["a"],"input_type":"search_query","embedding_types":["float"]}`,
+				`{"model":"embed-multilingual-v3.0","texts":["b"],"input_type":"search_query","embedding_types":["float"]}`,
+			},
+			expectedEmbeddings: [][]float32{{0.1}, {0.2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			tt.embedder.HttpClient = &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "https://api.cohere.com/v2/embed", req.URL.String(), "unexpected request URL")
+					assert.Equal(t, "Bearer test-key", req.Header.Get("Authorization"), "unexpected Authorization header")
+					body, err := io.ReadAll(req.Body)
+					assert.NoError(t, err)
+					requests = append(requests, string(body))
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(tt.responses[len(requests)-1])),
+					}, nil
+				},
+			}
+
+			embeddings, err := tt.embedder.Embed(context.Background(), tt.texts)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedEmbeddings, embeddings)
+			assert.Equal(t, len(tt.expectedRequests), len(requests), "unexpected number of requests")
+			for i := range requests {
+				if i < len(tt.expectedRequests) {
+					assert.JSONEq(t, tt.expectedRequests[i], requests[i], "unexpected request body")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package tpufembed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/cenkalti/backoff/v4"
+)
+
+const defaultMaxRetries = 6
+
+// Transport configures how an embedder sends requests to its provider.  Requests which are rate limited
+// (HTTP 429) or fail with a server error are retried with exponential backoff, honoring the provider's
+// Retry-After header when present.
+type Transport struct {
+	// HttpClient is the HTTP client used for making requests.  Defaults to http.DefaultClient.
+	HttpClient tpuf.HttpClient
+	// MaxRetries is the maximum number of times to retry a request.  Defaults to 6.
+	MaxRetries int
+	// DisableRetry disables retries for all requests.
+	DisableRetry bool
+	// Timer is the timer used for backoff.
+	Timer backoff.Timer
+}
+
+func (t *Transport) httpClient() tpuf.HttpClient {
+	if t.HttpClient == nil {
+		return http.DefaultClient
+	}
+	return t.HttpClient
+}
+
+func (t *Transport) maxRetries() uint64 {
+	if t.DisableRetry {
+		return 0
+	}
+	if t.MaxRetries <= 0 {
+		return defaultMaxRetries
+	}
+	return uint64(t.MaxRetries)
+}
+
+// retryAfterBackOff uses the delay requested by the server, if any, in place of the next backoff interval.
+type retryAfterBackOff struct {
+	backoff.BackOff
+	retryAfter time.Duration
+}
+
+func (b *retryAfterBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next != backoff.Stop && b.retryAfter > 0 {
+		next = b.retryAfter
+	}
+	b.retryAfter = 0
+	return next
+}
+
+// postJSON marshals request, posts it to url with the given headers, and decodes the response into response.
+func (t *Transport) postJSON(ctx context.Context, url string, header http.Header, request interface{}, response interface{}) error {
+	reqJson, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	policy := &retryAfterBackOff{BackOff: backoff.WithMaxRetries(backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(time.Second),
+		backoff.WithMultiplier(2.0),
+		backoff.WithMaxInterval(60*time.Second),
+	), t.maxRetries())}
+	respData, err := backoff.RetryNotifyWithTimerAndData(func() ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqJson))
+		if err != nil {
+			return nil, backoff.Permanent(err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := t.httpClient().Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, backoff.Permanent(err)
+			}
+			return nil, err
+		}
+		defer resp.Body.Close()
+		respData, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return respData, nil
+		}
+		err = providerError(resp.StatusCode, respData)
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return nil, backoff.Permanent(err)
+		}
+		if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+			policy.retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, err
+	}, backoff.WithContext(policy, ctx), nil, t.Timer)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(respData, response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// providerError extracts the error message from a provider's error response.  Providers report errors
+// either as {"error": {"message": ...}} or {"message": ...}.
+func providerError(statusCode int, respData []byte) error {
+	var body struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(respData, &body) == nil {
+		var nested struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body.Error, &nested) == nil && nested.Message != "" {
+			return fmt.Errorf("%s (HTTP %d)", nested.Message, statusCode)
+		}
+		var message string
+		if json.Unmarshal(body.Error, &message) == nil && message != "" {
+			return fmt.Errorf("%s (HTTP %d)", message, statusCode)
+		}
+		if body.Message != "" {
+			return fmt.Errorf("%s (HTTP %d)", body.Message, statusCode)
+		}
+	}
+	return fmt.Errorf("unexpected response: %s (HTTP %d)", respData, statusCode)
+}
+
+// embedInBatches calls embed for consecutive batches of at most batchSize texts and concatenates the results.
+func embedInBatches(texts []string, batchSize int, embed func(batch []string) ([][]float32, error)) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := embed(texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed texts %d to %d: %w", start, end-1, err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("failed to embed texts %d to %d: expected %d embeddings, got %d", start, end-1, end-start, len(batch))
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,125 @@
+package tpufembed_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go/tpufembed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransportRetries(t *testing.T) {
+	rateLimited := func(retryAfter string) *http.Response {
+		resp := &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewBufferString(`{"message":"rate limit exceeded"}`)),
+		}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+	ok := func() *http.Response {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`[[0.1]]`)),
+		}
+	}
+
+	tests := []struct {
+		name           string
+		transport      tpufembed.Transport
+		responses      []*http.Response
+		expectedCalls  int
+		expectedDelays []time.Duration
+		expectedError  string
+	}{
+		{
+			name:           "honors Retry-After",
+			responses:      []*http.Response{rateLimited("7"), ok()},
+			expectedCalls:  2,
+			expectedDelays: []time.Duration{7 * time.Second},
+		},
+		{
+			name: "retries server errors",
+			responses: []*http.Response{
+				{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(bytes.NewBufferString(`overloaded`))},
+				ok(),
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:          "gives up after max retries",
+			transport:     tpufembed.Transport{MaxRetries: 1},
+			responses:     []*http.Response{rateLimited(""), rateLimited("")},
+			expectedCalls: 2,
+			expectedError: "failed to embed texts 0 to 0: rate limit exceeded (HTTP 429)",
+		},
+		{
+			name:          "retry disabled",
+			transport:     tpufembed.Transport{DisableRetry: true},
+			responses:     []*http.Response{rateLimited("")},
+			expectedCalls: 1,
+			expectedError: "failed to embed texts 0 to 0: rate limit exceeded (HTTP 429)",
+		},
+		{
+			name: "does not retry client errors",
+			responses: []*http.Response{
+				{StatusCode: http.StatusBadRequest, Body: io.NopCloser(bytes.NewBufferString(`{"error":"input too long"}`))},
+			},
+			expectedCalls: 1,
+			expectedError: "failed to embed texts 0 to 0: input too long (HTTP 400)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			timer := &fakeTimer{}
+			embedder := &tpufembed.LocalEmbedder{Transport: tt.transport}
+			embedder.Timer = timer
+			embedder.HttpClient = &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					calls++
+					return tt.responses[calls-1], nil
+				},
+			}
+
+			_, err := embedder.Embed(context.Background(), []string{"a"})
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedCalls, calls, "unexpected number of calls")
+			if tt.expectedDelays != nil {
+				assert.Equal(t, tt.expectedDelays, timer.delays, "unexpected backoff delays")
+			}
+		})
+	}
+}
+
+type fakeTimer struct {
+	ch     chan time.Time
+	delays []time.Duration
+}
+
+func (f *fakeTimer) Start(duration time.Duration) {
+	f.delays = append(f.delays, duration)
+	if f.ch == nil {
+		f.ch = make(chan time.Time, 1)
+	}
+	f.ch <- time.Now()
+}
+
+func (f *fakeTimer) Stop() {}
+
+func (f *fakeTimer) C() <-chan time.Time {
+	return f.ch
+}
@@ -0,0 +1,54 @@
+package tpufembed
+
+import (
+	"context"
+
+	"github.com/bamo/tpuf-go"
+)
+
+const (
+	defaultLocalURL       = "http://localhost:8080/embed"
+	defaultLocalBatchSize = 32
+)
+
+// LocalEmbedder is a tpuf.Embedder which calls a self-hosted model server implementing the embed route of
+// Hugging Face's text-embeddings-inference, which serves ONNX and other local models over HTTP.
+// Texts are sent in batches of up to BatchSize per request.  For servers with an OpenAI-compatible API,
+// use OpenAIEmbedder with BaseURL instead.
+// See https://huggingface.github.io/text-embeddings-inference
+type LocalEmbedder struct {
+	// URL is the embed endpoint of the model server.  Defaults to http://localhost:8080/embed
+	URL string
+	// Normalize requests unit-length embeddings.  Defaults to the server's default, which normalizes.
+	Normalize *bool
+	// Truncate asks the server to truncate texts longer than the model's maximum input length,
+	// rather than rejecting them.
+	Truncate bool
+	// BatchSize is the maximum number of texts per request.  Defaults to 32, the server's default limit.
+	BatchSize int
+	Transport
+}
+
+var _ tpuf.Embedder = (*LocalEmbedder)(nil)
+
+type localRequest struct {
+	Inputs    []string `json:"inputs"`
+	Normalize *bool    `json:"normalize,omitempty"`
+	Truncate  bool     `json:"truncate,omitempty"`
+}
+
+func (e *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultLocalBatchSize
+	}
+	url := e.URL
+	if url == "" {
+		url = defaultLocalURL
+	}
+	return embedInBatches(texts, batchSize, func(batch []string) ([][]float32, error) {
+		var embeddings [][]float32
+		err := e.postJSON(ctx, url, nil, &localRequest{Inputs: batch, Normalize: e.Normalize, Truncate: e.Truncate}, &embeddings)
+		return embeddings, err
+	})
+}
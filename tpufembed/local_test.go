@@ -0,0 +1,75 @@
+package tpufembed_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go/tpufembed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalEmbedder(t *testing.T) {
+	normalize := false
+
+	tests := []struct {
+		name             string
+		embedder         *tpufembed.LocalEmbedder
+		expectedURL      string
+		expectedRequests []string
+	}{
+		{
+			name:        "defaults",
+			embedder:    &tpufembed.LocalEmbedder{},
+			expectedURL: "http://localhost:8080/embed",
+			expectedRequests: []string{
+				`{"inputs":["a","b"]}`,
+			},
+		},
+		{
+			name:        "options and batching",
+			embedder:    &tpufembed.LocalEmbedder{URL: "http://embedder:3000/embed", Normalize: &normalize, Truncate: true, BatchSize: 1},
+			expectedURL: "http://embedder:3000/embed",
+			expectedRequests: []string{
+				`{"inputs":["a"],"normalize":false,"truncate":true}`,
+				`{"inputs":["b"],"normalize":false,"truncate":true}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			tt.embedder.HttpClient = &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, tt.expectedURL, req.URL.String(), "unexpected request URL")
+					body, err := io.ReadAll(req.Body)
+					assert.NoError(t, err)
+					requests = append(requests, string(body))
+
+					response := `[[0.1],[0.2]]`
+					if len(tt.expectedRequests) > 1 {
+						response = `[[0.1]]`
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(response)),
+					}, nil
+				},
+			}
+
+			embeddings, err := tt.embedder.Embed(context.Background(), []string{"a", "b"})
+
+			assert.NoError(t, err)
+			assert.Len(t, embeddings, 2)
+			assert.Equal(t, len(tt.expectedRequests), len(requests), "unexpected number of requests")
+			for i := range requests {
+				if i < len(tt.expectedRequests) {
+					assert.JSONEq(t, tt.expectedRequests[i], requests[i], "unexpected request body")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package tpufembed provides tpuf.Embedder implementations backed by embedding providers.
+package tpufembed
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/bamo/tpuf-go"
+)
+
+const (
+	defaultOpenAIBaseURL   = "https://api.openai.com/v1"
+	defaultOpenAIModel     = "text-embedding-3-small"
+	defaultOpenAIBatchSize = 2048
+)
+
+// OpenAIEmbedder is a tpuf.Embedder which calls OpenAI's embeddings API.
+// Texts are sent in batches of up to BatchSize per request.  Local model servers which expose an
+// OpenAI-compatible API, such as Ollama or vLLM, can be used by setting BaseURL.
+// See https://platform.openai.com/docs/api-reference/embeddings
+type OpenAIEmbedder struct {
+	// ApiKey is the OpenAI API key.  Required.
+	ApiKey string
+	// Model is the embedding model to use.  Defaults to text-embedding-3-small.
+	Model string
+	// Dimensions optionally shortens the returned embeddings, for models which support it.
+	Dimensions int
+	// BatchSize is the maximum number of texts per request.  Defaults to 2048, the API's limit.
+	BatchSize int
+	// BaseURL is the base URL of the API, e.g. for Azure OpenAI or a compatible proxy.
+	// Defaults to https://api.openai.com/v1
+	BaseURL string
+	Transport
+}
+
+var _ tpuf.Embedder = (*OpenAIEmbedder)(nil)
+
+type openAIRequest struct {
+	Input      []string `json:"input"`
+	Model      string   `json:"model"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOpenAIBatchSize
+	}
+	return embedInBatches(texts, batchSize, func(batch []string) ([][]float32, error) {
+		return e.embedBatch(ctx, batch)
+	})
+}
+
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	model := e.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	baseURL := e.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+e.ApiKey)
+
+	var response openAIResponse
+	err := e.postJSON(ctx, baseURL+"/embeddings", header, &openAIRequest{Input: texts, Model: model, Dimensions: e.Dimensions}, &response)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(response.Data, func(i, j int) bool {
+		return response.Data[i].Index < response.Data[j].Index
+	})
+	embeddings := make([][]float32, len(response.Data))
+	for i, data := range response.Data {
+		embeddings[i] = data.Embedding
+	}
+	return embeddings, nil
+}
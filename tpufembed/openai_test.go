@@ -0,0 +1,124 @@
+package tpufembed_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go/tpufembed"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHttpClient struct {
+	doFunc func(*http.Request) (*http.Response, error)
+}
+
+func (f *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	return f.doFunc(req)
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	tests := []struct {
+		name               string
+		embedder           *tpufembed.OpenAIEmbedder
+		texts              []string
+		responses          []string
+		status             int
+		expectedRequests   []string
+		expectedEmbeddings [][]float32
+		expectedError      string
+	}{
+		{
+			name:     "single batch with defaults",
+			embedder: &tpufembed.OpenAIEmbedder{ApiKey: "test-key", Transport: tpufembed.Transport{DisableRetry: true}},
+			texts:    []string{"a", "b"},
+			responses: []string{
+				`{"data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}]}`,
+			},
+			expectedRequests: []string{
+				`{"input":["a","b"],"model":"text-embedding-3-small"}`,
+			},
+			expectedEmbeddings: [][]float32{{0.1, 0.2}, {0.3, 0.4}},
+		},
+		{
+			name:     "batches with model and dimensions",
+			embedder: &tpufembed.OpenAIEmbedder{ApiKey: "test-key", Model: "text-embedding-3-large", Dimensions: 1, BatchSize: 2},
+			texts:    []string{"a", "b", "c"},
+			responses: []string{
+				`{"data":[{"index":0,"embedding":[0.1]},{"index":1,"embedding":[0.2]}]}`,
+				`{"data":[{"index":0,"embedding":[0.3]}]}`,
+			},
+			expectedRequests: []string{
+				`{"input":["a","b"],"model":"text-embedding-3-large","dimensions":1}`,
+				`{"input":["c"],"model":"text-embedding-3-large","dimensions":1}`,
+			},
+			expectedEmbeddings: [][]float32{{0.1}, {0.2}, {0.3}},
+		},
+		{
+			name:     "api error",
+			embedder: &tpufembed.OpenAIEmbedder{ApiKey: "test-key", Transport: tpufembed.Transport{DisableRetry: true}},
+			texts:    []string{"a"},
+			status:   http.StatusUnauthorized,
+			responses: []string{
+				`{"error":{"message":"Incorrect API key provided"}}`,
+			},
+			expectedRequests: []string{
+				`{"input":["a"],"model":"text-embedding-3-small"}`,
+			},
+			expectedError: "failed to embed texts 0 to 0: Incorrect API key provided (HTTP 401)",
+		},
+		{
+			name:     "missing embeddings",
+			embedder: &tpufembed.OpenAIEmbedder{ApiKey: "test-key", Transport: tpufembed.Transport{DisableRetry: true}},
+			texts:    []string{"a", "b"},
+			responses: []string{
+				`{"data":[{"index":0,"embedding":[0.1]}]}`,
+			},
+			expectedRequests: []string{
+				`{"input":["a","b"],"model":"text-embedding-3-small"}`,
+			},
+			expectedError: "failed to embed texts 0 to 1: expected 2 embeddings, got 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			tt.embedder.HttpClient = &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "https://api.openai.com/v1/embeddings", req.URL.String(), "unexpected request URL")
+					assert.Equal(t, "Bearer test-key", req.Header.Get("Authorization"), "unexpected Authorization header")
+					body, err := io.ReadAll(req.Body)
+					assert.NoError(t, err)
+					requests = append(requests, string(body))
+
+					status := tt.status
+					if status == 0 {
+						status = http.StatusOK
+					}
+					return &http.Response{
+						StatusCode: status,
+						Body:       io.NopCloser(bytes.NewBufferString(tt.responses[len(requests)-1])),
+					}, nil
+				},
+			}
+
+			embeddings, err := tt.embedder.Embed(context.Background(), tt.texts)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedEmbeddings, embeddings)
+			assert.Equal(t, len(tt.expectedRequests), len(requests), "unexpected number of requests")
+			for i := range requests {
+				if i < len(tt.expectedRequests) {
+					assert.JSONEq(t, tt.expectedRequests[i], requests[i], "unexpected request body")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package tpufembed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bamo/tpuf-go"
+)
+
+const (
+	defaultVertexLocation  = "us-central1"
+	defaultVertexModel     = "text-embedding-004"
+	defaultVertexBatchSize = 250
+)
+
+// VertexEmbedder is a tpuf.Embedder which calls a Vertex AI text embedding model.
+// Texts are sent in batches of up to BatchSize per request.
+// See https://cloud.google.com/vertex-ai/generative-ai/docs/embeddings/get-text-embeddings
+type VertexEmbedder struct {
+	// Project is the Google Cloud project ID.  Required.
+	Project string
+	// Location is the Google Cloud region.  Defaults to us-central1.
+	Location string
+	// Model is the embedding model to use.  Defaults to text-embedding-004.
+	Model string
+	// TaskType tells the model how the embeddings will be used, e.g. "RETRIEVAL_DOCUMENT" or "RETRIEVAL_QUERY".
+	// Optional.
+	TaskType string
+	// Dimensions optionally shortens the returned embeddings, for models which support it.
+	Dimensions int
+	// BatchSize is the maximum number of texts per request.  Defaults to 250, the API's limit.
+	BatchSize int
+	// AccessToken returns an OAuth2 access token for each request, e.g. from
+	// golang.org/x/oauth2/google.DefaultTokenSource.  Required.
+	AccessToken func(ctx context.Context) (string, error)
+	// BaseURL overrides the regional endpoint, e.g. https://us-central1-aiplatform.googleapis.com
+	BaseURL string
+	Transport
+}
+
+var _ tpuf.Embedder = (*VertexEmbedder)(nil)
+
+type vertexInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
+}
+
+type vertexParameters struct {
+	OutputDimensionality int `json:"outputDimensionality,omitempty"`
+}
+
+type vertexRequest struct {
+	Instances  []*vertexInstance `json:"instances"`
+	Parameters *vertexParameters `json:"parameters,omitempty"`
+}
+
+type vertexResponse struct {
+	Predictions []struct {
+		Embeddings struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	} `json:"predictions"`
+}
+
+func (e *VertexEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.Project == "" {
+		return nil, fmt.Errorf("a project is required")
+	}
+	if e.AccessToken == nil {
+		return nil, fmt.Errorf("an access token is required")
+	}
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultVertexBatchSize
+	}
+	return embedInBatches(texts, batchSize, func(batch []string) ([][]float32, error) {
+		return e.embedBatch(ctx, batch)
+	})
+}
+
+func (e *VertexEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	request := &vertexRequest{}
+	for _, text := range texts {
+		request.Instances = append(request.Instances, &vertexInstance{Content: text, TaskType: e.TaskType})
+	}
+	if e.Dimensions > 0 {
+		request.Parameters = &vertexParameters{OutputDimensionality: e.Dimensions}
+	}
+
+	location := e.Location
+	if location == "" {
+		location = defaultVertexLocation
+	}
+	model := e.Model
+	if model == "" {
+		model = defaultVertexModel
+	}
+	baseURL := e.BaseURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://%s-aiplatform.googleapis.com", location)
+	}
+	url := fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/google/models/%s:predict", baseURL, e.Project, location, model)
+
+	token, err := e.AccessToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+
+	var response vertexResponse
+	if err := e.postJSON(ctx, url, header, request, &response); err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float32, len(response.Predictions))
+	for i, prediction := range response.Predictions {
+		embeddings[i] = prediction.Embeddings.Values
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,89 @@
+package tpufembed_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go/tpufembed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVertexEmbedder(t *testing.T) {
+	token := func(ctx context.Context) (string, error) {
+		return "test-token", nil
+	}
+
+	tests := []struct {
+		name               string
+		embedder           *tpufembed.VertexEmbedder
+		expectedURL        string
+		expectedRequest    string
+		expectedEmbeddings [][]float32
+		expectedError      string
+	}{
+		{
+			name:               "defaults",
+			embedder:           &tpufembed.VertexEmbedder{Project: "my-project", AccessToken: token},
+			expectedURL:        "https://us-central1-aiplatform.googleapis.com/v1/projects/my-project/locations/us-central1/publishers/google/models/text-embedding-004:predict",
+			expectedRequest:    `{"instances":[{"content":"a"},{"content":"b"}]}`,
+			expectedEmbeddings: [][]float32{{0.1}, {0.2}},
+		},
+		{
+			name: "task type, dimensions and location",
+			embedder: &tpufembed.VertexEmbedder{
+				Project:     "my-project",
+				Location:    "europe-west4",
+				Model:       "text-multilingual-embedding-002",
+				TaskType:    "RETRIEVAL_DOCUMENT",
+				Dimensions:  1,
+				AccessToken: token,
+			},
+			expectedURL:        "https://europe-west4-aiplatform.googleapis.com/v1/projects/my-project/locations/europe-west4/publishers/google/models/text-multilingual-embedding-002:predict",
+			expectedRequest:    `{"instances":[{"content":"a","task_type":"RETRIEVAL_DOCUMENT"},{"content":"b","task_type":"RETRIEVAL_DOCUMENT"}],"parameters":{"outputDimensionality":1}}`,
+			expectedEmbeddings: [][]float32{{0.1}, {0.2}},
+		},
+		{
+			name: "access token failure",
+			embedder: &tpufembed.VertexEmbedder{Project: "my-project", AccessToken: func(ctx context.Context) (string, error) {
+				return "", errors.New("no credentials")
+			}},
+			expectedError: "failed to embed texts 0 to 1: failed to get access token: no credentials",
+		},
+		{
+			name:          "missing project",
+			embedder:      &tpufembed.VertexEmbedder{AccessToken: token},
+			expectedError: "a project is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.embedder.HttpClient = &fakeHttpClient{
+				doFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, tt.expectedURL, req.URL.String(), "unexpected request URL")
+					assert.Equal(t, "Bearer test-token", req.Header.Get("Authorization"), "unexpected Authorization header")
+					body, err := io.ReadAll(req.Body)
+					assert.NoError(t, err)
+					assert.JSONEq(t, tt.expectedRequest, string(body), "unexpected request body")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{"predictions":[{"embeddings":{"values":[0.1]}},{"embeddings":{"values":[0.2]}}]}`)),
+					}, nil
+				},
+			}
+
+			embeddings, err := tt.embedder.Embed(context.Background(), []string{"a", "b"})
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedEmbeddings, embeddings)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package tpuflog
+
+import (
+	"runtime"
+	"strings"
+)
+
+// internalPrefixes are the function name prefixes of frames between the application's call into the client
+// and an adapter: the tpuf package, this package, and the backoff library which calls the client's retry
+// notifications.
+var internalPrefixes = []string{
+	"github.com/bamo/tpuf-go.",
+	"github.com/bamo/tpuf-go/tpuflog.",
+	"github.com/cenkalti/backoff/",
+}
+
+// callerDepth returns how many frames above the adapter function calling it the application's call into
+// the client is, so that the application's call site is reported as the caller of each message rather than
+// the client's internals.  The depth varies with the path a message takes through the client, so it can't
+// be fixed when an adapter is created.  Messages logged from a goroutine started by the client, which has
+// no application frames, are attributed to the outermost client frame.
+func callerDepth() int {
+	pcs := make([]uintptr, 64)
+	// Skip runtime.Callers, callerDepth and the adapter function.
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	depth, outermost := 0, 0
+	for {
+		frame, more := frames.Next()
+		depth++
+		if isInternal(frame.Function) {
+			outermost = depth
+		} else if !strings.HasPrefix(frame.Function, "runtime.") {
+			return depth
+		}
+		if !more {
+			return outermost
+		}
+	}
+}
+
+func isInternal(function string) bool {
+	for _, prefix := range internalPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+// Package tpuflog adapts popular logging libraries to the tpuf.Logger interface, so that a tpuf.Client's
+// logs go wherever the rest of an application's logs go.
+//
+//	client := &tpuf.Client{ApiToken: token, Logger: tpuflog.Zap(zapLogger)}
+//
+// It is a separate module so that the tpuf package doesn't depend on any logging library.
+package tpuflog
@@ -0,0 +1,38 @@
+package tpuflog
+
+import (
+	"context"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/go-logr/logr"
+)
+
+// Logr returns a tpuf.Logger which writes to a logr logger.  logr has verbosity levels rather than
+// severities: debug messages are logged at V(1), info and warning messages at V(0), and error messages
+// with Error, passing the "error" value if there is one.  The application code which called the client is
+// reported as the caller.
+func Logr(logger logr.Logger) tpuf.Logger {
+	return tpuf.LoggerFunc(func(ctx context.Context, level tpuf.LogLevel, msg string, keysAndValues ...interface{}) {
+		if level == tpuf.LogLevelDebug && !logger.V(1).Enabled() {
+			return
+		}
+		logger := logger.WithCallDepth(callerDepth())
+		switch level {
+		case tpuf.LogLevelDebug:
+			logger.V(1).Info(msg, keysAndValues...)
+		case tpuf.LogLevelInfo, tpuf.LogLevelWarn:
+			logger.Info(msg, keysAndValues...)
+		default:
+			logger.Error(findError(keysAndValues), msg, keysAndValues...)
+		}
+	})
+}
+
+func findError(keysAndValues []interface{}) error {
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if err, ok := keysAndValues[i+1].(error); ok && keysAndValues[i] == "error" {
+			return err
+		}
+	}
+	return nil
+}
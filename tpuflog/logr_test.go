@@ -0,0 +1,43 @@
+package tpuflog_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpuflog"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogr(t *testing.T) {
+	var entries []map[string]interface{}
+	logger := tpuflog.Logr(funcr.NewJSON(func(obj string) {
+		var entry map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(obj), &entry))
+		entries = append(entries, entry)
+	}, funcr.Options{LogCaller: funcr.All, Verbosity: 1}))
+
+	_, err := newClient(logger, http.StatusServiceUnavailable, http.StatusOK).Query(context.Background(), "docs", &tpuf.QueryRequest{})
+	assert.NoError(t, err)
+	logger.Log(context.Background(), tpuf.LogLevelError, "direct", "error", errors.New("boom"))
+
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	for _, entry := range entries {
+		caller, _ := entry["caller"].(map[string]interface{})
+		assert.Equal(t, "logr_test.go", caller["file"], "the application's call site should be reported for %q", entry["msg"])
+	}
+	assert.Equal(t, "retrying request", entries[0]["msg"])
+	assert.Equal(t, float64(0), entries[0]["level"])
+	assert.Equal(t, "query", entries[0]["operation"])
+	assert.Equal(t, "docs", entries[0]["namespace"])
+	assert.Equal(t, "request completed", entries[1]["msg"])
+	assert.Equal(t, float64(1), entries[1]["level"], "debug messages should be logged at V(1)")
+	assert.Equal(t, "direct", entries[2]["msg"])
+	assert.Equal(t, "boom", entries[2]["error"])
+}
@@ -0,0 +1,38 @@
+package tpuflog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Logrus returns a tpuf.Logger which writes to a logrus logger or entry.  Key-value pairs become fields,
+// and the request's context is attached to the entry so that hooks can read it.
+func Logrus(logger logrus.FieldLogger) tpuf.Logger {
+	return tpuf.LoggerFunc(func(ctx context.Context, level tpuf.LogLevel, msg string, keysAndValues ...interface{}) {
+		fields := make(logrus.Fields, len(keysAndValues)/2)
+		for i := 0; i < len(keysAndValues); i += 2 {
+			var value interface{} = "(missing)"
+			if i+1 < len(keysAndValues) {
+				value = keysAndValues[i+1]
+			}
+			fields[fmt.Sprint(keysAndValues[i])] = value
+		}
+		entry := logger.WithFields(fields)
+		if ctx != nil {
+			entry = entry.WithContext(ctx)
+		}
+		switch level {
+		case tpuf.LogLevelDebug:
+			entry.Debug(msg)
+		case tpuf.LogLevelInfo:
+			entry.Info(msg)
+		case tpuf.LogLevelWarn:
+			entry.Warn(msg)
+		default:
+			entry.Error(msg)
+		}
+	})
+}
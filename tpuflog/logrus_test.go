@@ -0,0 +1,41 @@
+package tpuflog_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpuflog"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type contextKey struct{}
+
+func TestLogrus(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	client := newClient(tpuflog.Logrus(logger), http.StatusServiceUnavailable, http.StatusOK)
+	ctx := context.WithValue(context.Background(), contextKey{}, "value")
+
+	_, err := client.Query(ctx, "docs", &tpuf.QueryRequest{})
+	assert.NoError(t, err)
+	tpuflog.Logrus(logger).Log(ctx, tpuf.LogLevelInfo, "dangling", "key")
+
+	entries := hook.AllEntries()
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	assert.Equal(t, logrus.WarnLevel, entries[0].Level)
+	assert.Equal(t, "retrying request", entries[0].Message)
+	assert.Equal(t, tpuf.OperationQuery, entries[0].Data["operation"])
+	assert.Equal(t, "docs", entries[0].Data["namespace"])
+	assert.Equal(t, 1, entries[0].Data["attempt"])
+	assert.Equal(t, "value", entries[0].Context.Value(contextKey{}), "the request's context should be attached")
+	assert.Equal(t, logrus.DebugLevel, entries[1].Level)
+	assert.Equal(t, "request completed", entries[1].Message)
+	assert.Equal(t, logrus.InfoLevel, entries[2].Level)
+	assert.Equal(t, logrus.Fields{"key": "(missing)"}, entries[2].Data)
+}
@@ -0,0 +1,34 @@
+package tpuflog
+
+import (
+	"context"
+
+	"github.com/bamo/tpuf-go"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Zap returns a tpuf.Logger which writes to a zap logger.  Key-value pairs become structured fields.  If the
+// logger adds callers, the application code which called the client is reported as the caller.
+func Zap(logger *zap.Logger) tpuf.Logger {
+	sugared := logger.Sugar()
+	return tpuf.LoggerFunc(func(ctx context.Context, level tpuf.LogLevel, msg string, keysAndValues ...interface{}) {
+		lvl := zapLevel(level)
+		if !logger.Core().Enabled(lvl) {
+			return
+		}
+		sugared.WithOptions(zap.AddCallerSkip(callerDepth())).Logw(lvl, msg, keysAndValues...)
+	})
+}
+
+func zapLevel(level tpuf.LogLevel) zapcore.Level {
+	switch level {
+	case tpuf.LogLevelDebug:
+		return zapcore.DebugLevel
+	case tpuf.LogLevelInfo:
+		return zapcore.InfoLevel
+	case tpuf.LogLevelWarn:
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
@@ -0,0 +1,95 @@
+package tpuflog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpuflog"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type fakeHttpClient struct {
+	doFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (f *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	return f.doFunc(req)
+}
+
+// newClient returns a client which logs to logger and whose requests receive statuses in turn.
+func newClient(logger tpuf.Logger, statuses ...int) *tpuf.Client {
+	calls := 0
+	return &tpuf.Client{
+		ApiToken: "test-token",
+		Logger:   logger,
+		RetryPolicies: map[tpuf.Operation]*tpuf.RetryPolicy{
+			tpuf.OperationQuery: {InitialInterval: time.Millisecond, MaxInterval: time.Millisecond},
+		},
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				status := statuses[calls]
+				calls++
+				body := `[{"id":"1"}]`
+				if status != http.StatusOK {
+					body = `{"status":"error","error":"oops"}`
+				}
+				return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+			},
+		},
+	}
+}
+
+func TestZap(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := tpuflog.Zap(zap.New(core, zap.AddCaller()))
+
+	ctx := context.Background()
+
+	_, err := newClient(logger, http.StatusServiceUnavailable, http.StatusOK).Query(ctx, "docs", &tpuf.QueryRequest{})
+	assert.NoError(t, err)
+	_, err = newClient(logger, http.StatusBadRequest).Query(ctx, "docs", &tpuf.QueryRequest{})
+	assert.Error(t, err)
+	logger.Log(ctx, tpuf.LogLevelError, "direct", "error", errors.New("boom"))
+
+	entries := logs.AllUntimed()
+	if !assert.Len(t, entries, 4) {
+		return
+	}
+	for _, entry := range entries {
+		assert.Equal(t, "zap_test.go", filepath.Base(entry.Caller.File),
+			"the application's call site should be reported for %q, not %s", entry.Message, entry.Caller.Function)
+	}
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Equal(t, "retrying request", entries[0].Message)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, tpuf.OperationQuery, fields["operation"])
+	assert.Equal(t, "docs", fields["namespace"])
+	assert.Equal(t, int64(1), fields["attempt"])
+	assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+	assert.Equal(t, "request completed", entries[1].Message)
+	assert.Equal(t, int64(2), entries[1].ContextMap()["attempts"])
+	assert.Equal(t, zapcore.WarnLevel, entries[2].Level)
+	assert.Equal(t, "request failed", entries[2].Message)
+	assert.Contains(t, entries[2].ContextMap()["error"], "oops")
+	assert.Equal(t, zapcore.ErrorLevel, entries[3].Level)
+	assert.Equal(t, "boom", entries[3].ContextMap()["error"])
+}
+
+func TestZapLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	_, err := newClient(tpuflog.Zap(zap.New(core)), http.StatusOK).Query(context.Background(), "docs", &tpuf.QueryRequest{})
+	assert.NoError(t, err)
+
+	assert.Zero(t, logs.Len(), "debug messages should be dropped by an info logger")
+}
@@ -0,0 +1,117 @@
+// Package tpufotel records OpenTelemetry metrics for requests made by a tpuf.Client.
+//
+//	observer, err := tpufotel.NewMetricsObserver(&tpufotel.Options{MeterProvider: provider})
+//	if err != nil {
+//		...
+//	}
+//	client := &tpuf.Client{ApiToken: token, Observer: observer}
+//
+// It is a separate module so that the tpuf package doesn't depend on OpenTelemetry.
+package tpufotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bamo/tpuf-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/bamo/tpuf-go/tpufotel"
+
+// Attribute keys recorded with every measurement.
+const (
+	OperationKey  = attribute.Key("tpuf.operation")
+	NamespaceKey  = attribute.Key("tpuf.namespace")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	ErrorKey      = attribute.Key("error")
+)
+
+// Options configures NewMetricsObserver.
+type Options struct {
+	// MeterProvider provides the meter used to create instruments.  Defaults to the global MeterProvider.
+	MeterProvider metric.MeterProvider
+	// OmitNamespace leaves the namespace out of the recorded attributes, e.g. when there are so many
+	// namespaces that they would make the metrics' cardinality too high.
+	OmitNamespace bool
+}
+
+// MetricsObserver is a tpuf.RequestObserver which records request counts, durations, payload sizes and
+// retries, keyed by operation and namespace.
+type MetricsObserver struct {
+	omitNamespace bool
+	requests      metric.Int64Counter
+	retries       metric.Int64Counter
+	duration      metric.Float64Histogram
+	requestSize   metric.Int64Histogram
+	responseSize  metric.Int64Histogram
+}
+
+var _ tpuf.RequestObserver = (*MetricsObserver)(nil)
+
+// NewMetricsObserver creates the instruments for a MetricsObserver.  opts may be nil.
+func NewMetricsObserver(opts *Options) (*MetricsObserver, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	provider := opts.MeterProvider
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	meter := provider.Meter(instrumentationName)
+
+	o := &MetricsObserver{omitNamespace: opts.OmitNamespace}
+	var err error
+	if o.requests, err = meter.Int64Counter("tpuf.client.requests",
+		metric.WithDescription("Number of API requests, counting each request once regardless of retries."),
+		metric.WithUnit("{request}")); err != nil {
+		return nil, fmt.Errorf("failed to create requests counter: %w", err)
+	}
+	if o.retries, err = meter.Int64Counter("tpuf.client.retries",
+		metric.WithDescription("Number of times API requests were retried."),
+		metric.WithUnit("{retry}")); err != nil {
+		return nil, fmt.Errorf("failed to create retries counter: %w", err)
+	}
+	if o.duration, err = meter.Float64Histogram("tpuf.client.request.duration",
+		metric.WithDescription("Duration of API requests, including retries."),
+		metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("failed to create duration histogram: %w", err)
+	}
+	if o.requestSize, err = meter.Int64Histogram("tpuf.client.request.body.size",
+		metric.WithDescription("Size of API request bodies, excluding streamed requests."),
+		metric.WithUnit("By")); err != nil {
+		return nil, fmt.Errorf("failed to create request size histogram: %w", err)
+	}
+	if o.responseSize, err = meter.Int64Histogram("tpuf.client.response.body.size",
+		metric.WithDescription("Size of successful API response bodies, excluding streamed responses."),
+		metric.WithUnit("By")); err != nil {
+		return nil, fmt.Errorf("failed to create response size histogram: %w", err)
+	}
+	return o, nil
+}
+
+func (o *MetricsObserver) ObserveRequest(ctx context.Context, stats *tpuf.RequestStats) {
+	attrs := []attribute.KeyValue{
+		OperationKey.String(string(stats.Operation)),
+		StatusCodeKey.Int(stats.StatusCode),
+		ErrorKey.Bool(stats.Err != nil),
+	}
+	if !o.omitNamespace && stats.Namespace != "" {
+		attrs = append(attrs, NamespaceKey.String(stats.Namespace))
+	}
+	set := metric.WithAttributes(attrs...)
+
+	o.requests.Add(ctx, 1, set)
+	if stats.Attempts > 1 {
+		o.retries.Add(ctx, int64(stats.Attempts-1), set)
+	}
+	o.duration.Record(ctx, stats.Duration.Seconds(), set)
+	if stats.RequestBytes >= 0 {
+		o.requestSize.Record(ctx, int64(stats.RequestBytes), set)
+	}
+	if stats.ResponseBytes >= 0 && stats.Err == nil {
+		o.responseSize.Record(ctx, int64(stats.ResponseBytes), set)
+	}
+}
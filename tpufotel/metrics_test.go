@@ -0,0 +1,148 @@
+package tpufotel_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpufotel"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+type measurement struct {
+	value float64
+	attrs attribute.Set
+}
+
+// recordingMeter records every measurement made with its instruments, keyed by instrument name.
+type recordingMeter struct {
+	noop.Meter
+
+	mu           sync.Mutex
+	measurements map[string][]measurement
+}
+
+func (m *recordingMeter) record(name string, value float64, attrs attribute.Set) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.measurements[name] = append(m.measurements[name], measurement{value: value, attrs: attrs})
+}
+
+func (m *recordingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return &int64Counter{meter: m, name: name}, nil
+}
+
+func (m *recordingMeter) Int64Histogram(name string, _ ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	return &int64Histogram{meter: m, name: name}, nil
+}
+
+func (m *recordingMeter) Float64Histogram(name string, _ ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return &float64Histogram{meter: m, name: name}, nil
+}
+
+type int64Counter struct {
+	noop.Int64Counter
+	meter *recordingMeter
+	name  string
+}
+
+func (c *int64Counter) Add(_ context.Context, value int64, opts ...metric.AddOption) {
+	c.meter.record(c.name, float64(value), metric.NewAddConfig(opts).Attributes())
+}
+
+type int64Histogram struct {
+	noop.Int64Histogram
+	meter *recordingMeter
+	name  string
+}
+
+func (h *int64Histogram) Record(_ context.Context, value int64, opts ...metric.RecordOption) {
+	h.meter.record(h.name, float64(value), metric.NewRecordConfig(opts).Attributes())
+}
+
+type float64Histogram struct {
+	noop.Float64Histogram
+	meter *recordingMeter
+	name  string
+}
+
+func (h *float64Histogram) Record(_ context.Context, value float64, opts ...metric.RecordOption) {
+	h.meter.record(h.name, value, metric.NewRecordConfig(opts).Attributes())
+}
+
+type meterProvider struct {
+	embedded.MeterProvider
+	meter *recordingMeter
+}
+
+func (p *meterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return p.meter
+}
+
+func newObserver(t *testing.T, opts tpufotel.Options) (*tpufotel.MetricsObserver, *recordingMeter) {
+	meter := &recordingMeter{measurements: map[string][]measurement{}}
+	opts.MeterProvider = &meterProvider{meter: meter}
+	observer, err := tpufotel.NewMetricsObserver(&opts)
+	assert.NoError(t, err)
+	return observer, meter
+}
+
+func TestMetricsObserver(t *testing.T) {
+	observer, meter := newObserver(t, tpufotel.Options{})
+
+	observer.ObserveRequest(context.Background(), &tpuf.RequestStats{
+		Operation:     tpuf.OperationQuery,
+		Namespace:     "docs",
+		Duration:      1500 * time.Millisecond,
+		Attempts:      3,
+		StatusCode:    200,
+		RequestBytes:  100,
+		ResponseBytes: 2000,
+	})
+
+	expected := attribute.NewSet(
+		tpufotel.OperationKey.String("query"),
+		tpufotel.NamespaceKey.String("docs"),
+		tpufotel.StatusCodeKey.Int(200),
+		tpufotel.ErrorKey.Bool(false),
+	)
+	assert.Equal(t, map[string][]measurement{
+		"tpuf.client.requests":           {{value: 1, attrs: expected}},
+		"tpuf.client.retries":            {{value: 2, attrs: expected}},
+		"tpuf.client.request.duration":   {{value: 1.5, attrs: expected}},
+		"tpuf.client.request.body.size":  {{value: 100, attrs: expected}},
+		"tpuf.client.response.body.size": {{value: 2000, attrs: expected}},
+	}, meter.measurements)
+}
+
+func TestMetricsObserverFailedRequest(t *testing.T) {
+	observer, meter := newObserver(t, tpufotel.Options{OmitNamespace: true})
+
+	observer.ObserveRequest(context.Background(), &tpuf.RequestStats{
+		Operation:     tpuf.OperationUpsert,
+		Namespace:     "docs",
+		Duration:      time.Second,
+		Attempts:      1,
+		StatusCode:    500,
+		RequestBytes:  -1,
+		ResponseBytes: -1,
+		Err:           errors.New("boom"),
+	})
+
+	expected := attribute.NewSet(
+		tpufotel.OperationKey.String("upsert"),
+		tpufotel.StatusCodeKey.Int(500),
+		tpufotel.ErrorKey.Bool(true),
+	)
+	assert.Equal(t, map[string][]measurement{
+		"tpuf.client.requests":         {{value: 1, attrs: expected}},
+		"tpuf.client.request.duration": {{value: 1, attrs: expected}},
+	}, meter.measurements, "retries, streamed bodies and failed responses should not be recorded")
+}
@@ -0,0 +1,580 @@
+package tpuftest
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bamo/tpuf-go"
+)
+
+// defaultExportPageSize is the number of documents per export page unless Emulator.ExportPageSize is set.
+const defaultExportPageSize = 1000
+
+// defaultNamespacesPageSize is the number of namespaces per page unless the request sets a page size.
+const defaultNamespacesPageSize = 1000
+
+// Emulator is an in-memory stand-in for the turbopuffer API, for testing application logic offline with
+// realistic behavior.  It embeds a *tpuf.Client whose requests are served by the emulator instead of being
+// sent over the network, so every client method works against it, including those built on top of the
+// API such as Scan, DeleteWhere and HybridQuery, and it implements tpuf.API.  Emulator is also an
+// http.Handler, e.g. for serving it with httptest.NewServer to clients in other processes.
+//
+// Queries are exact: vector searches compute the distance to every document, and filters are evaluated
+// as the API documents them.  BM25 scores use the attribute's k1 and b, but tokenize naively, splitting
+// text into runs of letters and digits without stemming or removing stop words, so full-text search
+// rankings may differ from the API's.  Aggregations, recall measurement and cache warming aren't supported.
+type Emulator struct {
+	*tpuf.Client
+
+	// ExportPageSize is the number of documents per page of exports.  Defaults to 1000.
+	ExportPageSize int
+
+	mu         sync.Mutex
+	namespaces map[string]*emulatedNamespace
+}
+
+var _ tpuf.API = (*Emulator)(nil)
+
+type emulatedNamespace struct {
+	distanceMetric tpuf.DistanceMetric
+	dims           int
+	// schema holds the declared attributes.  Attributes which were never declared are inferred from the
+	// documents when the schema is requested.
+	schema tpuf.Schema
+	docs   map[docKey]*emulatedDocument
+}
+
+// docKey is the key under which a document is stored.  Numeric and string IDs are distinct, so 5 and "5" are
+// different documents, as they are to the API.
+type docKey struct {
+	numeric bool
+	id      string
+}
+
+type emulatedDocument struct {
+	// id is a string, or a json.Number for numeric IDs.
+	id         interface{}
+	vector     []float32
+	attributes map[string]interface{}
+}
+
+// NewEmulator returns an Emulator with no namespaces.  Its Client doesn't retry failed requests, so that
+// errors surface immediately; its other settings are the defaults and can be changed before use.
+func NewEmulator() *Emulator {
+	e := &Emulator{namespaces: map[string]*emulatedNamespace{}}
+	e.Client = &tpuf.Client{
+		ApiToken:     "emulator",
+		HttpClient:   handlerClient{e},
+		DisableRetry: true,
+	}
+	return e
+}
+
+// handlerClient is a tpuf.HttpClient which serves requests with an http.Handler.
+type handlerClient struct {
+	handler http.Handler
+}
+
+func (c handlerClient) Do(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	c.handler.ServeHTTP(recorder, req)
+	return recorder.Result(), nil
+}
+
+// emulatorError is an error response, reported to the client as an ApiError with the given HTTP status.
+type emulatorError struct {
+	status  int
+	message string
+}
+
+func (e *emulatorError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &emulatorError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func notFound() error {
+	return &emulatorError{status: http.StatusNotFound, message: "Namespace not found"}
+}
+
+// ServeHTTP serves a turbopuffer API request with either path style.
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response, err := e.serve(r)
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusBadRequest
+		if emulatorErr, ok := err.(*emulatorError); ok {
+			status = emulatorErr.status
+		}
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(tpuf.ApiError{Status: "error", Err: err.Error()})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (e *Emulator) serve(r *http.Request) (interface{}, error) {
+	var rest string
+	var ok bool
+	for _, prefix := range []string{"/v1/vectors", "/v1/namespaces"} {
+		if rest, ok = strings.CutPrefix(r.URL.Path, prefix); ok {
+			break
+		}
+	}
+	if !ok {
+		return nil, &emulatorError{status: http.StatusNotFound, message: fmt.Sprintf("unsupported endpoint %s", r.URL.Path)}
+	}
+	namespace, endpoint, _ := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch {
+	case namespace == "" && r.Method == http.MethodGet:
+		return e.listNamespaces(r)
+	case endpoint == "" && r.Method == http.MethodPost:
+		var request writeRequest
+		if err := decodeBody(r, &request); err != nil {
+			return nil, err
+		}
+		return e.write(namespace, &request)
+	case endpoint == "" && r.Method == http.MethodGet:
+		return e.export(namespace, r.URL.Query().Get("cursor"))
+	case endpoint == "" && r.Method == http.MethodDelete:
+		if e.namespaces[namespace] == nil {
+			return nil, notFound()
+		}
+		delete(e.namespaces, namespace)
+		return map[string]string{"status": tpuf.ApiStatusOK}, nil
+	case endpoint == "query" && r.Method == http.MethodPost:
+		var request queryRequest
+		if err := decodeBody(r, &request); err != nil {
+			return nil, err
+		}
+		return e.query(namespace, &request)
+	case endpoint == "schema" && r.Method == http.MethodGet:
+		ns := e.namespaces[namespace]
+		if ns == nil {
+			return nil, notFound()
+		}
+		return ns.fullSchema(), nil
+	case endpoint == "schema" && r.Method == http.MethodPost:
+		var schema tpuf.Schema
+		if err := decodeBody(r, &schema); err != nil {
+			return nil, err
+		}
+		ns := e.namespaces[namespace]
+		if ns == nil {
+			return nil, notFound()
+		}
+		if err := ns.mergeSchema(schema); err != nil {
+			return nil, err
+		}
+		return ns.fullSchema(), nil
+	}
+	return nil, &emulatorError{status: http.StatusNotFound, message: fmt.Sprintf("unsupported endpoint %s %s", r.Method, r.URL.Path)}
+}
+
+// decodeBody decodes the request's JSON body into v, keeping numbers as json.Numbers.
+func decodeBody(r *http.Request, v interface{}) error {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return badRequest("invalid gzip body: %v", err)
+		}
+		defer zr.Close()
+		body = zr
+	}
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+type writeRequest struct {
+	DistanceMetric    tpuf.DistanceMetric `json:"distance_metric"`
+	Schema            tpuf.Schema         `json:"schema"`
+	Upserts           []*writeDocument    `json:"upserts"`
+	CopyFromNamespace string              `json:"copy_from_namespace"`
+	UpsertCondition   interface{}         `json:"upsert_condition"`
+	DeleteByFilter    interface{}         `json:"delete_by_filter"`
+}
+
+type writeDocument struct {
+	ID         interface{}            `json:"id"`
+	Vector     interface{}            `json:"vector"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+func (e *Emulator) write(namespace string, request *writeRequest) (interface{}, error) {
+	if request.DeleteByFilter != nil {
+		ns := e.namespaces[namespace]
+		if ns == nil {
+			return nil, notFound()
+		}
+		var deleted []docKey
+		for key, doc := range ns.docs {
+			match, err := ns.matches(doc, request.DeleteByFilter, nil)
+			if err != nil {
+				return nil, err
+			}
+			if match {
+				deleted = append(deleted, key)
+			}
+		}
+		for _, key := range deleted {
+			delete(ns.docs, key)
+		}
+		return map[string]interface{}{"status": tpuf.ApiStatusOK, "rows_affected": len(deleted)}, nil
+	}
+
+	docs := make([]*emulatedDocument, len(request.Upserts))
+	dims := 0
+	for i, upsert := range request.Upserts {
+		if _, err := documentKey(upsert.ID); err != nil {
+			return nil, err
+		}
+		vector, err := decodeVector(upsert.Vector)
+		if err != nil {
+			return nil, badRequest("invalid vector for document %v: %v", upsert.ID, err)
+		}
+		if len(vector) > 0 {
+			if dims != 0 && len(vector) != dims {
+				return nil, badRequest("vector for document %v has %d dimensions, expected %d", upsert.ID, len(vector), dims)
+			}
+			dims = len(vector)
+		}
+		docs[i] = &emulatedDocument{id: upsert.ID, vector: vector, attributes: upsert.Attributes}
+	}
+
+	// The request is applied to a copy of the namespace, which replaces it only once the whole request has
+	// succeeded, so that a request which fails partway, e.g. on an invalid upsert condition, changes nothing.
+	ns := e.namespaces[namespace].clone()
+	if request.CopyFromNamespace != "" {
+		src := e.namespaces[request.CopyFromNamespace]
+		if src == nil {
+			return nil, notFound()
+		}
+		ns.copyFrom(src)
+	}
+	if request.DistanceMetric != "" {
+		if err := request.DistanceMetric.Validate(); err != nil {
+			return nil, badRequest("%v", err)
+		}
+		if ns.distanceMetric != "" && ns.distanceMetric != request.DistanceMetric {
+			return nil, badRequest("distance metric can't be changed from %s to %s", ns.distanceMetric, request.DistanceMetric)
+		}
+	}
+	if dims != 0 {
+		if request.DistanceMetric == "" && ns.distanceMetric == "" {
+			return nil, badRequest("distance_metric is required to upsert vectors")
+		}
+		if ns.dims != 0 && ns.dims != dims {
+			return nil, badRequest("vectors have %d dimensions, but the namespace's have %d", dims, ns.dims)
+		}
+	}
+	if err := ns.mergeSchema(request.Schema); err != nil {
+		return nil, err
+	}
+	if request.DistanceMetric != "" {
+		ns.distanceMetric = request.DistanceMetric
+	}
+	if dims != 0 {
+		ns.dims = dims
+	}
+
+	written := 0
+	for _, doc := range docs {
+		key, _ := documentKey(doc.id)
+		existing := ns.docs[key]
+		if existing != nil && request.UpsertCondition != nil {
+			match, err := ns.matches(existing, request.UpsertCondition, doc.attributes)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+		if doc.vector == nil && len(doc.attributes) == 0 {
+			delete(ns.docs, key)
+		} else {
+			ns.docs[key] = doc
+		}
+		written++
+	}
+	e.namespaces[namespace] = ns
+	return map[string]interface{}{"status": tpuf.ApiStatusOK, "rows_affected": written}, nil
+}
+
+// clone returns a copy of the namespace, or an empty namespace if ns is nil.  Documents are shared, since they
+// are replaced rather than modified.
+func (ns *emulatedNamespace) clone() *emulatedNamespace {
+	clone := &emulatedNamespace{schema: tpuf.Schema{}, docs: map[docKey]*emulatedDocument{}}
+	if ns == nil {
+		return clone
+	}
+	clone.distanceMetric, clone.dims = ns.distanceMetric, ns.dims
+	for name, attr := range ns.schema {
+		clone.schema[name] = attr
+	}
+	for key, doc := range ns.docs {
+		clone.docs[key] = doc
+	}
+	return clone
+}
+
+func (ns *emulatedNamespace) copyFrom(src *emulatedNamespace) {
+	ns.distanceMetric, ns.dims = src.distanceMetric, src.dims
+	for name, attr := range src.schema {
+		ns.schema[name] = attr
+	}
+	for key, doc := range src.docs {
+		ns.docs[key] = doc
+	}
+}
+
+// mergeSchema adds or updates the declared attributes, rejecting type changes.
+func (ns *emulatedNamespace) mergeSchema(schema tpuf.Schema) error {
+	live := ns.fullSchema()
+	for name, attr := range schema {
+		if attr == nil {
+			continue
+		}
+		if have := live[name]; have != nil && attr.Type != "" && attr.Type != have.Type {
+			return badRequest("attribute %q: type can't be changed from %s to %s", name, have.Type, attr.Type)
+		}
+	}
+	for name, attr := range schema {
+		if attr != nil {
+			ns.schema[name] = attr
+		}
+	}
+	return nil
+}
+
+// fullSchema returns the declared attributes, along with those inferred from the documents and the vector
+// attribute if there are vectors.
+func (ns *emulatedNamespace) fullSchema() tpuf.Schema {
+	schema := tpuf.Schema{}
+	for _, doc := range ns.sortedDocs() {
+		for name, value := range doc.attributes {
+			if schema[name] != nil {
+				continue
+			}
+			if attrType := inferType(value); attrType != "" {
+				schema[name] = &tpuf.Attribute{Type: attrType}
+			}
+		}
+	}
+	if ns.dims > 0 {
+		schema[tpuf.VectorAttribute] = &tpuf.Attribute{Type: tpuf.VectorType(ns.dims, tpuf.VectorDTypeF32)}
+	}
+	for name, attr := range ns.schema {
+		schema[name] = attr
+	}
+	return schema
+}
+
+// inferType returns the type the API would infer for an undeclared attribute value, or "" for null values and
+// empty arrays.
+func inferType(value interface{}) tpuf.AttributeType {
+	switch v := value.(type) {
+	case string:
+		return tpuf.AttributeTypeString
+	case bool:
+		return tpuf.AttributeTypeBool
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return tpuf.AttributeTypeInt
+		}
+		return tpuf.AttributeTypeFloat
+	case []interface{}:
+		if len(v) == 0 {
+			return ""
+		}
+		if elemType := inferType(v[0]); elemType != "" {
+			return "[]" + elemType
+		}
+	}
+	return ""
+}
+
+// documentKey returns the key under which a document with the given ID is stored.
+func documentKey(id interface{}) (docKey, error) {
+	switch v := id.(type) {
+	case string:
+		return docKey{id: v}, nil
+	case json.Number:
+		if _, err := strconv.ParseUint(v.String(), 10, 64); err != nil {
+			return docKey{}, badRequest("invalid document ID %s: numeric IDs must be unsigned integers", v)
+		}
+		return docKey{numeric: true, id: v.String()}, nil
+	}
+	return docKey{}, badRequest("invalid document ID %v", id)
+}
+
+// compareIDs orders numeric IDs numerically, before string IDs in lexicographic order.
+func compareIDs(a, b interface{}) int {
+	an, aNumeric := a.(json.Number)
+	bn, bNumeric := b.(json.Number)
+	switch {
+	case aNumeric && bNumeric:
+		au, _ := strconv.ParseUint(an.String(), 10, 64)
+		bu, _ := strconv.ParseUint(bn.String(), 10, 64)
+		return compareOrdered(au, bu)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a.(string), b.(string))
+}
+
+func (ns *emulatedNamespace) sortedDocs() []*emulatedDocument {
+	docs := make([]*emulatedDocument, 0, len(ns.docs))
+	for _, doc := range ns.docs {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return compareIDs(docs[i].id, docs[j].id) < 0 })
+	return docs
+}
+
+// decodeVector decodes a vector sent as a JSON array or as base64 little-endian float32s.
+func decodeVector(value interface{}) ([]float32, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		data, err := base64.StdEncoding.DecodeString(v)
+		if err != nil || len(data)%4 != 0 {
+			return nil, fmt.Errorf("invalid base64 vector")
+		}
+		vector := make([]float32, len(data)/4)
+		for i := range vector {
+			vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+		return vector, nil
+	case []interface{}:
+		vector := make([]float32, len(v))
+		for i, component := range v {
+			f, ok := toFloat(component)
+			if !ok {
+				return nil, fmt.Errorf("component %d is not a number", i)
+			}
+			vector[i] = float32(f)
+		}
+		return vector, nil
+	}
+	return nil, fmt.Errorf("vector of type %T is not a list or a base64 string", value)
+}
+
+func (e *Emulator) export(namespace string, cursor string) (interface{}, error) {
+	ns := e.namespaces[namespace]
+	if ns == nil {
+		return nil, notFound()
+	}
+	pageSize := e.ExportPageSize
+	if pageSize <= 0 {
+		pageSize = defaultExportPageSize
+	}
+	docs := ns.sortedDocs()
+	start := 0
+	if cursor != "" {
+		after, err := cursorID(cursor)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(docs), func(i int) bool { return compareIDs(docs[i].id, after) > 0 })
+	}
+	end := start + pageSize
+	nextCursor := ""
+	if end < len(docs) {
+		nextCursor = exportCursor(docs[end-1].id)
+	} else {
+		end = len(docs)
+	}
+	page := docs[start:end]
+
+	ids := make([]interface{}, len(page))
+	vectors := make([][]float32, len(page))
+	attributes := map[string][]interface{}{}
+	for i, doc := range page {
+		ids[i], vectors[i] = doc.id, doc.vector
+		for name := range doc.attributes {
+			if attributes[name] == nil {
+				attributes[name] = make([]interface{}, len(page))
+			}
+		}
+	}
+	for name, column := range attributes {
+		for i, doc := range page {
+			column[i] = doc.attributes[name]
+		}
+	}
+	return map[string]interface{}{"ids": ids, "vectors": vectors, "attributes": attributes, "next_cursor": nextCursor}, nil
+}
+
+// exportCursor encodes the ID of the last document of an export page as a cursor.  The ID is encoded as JSON,
+// so that numeric IDs and numeric-looking string IDs remain distinct.
+func exportCursor(id interface{}) string {
+	cursor, _ := json.Marshal(id)
+	return string(cursor)
+}
+
+// cursorID returns the document ID encoded in an export cursor by exportCursor.
+func cursorID(cursor string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(cursor))
+	decoder.UseNumber()
+	var id interface{}
+	if err := decoder.Decode(&id); err != nil {
+		return nil, badRequest("invalid cursor %q", cursor)
+	}
+	if _, err := documentKey(id); err != nil {
+		return nil, badRequest("invalid cursor %q", cursor)
+	}
+	return id, nil
+}
+
+func (e *Emulator) listNamespaces(r *http.Request) (interface{}, error) {
+	params := r.URL.Query()
+	pageSize := defaultNamespacesPageSize
+	if value := params.Get("page_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, badRequest("invalid page_size %q", value)
+		}
+		pageSize = n
+	}
+	var names []string
+	for name := range e.namespaces {
+		if strings.HasPrefix(name, params.Get("prefix")) && name > params.Get("cursor") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	response := &tpuf.NamespacesResponse{Namespaces: []*tpuf.Namespace{}}
+	if len(names) > pageSize {
+		names = names[:pageSize]
+		response.NextCursor = tpuf.NamespaceCursor(names[pageSize-1])
+	}
+	for _, name := range names {
+		response.Namespaces = append(response.Namespaces, &tpuf.Namespace{ID: name})
+	}
+	return response, nil
+}
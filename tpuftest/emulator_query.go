@@ -0,0 +1,684 @@
+package tpuftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bamo/tpuf-go"
+)
+
+// Default BM25 parameters, used unless the attribute's schema sets them.
+const (
+	defaultK1 = 1.2
+	defaultB  = 0.75
+)
+
+type queryRequest struct {
+	Vector            interface{}         `json:"vector"`
+	DistanceMetric    tpuf.DistanceMetric `json:"distance_metric"`
+	RankBy            interface{}         `json:"rank_by"`
+	TopK              int                 `json:"top_k"`
+	IncludeVectors    bool                `json:"include_vectors"`
+	IncludeAttributes interface{}         `json:"include_attributes"`
+	Filters           interface{}         `json:"filters"`
+	AggregateBy       interface{}         `json:"aggregate_by"`
+}
+
+type scoredDocument struct {
+	doc   *emulatedDocument
+	score float64
+}
+
+func (e *Emulator) query(namespace string, request *queryRequest) (interface{}, error) {
+	ns := e.namespaces[namespace]
+	if ns == nil {
+		return nil, notFound()
+	}
+	if request.AggregateBy != nil {
+		return nil, badRequest("aggregations aren't supported by the emulator")
+	}
+	vector, err := decodeVector(request.Vector)
+	if err != nil {
+		return nil, badRequest("invalid query vector: %v", err)
+	}
+	if len(vector) > 0 && request.RankBy != nil {
+		return nil, badRequest("vector and rank_by can't both be set")
+	}
+
+	var matches []*scoredDocument
+	for _, doc := range ns.sortedDocs() {
+		match, err := ns.matches(doc, request.Filters, nil)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			matches = append(matches, &scoredDocument{doc: doc})
+		}
+	}
+
+	hasDist := false
+	switch {
+	case len(vector) > 0:
+		if matches, err = ns.rankByVector(matches, vector, request.DistanceMetric); err != nil {
+			return nil, err
+		}
+		hasDist = true
+	case request.RankBy != nil:
+		if attribute, direction, ok := attributeOrder(request.RankBy); ok {
+			sortByAttribute(matches, attribute, direction)
+			break
+		}
+		scores, err := ns.score(request.RankBy)
+		if err != nil {
+			return nil, err
+		}
+		scored := matches[:0]
+		for _, match := range matches {
+			key, _ := documentKey(match.doc.id)
+			if match.score = scores[key]; match.score > 0 {
+				scored = append(scored, match)
+			}
+		}
+		matches = scored
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+		hasDist = true
+	}
+
+	topK := request.TopK
+	if topK <= 0 {
+		topK = 10
+	}
+	if len(matches) > topK {
+		matches = matches[:topK]
+	}
+	includeAll, includeNames := parseIncludeAttributes(request.IncludeAttributes)
+	results := make([]map[string]interface{}, len(matches))
+	for i, match := range matches {
+		result := map[string]interface{}{"id": match.doc.id}
+		if hasDist {
+			result["dist"] = match.score
+		}
+		if request.IncludeVectors && match.doc.vector != nil {
+			result["vector"] = match.doc.vector
+		}
+		attributes := map[string]interface{}{}
+		for name, value := range match.doc.attributes {
+			if includeAll || includeNames[name] {
+				attributes[name] = value
+			}
+		}
+		if len(attributes) > 0 {
+			result["attributes"] = attributes
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// parseIncludeAttributes parses include_attributes, which is a bool or a list of names.
+func parseIncludeAttributes(value interface{}) (bool, map[string]bool) {
+	names := map[string]bool{}
+	switch v := value.(type) {
+	case bool:
+		return v, names
+	case []interface{}:
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				names[s] = true
+			}
+		}
+	}
+	return false, names
+}
+
+func (ns *emulatedNamespace) rankByVector(matches []*scoredDocument, vector []float32, metric tpuf.DistanceMetric) ([]*scoredDocument, error) {
+	if metric == "" {
+		return nil, badRequest("distance_metric is required for vector search")
+	}
+	if err := metric.Validate(); err != nil {
+		return nil, badRequest("%v", err)
+	}
+	if ns.distanceMetric != "" && metric != ns.distanceMetric {
+		return nil, badRequest("distance metric %s doesn't match the namespace's %s", metric, ns.distanceMetric)
+	}
+	if ns.dims != 0 && len(vector) != ns.dims {
+		return nil, badRequest("query vector has %d dimensions, but the namespace's have %d", len(vector), ns.dims)
+	}
+	ranked := matches[:0]
+	for _, match := range matches {
+		if match.doc.vector == nil {
+			continue
+		}
+		match.score = distance(metric, vector, match.doc.vector)
+		ranked = append(ranked, match)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score < ranked[j].score })
+	return ranked, nil
+}
+
+func distance(metric tpuf.DistanceMetric, a, b []float32) float64 {
+	var dot, normA, normB, squared float64
+	for i := range a {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		squared += (x - y) * (x - y)
+	}
+	if metric == tpuf.DistanceMetricEuclidean {
+		return squared
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/(math.Sqrt(normA)*math.Sqrt(normB))
+}
+
+// attributeOrder reports whether the rank_by expression orders results by an attribute, e.g. ["price", "asc"].
+func attributeOrder(rankBy interface{}) (string, tpuf.SortDirection, bool) {
+	expr, ok := rankBy.([]interface{})
+	if !ok || len(expr) != 2 {
+		return "", "", false
+	}
+	attribute, ok := expr[0].(string)
+	direction, _ := expr[1].(string)
+	if !ok || (direction != string(tpuf.SortAsc) && direction != string(tpuf.SortDesc)) {
+		return "", "", false
+	}
+	return attribute, tpuf.SortDirection(direction), true
+}
+
+// sortByAttribute orders documents by an attribute, with documents missing it last.
+func sortByAttribute(matches []*scoredDocument, attribute string, direction tpuf.SortDirection) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i].doc.attribute(attribute), matches[j].doc.attribute(attribute)
+		if a == nil || b == nil {
+			return a != nil
+		}
+		c, _ := compareValues(a, b)
+		if direction == tpuf.SortDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// score evaluates a rank_by expression other than an attribute order, returning the score of each document by
+// key.  Documents which don't match have no score.
+func (ns *emulatedNamespace) score(rankBy interface{}) (map[docKey]float64, error) {
+	if _, _, ok := attributeOrder(rankBy); ok {
+		return nil, badRequest("ordering by an attribute can't be combined with other rank_by expressions")
+	}
+	expr, ok := rankBy.([]interface{})
+	if !ok {
+		return nil, badRequest("invalid rank_by expression %v", rankBy)
+	}
+	if len(expr) == 3 && expr[1] == "BM25" {
+		attribute, _ := expr[0].(string)
+		return ns.bm25(attribute, expr[2])
+	}
+	if len(expr) != 2 {
+		return nil, badRequest("invalid rank_by expression %v", rankBy)
+	}
+	operands, _ := expr[1].([]interface{})
+	switch expr[0] {
+	case "Sum", "Max":
+		combined := map[docKey]float64{}
+		for _, operand := range operands {
+			scores, err := ns.score(operand)
+			if err != nil {
+				return nil, err
+			}
+			for key, score := range scores {
+				if expr[0] == "Sum" {
+					combined[key] += score
+				} else {
+					combined[key] = math.Max(combined[key], score)
+				}
+			}
+		}
+		return combined, nil
+	case "Product":
+		if len(operands) != 2 {
+			return nil, badRequest("invalid Product expression %v", rankBy)
+		}
+		weight, ok := toFloat(operands[0])
+		if !ok {
+			return nil, badRequest("invalid Product weight %v", operands[0])
+		}
+		scores, err := ns.score(operands[1])
+		if err != nil {
+			return nil, err
+		}
+		for key := range scores {
+			scores[key] *= weight
+		}
+		return scores, nil
+	}
+	return nil, badRequest("unsupported rank_by expression %v", rankBy)
+}
+
+// fullTextSearch returns the full-text search settings of an attribute, failing if it isn't full-text searchable.
+func (ns *emulatedNamespace) fullTextSearch(attribute string) (*tpuf.FullTextSearchParams, error) {
+	attr := ns.schema[attribute]
+	if attr == nil || attr.FullTextSearch == nil {
+		return nil, badRequest("attribute %q is not full-text searchable", attribute)
+	}
+	return attr.FullTextSearch, nil
+}
+
+// bm25 scores every document containing any of the query's tokens in the attribute.
+func (ns *emulatedNamespace) bm25(attribute string, query interface{}) (map[docKey]float64, error) {
+	params, err := ns.fullTextSearch(attribute)
+	if err != nil {
+		return nil, err
+	}
+	queryTokens, err := queryTokens(params, query)
+	if err != nil {
+		return nil, err
+	}
+	k1, b := defaultK1, defaultB
+	if params.K1 != nil {
+		k1 = *params.K1
+	}
+	if params.B != nil {
+		b = *params.B
+	}
+
+	termFrequencies := map[docKey]map[string]int{}
+	lengths := map[docKey]int{}
+	totalLength := 0
+	for key, doc := range ns.docs {
+		value := doc.attributes[attribute]
+		if value == nil {
+			continue
+		}
+		tokens := documentTokens(params, value)
+		frequencies := map[string]int{}
+		for _, token := range tokens {
+			frequencies[token]++
+		}
+		termFrequencies[key], lengths[key] = frequencies, len(tokens)
+		totalLength += len(tokens)
+	}
+	if len(lengths) == 0 {
+		return map[docKey]float64{}, nil
+	}
+	n := float64(len(lengths))
+	averageLength := float64(totalLength) / n
+
+	scores := map[docKey]float64{}
+	seen := map[string]bool{}
+	for _, token := range queryTokens {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		containing := 0
+		for _, frequencies := range termFrequencies {
+			if frequencies[token] > 0 {
+				containing++
+			}
+		}
+		if containing == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(containing)+0.5)/(float64(containing)+0.5))
+		for key, frequencies := range termFrequencies {
+			tf := float64(frequencies[token])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - b
+			if averageLength > 0 {
+				norm += b * float64(lengths[key]) / averageLength
+			}
+			scores[key] += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+	return scores, nil
+}
+
+// queryTokens tokenizes a BM25 query or ContainsAllTokens value, which is a list of tokens for pre-tokenized
+// attributes and text otherwise.
+func queryTokens(params *tpuf.FullTextSearchParams, query interface{}) ([]string, error) {
+	preTokenized := params.Tokenizer == tpuf.TokenizerPreTokenizedArray
+	switch q := query.(type) {
+	case string:
+		if preTokenized {
+			return nil, badRequest("pre-tokenized attributes must be queried with a list of tokens")
+		}
+		return tokenize(q, params.CaseSensitive != nil && *params.CaseSensitive), nil
+	case []interface{}:
+		if !preTokenized {
+			return nil, badRequest("a list of tokens requires a pre-tokenized attribute")
+		}
+		tokens := make([]string, 0, len(q))
+		for _, token := range q {
+			if s, ok := token.(string); ok {
+				tokens = append(tokens, s)
+			}
+		}
+		return tokens, nil
+	}
+	return nil, badRequest("invalid full-text query %v", query)
+}
+
+// documentTokens tokenizes an attribute value for full-text search.
+func documentTokens(params *tpuf.FullTextSearchParams, value interface{}) []string {
+	caseSensitive := params.CaseSensitive != nil && *params.CaseSensitive
+	switch v := value.(type) {
+	case string:
+		return tokenize(v, caseSensitive)
+	case []interface{}:
+		var tokens []string
+		for _, element := range v {
+			s, ok := element.(string)
+			switch {
+			case !ok:
+			case params.Tokenizer == tpuf.TokenizerPreTokenizedArray:
+				tokens = append(tokens, s)
+			default:
+				tokens = append(tokens, tokenize(s, caseSensitive)...)
+			}
+		}
+		return tokens
+	}
+	return nil
+}
+
+// tokenize splits text into runs of letters and digits, lowercased unless caseSensitive is set.
+func tokenize(text string, caseSensitive bool) []string {
+	if !caseSensitive {
+		text = strings.ToLower(text)
+	}
+	return strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
+// attribute returns the value of the named attribute, or the document's ID for IDAttribute.
+func (d *emulatedDocument) attribute(name string) interface{} {
+	if name == tpuf.IDAttribute {
+		return d.id
+	}
+	return d.attributes[name]
+}
+
+// matches evaluates a filter against a document.  refNew holds the attributes of the document being written,
+// for upsert conditions referring to them with tpuf.RefNew.
+func (ns *emulatedNamespace) matches(doc *emulatedDocument, filter interface{}, refNew map[string]interface{}) (bool, error) {
+	if filter == nil {
+		return true, nil
+	}
+	expr, ok := filter.([]interface{})
+	if !ok {
+		return false, badRequest("invalid filter %v", filter)
+	}
+	if len(expr) == 2 && (expr[0] == "And" || expr[0] == "Or") {
+		subFilters, _ := expr[1].([]interface{})
+		for _, subFilter := range subFilters {
+			match, err := ns.matches(doc, subFilter, refNew)
+			if err != nil {
+				return false, err
+			}
+			if expr[0] == "And" && !match {
+				return false, nil
+			}
+			if expr[0] == "Or" && match {
+				return true, nil
+			}
+		}
+		return expr[0] == "And", nil
+	}
+	if len(expr) != 3 {
+		return false, badRequest("invalid filter %v", filter)
+	}
+	attribute, _ := expr[0].(string)
+	operator, _ := expr[1].(string)
+	return ns.evaluate(doc, attribute, tpuf.Operator(operator), resolveRefNew(expr[2], refNew))
+}
+
+// resolveRefNew replaces a reference to an attribute of the document being written with its value.
+func resolveRefNew(value interface{}, refNew map[string]interface{}) interface{} {
+	if ref, ok := value.(map[string]interface{}); ok {
+		if name, ok := ref["$ref_new"].(string); ok {
+			return refNew[name]
+		}
+	}
+	return value
+}
+
+func (ns *emulatedNamespace) evaluate(doc *emulatedDocument, attribute string, operator tpuf.Operator, value interface{}) (bool, error) {
+	docValue := doc.attribute(attribute)
+	switch operator {
+	case tpuf.OpEq:
+		return valuesEqual(docValue, value), nil
+	case tpuf.OpNotEq:
+		return !valuesEqual(docValue, value), nil
+	case tpuf.OpIn, tpuf.OpNotIn:
+		values, ok := value.([]interface{})
+		if !ok {
+			return false, badRequest("%s filter on %q requires a list", operator, attribute)
+		}
+		return containsValue(values, docValue) == (operator == tpuf.OpIn), nil
+	case tpuf.OpLt, tpuf.OpLte, tpuf.OpGt, tpuf.OpGte:
+		c, ok := compareValues(docValue, value)
+		if !ok {
+			return false, nil
+		}
+		switch operator {
+		case tpuf.OpLt:
+			return c < 0, nil
+		case tpuf.OpLte:
+			return c <= 0, nil
+		case tpuf.OpGt:
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case tpuf.OpGlob, tpuf.OpNotGlob, tpuf.OpIGlob, tpuf.OpNotIGlob:
+		pattern, ok := value.(string)
+		if !ok {
+			return false, badRequest("%s filter on %q requires a string pattern", operator, attribute)
+		}
+		re, err := globRegexp(pattern, operator == tpuf.OpIGlob || operator == tpuf.OpNotIGlob)
+		if err != nil {
+			return false, badRequest("invalid glob pattern %q: %v", pattern, err)
+		}
+		s, isString := docValue.(string)
+		match := isString && re.MatchString(s)
+		return match == (operator == tpuf.OpGlob || operator == tpuf.OpIGlob), nil
+	case tpuf.OpRegex, tpuf.OpNotRegex:
+		pattern, _ := value.(string)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, badRequest("invalid regular expression %q: %v", pattern, err)
+		}
+		s, isString := docValue.(string)
+		match := isString && re.MatchString(s)
+		return match == (operator == tpuf.OpRegex), nil
+	case tpuf.OpContains, tpuf.OpNotContains:
+		elements, _ := docValue.([]interface{})
+		return containsValue(elements, value) == (operator == tpuf.OpContains), nil
+	case tpuf.OpContainsAny, tpuf.OpNotContainsAny:
+		values, ok := value.([]interface{})
+		if !ok {
+			return false, badRequest("%s filter on %q requires a list", operator, attribute)
+		}
+		elements, _ := docValue.([]interface{})
+		found := false
+		for _, v := range values {
+			if containsValue(elements, v) {
+				found = true
+				break
+			}
+		}
+		return found == (operator == tpuf.OpContainsAny), nil
+	case tpuf.OpContainsAllTokens:
+		params, err := ns.fullTextSearch(attribute)
+		if err != nil {
+			return false, err
+		}
+		tokens, err := queryTokens(params, value)
+		if err != nil {
+			return false, err
+		}
+		present := map[string]bool{}
+		for _, token := range documentTokens(params, docValue) {
+			present[token] = true
+		}
+		for _, token := range tokens {
+			if !present[token] {
+				return false, nil
+			}
+		}
+		return docValue != nil, nil
+	}
+	return false, badRequest("unsupported filter operator %q", operator)
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if valuesEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if c, ok := compareValues(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compareValues compares two numbers, datetimes, strings or bools, reporting false if they can't be compared.
+// Integers are compared exactly, since IDs and other uint64 values above 2^53 can't be represented as float64.
+func compareValues(a, b interface{}) (int, bool) {
+	if c, ok := compareIntegers(a, b); ok {
+		return c, true
+	}
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		}
+		return 0, true
+	}
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		at, aErr := time.Parse(time.RFC3339Nano, av)
+		bt, bErr := time.Parse(time.RFC3339Nano, bv)
+		if aErr == nil && bErr == nil {
+			return at.Compare(bt), true
+		}
+		return strings.Compare(av, bv), true
+	case bool:
+		bv, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av == bv:
+			return 0, true
+		case !av:
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+// compareIntegers compares two JSON numbers which are both unsigned or both signed integers.
+func compareIntegers(a, b interface{}) (int, bool) {
+	an, aOK := a.(json.Number)
+	bn, bOK := b.(json.Number)
+	if !aOK || !bOK {
+		return 0, false
+	}
+	if au, err := strconv.ParseUint(an.String(), 10, 64); err == nil {
+		if bu, err := strconv.ParseUint(bn.String(), 10, 64); err == nil {
+			return compareOrdered(au, bu), true
+		}
+	}
+	if ai, err := strconv.ParseInt(an.String(), 10, 64); err == nil {
+		if bi, err := strconv.ParseInt(bn.String(), 10, 64); err == nil {
+			return compareOrdered(ai, bi), true
+		}
+	}
+	return 0, false
+}
+
+func compareOrdered[T uint64 | int64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// globRegexp converts a Unix glob pattern, with *, ? and [...] character classes, to a regular expression.
+func globRegexp(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	if caseInsensitive {
+		expr.WriteString("(?i)")
+	}
+	expr.WriteString("^")
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := string(runes[i+1 : end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i = end
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
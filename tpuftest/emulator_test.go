@@ -0,0 +1,339 @@
+package tpuftest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpuftest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSeededEmulator(t *testing.T) *tpuftest.Emulator {
+	emulator := tpuftest.NewEmulator()
+	_, err := emulator.Upsert(context.Background(), "docs", &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		Schema: tpuf.Schema{
+			"title":      &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{}},
+			"created_at": &tpuf.Attribute{Type: tpuf.AttributeTypeDatetime},
+		},
+		Upserts: []*tpuf.Upsert{
+			{ID: "a", Vector: []float32{1, 0}, Attributes: map[string]interface{}{
+				"title": "The quick brown fox", "category": "animals", "views": 10, "tags": []string{"fox", "fast"},
+				"created_at": "2024-01-01T00:00:00Z",
+			}},
+			{ID: "b", Vector: []float32{0.8, 0.6}, Attributes: map[string]interface{}{
+				"title": "A lazy dog sleeps", "category": "animals", "views": 50, "tags": []string{"dog"},
+				"created_at": "2024-06-01T00:00:00Z",
+			}},
+			{ID: "c", Vector: []float32{0, 1}, Attributes: map[string]interface{}{
+				"title": "Quick recipes for dinner", "category": "food", "views": 30,
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	return emulator
+}
+
+func resultIDs(results []*tpuf.QueryResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return ids
+}
+
+func TestEmulatorQuery(t *testing.T) {
+	emulator := newSeededEmulator(t)
+	ctx := context.Background()
+
+	results, err := emulator.Query(ctx, "docs", &tpuf.QueryRequest{
+		Vector:            []float32{1, 0},
+		DistanceMetric:    tpuf.DistanceMetricCosine,
+		IncludeAttributes: tpuf.IncludeAttributeNames("category"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, resultIDs(results))
+	assert.InDelta(t, 0.2, results[1].Dist, 1e-6)
+	assert.JSONEq(t, `{"category":"animals"}`, string(results[0].Attributes))
+
+	results, err = emulator.Query(ctx, "docs", &tpuf.QueryRequest{
+		Vector:         []float32{0, 1},
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		Filters:        tpuf.Eq("category", "animals"),
+		TopK:           1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, resultIDs(results))
+
+	results, err = emulator.Query(ctx, "docs", &tpuf.QueryRequest{RankBy: tpuf.BM25("title", "quick fox")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, resultIDs(results), "documents matching more query tokens should rank first")
+	assert.True(t, results[0].Dist > results[1].Dist)
+
+	results, err = emulator.Query(ctx, "docs", &tpuf.QueryRequest{RankBy: tpuf.Desc("views")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c", "a"}, resultIDs(results))
+	assert.False(t, results[0].HasDist)
+
+	hybrid, err := emulator.HybridQuery(ctx, "docs", &tpuf.HybridRequest{
+		Vector:         []float32{1, 0},
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		TextAttribute:  "title",
+		Text:           "quick fox",
+		TopK:           2,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, hybrid, 2) {
+		assert.Equal(t, "a", hybrid[0].ID, "the document ranked first by both searches should be fused first")
+	}
+
+	_, err = emulator.Query(ctx, "docs", &tpuf.QueryRequest{RankBy: tpuf.BM25("category", "food")})
+	assert.EqualError(t, err, `failed to query documents: error: attribute "category" is not full-text searchable (HTTP 400)`)
+	_, err = emulator.Query(ctx, "missing", &tpuf.QueryRequest{})
+	assert.EqualError(t, err, "failed to query documents: error: Namespace not found (HTTP 404)")
+}
+
+func TestEmulatorFilters(t *testing.T) {
+	emulator := newSeededEmulator(t)
+
+	tests := []struct {
+		name     string
+		filter   tpuf.Filter
+		expected []string
+	}{
+		{"eq", tpuf.Eq("category", "food"), []string{"c"}},
+		{"not eq", tpuf.NotEq("category", "food"), []string{"a", "b"}},
+		{"eq null", tpuf.Eq("tags", nil), []string{"c"}},
+		{"in", tpuf.In("views", []int{10, 30}), []string{"a", "c"}},
+		{"id in", tpuf.IDIn("b", "c"), []string{"b", "c"}},
+		{"range", tpuf.And(tpuf.Gt("views", 10), tpuf.Lte("views", 50)), []string{"b", "c"}},
+		{"datetime range", tpuf.GteTime("created_at", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), []string{"b"}},
+		{"or", tpuf.Or(tpuf.Eq("category", "food"), tpuf.Gte("views", 50)), []string{"b", "c"}},
+		{"glob", tpuf.Glob("title", "*dog*"), []string{"b"}},
+		{"iglob", tpuf.IGlob("title", "quick*"), []string{"c"}},
+		{"contains", tpuf.Contains("tags", "dog"), []string{"b"}},
+		{"contains any", tpuf.ContainsAny("tags", []string{"fox", "dog"}), []string{"a", "b"}},
+		{"not contains any", tpuf.NotContainsAny("tags", []string{"fox"}), []string{"b", "c"}},
+		{"contains all tokens", tpuf.ContainsAllTokens("title", "QUICK fox"), []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := emulator.Query(context.Background(), "docs", &tpuf.QueryRequest{Filters: tt.filter})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, resultIDs(results))
+		})
+	}
+}
+
+func TestEmulatorPagination(t *testing.T) {
+	emulator := tpuftest.NewEmulator()
+	emulator.ExportPageSize = 4
+	ctx := context.Background()
+	var upserts []*tpuf.Upsert
+	for i := 0; i < 10; i++ {
+		upserts = append(upserts, &tpuf.Upsert{IDUint64: tpuf.Uint64(uint64(i)), Vector: []float32{1}, Attributes: map[string]interface{}{"even": i%2 == 0}})
+	}
+	_, err := emulator.Upsert(ctx, "docs", &tpuf.UpsertRequest{DistanceMetric: tpuf.DistanceMetricEuclidean, Upserts: upserts})
+	assert.NoError(t, err)
+
+	var scanned []string
+	it := emulator.Scan(ctx, "docs", tpuf.Eq("even", true), &tpuf.ScanOptions{PageSize: 2})
+	for it.Next() {
+		scanned = append(scanned, it.Result().ID)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"0", "2", "4", "6", "8"}, scanned)
+
+	var exported []string
+	var pages int
+	cursor := ""
+	for {
+		page, err := emulator.Export(ctx, "docs", cursor)
+		if !assert.NoError(t, err) {
+			return
+		}
+		pages++
+		exported = append(exported, page.IDs...)
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, exported)
+
+	deleted, err := emulator.DeleteWhere(ctx, "docs", tpuf.Eq("even", false), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, deleted)
+	report, err := emulator.DeleteUint64(ctx, "docs", []uint64{0})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Deleted)
+	results, err := emulator.Query(ctx, "docs", &tpuf.QueryRequest{TopK: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "4", "6", "8"}, resultIDs(results))
+	assert.Equal(t, uint64(2), *results[0].IDUint64)
+}
+
+func TestEmulatorUpsertCondition(t *testing.T) {
+	emulator := tpuftest.NewEmulator()
+	ctx := context.Background()
+	write := func(version int, title string) int {
+		response, err := emulator.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+			DistanceMetric:  tpuf.DistanceMetricCosine,
+			Upserts:         []*tpuf.Upsert{{ID: "1", Vector: []float32{1}, Attributes: map[string]interface{}{"version": version, "title": title}}},
+			UpsertCondition: tpuf.Lt("version", tpuf.RefNew("version")),
+		})
+		if response.ConditionSkipped > 0 {
+			assert.ErrorIs(t, err, tpuf.ErrConditionFailed)
+		} else {
+			assert.NoError(t, err)
+		}
+		return response.ConditionSkipped
+	}
+
+	assert.Equal(t, 0, write(2, "second"))
+	assert.Equal(t, 1, write(1, "stale"), "the stale write should be skipped")
+	assert.Equal(t, 0, write(3, "third"))
+
+	results, err := emulator.Query(ctx, "docs", &tpuf.QueryRequest{IncludeAttributes: tpuf.IncludeAllAttributes()})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.JSONEq(t, `{"version":3,"title":"third"}`, string(results[0].Attributes))
+	}
+}
+
+func TestEmulatorIDKinds(t *testing.T) {
+	emulator := tpuftest.NewEmulator()
+	emulator.ExportPageSize = 1
+	ctx := context.Background()
+	_, err := emulator.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		Upserts: []*tpuf.Upsert{
+			{IDUint64: tpuf.Uint64(5), Vector: []float32{1}, Attributes: map[string]interface{}{"kind": "numeric"}},
+			{ID: "5", Vector: []float32{1}, Attributes: map[string]interface{}{"kind": "string"}},
+			{ID: "10", Vector: []float32{1}, Attributes: map[string]interface{}{"kind": "string"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	results, err := emulator.Query(ctx, "docs", &tpuf.QueryRequest{IncludeAttributes: tpuf.IncludeAllAttributes()})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3, "5 and \"5\" should be different documents") {
+		assert.Equal(t, uint64(5), *results[0].IDUint64)
+		assert.JSONEq(t, `{"kind":"numeric"}`, string(results[0].Attributes))
+	}
+
+	var exported []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := emulator.Export(ctx, "docs", cursor)
+		if !assert.NoError(t, err) {
+			return
+		}
+		exported = append(exported, page.IDs...)
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, []string{"5", "10", "5"}, exported, "a numeric-looking string cursor should continue after the string ID")
+}
+
+func TestEmulatorLargeNumericIDs(t *testing.T) {
+	emulator := tpuftest.NewEmulator()
+	ctx := context.Background()
+	const id = 1<<53 + 1
+	_, err := emulator.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		Upserts: []*tpuf.Upsert{
+			{IDUint64: tpuf.Uint64(id - 1), Vector: []float32{1}, Attributes: map[string]interface{}{"n": 1}},
+			{IDUint64: tpuf.Uint64(id), Vector: []float32{1}, Attributes: map[string]interface{}{"n": 2}},
+		},
+	})
+	assert.NoError(t, err)
+
+	results, err := emulator.Query(ctx, "docs", &tpuf.QueryRequest{Filters: tpuf.Eq(tpuf.IDAttribute, uint64(id))})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9007199254740993"}, resultIDs(results), "IDs above 2^53 should be compared exactly")
+
+	results, err = emulator.Query(ctx, "docs", &tpuf.QueryRequest{Filters: tpuf.Lt(tpuf.IDAttribute, uint64(id))})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"9007199254740992"}, resultIDs(results))
+}
+
+func TestEmulatorFailedWriteChangesNothing(t *testing.T) {
+	emulator := newSeededEmulator(t)
+	ctx := context.Background()
+	_, err := emulator.Upsert(ctx, "other", &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricEuclidean,
+		Upserts:        []*tpuf.Upsert{{ID: "x", Vector: []float32{1, 0}}},
+	})
+	assert.NoError(t, err)
+
+	_, err = emulator.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+		DistanceMetric:    tpuf.DistanceMetricCosine,
+		CopyFromNamespace: "other",
+	})
+	assert.Error(t, err, "copying a namespace with a different distance metric should fail")
+
+	_, err = emulator.Upsert(ctx, "docs", &tpuf.UpsertRequest{
+		Upserts: []*tpuf.Upsert{
+			{ID: "new", Vector: []float32{1, 0}, Attributes: map[string]interface{}{"category": "new"}},
+			{ID: "a", Vector: []float32{1, 0}, Attributes: map[string]interface{}{"category": "changed"}},
+		},
+		UpsertCondition: &tpuf.BaseFilter{Attribute: "category", Operator: tpuf.OpGlob, Value: 42},
+	})
+	assert.Error(t, err, "an invalid upsert condition should fail")
+
+	results, err := emulator.Query(ctx, "docs", &tpuf.QueryRequest{IncludeAttributes: tpuf.IncludeAttributeNames("category")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, resultIDs(results), "no document should have been written or copied")
+	if assert.Len(t, results, 3) {
+		assert.JSONEq(t, `{"category":"animals"}`, string(results[0].Attributes))
+	}
+}
+
+func TestEmulatorNamespaces(t *testing.T) {
+	emulator := newSeededEmulator(t)
+	ctx := context.Background()
+
+	schema, err := emulator.Schema(ctx, "docs")
+	assert.NoError(t, err)
+	assert.Equal(t, tpuf.AttributeTypeInt, schema["views"].Type, "undeclared attributes should be inferred")
+	assert.Equal(t, tpuf.AttributeTypeStringArray, schema["tags"].Type)
+	assert.Equal(t, tpuf.VectorType(2, tpuf.VectorDTypeF32), schema["vector"].Type)
+	assert.NotNil(t, schema["title"].FullTextSearch)
+
+	_, err = emulator.EnsureNamespace(ctx, "docs", tpuf.Schema{"views": &tpuf.Attribute{Type: tpuf.AttributeTypeString}}, nil)
+	var conflictErr *tpuf.SchemaConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	result, err := emulator.EnsureNamespace(ctx, "other", tpuf.Schema{"body": &tpuf.Attribute{Type: tpuf.AttributeTypeString}}, nil)
+	assert.NoError(t, err)
+	assert.True(t, result.Created)
+
+	ids, err := emulator.NamespaceIDs(ctx, &tpuf.NamespacesRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs", "other"}, ids)
+
+	assert.NoError(t, emulator.DeleteNamespace(ctx, "other"))
+	response, err := emulator.Namespaces(ctx, &tpuf.NamespacesRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*tpuf.Namespace{{ID: "docs"}}, response.Namespaces)
+	err = emulator.DeleteNamespace(ctx, "other")
+	assert.EqualError(t, err, "failed to delete namespace: error: Namespace not found (HTTP 404)")
+}
+
+func TestEmulatorServesHTTP(t *testing.T) {
+	emulator := newSeededEmulator(t)
+	server := httptest.NewServer(emulator)
+	defer server.Close()
+	client, err := tpuf.NewClient("test-token", tpuf.WithBaseURL(server.URL), tpuf.WithHttpClient(http.DefaultClient), tpuf.WithGzipEncoding())
+	assert.NoError(t, err)
+
+	results, err := client.Query(context.Background(), "docs", &tpuf.QueryRequest{Filters: tpuf.Eq("category", "food")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, resultIDs(results))
+}
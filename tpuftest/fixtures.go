@@ -0,0 +1,58 @@
+// Package tpuftest helps test code which uses the tpuf client.
+//
+// It provides canonical JSON fixtures for the API's wire types, which are checked against the client's
+// encoding and decoding in this package's tests, for validating integrations such as proxies and fakes
+// against known-good payloads:
+//
+//	data, err := tpuftest.LoadFixture("query_vector")
+//
+// RecordingClient wraps a tpuf.API and records the calls made through it, for asserting on how code uses
+// the client:
+//
+//	recorder := tpuftest.NewRecordingClient(client)
+//	indexDocuments(ctx, recorder)
+//	upserts := recorder.UpsertsFor("docs")
+package tpuftest
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// LoadFixture returns the JSON of the named fixture, as listed by FixtureNames.  The error wraps
+// fs.ErrNotExist if there is no such fixture.
+func LoadFixture(name string) ([]byte, error) {
+	data, err := fixtures.ReadFile(path.Join("fixtures", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fixture %q: %w", name, err)
+	}
+	return data, nil
+}
+
+// FixtureNames returns the names of all fixtures, sorted.  They are:
+//
+//   - filter and filter_compound: filters, as sent in a query's filters
+//   - schema: a namespace schema, as sent in an upsert and returned by the schema endpoint
+//   - query_vector and query_bm25: query request bodies
+//   - query_response: query results, including a numeric ID and a result without a distance
+//   - export_response: a page of exported documents
+//   - upsert_request: a write request body
+func FixtureNames() []string {
+	entries, err := fs.ReadDir(fixtures, "fixtures")
+	if err != nil {
+		panic(fmt.Sprintf("failed to list embedded fixtures: %v", err))
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = strings.TrimSuffix(entry.Name(), ".json")
+	}
+	sort.Strings(names)
+	return names
+}
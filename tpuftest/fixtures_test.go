@@ -0,0 +1,165 @@
+package tpuftest_test
+
+import (
+	"encoding/json"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpuftest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixtures(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		// encodeOnly is set for types holding filters or rank_by expressions, which can't be decoded.
+		encodeOnly bool
+		// decodeOnly is set for response types, which the client never encodes.
+		decodeOnly bool
+	}{
+		{
+			name:       "filter",
+			value:      tpuf.Eq("category", "news"),
+			encodeOnly: true,
+		},
+		{
+			name: "filter_compound",
+			value: tpuf.And(
+				tpuf.Gte("published_at", "2024-01-01T00:00:00Z"),
+				tpuf.Or(
+					tpuf.ContainsAny("tags", []string{"go", "rust"}),
+					tpuf.Glob("title", "*release*"),
+				),
+				tpuf.NotEq("archived", true),
+			),
+			encodeOnly: true,
+		},
+		{
+			name: "schema",
+			value: &tpuf.Schema{
+				"vector": &tpuf.Attribute{Type: tpuf.VectorType(1536, tpuf.VectorDTypeF32), ANN: boolPtr(true)},
+				"title": &tpuf.Attribute{
+					Type: tpuf.AttributeTypeString,
+					FullTextSearch: &tpuf.FullTextSearchParams{
+						Language:        "english",
+						Stemming:        boolPtr(true),
+						RemoveStopWords: boolPtr(true),
+						Tokenizer:       tpuf.TokenizerWordV1,
+						K1:              float64Ptr(1.2),
+						B:               float64Ptr(0.75),
+					},
+				},
+				"tokens": &tpuf.Attribute{
+					Type:           tpuf.AttributeTypeStringArray,
+					FullTextSearch: &tpuf.FullTextSearchParams{Tokenizer: tpuf.TokenizerPreTokenizedArray},
+				},
+				"category":     &tpuf.Attribute{Type: tpuf.AttributeTypeString, Filterable: boolPtr(true)},
+				"related_id":   &tpuf.Attribute{Type: tpuf.AttributeTypeUUID},
+				"published_at": &tpuf.Attribute{Type: tpuf.AttributeTypeDatetime},
+				"views":        &tpuf.Attribute{Type: tpuf.AttributeTypeUint},
+			},
+		},
+		{
+			name: "query_vector",
+			value: &tpuf.QueryRequest{
+				Vector:            []float32{0.1, 0.2, 0.3},
+				DistanceMetric:    tpuf.DistanceMetricCosine,
+				TopK:              10,
+				IncludeVectors:    true,
+				IncludeAttributes: tpuf.IncludeAttributeNames("title", "category"),
+				Filters:           tpuf.And(tpuf.Eq("category", "news"), tpuf.Gt("views", 100)),
+				Consistency:       &tpuf.Consistency{Level: tpuf.ConsistencyEventual},
+			},
+			encodeOnly: true,
+		},
+		{
+			name: "query_bm25",
+			value: &tpuf.QueryRequest{
+				RankBy:            tpuf.Sum(tpuf.BM25("title", "quick fox"), tpuf.BM25Tokens("tokens", "quick", "fox")),
+				TopK:              5,
+				IncludeAttributes: tpuf.IncludeAllAttributes(),
+				Filters:           tpuf.ContainsAllTokens("title", "fox"),
+			},
+			encodeOnly: true,
+		},
+		{
+			name: "query_response",
+			value: &[]*tpuf.QueryResult{
+				{
+					ID:         "doc-1",
+					Dist:       0.12,
+					Vector:     []float32{0.1, 0.2, 0.3},
+					Attributes: json.RawMessage(`{"title": "Quick fox", "category": "news"}`),
+				},
+				{ID: "42", IDUint64: tpuf.Uint64(42), Dist: 0.5},
+				{ID: "doc-3"},
+			},
+			decodeOnly: true,
+		},
+		{
+			name: "export_response",
+			value: &tpuf.ExportResponse{
+				IDs:       []string{"doc-1", "42"},
+				IDsUint64: []*uint64{nil, tpuf.Uint64(42)},
+				Vectors:   [][]float32{{0.1, 0.2}, {0.3, 0.4}},
+				Attributes: map[string][]json.RawMessage{
+					"title": {json.RawMessage(`"Quick fox"`), json.RawMessage(`null`)},
+					"views": {json.RawMessage(`10`), json.RawMessage(`20`)},
+				},
+				NextCursor: "cursor-2",
+			},
+		},
+		{
+			name: "upsert_request",
+			value: &tpuf.UpsertRequest{
+				DistanceMetric: tpuf.DistanceMetricCosine,
+				Schema:         tpuf.Schema{"title": &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{}}},
+				Upserts: []*tpuf.Upsert{
+					{ID: "doc-1", Vector: []float32{0.1, 0.2, 0.3}, Attributes: map[string]interface{}{"title": "Quick fox", "views": 10}},
+					{ID: "doc-2", Vector: []float32{0.4, 0.5, 0.6}},
+				},
+				UpsertCondition: tpuf.Lt("version", tpuf.RefNew("version")),
+			},
+			encodeOnly: true,
+		},
+	}
+
+	var tested []string
+	for _, tt := range tests {
+		tested = append(tested, tt.name)
+		t.Run(tt.name, func(t *testing.T) {
+			fixture, err := tpuftest.LoadFixture(tt.name)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if !tt.decodeOnly {
+				encoded, err := json.Marshal(tt.value)
+				assert.NoError(t, err)
+				assert.JSONEq(t, string(fixture), string(encoded), "encoding doesn't match the fixture")
+			}
+			if !tt.encodeOnly {
+				decoded := reflect.New(reflect.TypeOf(tt.value).Elem()).Interface()
+				assert.NoError(t, json.Unmarshal(fixture, decoded))
+				assert.Equal(t, tt.value, decoded, "decoding doesn't match the fixture")
+			}
+		})
+	}
+	assert.ElementsMatch(t, tpuftest.FixtureNames(), tested, "every fixture should be tested")
+}
+
+func TestLoadFixtureNotFound(t *testing.T) {
+	_, err := tpuftest.LoadFixture("nonexistent")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
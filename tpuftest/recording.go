@@ -0,0 +1,171 @@
+package tpuftest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bamo/tpuf-go"
+)
+
+// Call is a single call recorded by a RecordingClient.
+type Call struct {
+	// Operation is the kind of call.  Delete and DeleteByFilter are both tpuf.OperationDelete.
+	Operation tpuf.Operation
+	// Namespace is the namespace the call targeted, or empty for Namespaces.
+	Namespace string
+	// Request is the call's request: the *tpuf.UpsertRequest, *tpuf.QueryRequest or *tpuf.NamespacesRequest,
+	// the []string of IDs passed to Delete, the tpuf.Filter passed to DeleteByFilter, the string cursor passed
+	// to Export, the tpuf.Schema passed to UpdateSchema, or nil for calls without one.
+	Request interface{}
+	// Err is the error returned by the wrapped API, if any.
+	Err error
+}
+
+// RecordingClient wraps a tpuf.API, such as a *tpuf.Client with a fake HTTP client, recording every call
+// made through it, so that tests can assert on how the API was used rather than on request bodies.
+// It is safe for concurrent use.
+type RecordingClient struct {
+	// API is the wrapped implementation, which handles every call.
+	API tpuf.API
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ tpuf.API = (*RecordingClient)(nil)
+
+// NewRecordingClient returns a RecordingClient wrapping api.
+func NewRecordingClient(api tpuf.API) *RecordingClient {
+	return &RecordingClient{API: api}
+}
+
+func (r *RecordingClient) record(op tpuf.Operation, namespace string, request interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Operation: op, Namespace: namespace, Request: request, Err: err})
+}
+
+func (r *RecordingClient) Upsert(ctx context.Context, namespace string, request *tpuf.UpsertRequest) (*tpuf.UpsertResponse, error) {
+	response, err := r.API.Upsert(ctx, namespace, request)
+	r.record(tpuf.OperationUpsert, namespace, request, err)
+	return response, err
+}
+
+func (r *RecordingClient) Query(ctx context.Context, namespace string, request *tpuf.QueryRequest) ([]*tpuf.QueryResult, error) {
+	results, err := r.API.Query(ctx, namespace, request)
+	r.record(tpuf.OperationQuery, namespace, request, err)
+	return results, err
+}
+
+func (r *RecordingClient) Delete(ctx context.Context, namespace string, ids []string) (*tpuf.DeleteReport, error) {
+	report, err := r.API.Delete(ctx, namespace, ids)
+	r.record(tpuf.OperationDelete, namespace, ids, err)
+	return report, err
+}
+
+func (r *RecordingClient) DeleteByFilter(ctx context.Context, namespace string, filter tpuf.Filter) (*tpuf.DeleteResponse, error) {
+	response, err := r.API.DeleteByFilter(ctx, namespace, filter)
+	r.record(tpuf.OperationDelete, namespace, filter, err)
+	return response, err
+}
+
+func (r *RecordingClient) Export(ctx context.Context, namespace string, cursor string) (*tpuf.ExportResponse, error) {
+	response, err := r.API.Export(ctx, namespace, cursor)
+	r.record(tpuf.OperationExport, namespace, cursor, err)
+	return response, err
+}
+
+func (r *RecordingClient) Schema(ctx context.Context, namespace string) (tpuf.Schema, error) {
+	schema, err := r.API.Schema(ctx, namespace)
+	r.record(tpuf.OperationSchema, namespace, nil, err)
+	return schema, err
+}
+
+func (r *RecordingClient) UpdateSchema(ctx context.Context, namespace string, schema tpuf.Schema) (tpuf.Schema, error) {
+	updated, err := r.API.UpdateSchema(ctx, namespace, schema)
+	r.record(tpuf.OperationUpdateSchema, namespace, schema, err)
+	return updated, err
+}
+
+func (r *RecordingClient) Namespaces(ctx context.Context, request *tpuf.NamespacesRequest) (*tpuf.NamespacesResponse, error) {
+	response, err := r.API.Namespaces(ctx, request)
+	r.record(tpuf.OperationNamespaces, "", request, err)
+	return response, err
+}
+
+func (r *RecordingClient) DeleteNamespace(ctx context.Context, namespace string) error {
+	err := r.API.DeleteNamespace(ctx, namespace)
+	r.record(tpuf.OperationDeleteNamespace, namespace, nil, err)
+	return err
+}
+
+// Calls returns every recorded call, in the order the calls returned.
+func (r *RecordingClient) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]Call, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+// CallsFor returns the recorded calls targeting namespace.
+func (r *RecordingClient) CallsFor(namespace string) []Call {
+	var calls []Call
+	for _, call := range r.Calls() {
+		if call.Namespace == namespace {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// UpsertsFor returns the requests of the recorded Upsert calls targeting namespace.
+func (r *RecordingClient) UpsertsFor(namespace string) []*tpuf.UpsertRequest {
+	var requests []*tpuf.UpsertRequest
+	for _, call := range r.CallsFor(namespace) {
+		if request, ok := call.Request.(*tpuf.UpsertRequest); ok && call.Operation == tpuf.OperationUpsert {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// UpsertedIDs returns the IDs of every document upserted in namespace, in order, including repeats.
+func (r *RecordingClient) UpsertedIDs(namespace string) []string {
+	var ids []string
+	for _, request := range r.UpsertsFor(namespace) {
+		for _, upsert := range request.Upserts {
+			ids = append(ids, upsert.ID)
+		}
+	}
+	return ids
+}
+
+// QueriesFor returns the requests of the recorded Query calls targeting namespace.
+func (r *RecordingClient) QueriesFor(namespace string) []*tpuf.QueryRequest {
+	var requests []*tpuf.QueryRequest
+	for _, call := range r.CallsFor(namespace) {
+		if request, ok := call.Request.(*tpuf.QueryRequest); ok && call.Operation == tpuf.OperationQuery {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// DeletedIDs returns the IDs passed to the recorded Delete calls targeting namespace, in order.
+func (r *RecordingClient) DeletedIDs(namespace string) []string {
+	var ids []string
+	for _, call := range r.CallsFor(namespace) {
+		if deleted, ok := call.Request.([]string); ok && call.Operation == tpuf.OperationDelete {
+			ids = append(ids, deleted...)
+		}
+	}
+	return ids
+}
+
+// Reset discards the recorded calls.
+func (r *RecordingClient) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
@@ -0,0 +1,84 @@
+package tpuftest_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/bamo/tpuf-go/tpuftest"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHttpClient struct {
+	doFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (f *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	return f.doFunc(req)
+}
+
+func TestRecordingClient(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				status, body := http.StatusOK, `{"status":"OK"}`
+				switch {
+				case strings.HasSuffix(req.URL.Path, "/query"):
+					body = `[{"id":"1","dist":0.5}]`
+				case strings.Contains(req.URL.Path, "/missing"):
+					status, body = http.StatusNotFound, `{"error":"Namespace not found","status":"error"}`
+				}
+				return &http.Response{StatusCode: status, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+			},
+		},
+	}
+	recorder := tpuftest.NewRecordingClient(client)
+	ctx := context.Background()
+
+	first := &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		Upserts:        []*tpuf.Upsert{{ID: "1", Vector: []float32{1}}, {ID: "2", Vector: []float32{2}}},
+	}
+	second := &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		Upserts:        []*tpuf.Upsert{{ID: "3", Vector: []float32{3}}},
+	}
+	query := &tpuf.QueryRequest{Filters: tpuf.Eq("category", "news")}
+
+	_, err := recorder.Upsert(ctx, "docs", first)
+	assert.NoError(t, err)
+	_, err = recorder.Upsert(ctx, "other", second)
+	assert.NoError(t, err)
+	results, err := recorder.Query(ctx, "docs", query)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1, "results should be passed through")
+	_, err = recorder.Delete(ctx, "docs", []string{"2"})
+	assert.NoError(t, err)
+	_, err = recorder.DeleteByFilter(ctx, "docs", tpuf.Eq("category", "old"))
+	assert.NoError(t, err)
+	_, err = recorder.Schema(ctx, "missing")
+	assert.Error(t, err)
+
+	assert.Equal(t, []*tpuf.UpsertRequest{first}, recorder.UpsertsFor("docs"))
+	assert.Equal(t, []*tpuf.UpsertRequest{second}, recorder.UpsertsFor("other"))
+	assert.Equal(t, []string{"1", "2"}, recorder.UpsertedIDs("docs"))
+	assert.Equal(t, []*tpuf.QueryRequest{query}, recorder.QueriesFor("docs"))
+	assert.Equal(t, []string{"2"}, recorder.DeletedIDs("docs"))
+	assert.Len(t, recorder.CallsFor("docs"), 4)
+
+	calls := recorder.Calls()
+	assert.Len(t, calls, 6)
+	assert.Equal(t, tpuf.OperationDelete, calls[4].Operation)
+	assert.Equal(t, tpuf.Eq("category", "old"), calls[4].Request)
+	assert.Equal(t, tpuftest.Call{Operation: tpuf.OperationSchema, Namespace: "missing", Err: calls[5].Err}, calls[5])
+	assert.Error(t, calls[5].Err, "errors should be recorded")
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Calls())
+}
@@ -0,0 +1,84 @@
+package tpuf
+
+import (
+	"context"
+	"fmt"
+)
+
+type TransferOptions struct {
+	// DistanceMetric is the distance metric for the destination namespace.  Defaults to the destination
+	// handle's DistanceMetric.  Required if the documents have vectors, since it can't be read from the source.
+	DistanceMetric DistanceMetric
+	// BatchSize is the number of documents per upsert request.  Defaults to 1000.
+	BatchSize int
+	// SkipSchema disables carrying the source namespace's schema over to the destination.
+	SkipSchema bool
+	// Progress, if set, is called after each exported page has been written to the destination.
+	Progress func(*TransferProgress)
+}
+
+// TransferProgress reports how much of a namespace has been transferred.
+type TransferProgress struct {
+	// Pages is the number of export pages written.
+	Pages int
+	// Documents is the number of documents written.
+	Documents int64
+}
+
+// TransferNamespace copies every document from the source namespace into the destination namespace by
+// exporting pages from the source and upserting them in batches into the destination.  Unlike CopyNamespace,
+// the source and destination may use different clients, e.g. with BaseURLs in different regions.
+// The source schema is carried over unless disabled.  Requests are retried according to each client's
+// retry settings.  opts may be nil.
+func TransferNamespace(ctx context.Context, src *NamespaceClient, dst *NamespaceClient, opts *TransferOptions) (*TransferProgress, error) {
+	if opts == nil {
+		opts = &TransferOptions{}
+	}
+	distanceMetric := opts.DistanceMetric
+	if distanceMetric == "" {
+		distanceMetric = dst.DistanceMetric
+	}
+
+	var schema Schema
+	if !opts.SkipSchema {
+		var err error
+		schema, err = src.GetSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transfer schema: %w", err)
+		}
+	}
+
+	upserter := &BulkUpserter{
+		Client:         dst.Client,
+		Namespace:      dst.Name,
+		BatchSize:      opts.BatchSize,
+		DistanceMetric: distanceMetric,
+		Schema:         schema,
+		AllowNoVector:  true,
+	}
+	progress := &TransferProgress{}
+	it := src.Client.ExportIter(ctx, src.Name)
+	for it.Next() {
+		for _, document := range it.Documents() {
+			if len(document.Vector) > 0 && distanceMetric == "" {
+				return progress, fmt.Errorf("a distance metric is required to transfer documents with vectors")
+			}
+			err := upserter.Add(ctx, &Upsert{ID: document.ID, IDUint64: document.IDUint64, Vector: document.Vector, Attributes: document.Attributes})
+			if err != nil {
+				return progress, fmt.Errorf("failed to transfer page %d: %w", progress.Pages+1, err)
+			}
+		}
+		if err := upserter.Flush(ctx); err != nil {
+			return progress, fmt.Errorf("failed to transfer page %d: %w", progress.Pages+1, err)
+		}
+		progress.Pages++
+		progress.Documents += int64(len(it.Page().IDs))
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return progress, fmt.Errorf("failed to transfer page %d: %w", progress.Pages+1, err)
+	}
+	return progress, nil
+}
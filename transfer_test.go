@@ -0,0 +1,130 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferNamespace(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             *tpuf.TransferOptions
+		srcResponses     map[string]string
+		expectedError    string
+		expectedUpserts  []string
+		expectedProgress []tpuf.TransferProgress
+	}{
+		{
+			name: "two pages with schema",
+			opts: &tpuf.TransferOptions{DistanceMetric: tpuf.DistanceMetricCosine},
+			srcResponses: map[string]string{
+				"/v1/vectors/src-ns/schema":       `{"title":{"type":"string","full_text_search":true}}`,
+				"/v1/vectors/src-ns":              `{"ids":["1"],"vectors":[[0.1]],"attributes":{"title":["a"]},"next_cursor":"page2"}`,
+				"/v1/vectors/src-ns?cursor=page2": `{"ids":["2","3"],"vectors":[[0.2],[0.3]],"attributes":{"title":["b",null]}}`,
+			},
+			expectedUpserts: []string{
+				`{"distance_metric":"cosine_distance","schema":{"title":{"type":"string","full_text_search":{}}},"upserts":[{"id":"1","vector":[0.1],"attributes":{"title":"a"}}]}`,
+				`{"distance_metric":"cosine_distance","schema":{"title":{"type":"string","full_text_search":{}}},"upserts":[{"id":"2","vector":[0.2],"attributes":{"title":"b"}},{"id":"3","vector":[0.3],"attributes":{}}]}`,
+			},
+			expectedProgress: []tpuf.TransferProgress{{Pages: 1, Documents: 1}, {Pages: 2, Documents: 3}},
+		},
+		{
+			name: "skip schema without vectors",
+			opts: &tpuf.TransferOptions{SkipSchema: true},
+			srcResponses: map[string]string{
+				"/v1/vectors/src-ns": `{"ids":["1"],"attributes":{"title":["a"]}}`,
+			},
+			expectedUpserts: []string{
+				`{"upserts":[{"id":"1","attributes":{"title":"a"}}]}`,
+			},
+			expectedProgress: []tpuf.TransferProgress{{Pages: 1, Documents: 1}},
+		},
+		{
+			name: "vectors without distance metric",
+			opts: &tpuf.TransferOptions{SkipSchema: true},
+			srcResponses: map[string]string{
+				"/v1/vectors/src-ns": `{"ids":["1"],"vectors":[[0.1]]}`,
+			},
+			expectedError: "a distance metric is required to transfer documents with vectors",
+		},
+		{
+			name: "export failure",
+			opts: &tpuf.TransferOptions{SkipSchema: true},
+			srcResponses: map[string]string{
+				"/v1/vectors/src-ns": `{"ids":["1"],"attributes":{"title":["a"]},"next_cursor":"page2"}`,
+			},
+			expectedUpserts: []string{
+				`{"upserts":[{"id":"1","attributes":{"title":"a"}}]}`,
+			},
+			expectedProgress: []tpuf.TransferProgress{{Pages: 1, Documents: 1}},
+			expectedError:    "failed to transfer page 2: failed to export documents: error: not found (HTTP 404)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &tpuf.Client{
+				ApiToken:     "src-token",
+				BaseURL:      "https://gcp-us-east4.turbopuffer.com",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "gcp-us-east4.turbopuffer.com", req.URL.Host, "unexpected source host")
+						body, ok := tt.srcResponses[req.URL.RequestURI()]
+						if !ok {
+							return &http.Response{
+								StatusCode: http.StatusNotFound,
+								Body:       io.NopCloser(bytes.NewBufferString(`{"status":"error","error":"not found"}`)),
+							}, nil
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(body)),
+						}, nil
+					},
+				},
+			}
+			var upserts []string
+			dst := &tpuf.Client{
+				ApiToken: "dst-token",
+				BaseURL:  "https://gcp-europe-west3.turbopuffer.com",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "https://gcp-europe-west3.turbopuffer.com/v1/vectors/dst-ns", req.URL.String(), "unexpected destination URL")
+						body, _ := io.ReadAll(req.Body)
+						upserts = append(upserts, string(body))
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+						}, nil
+					},
+				},
+			}
+			var progress []tpuf.TransferProgress
+			tt.opts.Progress = func(p *tpuf.TransferProgress) {
+				progress = append(progress, *p)
+			}
+
+			_, err := tpuf.TransferNamespace(context.Background(), src.Namespace("src-ns"), dst.Namespace("dst-ns"), tt.opts)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, len(tt.expectedUpserts), len(upserts), "unexpected number of upserts")
+			for i := range upserts {
+				if i < len(tt.expectedUpserts) {
+					assert.JSONEq(t, tt.expectedUpserts[i], upserts[i], "unexpected upsert body")
+				}
+			}
+			assert.Equal(t, tt.expectedProgress, progress, "unexpected progress")
+		})
+	}
+}
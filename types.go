@@ -1,6 +1,18 @@
 package tpuf
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // DistanceMetric represents the available distance functions used to calculate vector similarity.
+// These are the only metrics the API supports; for dot product similarity, normalize vectors and use
+// cosine distance, which then ranks results identically.
 type DistanceMetric string
 
 const (
@@ -8,17 +20,91 @@ const (
 	DistanceMetricEuclidean DistanceMetric = "euclidean_squared"
 )
 
+// ErrDistanceMetricMismatch is returned by NamespaceClient for requests whose distance metric differs from
+// the one configured on the handle.
+var ErrDistanceMetricMismatch = errors.New("distance metric mismatch")
+
+// Validate checks that m is a distance metric supported by the API.
+func (m DistanceMetric) Validate() error {
+	switch m {
+	case DistanceMetricCosine, DistanceMetricEuclidean:
+		return nil
+	}
+	return fmt.Errorf("unsupported distance metric %q; supported metrics are %s and %s", m, DistanceMetricCosine, DistanceMetricEuclidean)
+}
+
 // AttributeType is the data type of an attribute.
 type AttributeType string
 
 const (
-	AttributeTypeString      AttributeType = "string"
-	AttributeTypeUint        AttributeType = "uint"
-	AttributeTypeUUID        AttributeType = "uuid"
-	AttributeTypeBool        AttributeType = "bool"
-	AttributeTypeStringArray AttributeType = "[]string"
-	AttributeTypeUintArray   AttributeType = "[]uint"
-	AttributeTypeUUIDArray   AttributeType = "[]uuid"
+	AttributeTypeString        AttributeType = "string"
+	AttributeTypeInt           AttributeType = "int"
+	AttributeTypeUint          AttributeType = "uint"
+	AttributeTypeFloat         AttributeType = "float"
+	AttributeTypeUUID          AttributeType = "uuid"
+	AttributeTypeDatetime      AttributeType = "datetime"
+	AttributeTypeBool          AttributeType = "bool"
+	AttributeTypeStringArray   AttributeType = "[]string"
+	AttributeTypeIntArray      AttributeType = "[]int"
+	AttributeTypeUintArray     AttributeType = "[]uint"
+	AttributeTypeFloatArray    AttributeType = "[]float"
+	AttributeTypeUUIDArray     AttributeType = "[]uuid"
+	AttributeTypeDatetimeArray AttributeType = "[]datetime"
+)
+
+// VectorAttribute is the name of the special attribute holding each document's vector.  Its entry in a
+// Schema, if any, has a type created by VectorType.
+const VectorAttribute = "vector"
+
+// VectorDType is the element type of a vector attribute.
+type VectorDType string
+
+const (
+	VectorDTypeF32 VectorDType = "f32"
+	VectorDTypeF16 VectorDType = "f16"
+)
+
+// VectorType returns the attribute type of vectors with the given dimensions and element type, such as
+// "[1536]f32".
+func VectorType(dims int, dtype VectorDType) AttributeType {
+	return AttributeType(fmt.Sprintf("[%d]%s", dims, dtype))
+}
+
+// VectorDimensions returns the dimensions and element type of a vector attribute type created by VectorType,
+// and false for any other type.
+func (t AttributeType) VectorDimensions() (int, VectorDType, bool) {
+	rest, ok := strings.CutPrefix(string(t), "[")
+	if !ok {
+		return 0, "", false
+	}
+	dims, dtype, ok := strings.Cut(rest, "]")
+	if !ok {
+		return 0, "", false
+	}
+	n, err := strconv.Atoi(dims)
+	if err != nil || n <= 0 {
+		return 0, "", false
+	}
+	switch VectorDType(dtype) {
+	case VectorDTypeF32, VectorDTypeF16:
+		return n, VectorDType(dtype), true
+	}
+	return 0, "", false
+}
+
+// Tokenizer determines how a full-text searchable attribute is split into tokens.
+// See https://turbopuffer.com/docs/schema
+type Tokenizer string
+
+const (
+	// TokenizerWordV1 splits text into words using Unicode word boundaries.  This is the server default.
+	TokenizerWordV1 Tokenizer = "word_v1"
+	// TokenizerWordV0 is the original word tokenizer, for namespaces created before word_v1.
+	TokenizerWordV0 Tokenizer = "word_v0"
+	// TokenizerPreTokenizedArray uses the elements of a []string attribute as its tokens, as they are, for
+	// tokenizing and analyzing text upstream.  Query such attributes with BM25Tokens and
+	// ContainsAllTokensArray, passing tokens produced the same way.
+	TokenizerPreTokenizedArray Tokenizer = "pre_tokenized_array"
 )
 
 type FullTextSearchParams struct {
@@ -31,6 +117,13 @@ type FullTextSearchParams struct {
 	RemoveStopWords *bool `json:"remove_stop_words,omitempty"`
 	// Whether searching is case-sensitive. Default is false.
 	CaseSensitive *bool `json:"case_sensitive,omitempty"`
+	// Tokenizer determines how text is split into tokens.  Default is the server's default tokenizer.
+	Tokenizer Tokenizer `json:"tokenizer,omitempty"`
+	// K1 is the BM25 term frequency saturation parameter: higher values let repeated terms keep adding to
+	// the score for longer.  Default is 1.2.
+	K1 *float64 `json:"k1,omitempty"`
+	// B is the BM25 document length normalization parameter, from 0 (none) to 1 (full).  Default is 0.75.
+	B *float64 `json:"b,omitempty"`
 }
 
 // Attribute represents a single document attribute.
@@ -42,8 +135,184 @@ type Attribute struct {
 	// Whether this attribute is full text searchable using BM25.  Defaults to disabled.
 	// For behavior consistent with full_text_search=true, simply use empty FullTextSearchParams.
 	FullTextSearch *FullTextSearchParams `json:"full_text_search,omitempty"`
+	// Whether to build an approximate nearest neighbor index for a vector attribute.  Defaults to enabled.
+	ANN *bool `json:"ann,omitempty"`
+	// Whether to build an index for Regex and NotRegex filters on a string attribute.  Defaults to disabled.
+	Regex *bool `json:"regex,omitempty"`
+}
+
+// UnmarshalJSON decodes an attribute, accepting full_text_search as either a boolean or an object
+// of parameters, as returned by the schema endpoint.
+func (a *Attribute) UnmarshalJSON(data []byte) error {
+	type attribute Attribute
+	var decoded struct {
+		*attribute
+		FullTextSearch json.RawMessage `json:"full_text_search,omitempty"`
+	}
+	decoded.attribute = (*attribute)(a)
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	switch string(decoded.FullTextSearch) {
+	case "", "null", "false":
+		a.FullTextSearch = nil
+	case "true":
+		a.FullTextSearch = &FullTextSearchParams{}
+	default:
+		a.FullTextSearch = &FullTextSearchParams{}
+		if err := json.Unmarshal(decoded.FullTextSearch, a.FullTextSearch); err != nil {
+			return fmt.Errorf("failed to decode full_text_search: %w", err)
+		}
+	}
+	return nil
+}
+
+// tokenizer returns the tokenizer, or the server's default if none is set.
+func (p *FullTextSearchParams) tokenizer() Tokenizer {
+	if p.Tokenizer == "" {
+		return TokenizerWordV1
+	}
+	return p.Tokenizer
+}
+
+// language returns the language, or the server's default if none is set.
+func (p *FullTextSearchParams) language() string {
+	if p.Language == "" {
+		return "english"
+	}
+	return p.Language
 }
 
 // Schema represents the schema of a namespace. Allows customization of document attributes.
 // See https://turbopuffer.com/docs/schema
 type Schema map[string]*Attribute
+
+// VectorDimensions returns the dimensions of the vector attribute declared in the schema, or 0 if the
+// schema doesn't declare one.
+func (s Schema) VectorDimensions() int {
+	attr := s[VectorAttribute]
+	if attr == nil {
+		return 0
+	}
+	dims, _, _ := attr.Type.VectorDimensions()
+	return dims
+}
+
+// ValidateFilter checks the given filter against the schema, returning an error for filters that
+// the server would reject or silently misinterpret.
+// Currently this verifies that range filters (Lt, Lte, Gt, Gte) on datetime attributes have
+// a time.Time or RFC 3339 string value, and that Contains and ContainsAny filters (and their negations)
+// have a single element or a list value respectively and target array attributes, and that
+// ContainsAllTokens filters have a string value, or a list of tokens for pre-tokenized attributes, and target
+// full-text searchable attributes.
+func (s Schema) ValidateFilter(filter Filter) error {
+	switch f := filter.(type) {
+	case *AndFilter:
+		for _, sub := range f.Filters {
+			if err := s.ValidateFilter(sub); err != nil {
+				return err
+			}
+		}
+	case *OrFilter:
+		for _, sub := range f.Filters {
+			if err := s.ValidateFilter(sub); err != nil {
+				return err
+			}
+		}
+	case *BaseFilter:
+		return s.validateBaseFilter(f)
+	}
+	return nil
+}
+
+func (s Schema) validateBaseFilter(f *BaseFilter) error {
+	contains := f.Operator == OpContains || f.Operator == OpNotContains
+	containsAny := f.Operator == OpContainsAny || f.Operator == OpNotContainsAny
+	if contains {
+		if err := validateElementValue(f.Value); err != nil {
+			return fmt.Errorf("invalid %s filter on attribute %q: %w", f.Operator, f.Attribute, err)
+		}
+	}
+	if containsAny {
+		if err := validateListValue(f.Value); err != nil {
+			return fmt.Errorf("invalid %s filter on attribute %q: %w", f.Operator, f.Attribute, err)
+		}
+	}
+
+	_, tokenList := f.Value.([]string)
+	if f.Operator == OpContainsAllTokens {
+		if _, ok := f.Value.(string); !ok && !tokenList {
+			return fmt.Errorf("invalid %s filter on attribute %q: value of type %T is not a string or a list of tokens", f.Operator, f.Attribute, f.Value)
+		}
+	}
+
+	attr, ok := s[f.Attribute]
+	if !ok || attr == nil {
+		return nil
+	}
+	if f.Operator == OpContainsAllTokens {
+		if attr.FullTextSearch == nil {
+			return fmt.Errorf("invalid %s filter on attribute %q: attribute is not full-text searchable", f.Operator, f.Attribute)
+		}
+		if preTokenized := attr.FullTextSearch.Tokenizer == TokenizerPreTokenizedArray; preTokenized != tokenList {
+			if preTokenized {
+				return fmt.Errorf("invalid %s filter on attribute %q: pre-tokenized attribute requires a list of tokens", f.Operator, f.Attribute)
+			}
+			return fmt.Errorf("invalid %s filter on attribute %q: a list of tokens requires a pre-tokenized attribute", f.Operator, f.Attribute)
+		}
+	}
+	if (f.Operator == OpRegex || f.Operator == OpNotRegex) && (attr.Regex == nil || !*attr.Regex) {
+		return fmt.Errorf("invalid %s filter on attribute %q: attribute doesn't have regex enabled", f.Operator, f.Attribute)
+	}
+	if (contains || containsAny) && !strings.HasPrefix(string(attr.Type), "[]") {
+		return fmt.Errorf("invalid %s filter on attribute %q: attribute of type %s is not an array", f.Operator, f.Attribute, attr.Type)
+	}
+	if isRangeOperator(f.Operator) && (attr.Type == AttributeTypeDatetime || attr.Type == AttributeTypeDatetimeArray) {
+		if err := validateDatetimeValue(f.Value); err != nil {
+			return fmt.Errorf("invalid %s filter on datetime attribute %q: %w", f.Operator, f.Attribute, err)
+		}
+	}
+	return nil
+}
+
+func isRangeOperator(op Operator) bool {
+	return op == OpLt || op == OpLte || op == OpGt || op == OpGte
+}
+
+func validateDatetimeValue(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time, *time.Time:
+		return nil
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+			return fmt.Errorf("value %q is not an RFC 3339 datetime", v)
+		}
+		return nil
+	default:
+		return fmt.Errorf("value of type %T is not a datetime", value)
+	}
+}
+
+// validateElementValue checks that value is a single array element, rather than a list of them.
+func validateElementValue(value interface{}) error {
+	if value == nil {
+		return fmt.Errorf("value is nil")
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Errorf("value of type %T is not a single element", value)
+	}
+	return nil
+}
+
+// validateListValue checks that value is a list of array elements.
+func validateListValue(value interface{}) error {
+	if value == nil {
+		return fmt.Errorf("value is nil")
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Slice, reflect.Array:
+		return nil
+	}
+	return fmt.Errorf("value of type %T is not a list", value)
+}
@@ -3,6 +3,7 @@ package tpuf_test
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/bamo/tpuf-go"
 	"github.com/stretchr/testify/assert"
@@ -32,6 +33,30 @@ func TestSchemaMarshalJSON(t *testing.T) {
 			},
 			expected: `{"text":{"type":"string","full_text_search":{"language":"english","stemming":false,"remove_stop_words":true,"case_sensitive":false}},"relatedID":{"type":"uuid"}}`,
 		},
+		{
+			name: "Full text search with BM25 parameters and tokenizer",
+			schema: tpuf.Schema{
+				"text": &tpuf.Attribute{
+					Type: tpuf.AttributeTypeString,
+					FullTextSearch: &tpuf.FullTextSearchParams{
+						Tokenizer: tpuf.TokenizerWordV1,
+						K1:        float64Ptr(1.5),
+						B:         float64Ptr(0.5),
+					},
+				},
+			},
+			expected: `{"text":{"type":"string","full_text_search":{"tokenizer":"word_v1","k1":1.5,"b":0.5}}}`,
+		},
+		{
+			name: "Pre-tokenized full text search",
+			schema: tpuf.Schema{
+				"tokens": &tpuf.Attribute{
+					Type:           tpuf.AttributeTypeStringArray,
+					FullTextSearch: &tpuf.FullTextSearchParams{Tokenizer: tpuf.TokenizerPreTokenizedArray},
+				},
+			},
+			expected: `{"tokens":{"type":"[]string","full_text_search":{"tokenizer":"pre_tokenized_array"}}}`,
+		},
 		{
 			name: "Schema with filterable attribute",
 			schema: tpuf.Schema{
@@ -42,6 +67,26 @@ func TestSchemaMarshalJSON(t *testing.T) {
 			},
 			expected: `{"age":{"type":"uint","filterable":true}}`,
 		},
+		{
+			name: "Vector attribute",
+			schema: tpuf.Schema{
+				"vector": &tpuf.Attribute{
+					Type: tpuf.VectorType(1536, tpuf.VectorDTypeF16),
+					ANN:  boolPtr(false),
+				},
+			},
+			expected: `{"vector":{"type":"[1536]f16","ann":false}}`,
+		},
+		{
+			name: "Attribute with regex enabled",
+			schema: tpuf.Schema{
+				"title": &tpuf.Attribute{
+					Type:  tpuf.AttributeTypeString,
+					Regex: boolPtr(true),
+				},
+			},
+			expected: `{"title":{"type":"string","regex":true}}`,
+		},
 		{
 			name:     "Empty schema",
 			schema:   tpuf.Schema{},
@@ -56,6 +101,16 @@ func TestSchemaMarshalJSON(t *testing.T) {
 			},
 			expected: `{"name":{"type":"string"}}`,
 		},
+		{
+			name: "Numeric and datetime attributes",
+			schema: tpuf.Schema{
+				"count":      &tpuf.Attribute{Type: tpuf.AttributeTypeInt},
+				"score":      &tpuf.Attribute{Type: tpuf.AttributeTypeFloat},
+				"created_at": &tpuf.Attribute{Type: tpuf.AttributeTypeDatetime},
+				"embeddings": &tpuf.Attribute{Type: tpuf.AttributeTypeFloatArray},
+			},
+			expected: `{"count":{"type":"int"},"score":{"type":"float"},"created_at":{"type":"datetime"},"embeddings":{"type":"[]float"}}`,
+		},
 		{
 			name: "Attribute with empty FullTextSearch",
 			schema: tpuf.Schema{
@@ -84,6 +139,179 @@ func TestSchemaMarshalJSON(t *testing.T) {
 	}
 }
 
+func TestSchemaValidateFilter(t *testing.T) {
+	schema := tpuf.Schema{
+		"created_at": &tpuf.Attribute{Type: tpuf.AttributeTypeDatetime},
+		"score":      &tpuf.Attribute{Type: tpuf.AttributeTypeFloat},
+		"tags":       &tpuf.Attribute{Type: tpuf.AttributeTypeStringArray},
+		"title":      &tpuf.Attribute{Type: tpuf.AttributeTypeString, Regex: boolPtr(true)},
+		"body":       &tpuf.Attribute{Type: tpuf.AttributeTypeString, FullTextSearch: &tpuf.FullTextSearchParams{}},
+		"tokens": &tpuf.Attribute{
+			Type:           tpuf.AttributeTypeStringArray,
+			FullTextSearch: &tpuf.FullTextSearchParams{Tokenizer: tpuf.TokenizerPreTokenizedArray},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		filter        tpuf.Filter
+		expectedError string
+	}{
+		{
+			name:   "datetime range with time.Time",
+			filter: &tpuf.BaseFilter{Attribute: "created_at", Operator: tpuf.OpGte, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "datetime range with RFC 3339 string",
+			filter: &tpuf.BaseFilter{Attribute: "created_at", Operator: tpuf.OpLt, Value: "2024-01-01T00:00:00Z"},
+		},
+		{
+			name:          "datetime range with malformed string",
+			filter:        &tpuf.BaseFilter{Attribute: "created_at", Operator: tpuf.OpLt, Value: "yesterday"},
+			expectedError: `invalid Lt filter on datetime attribute "created_at": value "yesterday" is not an RFC 3339 datetime`,
+		},
+		{
+			name: "datetime range with number in nested filter",
+			filter: &tpuf.OrFilter{
+				Filters: []tpuf.Filter{
+					&tpuf.BaseFilter{Attribute: "score", Operator: tpuf.OpGt, Value: 0.5},
+					&tpuf.AndFilter{
+						Filters: []tpuf.Filter{
+							&tpuf.BaseFilter{Attribute: "created_at", Operator: tpuf.OpGt, Value: 1704067200},
+						},
+					},
+				},
+			},
+			expectedError: `invalid Gt filter on datetime attribute "created_at": value of type int is not a datetime`,
+		},
+		{
+			name:   "datetime equality is not validated",
+			filter: &tpuf.BaseFilter{Attribute: "created_at", Operator: tpuf.OpEq, Value: nil},
+		},
+		{
+			name:   "attribute not in schema",
+			filter: &tpuf.BaseFilter{Attribute: "other", Operator: tpuf.OpGt, Value: "anything"},
+		},
+		{
+			name:   "contains on array attribute",
+			filter: tpuf.Contains("tags", "go"),
+		},
+		{
+			name:   "not contains on attribute not in schema",
+			filter: tpuf.NotContains("other", 42),
+		},
+		{
+			name:          "contains with list value",
+			filter:        tpuf.Contains("tags", []string{"go", "rust"}),
+			expectedError: `invalid Contains filter on attribute "tags": value of type []string is not a single element`,
+		},
+		{
+			name:          "not contains with nil value",
+			filter:        tpuf.NotContains("other", nil),
+			expectedError: `invalid NotContains filter on attribute "other": value is nil`,
+		},
+		{
+			name:   "contains any on array attribute",
+			filter: tpuf.ContainsAny("tags", []string{"go", "rust"}),
+		},
+		{
+			name:          "not contains any with single value",
+			filter:        &tpuf.BaseFilter{Attribute: "tags", Operator: tpuf.OpNotContainsAny, Value: "go"},
+			expectedError: `invalid NotContainsAny filter on attribute "tags": value of type string is not a list`,
+		},
+		{
+			name:          "contains any on non-array attribute",
+			filter:        tpuf.ContainsAny("score", []float64{0.5}),
+			expectedError: `invalid ContainsAny filter on attribute "score": attribute of type float is not an array`,
+		},
+		{
+			name:   "contains all on array attribute",
+			filter: tpuf.ContainsAll("tags", []string{"go", "rust"}),
+		},
+		{
+			name:   "contains all tokens on full-text searchable attribute",
+			filter: tpuf.ContainsAllTokens("body", "quick fox"),
+		},
+		{
+			name:          "contains all tokens with non-string value",
+			filter:        &tpuf.BaseFilter{Attribute: "body", Operator: tpuf.OpContainsAllTokens, Value: 42},
+			expectedError: `invalid ContainsAllTokens filter on attribute "body": value of type int is not a string or a list of tokens`,
+		},
+		{
+			name:          "contains all tokens with a list of tokens on attribute tokenized by the server",
+			filter:        tpuf.ContainsAllTokensArray("body", "quick", "fox"),
+			expectedError: `invalid ContainsAllTokens filter on attribute "body": a list of tokens requires a pre-tokenized attribute`,
+		},
+		{
+			name:   "contains all tokens with a list of tokens on pre-tokenized attribute",
+			filter: tpuf.ContainsAllTokensArray("tokens", "quick", "fox"),
+		},
+		{
+			name:          "contains all tokens with a string on pre-tokenized attribute",
+			filter:        tpuf.ContainsAllTokens("tokens", "quick fox"),
+			expectedError: `invalid ContainsAllTokens filter on attribute "tokens": pre-tokenized attribute requires a list of tokens`,
+		},
+		{
+			name:          "contains all tokens on attribute without full-text search",
+			filter:        tpuf.ContainsAllTokens("tags", "quick fox"),
+			expectedError: `invalid ContainsAllTokens filter on attribute "tags": attribute is not full-text searchable`,
+		},
+		{
+			name:   "regex on attribute with regex enabled",
+			filter: tpuf.Regex("title", "^quick"),
+		},
+		{
+			name:          "regex on attribute without regex enabled",
+			filter:        tpuf.NotRegex("body", "^quick"),
+			expectedError: `invalid NotRegex filter on attribute "body": attribute doesn't have regex enabled`,
+		},
+		{
+			name:          "contains on non-array attribute",
+			filter:        tpuf.Contains("score", 0.5),
+			expectedError: `invalid Contains filter on attribute "score": attribute of type float is not an array`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateFilter(tt.filter)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestVectorDimensions(t *testing.T) {
+	tests := []struct {
+		attrType      tpuf.AttributeType
+		expectedDims  int
+		expectedDType tpuf.VectorDType
+		expectedOK    bool
+	}{
+		{tpuf.VectorType(1536, tpuf.VectorDTypeF32), 1536, tpuf.VectorDTypeF32, true},
+		{"[3]f16", 3, tpuf.VectorDTypeF16, true},
+		{"[0]f32", 0, "", false},
+		{"[3]f64", 0, "", false},
+		{"[]float", 0, "", false},
+		{tpuf.AttributeTypeString, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.attrType), func(t *testing.T) {
+			dims, dtype, ok := tt.attrType.VectorDimensions()
+			assert.Equal(t, tt.expectedDims, dims)
+			assert.Equal(t, tt.expectedDType, dtype)
+			assert.Equal(t, tt.expectedOK, ok)
+		})
+	}
+
+	assert.Equal(t, 768, tpuf.Schema{"vector": &tpuf.Attribute{Type: tpuf.VectorType(768, tpuf.VectorDTypeF32)}}.VectorDimensions())
+	assert.Equal(t, 0, tpuf.Schema{"title": &tpuf.Attribute{Type: tpuf.AttributeTypeString}}.VectorDimensions())
+}
+
 // Helper function to create a pointer to a bool
 func boolPtr(b bool) *bool {
 	return &b
@@ -1,9 +1,14 @@
 package tpuf
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 )
 
 // Attributes represent a document's attributes.  Must be a json-marshalable type.
@@ -11,8 +16,11 @@ type Attributes interface{}
 
 // Upsert represents a single document to upsert.
 type Upsert struct {
-	// ID is the document's unique identifier.  Required.
+	// ID is the document's unique identifier.  Required unless IDUint64 is set.
 	ID string `json:"id"`
+	// IDUint64, if set, is sent as the document's ID instead of ID, for namespaces with numeric IDs.
+	// See Uint64.
+	IDUint64 *uint64 `json:"-"`
 	// Vector is an optionalvector embedding to use for similarity search.
 	Vector []float32 `json:"vector,omitempty"`
 	// Attributes is a json-marshalable object representing the document's attributes.
@@ -24,45 +32,409 @@ type UpsertRequest struct {
 	Schema            Schema         `json:"schema,omitempty"`
 	Upserts           []*Upsert      `json:"upserts,omitempty"`
 	CopyFromNamespace string         `json:"copy_from_namespace,omitempty"`
+	// UpsertCondition is an optional filter evaluated against each existing document before
+	// it is overwritten.  Documents which don't satisfy the condition are skipped, counted in
+	// UpsertResponse.ConditionSkipped, and reported by an error wrapping ErrConditionFailed, which is returned
+	// along with the response once the other documents have been written.  Upsert fails with
+	// ErrConditionUnconfirmed if the server doesn't report how many documents it wrote, since it may have
+	// ignored the condition.
+	// Use RefNew to compare against the value being written, e.g. only write if the new version is newer:
+	//   Lt("version", RefNew("version"))
+	// See https://turbopuffer.com/docs/write#conditional-writes
+	UpsertCondition Filter `json:"upsert_condition,omitempty"`
+	// AllowNoVector permits documents without a vector, e.g. for full-text-search-only namespaces.
+	// By default, Upsert rejects documents without vectors to avoid accidental deletions.
+	// Documents with neither a vector nor attributes are always rejected, since the server treats them as deletions.
+	AllowNoVector bool `json:"-"`
+	// DuplicateIDs determines what happens when the same document ID appears more than once in Upserts,
+	// which the server would otherwise resolve by silently keeping the last.  Defaults to sending the
+	// request as is.
+	DuplicateIDs DuplicateIDPolicy `json:"-"`
+	// SkipUnchanged skips documents whose attributes are unchanged since they were last written with
+	// SkipUnchanged, as recorded by a hash in ContentHashAttribute, at the cost of a query per
+	// Client.MaxTopK documents.  See ChangedUpserts.
+	SkipUnchanged bool `json:"-"`
+	// Version, if non-zero, is stamped on every document in VersionAttribute, along with the time of the
+	// write in UpdatedAtAttribute, and both are declared in the schema.  Combine it with an UpsertCondition of
+	// Lt(VersionAttribute, RefNew(VersionAttribute)) to keep newer documents from being overwritten, and use
+	// SweepVersions to delete documents left behind by a reindex.  Documents skipped by SkipUnchanged keep their
+	// previous version, so don't combine the two for a reindex which will be swept.
+	Version uint64 `json:"-"`
+	// NormalizeVectors scales every vector to unit length before sending it, e.g. for embedding models whose
+	// output isn't normalized.  It requires DistanceMetricCosine, which normalization doesn't affect, and
+	// fails for zero vectors.
+	NormalizeVectors bool `json:"-"`
+}
+
+// DuplicateIDPolicy determines how Upsert handles repeated document IDs within a request.
+type DuplicateIDPolicy string
+
+const (
+	// DuplicateIDsAllow sends duplicates to the server, which keeps the last.
+	DuplicateIDsAllow DuplicateIDPolicy = ""
+	// DuplicateIDsError fails the upsert with a *DuplicateIDError without sending it.
+	DuplicateIDsError DuplicateIDPolicy = "error"
+	// DuplicateIDsKeepFirst sends only the first document with each ID.
+	DuplicateIDsKeepFirst DuplicateIDPolicy = "keep_first"
+	// DuplicateIDsKeepLast sends only the last document with each ID, in the position of the first.
+	DuplicateIDsKeepLast DuplicateIDPolicy = "keep_last"
+)
+
+// Validate checks that p is a supported duplicate ID policy.
+func (p DuplicateIDPolicy) Validate() error {
+	switch p {
+	case DuplicateIDsAllow, DuplicateIDsError, DuplicateIDsKeepFirst, DuplicateIDsKeepLast:
+		return nil
+	}
+	return fmt.Errorf("unsupported duplicate ID policy %q", p)
+}
+
+// DuplicateIDError is returned by Upsert when DuplicateIDsError is set and a request repeats document IDs.
+type DuplicateIDError struct {
+	// IDs are the repeated IDs, in the order in which they were first repeated.
+	IDs []string
+}
+
+func (e *DuplicateIDError) Error() string {
+	return fmt.Sprintf("%d document IDs appear more than once: %s", len(e.IDs), strings.Join(e.IDs, ", "))
+}
+
+// dedupe applies the request's DuplicateIDs policy, returning a copy of the request if documents were
+// dropped.  The caller's request is never modified.
+func (r *UpsertRequest) dedupe() (*UpsertRequest, error) {
+	if err := r.DuplicateIDs.Validate(); err != nil {
+		return nil, err
+	}
+	if r.DuplicateIDs == DuplicateIDsAllow {
+		return r, nil
+	}
+	positions := make(map[interface{}]int, len(r.Upserts))
+	reported := make(map[interface{}]bool)
+	var duplicates []string
+	deduped := make([]*Upsert, 0, len(r.Upserts))
+	for _, upsert := range r.Upserts {
+		id := upsert.documentID()
+		i, seen := positions[id.key()]
+		if !seen {
+			positions[id.key()] = len(deduped)
+			deduped = append(deduped, upsert)
+			continue
+		}
+		if !reported[id.key()] {
+			reported[id.key()] = true
+			duplicates = append(duplicates, id.str)
+		}
+		if r.DuplicateIDs == DuplicateIDsKeepLast {
+			deduped[i] = upsert
+		}
+	}
+	switch {
+	case len(duplicates) == 0:
+		return r, nil
+	case r.DuplicateIDs == DuplicateIDsError:
+		return nil, &DuplicateIDError{IDs: duplicates}
+	}
+	copied := *r
+	copied.Upserts = deduped
+	return &copied, nil
+}
+
+func (u *Upsert) documentID() documentID {
+	return newDocumentID(u.ID, u.IDUint64)
+}
+
+// ErrConditionFailed is returned, along with the UpsertResponse, when some documents weren't written because
+// the existing documents didn't satisfy the UpsertCondition.
+var ErrConditionFailed = errors.New("upsert condition failed")
+
+// ErrConditionUnconfirmed is returned when an UpsertCondition was set but the server's response doesn't
+// report how many documents were written.  The server may not support conditional writes, in which case the
+// documents were written unconditionally.
+var ErrConditionUnconfirmed = errors.New("upsert condition was not confirmed by the server")
+
+// UpsertResponse is the server's response to a write.
+type UpsertResponse struct {
+	// Status is the status reported by the server, normally "OK".
+	Status string `json:"status"`
+	// Message is an optional human-readable message from the server.
+	Message string `json:"message,omitempty"`
+	// RowsAffected is the number of documents written, if reported by the server.
+	RowsAffected *int `json:"rows_affected,omitempty"`
+	// Skipped is the number of documents not sent because they were unchanged.  See UpsertRequest.SkipUnchanged.
+	Skipped int `json:"-"`
+	// ConditionSkipped is the number of documents sent but not written because the existing documents didn't
+	// satisfy UpsertRequest.UpsertCondition.
+	ConditionSkipped int `json:"-"`
 }
 
 // Upsert creates or updates documents in a namespace.
 // Note that although the API supports deletion via the upsert endpoint, this client requires
 // that you use the Delete method explicitly to avoid accidental deletions.
+// The returned UpsertResponse reports how many documents were written, when the server provides it.
+// A request which the server rejects as too large is split into halves, recursively, and documents which
+// are too large on their own are reported by a *BulkRejectedError, along with the response, once the rest
+// have been written.
 // See https://turbopuffer.com/docs/upsert
-func (c *Client) Upsert(ctx context.Context, namespace string, request *UpsertRequest) error {
+func (c *Client) Upsert(ctx context.Context, namespace string, request *UpsertRequest) (*UpsertResponse, error) {
 	return c.upsert(ctx, namespace, request, false)
 }
 
-// Delete deletes documents from a namespace.
+// Delete deletes documents from a namespace, returning a report of the number deleted.  Long lists of IDs are
+// deleted in batches of Client.DeleteBatchSize, sent concurrently; if any batch fails, the error reports which,
+// and the report, which is returned along with the error, shows what the others deleted.
 // See https://turbopuffer.com/docs/upsert#document-deletion
-func (c *Client) Delete(ctx context.Context, namespace string, ids []string) error {
+func (c *Client) Delete(ctx context.Context, namespace string, ids []string) (*DeleteReport, error) {
 	var upserts []*Upsert
 	for _, id := range ids {
 		upserts = append(upserts, &Upsert{ID: id})
 	}
-	return c.upsert(ctx, namespace, &UpsertRequest{
-		Upserts: upserts,
-	}, true)
+	return c.deleteDocuments(ctx, namespace, upserts)
+}
+
+// PreviewDeleteByFilter returns up to limit documents, with attributes, which DeleteByFilter would delete
+// for the same filter, without deleting anything.  Use this to inspect the blast radius of a deletion.
+func (c *Client) PreviewDeleteByFilter(ctx context.Context, namespace string, filter Filter, limit int) ([]*QueryResult, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("a filter is required")
+	}
+	return c.Query(ctx, namespace, &QueryRequest{
+		Filters:           filter,
+		TopK:              limit,
+		IncludeAttributes: IncludeAllAttributes(),
+	})
+}
+
+// DeleteUint64 deletes documents with numeric IDs from a namespace, in batches like Delete.
+// See https://turbopuffer.com/docs/upsert#document-deletion
+func (c *Client) DeleteUint64(ctx context.Context, namespace string, ids []uint64) (*DeleteReport, error) {
+	upserts := make([]*Upsert, len(ids))
+	for i := range ids {
+		upserts[i] = &Upsert{IDUint64: &ids[i]}
+	}
+	return c.deleteDocuments(ctx, namespace, upserts)
+}
+
+// DeleteIf deletes the documents with the given IDs, but only those which also match the given condition.
+// For example, passing a condition of owner == tenantA protects against deleting another tenant's
+// document which happens to share an ID.  The ID and attribute conditions are evaluated together server-side.
+func (c *Client) DeleteIf(ctx context.Context, namespace string, ids []string, condition Filter) (*DeleteResponse, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one id is required")
+	}
+	if condition == nil {
+		return nil, fmt.Errorf("a condition is required; use Delete to delete unconditionally")
+	}
+	return c.DeleteByFilter(ctx, namespace, And(IDIn(ids...), condition))
 }
 
-func (c *Client) upsert(ctx context.Context, namespace string, request *UpsertRequest, allowDelete bool) error {
-	path := fmt.Sprintf("/v1/vectors/%s", namespace)
+// DeleteResponse is the server's response to a deletion.
+type DeleteResponse struct {
+	// Status is the status reported by the server, normally "OK".
+	Status string `json:"status"`
+	// RowsAffected is the number of documents deleted, if reported by the server.
+	RowsAffected *int `json:"rows_affected,omitempty"`
+}
+
+type deleteByFilterRequest struct {
+	DeleteByFilter Filter `json:"delete_by_filter"`
+}
+
+// DeleteByFilter deletes all documents in a namespace which match the given filter.
+// The returned DeleteResponse reports how many documents were deleted, when the server provides it.
+// See https://turbopuffer.com/docs/upsert#delete-by-filter
+func (c *Client) DeleteByFilter(ctx context.Context, namespace string, filter Filter) (*DeleteResponse, error) {
+	if filter == nil {
+		return nil, fmt.Errorf("a filter is required; use DeleteNamespace to delete all documents")
+	}
+	path := c.namespacePath(namespace, "")
+	reqJson, err := json.Marshal(&deleteByFilterRequest{DeleteByFilter: filter})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	event := &AuditEvent{Operation: OperationDelete, Namespace: namespace, Time: time.Now()}
+	response, err := c.sendDeleteByFilter(ctx, path, reqJson)
+	if response != nil {
+		event.RowsAffected = response.RowsAffected
+	}
+	c.audit(ctx, event, filter, err)
+	return response, err
+}
+
+func (c *Client) sendDeleteByFilter(ctx context.Context, path string, reqJson []byte) (*DeleteResponse, error) {
+	respData, err := c.post(ctx, OperationDelete, path, reqJson)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete documents: %w", err)
+	}
+
+	var response DeleteResponse
+	if err := json.Unmarshal(respData, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &response, nil
+}
+
+func (c *Client) upsert(ctx context.Context, namespace string, request *UpsertRequest, allowDelete bool) (*UpsertResponse, error) {
+	path := c.namespacePath(namespace, "")
 	if !allowDelete {
 		for _, upsert := range request.Upserts {
-			if len(upsert.Vector) == 0 {
-				return fmt.Errorf("deletion must be performed using Delete, not Upsert to avoid accidental deletion")
+			if len(upsert.Vector) == 0 && (!request.AllowNoVector || upsert.Attributes == nil) {
+				return nil, fmt.Errorf("deletion must be performed using Delete, not Upsert to avoid accidental deletion")
 			}
 		}
 	}
-	reqJson, err := json.Marshal(request)
+	if err := c.validateSchemaNames(request.Schema); err != nil {
+		return nil, err
+	}
+	if err := c.validateUpsertNames(request.Upserts); err != nil {
+		return nil, err
+	}
+	request, err := request.dedupe()
+	if err != nil {
+		return nil, err
+	}
+	if request, err = request.prepareVectors(); err != nil {
+		return nil, err
+	}
+	skipped := 0
+	if request.SkipUnchanged && len(request.Upserts) > 0 {
+		changed, err := c.ChangedUpserts(ctx, namespace, request.Upserts)
+		if err != nil {
+			return nil, err
+		}
+		skipped = len(request.Upserts) - len(changed)
+		if len(changed) == 0 && request.Schema == nil && request.CopyFromNamespace == "" {
+			return &UpsertResponse{Skipped: skipped}, nil
+		}
+		copied := *request
+		copied.Upserts = changed
+		request = &copied
+	}
+	if request.Version != 0 {
+		if request, err = request.stampVersion(time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	op := OperationUpsert
+	if allowDelete {
+		op = OperationDelete
+	}
+	var rejected []*RejectedDocument
+	response, err := c.splitUpsert(ctx, op, namespace, path, request, &rejected)
+	if response != nil {
+		response.Skipped = skipped
+	}
+	if err == nil && len(rejected) > 0 {
+		err = &BulkRejectedError{Rejected: rejected}
+	}
+	if err == nil && response.ConditionSkipped > 0 {
+		sent := len(request.Upserts) - len(rejected)
+		err = fmt.Errorf("%w: %d of %d documents written", ErrConditionFailed, sent-response.ConditionSkipped, sent)
+	}
+	return response, err
+}
+
+// splitUpsert sends the request, splitting it into halves, recursively, when the server rejects it as too
+// large.  Documents which are too large on their own are added to rejected, and the rest are written.
+// Requests which copy from another namespace are never split, since the copy must only happen once.
+func (c *Client) splitUpsert(ctx context.Context, op Operation, namespace, path string, request *UpsertRequest, rejected *[]*RejectedDocument) (*UpsertResponse, error) {
+	response, err := c.sendUpsert(ctx, op, namespace, path, request)
+	if err == nil || !isTooLargeError(err) || request.CopyFromNamespace != "" || len(request.Upserts) == 0 {
+		return response, err
+	}
+	if len(request.Upserts) == 1 {
+		*rejected = append(*rejected, &RejectedDocument{ID: request.Upserts[0].documentID().str, Err: err})
+		return &UpsertResponse{RowsAffected: new(int)}, nil
+	}
+	mid := len(request.Upserts) / 2
+	first, second := *request, *request
+	first.Upserts, second.Upserts = request.Upserts[:mid], request.Upserts[mid:]
+	firstResponse, err := c.splitUpsert(ctx, op, namespace, path, &first, rejected)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
-	_, err = c.post(ctx, path, reqJson)
+	secondResponse, err := c.splitUpsert(ctx, op, namespace, path, &second, rejected)
 	if err != nil {
-		return fmt.Errorf("failed to upsert documents: %w", err)
+		return nil, err
+	}
+	return mergeUpsertResponses(firstResponse, secondResponse), nil
+}
+
+// mergeUpsertResponses combines the responses to the parts of a split request.  RowsAffected is only
+// reported if every part reported it.
+func mergeUpsertResponses(a, b *UpsertResponse) *UpsertResponse {
+	merged := &UpsertResponse{
+		Status:           b.Status,
+		Message:          b.Message,
+		ConditionSkipped: a.ConditionSkipped + b.ConditionSkipped,
+	}
+	if merged.Status == "" {
+		merged.Status = a.Status
+	}
+	if merged.Message == "" {
+		merged.Message = a.Message
+	}
+	if a.RowsAffected != nil && b.RowsAffected != nil {
+		rows := *a.RowsAffected + *b.RowsAffected
+		merged.RowsAffected = &rows
+	}
+	return merged
+}
+
+// isTooLargeError reports whether the server rejected a request for exceeding its size limit: either with
+// 413 Payload Too Large, whatever the response body, or with a 400 whose message says the request is too large.
+func isTooLargeError(err error) bool {
+	var apiErr ApiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.HttpStatus == http.StatusRequestEntityTooLarge ||
+		apiErr.HttpStatus == http.StatusBadRequest && strings.Contains(strings.ToLower(apiErr.Err), "too large")
+}
+
+// sendUpsert sends a single upsert request and audits it.
+func (c *Client) sendUpsert(ctx context.Context, op Operation, namespace, path string, request *UpsertRequest) (*UpsertResponse, error) {
+	payload, release, err := c.jsonPayload(c.upsertRequestJSON(request))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	defer release()
+	event := &AuditEvent{
+		Operation:         op,
+		Namespace:         namespace,
+		Time:              time.Now(),
+		Documents:         len(request.Upserts),
+		CopyFromNamespace: request.CopyFromNamespace,
+	}
+	response, err := c.postUpsert(ctx, op, path, payload, request)
+	if response != nil {
+		event.RowsAffected = response.RowsAffected
+	}
+	c.audit(ctx, event, request.UpsertCondition, err)
+	return response, err
+}
+
+func (c *Client) postUpsert(ctx context.Context, op Operation, path string, payload requestPayload, request *UpsertRequest) (*UpsertResponse, error) {
+	respData, _, err := c.doWithHeader(ctx, op, http.MethodPost, path, nil, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert documents: %w", err)
+	}
+
+	// The body is informational unless there's a condition, so an empty or non-JSON body, e.g. from a proxy,
+	// doesn't fail a write which the status code reports as successful.
+	var response UpsertResponse
+	if len(bytes.TrimSpace(respData)) > 0 {
+		if err := json.Unmarshal(respData, &response); err != nil {
+			response = UpsertResponse{}
+		}
+	}
+	if request.UpsertCondition != nil {
+		if response.RowsAffected == nil {
+			return &response, fmt.Errorf("%w: the response doesn't report rows_affected, so the condition may have been ignored", ErrConditionUnconfirmed)
+		}
+		if written := *response.RowsAffected; written < len(request.Upserts) {
+			response.ConditionSkipped = len(request.Upserts) - written
+		}
 	}
 
-	return nil
+	return &response, nil
 }
@@ -3,9 +3,12 @@ package tpuf_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"testing"
 
 	"github.com/bamo/tpuf-go"
@@ -31,6 +34,7 @@ func TestUpsert(t *testing.T) {
 		expectedMethod string
 		expectedURL    string
 		expectedBody   string
+		expectedResult *tpuf.UpsertResponse
 	}{
 		{
 			name:      "successful upsert",
@@ -64,6 +68,7 @@ func TestUpsert(t *testing.T) {
 			expectedMethod: http.MethodPost,
 			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
 			expectedBody:   `{"distance_metric":"cosine_distance","upserts":[{"id":"1","vector":[0.1,0.1],"attributes":{"my-bool":true,"my-string":"one","my-string-array":["a","b"],"my-uint":12}},{"id":"2","vector":[0.2,0.2],"attributes":{"my-string-array":["b","d"]}}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK"},
 		},
 		{
 			name:      "unsuccessful upsert",
@@ -90,6 +95,133 @@ func TestUpsert(t *testing.T) {
 			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
 			expectedBody:   `{}`,
 		},
+		{
+			name:      "conditional upsert",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1, 0.1}, Attributes: map[string]interface{}{"version": 2}}},
+				UpsertCondition: &tpuf.BaseFilter{
+					Attribute: "version",
+					Operator:  tpuf.OpLt,
+					Value:     tpuf.RefNew("version"),
+				},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK","message":"1 document upserted","rows_affected":1}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1,0.1],"attributes":{"version":2}}],"upsert_condition":["version","Lt",{"$ref_new":"version"}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK", Message: "1 document upserted", RowsAffected: intPtr(1)},
+		},
+		{
+			name:      "conditional upsert not applied",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1, 0.1}, Attributes: map[string]interface{}{"version": 2}}},
+				UpsertCondition: &tpuf.BaseFilter{
+					Attribute: "version",
+					Operator:  tpuf.OpLt,
+					Value:     tpuf.RefNew("version"),
+				},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK","rows_affected":0}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1,0.1],"attributes":{"version":2}}],"upsert_condition":["version","Lt",{"$ref_new":"version"}]}`,
+			expectedError:  "upsert condition failed: 0 of 1 documents written",
+			expectedResult: &tpuf.UpsertResponse{Status: "OK", RowsAffected: intPtr(0), ConditionSkipped: 1},
+		},
+		{
+			name:      "conditional upsert unconfirmed",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts:         []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1, 0.1}, Attributes: map[string]interface{}{"version": 2}}},
+				UpsertCondition: tpuf.Lt("version", tpuf.RefNew("version")),
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedError:  "upsert condition was not confirmed by the server: the response doesn't report rows_affected, so the condition may have been ignored",
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1,0.1],"attributes":{"version":2}}],"upsert_condition":["version","Lt",{"$ref_new":"version"}]}`,
+		},
+		{
+			name:      "empty response body",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1, 0.1}}},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(``)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1,0.1]}]}`,
+			expectedResult: &tpuf.UpsertResponse{},
+		},
+		{
+			name:      "non-JSON response body",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1, 0.1}}},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`OK`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1,0.1]}]}`,
+			expectedResult: &tpuf.UpsertResponse{},
+		},
+		{
+			name:      "conditional upsert with empty response body",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts:         []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1, 0.1}, Attributes: map[string]interface{}{"version": 2}}},
+				UpsertCondition: tpuf.Lt("version", tpuf.RefNew("version")),
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(``)),
+			},
+			expectedError:  "upsert condition was not confirmed by the server: the response doesn't report rows_affected, so the condition may have been ignored",
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1,0.1],"attributes":{"version":2}}],"upsert_condition":["version","Lt",{"$ref_new":"version"}]}`,
+		},
+		{
+			name:      "attribute-only upsert",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts:       []*tpuf.Upsert{{ID: "1", Attributes: map[string]interface{}{"text": "hello"}}},
+				AllowNoVector: true,
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","attributes":{"text":"hello"}}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK"},
+		},
+		{
+			name: "delete via attribute-only upsert",
+			request: &tpuf.UpsertRequest{
+				Upserts:       []*tpuf.Upsert{{ID: "1"}},
+				AllowNoVector: true,
+			},
+			expectedError: "deletion must be performed using Delete, not Upsert to avoid accidental deletion",
+		},
 		{
 			name: "delete via upsert",
 			request: &tpuf.UpsertRequest{
@@ -97,6 +229,142 @@ func TestUpsert(t *testing.T) {
 			},
 			expectedError: "deletion must be performed using Delete, not Upsert to avoid accidental deletion",
 		},
+		{
+			name:      "duplicate ids allowed by default",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{
+					{ID: "1", Vector: []float32{0.1}},
+					{ID: "2", Vector: []float32{0.2}},
+					{ID: "1", Vector: []float32{0.3}},
+					{ID: "1", Vector: []float32{0.4}},
+				},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1]},{"id":"2","vector":[0.2]},{"id":"1","vector":[0.3]},{"id":"1","vector":[0.4]}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK"},
+		},
+		{
+			name:      "duplicate ids rejected",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{
+					{ID: "1", Vector: []float32{0.1}},
+					{ID: "2", Vector: []float32{0.2}},
+					{ID: "1", Vector: []float32{0.3}},
+					{ID: "1", Vector: []float32{0.4}},
+				},
+				DuplicateIDs: tpuf.DuplicateIDsError,
+			},
+			expectedError: "1 document IDs appear more than once: 1",
+		},
+		{
+			name:      "duplicate ids keep first",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{
+					{ID: "1", Vector: []float32{0.1}},
+					{ID: "2", Vector: []float32{0.2}},
+					{ID: "1", Vector: []float32{0.3}},
+					{ID: "1", Vector: []float32{0.4}},
+				},
+				DuplicateIDs: tpuf.DuplicateIDsKeepFirst,
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1]},{"id":"2","vector":[0.2]}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK"},
+		},
+		{
+			name:      "duplicate ids keep last",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{
+					{ID: "1", Vector: []float32{0.1}},
+					{ID: "2", Vector: []float32{0.2}},
+					{ID: "1", Vector: []float32{0.3}},
+					{ID: "1", Vector: []float32{0.4}},
+				},
+				DuplicateIDs: tpuf.DuplicateIDsKeepLast,
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.4]},{"id":"2","vector":[0.2]}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK"},
+		},
+		{
+			name:      "numeric ids",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{
+					{IDUint64: tpuf.Uint64(18446744073709551615), Vector: []float32{0.1}},
+					{ID: "2", IDUint64: tpuf.Uint64(2), Vector: []float32{0.2}},
+				},
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":18446744073709551615,"vector":[0.1]},{"id":2,"vector":[0.2]}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK"},
+		},
+		{
+			name:      "numeric and string ids are distinct",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{
+					{ID: "1", Vector: []float32{0.1}},
+					{IDUint64: tpuf.Uint64(1), Vector: []float32{0.2}},
+				},
+				DuplicateIDs: tpuf.DuplicateIDsError,
+			},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1","vector":[0.1]},{"id":1,"vector":[0.2]}]}`,
+			expectedResult: &tpuf.UpsertResponse{Status: "OK"},
+		},
+		{
+			name:      "unsupported duplicate id policy",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts: []*tpuf.Upsert{
+					{ID: "1", Vector: []float32{0.1}},
+					{ID: "2", Vector: []float32{0.2}},
+					{ID: "1", Vector: []float32{0.3}},
+					{ID: "1", Vector: []float32{0.4}},
+				},
+				DuplicateIDs: "ignore",
+			},
+			expectedError: `unsupported duplicate ID policy "ignore"`,
+		},
+		{
+			name:      "unsupported duplicate id policy without duplicates",
+			namespace: "test-namespace",
+			request: &tpuf.UpsertRequest{
+				Upserts:      []*tpuf.Upsert{{ID: "1", Vector: []float32{0.1}}},
+				DuplicateIDs: "keep_latest",
+			},
+			expectedError: `unsupported duplicate ID policy "keep_latest"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -121,17 +389,95 @@ func TestUpsert(t *testing.T) {
 				},
 			}
 
-			err := client.Upsert(context.Background(), tt.namespace, tt.request)
+			result, err := client.Upsert(context.Background(), tt.namespace, tt.request)
 
 			if tt.expectedError == "" {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result, "unexpected upsert result")
 			} else {
 				assert.EqualError(t, err, tt.expectedError)
+				if tt.expectedResult != nil {
+					assert.Equal(t, tt.expectedResult, result, "the response should be returned with the error")
+				}
+			}
+			if tt.expectedResult != nil && tt.expectedResult.ConditionSkipped > 0 {
+				assert.ErrorIs(t, err, tpuf.ErrConditionFailed)
 			}
 		})
 	}
 }
 
+func TestUpsertDuplicateIDError(t *testing.T) {
+	client := &tpuf.Client{ApiToken: "test-token", HttpClient: &fakeHttpClient{
+		doFunc: func(req *http.Request) (*http.Response, error) {
+			t.Fatal("unexpected request")
+			return nil, nil
+		},
+	}}
+	request := &tpuf.UpsertRequest{
+		Upserts: []*tpuf.Upsert{
+			{ID: "b", Vector: []float32{0.1}},
+			{ID: "a", Vector: []float32{0.1}},
+			{ID: "a", Vector: []float32{0.1}},
+			{ID: "b", Vector: []float32{0.1}},
+			{ID: "b", Vector: []float32{0.1}},
+		},
+		DuplicateIDs: tpuf.DuplicateIDsError,
+	}
+	_, err := client.Upsert(context.Background(), "ns", request)
+
+	var dupErr *tpuf.DuplicateIDError
+	if assert.ErrorAs(t, err, &dupErr) {
+		assert.Equal(t, []string{"a", "b"}, dupErr.IDs)
+	}
+	assert.Len(t, request.Upserts, 5, "caller's request should not be modified")
+}
+
+func TestUpsertSplitsTooLargeRequests(t *testing.T) {
+	var batches [][]string
+	client := &tpuf.Client{
+		ApiToken:     "test-token",
+		DisableRetry: true,
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				var body tpuf.UpsertRequest
+				assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+				var ids []string
+				for _, upsert := range body.Upserts {
+					ids = append(ids, upsert.ID)
+				}
+				batches = append(batches, ids)
+				if len(ids) > 2 || ids[0] == "3" {
+					return &http.Response{
+						StatusCode: http.StatusRequestEntityTooLarge,
+						Body:       io.NopCloser(bytes.NewBufferString("<html>413 Request Entity Too Large</html>")),
+					}, nil
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{"status":"OK","rows_affected":%d}`, len(ids)))),
+				}, nil
+			},
+		},
+	}
+	var upserts []*tpuf.Upsert
+	for i := 1; i <= 4; i++ {
+		upserts = append(upserts, &tpuf.Upsert{ID: strconv.Itoa(i), Vector: []float32{0.1}})
+	}
+
+	response, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{Upserts: upserts})
+
+	var rejectedErr *tpuf.BulkRejectedError
+	if assert.ErrorAs(t, err, &rejectedErr) && assert.Len(t, rejectedErr.Rejected, 1) {
+		assert.Equal(t, "3", rejectedErr.Rejected[0].ID)
+		var apiErr tpuf.ApiError
+		assert.ErrorAs(t, rejectedErr.Rejected[0].Err, &apiErr, "a proxy's 413 should be exposed as an ApiError")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, apiErr.HttpStatus)
+	}
+	assert.Equal(t, &tpuf.UpsertResponse{Status: "OK", RowsAffected: intPtr(3)}, response)
+	assert.Equal(t, [][]string{{"1", "2", "3", "4"}, {"1", "2"}, {"3", "4"}, {"3"}, {"4"}}, batches)
+}
+
 func TestDelete(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -140,6 +486,7 @@ func TestDelete(t *testing.T) {
 		httpResponse   *http.Response
 		httpError      error
 		expectedError  string
+		expectedReport *tpuf.DeleteReport
 		expectedMethod string
 		expectedURL    string
 		expectedBody   string
@@ -150,12 +497,30 @@ func TestDelete(t *testing.T) {
 			ids:       []string{"1", "2", "3"},
 			httpResponse: &http.Response{
 				StatusCode: http.StatusOK,
-				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK","rows_affected":2}`)),
+			},
+			expectedReport: &tpuf.DeleteReport{
+				Requested: 3,
+				Deleted:   2,
+				Batches:   []*tpuf.DeleteBatch{{IDs: 3, RowsAffected: intPtr(2)}},
 			},
 			expectedMethod: http.MethodPost,
 			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
 			expectedBody:   `{"upserts":[{"id":"1"},{"id":"2"},{"id":"3"}]}`,
 		},
+		{
+			name:      "rows affected not reported",
+			namespace: "test-namespace",
+			ids:       []string{"1"},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+			},
+			expectedReport: &tpuf.DeleteReport{Requested: 1, Deleted: -1, Batches: []*tpuf.DeleteBatch{{IDs: 1}}},
+			expectedMethod: http.MethodPost,
+			expectedURL:    "https://api.turbopuffer.com/v1/vectors/test-namespace",
+			expectedBody:   `{"upserts":[{"id":"1"}]}`,
+		},
 		{
 			name:      "delete error",
 			namespace: "test-namespace",
@@ -192,13 +557,193 @@ func TestDelete(t *testing.T) {
 				},
 			}
 
-			err := client.Delete(context.Background(), tt.namespace, tt.ids)
+			report, err := client.Delete(context.Background(), tt.namespace, tt.ids)
 
 			if tt.expectedError == "" {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedReport, report)
 			} else {
 				assert.EqualError(t, err, tt.expectedError)
+				if assert.Len(t, report.Batches, 1) {
+					assert.Equal(t, err, report.Batches[0].Err)
+				}
 			}
 		})
 	}
 }
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestDeleteUint64(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				assert.JSONEq(t, `{"upserts":[{"id":1},{"id":2}]}`, string(body), "unexpected request body")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`)),
+				}, nil
+			},
+		},
+	}
+
+	_, err := client.DeleteUint64(context.Background(), "test-namespace", []uint64{1, 2})
+	assert.NoError(t, err)
+}
+
+func TestDeleteByFilter(t *testing.T) {
+	tests := []struct {
+		name           string
+		filter         tpuf.Filter
+		httpResponse   *http.Response
+		expectedError  string
+		expectedBody   string
+		expectedResult *tpuf.DeleteResponse
+	}{
+		{
+			name:   "successful delete by filter",
+			filter: &tpuf.BaseFilter{Attribute: "category", Operator: tpuf.OpEq, Value: "incriminating"},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK","rows_affected":3}`)),
+			},
+			expectedBody:   `{"delete_by_filter":["category","Eq","incriminating"]}`,
+			expectedResult: &tpuf.DeleteResponse{Status: "OK", RowsAffected: intPtr(3)},
+		},
+		{
+			name:   "delete by filter error",
+			filter: &tpuf.BaseFilter{Attribute: "category", Operator: tpuf.OpEq, Value: "incriminating"},
+			httpResponse: &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(bytes.NewBufferString(`{"error":"Invalid request","status":"error"}`)),
+			},
+			expectedError: "failed to delete documents: error: Invalid request (HTTP 400)",
+			expectedBody:  `{"delete_by_filter":["category","Eq","incriminating"]}`,
+		},
+		{
+			name:          "missing filter",
+			expectedError: "a filter is required; use DeleteNamespace to delete all documents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, http.MethodPost, req.Method, "unexpected request method")
+						assert.Equal(t, "https://api.turbopuffer.com/v1/vectors/test-namespace", req.URL.String(), "unexpected request URL")
+
+						body, _ := io.ReadAll(req.Body)
+						assert.JSONEq(t, tt.expectedBody, string(body), "unexpected request body")
+
+						return tt.httpResponse, nil
+					},
+				},
+			}
+
+			result, err := client.DeleteByFilter(context.Background(), "test-namespace", tt.filter)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result, "unexpected delete result")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, result)
+			}
+		})
+	}
+}
+
+func TestDeleteIf(t *testing.T) {
+	tests := []struct {
+		name           string
+		ids            []string
+		condition      tpuf.Filter
+		expectedError  string
+		expectedBody   string
+		expectedResult *tpuf.DeleteResponse
+	}{
+		{
+			name:           "delete guarded by owner",
+			ids:            []string{"1", "2"},
+			condition:      &tpuf.BaseFilter{Attribute: "owner", Operator: tpuf.OpEq, Value: "tenantA"},
+			expectedBody:   `{"delete_by_filter":["And",[["id","In",["1","2"]],["owner","Eq","tenantA"]]]}`,
+			expectedResult: &tpuf.DeleteResponse{Status: "OK", RowsAffected: intPtr(1)},
+		},
+		{
+			name:          "missing ids",
+			condition:     &tpuf.BaseFilter{Attribute: "owner", Operator: tpuf.OpEq, Value: "tenantA"},
+			expectedError: "at least one id is required",
+		},
+		{
+			name:          "missing condition",
+			ids:           []string{"1"},
+			expectedError: "a condition is required; use Delete to delete unconditionally",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "https://api.turbopuffer.com/v1/vectors/test-namespace", req.URL.String(), "unexpected request URL")
+
+						body, _ := io.ReadAll(req.Body)
+						assert.JSONEq(t, tt.expectedBody, string(body), "unexpected request body")
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{"status":"OK","rows_affected":1}`)),
+						}, nil
+					},
+				},
+			}
+
+			result, err := client.DeleteIf(context.Background(), "test-namespace", tt.ids, tt.condition)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result, "unexpected delete result")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, result)
+			}
+		})
+	}
+}
+
+func TestPreviewDeleteByFilter(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, http.MethodPost, req.Method, "unexpected request method")
+				assert.Equal(t, "https://api.turbopuffer.com/v1/vectors/test-namespace/query", req.URL.String(), "unexpected request URL")
+
+				body, _ := io.ReadAll(req.Body)
+				assert.JSONEq(t, `{"filters":["category","Eq","incriminating"],"top_k":2,"include_attributes":true}`, string(body), "unexpected request body")
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(`[{"id":"1","dist":0,"attributes":{"category":"incriminating"}}]`)),
+				}, nil
+			},
+		},
+	}
+
+	results, err := client.PreviewDeleteByFilter(context.Background(), "test-namespace",
+		&tpuf.BaseFilter{Attribute: "category", Operator: tpuf.OpEq, Value: "incriminating"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*tpuf.QueryResult{
+		{ID: "1", Attributes: json.RawMessage(`{"category":"incriminating"}`)},
+	}, results)
+}
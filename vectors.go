@@ -0,0 +1,241 @@
+package tpuf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// VectorEncoding determines how vectors are encoded in request payloads.
+type VectorEncoding string
+
+const (
+	// VectorEncodingFloat encodes vectors as JSON arrays of numbers.
+	VectorEncodingFloat VectorEncoding = "float"
+	// VectorEncodingBase64 encodes vectors as base64 strings of packed little-endian float32s,
+	// which is roughly a third of the size of the equivalent JSON array.
+	VectorEncodingBase64 VectorEncoding = "base64"
+)
+
+// ErrInvalidVector is wrapped by the errors returned for vectors which can't be sent or normalized, such as
+// those with NaN components.
+var ErrInvalidVector = errors.New("invalid vector")
+
+// ValidateVector checks that every component of vec is finite.  Upsert and Query check vectors before
+// sending them, since the server's errors for NaN and infinite components are hard to trace.
+func ValidateVector(vec []float32) error {
+	for i, f := range vec {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return fmt.Errorf("%w: component %d is %v", ErrInvalidVector, i, f)
+		}
+	}
+	return nil
+}
+
+// NormalizeL2 returns a copy of vec scaled to unit length.  It fails for zero vectors, which have no
+// direction, and for vectors with NaN or infinite components.
+func NormalizeL2(vec []float32) ([]float32, error) {
+	if err := ValidateVector(vec); err != nil {
+		return nil, err
+	}
+	var sumSquares float64
+	for _, f := range vec {
+		sumSquares += float64(f) * float64(f)
+	}
+	if sumSquares == 0 {
+		return nil, fmt.Errorf("%w: can't normalize a zero vector", ErrInvalidVector)
+	}
+	norm := math.Sqrt(sumSquares)
+	normalized := make([]float32, len(vec))
+	for i, f := range vec {
+		normalized[i] = float32(float64(f) / norm)
+	}
+	return normalized, nil
+}
+
+// Float32Vector converts a float64 vector, such as the output of a math library, to the float32 vector the
+// API stores, rounding each component to the nearest float32.  Use Float32VectorChecked to detect components
+// which don't survive the conversion.
+func Float32Vector(vec []float64) []float32 {
+	converted := make([]float32, len(vec))
+	for i, f := range vec {
+		converted[i] = float32(f)
+	}
+	return converted
+}
+
+// Float32VectorChecked is like Float32Vector, but fails if any component is NaN or infinite, is too large in
+// magnitude for a float32, or is non-zero but too small to be distinguished from zero as a float32.
+// Ordinary rounding to float32 precision isn't reported.
+func Float32VectorChecked(vec []float64) ([]float32, error) {
+	converted := make([]float32, len(vec))
+	for i, f := range vec {
+		converted[i] = float32(f)
+		switch {
+		case math.IsNaN(f) || math.IsInf(f, 0):
+			return nil, fmt.Errorf("%w: component %d is %v", ErrInvalidVector, i, f)
+		case math.IsInf(float64(converted[i]), 0):
+			return nil, fmt.Errorf("%w: component %d (%v) overflows float32", ErrInvalidVector, i, f)
+		case converted[i] == 0 && f != 0:
+			return nil, fmt.Errorf("%w: component %d (%v) underflows float32", ErrInvalidVector, i, f)
+		}
+	}
+	return converted, nil
+}
+
+// SetVectorFloat64 sets the document's vector from a float64 vector.  See Float32VectorChecked.
+func (u *Upsert) SetVectorFloat64(vec []float64) error {
+	converted, err := Float32VectorChecked(vec)
+	if err != nil {
+		return err
+	}
+	u.Vector = converted
+	return nil
+}
+
+// SetVectorFloat64 sets the query vector from a float64 vector.  See Float32VectorChecked.
+func (r *QueryRequest) SetVectorFloat64(vec []float64) error {
+	converted, err := Float32VectorChecked(vec)
+	if err != nil {
+		return err
+	}
+	r.Vector = converted
+	return nil
+}
+
+// prepareVectors checks the request's distance metric and vectors, including their dimensions if the
+// request's schema declares a vector type, returning a copy of the request with normalized vectors if
+// NormalizeVectors is set.
+func (r *UpsertRequest) prepareVectors() (*UpsertRequest, error) {
+	if r.DistanceMetric != "" {
+		if err := r.DistanceMetric.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if r.NormalizeVectors && r.DistanceMetric != DistanceMetricCosine {
+		return nil, fmt.Errorf("vectors can only be normalized for %s, not %q", DistanceMetricCosine, r.DistanceMetric)
+	}
+	var normalized []*Upsert
+	if r.NormalizeVectors {
+		normalized = make([]*Upsert, len(r.Upserts))
+	}
+	dims := r.Schema.VectorDimensions()
+	for i, upsert := range r.Upserts {
+		if err := ValidateVector(upsert.Vector); err != nil {
+			return nil, fmt.Errorf("invalid document %s: %w", upsert.documentID().str, err)
+		}
+		if dims > 0 && len(upsert.Vector) > 0 && len(upsert.Vector) != dims {
+			return nil, fmt.Errorf("invalid document %s: %w: has %d dimensions, but the schema declares %d", upsert.documentID().str, ErrInvalidVector, len(upsert.Vector), dims)
+		}
+		if normalized == nil {
+			continue
+		}
+		normalized[i] = upsert
+		if len(upsert.Vector) > 0 {
+			vector, err := NormalizeL2(upsert.Vector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid document %s: %w", upsert.documentID().str, err)
+			}
+			copied := *upsert
+			copied.Vector = vector
+			normalized[i] = &copied
+		}
+	}
+	if normalized == nil {
+		return r, nil
+	}
+	copied := *r
+	copied.Upserts = normalized
+	return &copied, nil
+}
+
+// prepareVector checks the query's distance metric and vector, returning a copy of the request with a
+// normalized vector if NormalizeVector is set.
+func (r *QueryRequest) prepareVector() (*QueryRequest, error) {
+	if r.DistanceMetric != "" {
+		if err := r.DistanceMetric.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if r.NormalizeVector && len(r.Vector) > 0 {
+		if r.DistanceMetric != DistanceMetricCosine {
+			return nil, fmt.Errorf("vectors can only be normalized for %s, not %q", DistanceMetricCosine, r.DistanceMetric)
+		}
+		normalized, err := NormalizeL2(r.Vector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query vector: %w", err)
+		}
+		copied := *r
+		copied.Vector = normalized
+		return &copied, nil
+	}
+	if err := ValidateVector(r.Vector); err != nil {
+		return nil, fmt.Errorf("invalid query vector: %w", err)
+	}
+	return r, nil
+}
+
+// base64Vector is a vector which marshals to a base64 string of little-endian float32s.
+type base64Vector []float32
+
+func (v base64Vector) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(buf))
+}
+
+// encodedUpsert shadows the ID and Vector fields of an Upsert where they need a different encoding:
+// numeric IDs are marshaled as numbers, and vectors as base64Vectors with VectorEncodingBase64.
+type encodedUpsert struct {
+	*Upsert
+	ID     documentID  `json:"id"`
+	Vector interface{} `json:"vector,omitempty"`
+}
+
+// upsertRequestJSON returns the value to encode as the body of an upsert request.
+func (c *Client) upsertRequestJSON(request *UpsertRequest) interface{} {
+	useBase64 := c.VectorEncoding == VectorEncodingBase64
+	if !useBase64 && !hasNumericIDs(request.Upserts) {
+		return request
+	}
+	upserts := make([]*encodedUpsert, len(request.Upserts))
+	for i, upsert := range request.Upserts {
+		upserts[i] = &encodedUpsert{Upsert: upsert, ID: upsert.documentID()}
+		switch {
+		case len(upsert.Vector) == 0:
+		case useBase64:
+			upserts[i].Vector = base64Vector(upsert.Vector)
+		default:
+			upserts[i].Vector = upsert.Vector
+		}
+	}
+	return struct {
+		*UpsertRequest
+		Upserts []*encodedUpsert `json:"upserts,omitempty"`
+	}{request, upserts}
+}
+
+func hasNumericIDs(upserts []*Upsert) bool {
+	for _, upsert := range upserts {
+		if upsert.IDUint64 != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// queryRequestJSON returns the value to encode as the body of a query request.
+func (c *Client) queryRequestJSON(request *QueryRequest) interface{} {
+	if c.VectorEncoding != VectorEncodingBase64 {
+		return request
+	}
+	return struct {
+		*QueryRequest
+		Vector base64Vector `json:"vector,omitempty"`
+	}{request, request.Vector}
+}
@@ -0,0 +1,366 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVectorEncoding(t *testing.T) {
+	tests := []struct {
+		name         string
+		encoding     tpuf.VectorEncoding
+		call         func(client *tpuf.Client) error
+		expectedBody string
+	}{
+		{
+			name: "upsert with default encoding",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{1, 2}}},
+				})
+				return err
+			},
+			expectedBody: `{"upserts":[{"id":"1","vector":[1,2]}]}`,
+		},
+		{
+			name:     "upsert with base64 encoding",
+			encoding: tpuf.VectorEncodingBase64,
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					DistanceMetric: tpuf.DistanceMetricCosine,
+					Upserts: []*tpuf.Upsert{
+						{ID: "1", Vector: []float32{1, 2}, Attributes: map[string]interface{}{"key": "value"}},
+					},
+				})
+				return err
+			},
+			expectedBody: `{"distance_metric":"cosine_distance","upserts":[{"id":"1","vector":"AACAPwAAAEA=","attributes":{"key":"value"}}]}`,
+		},
+		{
+			name:     "query with base64 encoding",
+			encoding: tpuf.VectorEncodingBase64,
+			call: func(client *tpuf.Client) error {
+				_, err := client.Query(context.Background(), "test-namespace", &tpuf.QueryRequest{
+					Vector:         []float32{1, 2},
+					DistanceMetric: tpuf.DistanceMetricCosine,
+					TopK:           5,
+				})
+				return err
+			},
+			expectedBody: `{"vector":"AACAPwAAAEA=","distance_metric":"cosine_distance","top_k":5}`,
+		},
+		{
+			name:     "filter-only query with base64 encoding",
+			encoding: tpuf.VectorEncodingBase64,
+			call: func(client *tpuf.Client) error {
+				_, err := client.Query(context.Background(), "test-namespace", &tpuf.QueryRequest{TopK: 5})
+				return err
+			},
+			expectedBody: `{"top_k":5}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tpuf.Client{
+				ApiToken:       "test-token",
+				VectorEncoding: tt.encoding,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						body, _ := io.ReadAll(req.Body)
+						assert.JSONEq(t, tt.expectedBody, string(body), "unexpected request body")
+						respBody := `{"status":"OK"}`
+						if strings.HasSuffix(req.URL.Path, "/query") {
+							respBody = `[]`
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(respBody)),
+						}, nil
+					},
+				},
+			}
+
+			assert.NoError(t, tt.call(client))
+		})
+	}
+}
+
+func TestNormalizeL2(t *testing.T) {
+	tests := []struct {
+		name          string
+		vector        []float32
+		expected      []float32
+		expectedError string
+	}{
+		{
+			name:     "scales to unit length",
+			vector:   []float32{3, 4},
+			expected: []float32{0.6, 0.8},
+		},
+		{
+			name:     "already normalized",
+			vector:   []float32{0, 1, 0},
+			expected: []float32{0, 1, 0},
+		},
+		{
+			name:          "zero vector",
+			vector:        []float32{0, 0},
+			expectedError: "invalid vector: can't normalize a zero vector",
+		},
+		{
+			name:          "NaN component",
+			vector:        []float32{1, float32(math.NaN())},
+			expectedError: "invalid vector: component 1 is NaN",
+		},
+		{
+			name:          "infinite component",
+			vector:        []float32{float32(math.Inf(-1))},
+			expectedError: "invalid vector: component 0 is -Inf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]float32(nil), tt.vector...)
+
+			normalized, err := tpuf.NormalizeL2(tt.vector)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.InDeltaSlice(t, tt.expected, normalized, 1e-6)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.ErrorIs(t, err, tpuf.ErrInvalidVector)
+			}
+			assert.Equal(t, fmt.Sprint(original), fmt.Sprint(tt.vector), "input must not be modified")
+		})
+	}
+}
+
+func TestNormalizeVectors(t *testing.T) {
+	tests := []struct {
+		name          string
+		call          func(client *tpuf.Client) error
+		expectedBody  string
+		expectedError string
+	}{
+		{
+			name: "upsert",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					DistanceMetric:   tpuf.DistanceMetricCosine,
+					Upserts:          []*tpuf.Upsert{{ID: "1", Vector: []float32{3, 4}}},
+					NormalizeVectors: true,
+				})
+				return err
+			},
+			expectedBody: `{"distance_metric":"cosine_distance","upserts":[{"id":"1","vector":[0.6,0.8]}]}`,
+		},
+		{
+			name: "query",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Query(context.Background(), "test-namespace", &tpuf.QueryRequest{
+					Vector:          []float32{0, 2},
+					DistanceMetric:  tpuf.DistanceMetricCosine,
+					NormalizeVector: true,
+				})
+				return err
+			},
+			expectedBody: `{"vector":[0,1],"distance_metric":"cosine_distance"}`,
+		},
+		{
+			name: "upsert requires cosine distance",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					DistanceMetric:   tpuf.DistanceMetricEuclidean,
+					Upserts:          []*tpuf.Upsert{{ID: "1", Vector: []float32{3, 4}}},
+					NormalizeVectors: true,
+				})
+				return err
+			},
+			expectedError: `vectors can only be normalized for cosine_distance, not "euclidean_squared"`,
+		},
+		{
+			name: "upsert zero vector",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					DistanceMetric:   tpuf.DistanceMetricCosine,
+					Upserts:          []*tpuf.Upsert{{ID: "1", Vector: []float32{0, 0}}},
+					NormalizeVectors: true,
+				})
+				return err
+			},
+			expectedError: "invalid document 1: invalid vector: can't normalize a zero vector",
+		},
+		{
+			name: "upsert with dimensions declared in schema",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					Schema:  tpuf.Schema{"vector": &tpuf.Attribute{Type: tpuf.VectorType(2, tpuf.VectorDTypeF32)}},
+					Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{3, 4}}},
+				})
+				return err
+			},
+			expectedBody: `{"schema":{"vector":{"type":"[2]f32"}},"upserts":[{"id":"1","vector":[3,4]}]}`,
+		},
+		{
+			name: "upsert with wrong dimensions",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					Schema:  tpuf.Schema{"vector": &tpuf.Attribute{Type: tpuf.VectorType(3, tpuf.VectorDTypeF32)}},
+					Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{3, 4}}},
+				})
+				return err
+			},
+			expectedError: "invalid document 1: invalid vector: has 2 dimensions, but the schema declares 3",
+		},
+		{
+			name: "upsert NaN without normalization",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+					Upserts: []*tpuf.Upsert{{ID: "1", Vector: []float32{float32(math.NaN())}}},
+				})
+				return err
+			},
+			expectedError: "invalid document 1: invalid vector: component 0 is NaN",
+		},
+		{
+			name: "query infinite component without normalization",
+			call: func(client *tpuf.Client) error {
+				_, err := client.Query(context.Background(), "test-namespace", &tpuf.QueryRequest{
+					Vector:         []float32{float32(math.Inf(1))},
+					DistanceMetric: tpuf.DistanceMetricCosine,
+				})
+				return err
+			},
+			expectedError: "invalid query vector: invalid vector: component 0 is +Inf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			client := &tpuf.Client{
+				ApiToken: "test-token",
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						data, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						body = string(data)
+						response := `{"status":"OK"}`
+						if strings.HasSuffix(req.URL.Path, "/query") {
+							response = `[]`
+						}
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(response))}, nil
+					},
+				},
+			}
+
+			err := tt.call(client)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedBody, body)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Empty(t, body, "nothing should be sent")
+			}
+		})
+	}
+}
+
+func TestUnsupportedDistanceMetric(t *testing.T) {
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				t.Fatal("no request should be sent")
+				return nil, nil
+			},
+		},
+	}
+
+	_, queryErr := client.Query(context.Background(), "test-namespace", &tpuf.QueryRequest{Vector: []float32{1}, DistanceMetric: "dot_product"})
+	_, upsertErr := client.Upsert(context.Background(), "test-namespace", &tpuf.UpsertRequest{
+		DistanceMetric: "dot_product",
+		Upserts:        []*tpuf.Upsert{{ID: "1", Vector: []float32{1}}},
+	})
+
+	for _, err := range []error{queryErr, upsertErr} {
+		assert.EqualError(t, err, `unsupported distance metric "dot_product"; supported metrics are cosine_distance and euclidean_squared`)
+	}
+	assert.NoError(t, tpuf.DistanceMetricCosine.Validate())
+	assert.NoError(t, tpuf.DistanceMetricEuclidean.Validate())
+}
+
+func TestFloat32VectorChecked(t *testing.T) {
+	tests := []struct {
+		name          string
+		vector        []float64
+		expected      []float32
+		expectedError string
+	}{
+		{
+			name:     "converts",
+			vector:   []float64{0.5, -1, 0, 1e-30},
+			expected: []float32{0.5, -1, 0, 1e-30},
+		},
+		{
+			name:     "rounds to float32 precision",
+			vector:   []float64{0.1},
+			expected: []float32{0.1},
+		},
+		{
+			name:          "overflow",
+			vector:        []float64{1, 1e39},
+			expectedError: "invalid vector: component 1 (1e+39) overflows float32",
+		},
+		{
+			name:          "underflow",
+			vector:        []float64{1e-50},
+			expectedError: "invalid vector: component 0 (1e-50) underflows float32",
+		},
+		{
+			name:          "NaN",
+			vector:        []float64{math.NaN()},
+			expectedError: "invalid vector: component 0 is NaN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := tpuf.Float32VectorChecked(tt.vector)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, converted)
+				assert.Equal(t, tt.expected, tpuf.Float32Vector(tt.vector))
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.ErrorIs(t, err, tpuf.ErrInvalidVector)
+			}
+		})
+	}
+}
+
+func TestSetVectorFloat64(t *testing.T) {
+	upsert := &tpuf.Upsert{ID: "1"}
+	assert.NoError(t, upsert.SetVectorFloat64([]float64{0.25, 0.5}))
+	assert.Equal(t, []float32{0.25, 0.5}, upsert.Vector)
+
+	query := &tpuf.QueryRequest{Vector: []float32{1}}
+	assert.Error(t, query.SetVectorFloat64([]float64{math.Inf(1)}))
+	assert.Equal(t, []float32{1}, query.Vector, "vector is unchanged on error")
+	assert.NoError(t, query.SetVectorFloat64([]float64{2}))
+	assert.Equal(t, []float32{2}, query.Vector)
+}
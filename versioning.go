@@ -0,0 +1,73 @@
+package tpuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// VersionAttribute is the attribute in which UpsertRequest.Version is stamped on each document.
+	VersionAttribute = "version"
+	// UpdatedAtAttribute is the attribute in which the time of the write is stamped on each document when
+	// UpsertRequest.Version is set.
+	UpdatedAtAttribute = "updated_at"
+)
+
+// OlderThanVersion matches documents stamped with a version lower than version.  Documents without a
+// version, e.g. those written before versioning was adopted, don't match.
+func OlderThanVersion(version uint64) *BaseFilter {
+	return Lt(VersionAttribute, version)
+}
+
+// UpdatedBefore matches documents stamped as updated before t.
+func UpdatedBefore(t time.Time) *BaseFilter {
+	return LtTime(UpdatedAtAttribute, t)
+}
+
+// SweepVersions deletes the documents in a namespace stamped with a version lower than version, e.g. those
+// not rewritten by a reindex which upserted every current document with a new version, and returns how
+// many were deleted.  See DeleteWhere for the meaning of opts and the count.
+func (c *Client) SweepVersions(ctx context.Context, namespace string, version uint64, opts *DeleteWhereOptions) (int, error) {
+	deleted, err := c.DeleteWhere(ctx, namespace, OlderThanVersion(version), opts)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to sweep versions older than %d: %w", version, err)
+	}
+	return deleted, nil
+}
+
+// stampVersion returns a copy of the request with VersionAttribute and UpdatedAtAttribute set on every
+// document, and declared in the schema unless the request already declares them.
+func (r *UpsertRequest) stampVersion(now time.Time) (*UpsertRequest, error) {
+	version, _ := json.Marshal(r.Version)
+	updatedAt, _ := json.Marshal(now.UTC().Format(time.RFC3339Nano))
+	upserts := make([]*Upsert, len(r.Upserts))
+	for i, upsert := range r.Upserts {
+		fields, err := attributeFields(upsert.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stamp version on document %s: %w", upsert.documentID().str, err)
+		}
+		fields[VersionAttribute] = version
+		fields[UpdatedAtAttribute] = updatedAt
+		copied := *upsert
+		copied.Attributes = fields
+		upserts[i] = &copied
+	}
+
+	schema := make(Schema, len(r.Schema)+2)
+	for name, attribute := range r.Schema {
+		schema[name] = attribute
+	}
+	if schema[VersionAttribute] == nil {
+		schema[VersionAttribute] = &Attribute{Type: AttributeTypeUint}
+	}
+	if schema[UpdatedAtAttribute] == nil {
+		schema[UpdatedAtAttribute] = &Attribute{Type: AttributeTypeDatetime}
+	}
+
+	copied := *r
+	copied.Upserts = upserts
+	copied.Schema = schema
+	return &copied, nil
+}
@@ -0,0 +1,92 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpsertVersion(t *testing.T) {
+	var sent struct {
+		Schema  map[string]json.RawMessage `json:"schema"`
+		Upserts []struct {
+			ID         string                 `json:"id"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"upserts"`
+	}
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				assert.NoError(t, err)
+				assert.NoError(t, json.Unmarshal(body, &sent))
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK"}`))}, nil
+			},
+		},
+	}
+	request := &tpuf.UpsertRequest{
+		DistanceMetric: tpuf.DistanceMetricCosine,
+		Schema:         tpuf.Schema{tpuf.VersionAttribute: &tpuf.Attribute{Type: tpuf.AttributeTypeInt}},
+		Upserts: []*tpuf.Upsert{
+			{ID: "1", Vector: []float32{1}, Attributes: map[string]string{"title": "a"}},
+			{ID: "2", Vector: []float32{2}},
+		},
+		Version: 7,
+	}
+	before := time.Now().UTC()
+
+	_, err := client.Upsert(context.Background(), "test-namespace", request)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"int"}`, string(sent.Schema[tpuf.VersionAttribute]), "declared types are kept")
+	assert.JSONEq(t, `{"type":"datetime"}`, string(sent.Schema[tpuf.UpdatedAtAttribute]))
+	assert.Len(t, sent.Upserts, 2)
+	for _, upsert := range sent.Upserts {
+		assert.Equal(t, float64(7), upsert.Attributes[tpuf.VersionAttribute])
+		updatedAt, err := time.Parse(time.RFC3339Nano, upsert.Attributes[tpuf.UpdatedAtAttribute].(string))
+		assert.NoError(t, err)
+		assert.False(t, updatedAt.Before(before.Truncate(time.Second)))
+	}
+	assert.Equal(t, "a", sent.Upserts[0].Attributes["title"])
+	assert.Equal(t, map[string]string{"title": "a"}, request.Upserts[0].Attributes, "caller's upserts must not be modified")
+	assert.Len(t, request.Schema, 1, "caller's schema must not be modified")
+}
+
+func TestVersionFilters(t *testing.T) {
+	older, err := json.Marshal(tpuf.OlderThanVersion(3))
+	assert.NoError(t, err)
+	assert.Equal(t, `["version","Lt",3]`, string(older))
+
+	updated, err := json.Marshal(tpuf.UpdatedBefore(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)))
+	assert.NoError(t, err)
+	assert.Equal(t, `["updated_at","Lt","2024-05-01T00:00:00Z"]`, string(updated))
+}
+
+func TestSweepVersions(t *testing.T) {
+	var sent string
+	client := &tpuf.Client{
+		ApiToken: "test-token",
+		HttpClient: &fakeHttpClient{
+			doFunc: func(req *http.Request) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				assert.NoError(t, err)
+				sent = string(body)
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(`{"status":"OK","rows_affected":4}`))}, nil
+			},
+		},
+	}
+
+	deleted, err := client.SweepVersions(context.Background(), "test-namespace", 3, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, deleted)
+	assert.Equal(t, `{"delete_by_filter":["version","Lt",3]}`, sent)
+}
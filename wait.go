@@ -0,0 +1,55 @@
+package tpuf
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WaitFor polls the number of documents matching filter until at least expectedMinCount are visible, e.g.
+// so that a pipeline reads its own writes before proceeding to the next stage.  It returns the last count.
+// A nil filter counts every document in the namespace.  If timeout is positive, WaitFor gives up after that
+// long, otherwise it waits until the context is done.  Documents are polled once a second.
+func (c *Client) WaitFor(ctx context.Context, namespace string, filter Filter, expectedMinCount int64, timeout time.Duration) (int64, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		count, err := c.Count(ctx, namespace, filter)
+		if err != nil {
+			return count, fmt.Errorf("failed to wait for documents: %w", err)
+		}
+		if count >= expectedMinCount {
+			return count, nil
+		}
+		if err := c.sleep(ctx, defaultPollInterval); err != nil {
+			return count, fmt.Errorf("failed to wait for %d documents, found %d: %w", expectedMinCount, count, err)
+		}
+	}
+}
+
+// sleep waits for the given duration using the client's Timer, returning early with the context's error if
+// it is done first.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	if c.Timer == nil {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		}
+	}
+	c.Timer.Start(d)
+	defer c.Timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.Timer.C():
+		return nil
+	}
+}
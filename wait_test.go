@@ -0,0 +1,97 @@
+package tpuf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bamo/tpuf-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitFor(t *testing.T) {
+	tests := []struct {
+		name             string
+		counts           []int
+		filter           tpuf.Filter
+		expectedMinCount int64
+		timeout          time.Duration
+		expectedCount    int64
+		expectedCalls    int
+		expectedFilter   string
+		expectedError    string
+	}{
+		{
+			name:             "already visible",
+			counts:           []int{5},
+			expectedMinCount: 5,
+			expectedCount:    5,
+			expectedCalls:    1,
+		},
+		{
+			name:             "polls until visible",
+			counts:           []int{0, 3, 7},
+			filter:           tpuf.Eq("batch", "b1"),
+			expectedMinCount: 5,
+			expectedCount:    7,
+			expectedCalls:    3,
+			expectedFilter:   `["batch","Eq","b1"]`,
+		},
+		{
+			name:             "times out",
+			counts:           []int{1},
+			expectedMinCount: 5,
+			timeout:          20 * time.Millisecond,
+			expectedCount:    1,
+			expectedError:    "failed to wait for 5 documents, found 1: context deadline exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := &tpuf.Client{
+				ApiToken:     "test-token",
+				DisableRetry: true,
+				HttpClient: &fakeHttpClient{
+					doFunc: func(req *http.Request) (*http.Response, error) {
+						var body struct {
+							Filters json.RawMessage `json:"filters"`
+						}
+						assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+						if tt.expectedFilter != "" {
+							assert.JSONEq(t, tt.expectedFilter, string(body.Filters))
+						}
+						count := tt.counts[len(tt.counts)-1]
+						if calls < len(tt.counts) {
+							count = tt.counts[calls]
+						}
+						calls++
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{"aggregations":{"count":%d}}`, count))),
+						}, nil
+					},
+				},
+			}
+			if tt.timeout == 0 {
+				client.Timer = &fakeTimer{}
+			}
+
+			count, err := client.WaitFor(context.Background(), "test-ns", tt.filter, tt.expectedMinCount, tt.timeout)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCalls, calls, "unexpected number of polls")
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedCount, count)
+		})
+	}
+}